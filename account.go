@@ -0,0 +1,204 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCreationTimeUnavailable is returned by [Wallet.Age] when the WoS API
+// does not report when the account was created.
+var ErrCreationTimeUnavailable = errors.New("account creation time not available")
+
+// Account represents the metadata of a WoS wallet account.
+type Account struct {
+	Addresses
+
+	// CreatedAt is the time the account was created, or the zero time
+	// if the WoS API did not report it.
+	CreatedAt time.Time
+
+	// Frozen is true if WoS reports that the account is frozen or restricted, in
+	// which case payments fail with [ErrWalletFrozen]. The WoS API does not document
+	// such a flag, so false may also mean that it was not reported.
+	Frozen bool
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. The creation time is accepted
+// under several field names and formats, as the WoS API is undocumented.
+func (account *Account) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Addresses
+		CreatedAt    flexibleTime `json:"createdAt"`
+		Created      flexibleTime `json:"created"`
+		CreationDate flexibleTime `json:"creationDate"`
+		Frozen       bool         `json:"frozen"`
+		IsFrozen     bool         `json:"isFrozen"`
+		Restricted   bool         `json:"restricted"`
+		Status       string       `json:"status"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	account.Addresses = raw.Addresses
+	switch strings.ToUpper(raw.Status) {
+	case "FROZEN", "SUSPENDED", "RESTRICTED":
+		account.Frozen = true
+	default:
+		account.Frozen = raw.Frozen || raw.IsFrozen || raw.Restricted
+	}
+	for _, t := range []flexibleTime{raw.CreatedAt, raw.Created, raw.CreationDate} {
+		if !t.IsZero() {
+			account.CreatedAt = t.Time
+			break
+		}
+	}
+	return nil
+}
+
+// Age returns how long ago the account was created, relative to now.
+// Returns zero if the creation time is unknown.
+func (account *Account) Age() time.Duration {
+	if account.CreatedAt.IsZero() {
+		return 0
+	}
+	return time.Since(account.CreatedAt)
+}
+
+// CreationInfo holds the details WoS reported when creating a wallet with [CreateWallet],
+// so that provisioning code can record them without further requests. Retrieve it with
+// [Wallet.CreationInfo].
+type CreationInfo struct {
+	// Account holds the wallet's addresses, and its creation time if WoS reported one.
+	Account
+
+	// AccountID is the identifier WoS assigned to the account, if it reported one.
+	AccountID string
+
+	// Region is the region WoS associated with the account, if it reported one.
+	Region string
+
+	// ReceivedAt is the local time at which the creation response was received. Unlike
+	// CreatedAt, it is always set.
+	ReceivedAt time.Time
+
+	// Raw is the full JSON response, minus the API token and secret so that it is
+	// safe to log. It may include fields this package does not understand.
+	Raw json.RawMessage
+}
+
+// parseCreationInfo decodes the creation details from a CreateWallet response.
+func parseCreationInfo(respData []byte, receivedAt time.Time) (*CreationInfo, error) {
+	info := &CreationInfo{ReceivedAt: receivedAt}
+	if err := json.Unmarshal(respData, &info.Account); err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(respData, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "apiSecret")
+	delete(fields, "apiToken")
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	info.Raw = raw
+
+	var ids struct {
+		ID        string `json:"id"`
+		AccountID string `json:"accountId"`
+		WalletID  string `json:"walletId"`
+		Region    string `json:"region"`
+		Country   string `json:"country"`
+	}
+	// The ID and region fields are undocumented, so tolerate unexpected types.
+	_ = json.Unmarshal(respData, &ids)
+	for _, id := range []string{ids.AccountID, ids.WalletID, ids.ID} {
+		if id != "" {
+			info.AccountID = id
+			break
+		}
+	}
+	info.Region = ids.Region
+	if info.Region == "" {
+		info.Region = ids.Country
+	}
+	return info, nil
+}
+
+// flexibleTime decodes a timestamp from JSON, accepting RFC3339 strings,
+// or unix timestamps in seconds or milliseconds as numbers or strings.
+type flexibleTime struct {
+	time.Time
+}
+
+func (ft *flexibleTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+	if s == "" {
+		return nil
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// Unix timestamps in seconds will not exceed this value until the year 5138.
+		if n > 100_000_000_000 {
+			ft.Time = time.UnixMilli(n).UTC()
+		} else {
+			ft.Time = time.Unix(n, 0).UTC()
+		}
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			ft.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unrecognized timestamp format: %s", data)
+}
+
+// Account fetches the wallet's account metadata.
+func (rdr *Reader) Account(ctx context.Context) (*Account, error) {
+	respData, err := rdr.GetRequest(ctx, "/api/v1/wallet/account")
+	if err != nil {
+		return nil, fmt.Errorf("Account: %w", err)
+	}
+
+	var account Account
+	if err := json.Unmarshal(respData, &account); err != nil {
+		return nil, fmt.Errorf("invalid Account response: %w", err)
+	}
+	return &account, nil
+}
+
+// Account fetches the wallet's account metadata.
+func (wallet *Wallet) Account(ctx context.Context) (*Account, error) {
+	return wallet.reader.Account(ctx)
+}
+
+// Age returns how long ago the wallet was created. Returns an error wrapping
+// [ErrCreationTimeUnavailable] if the WoS API does not report the creation time.
+func (wallet *Wallet) Age(ctx context.Context) (time.Duration, error) {
+	account, err := wallet.reader.Account(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("Age: %w", err)
+	} else if account.CreatedAt.IsZero() {
+		return 0, fmt.Errorf("Age: %w", ErrCreationTimeUnavailable)
+	}
+	return account.Age(), nil
+}
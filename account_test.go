@@ -0,0 +1,110 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAccountCreationTime(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, body := range []string{
+		`{"lightningAddress":"a@walletofsatoshi.com","createdAt":"2024-03-01T12:00:00Z"}`,
+		`{"lightningAddress":"a@walletofsatoshi.com","created":1709294400}`,
+		`{"lightningAddress":"a@walletofsatoshi.com","creationDate":1709294400000}`,
+		`{"lightningAddress":"a@walletofsatoshi.com","createdAt":"1709294400"}`,
+	} {
+		var account Account
+		if err := json.Unmarshal([]byte(body), &account); err != nil {
+			t.Fatalf("failed to decode %s: %v", body, err)
+		} else if !account.CreatedAt.Equal(want) {
+			t.Fatalf("decoding %s: expected %s, got %s", body, want, account.CreatedAt)
+		} else if account.Lightning != "a@walletofsatoshi.com" {
+			t.Fatalf("addresses not decoded: %+v", account.Addresses)
+		}
+	}
+}
+
+func TestAccountFrozen(t *testing.T) {
+	for body, want := range map[string]bool{
+		`{"lightningAddress":"a@walletofsatoshi.com"}`:                      false,
+		`{"lightningAddress":"a@walletofsatoshi.com","frozen":true}`:        true,
+		`{"lightningAddress":"a@walletofsatoshi.com","status":"SUSPENDED"}`: true,
+		`{"lightningAddress":"a@walletofsatoshi.com","status":"ACTIVE"}`:    false,
+	} {
+		var account Account
+		if err := json.Unmarshal([]byte(body), &account); err != nil {
+			t.Fatalf("failed to decode %s: %v", body, err)
+		} else if account.Frozen != want {
+			t.Fatalf("decoding %s: expected Frozen=%v", body, want)
+		}
+	}
+}
+
+func TestWalletAge(t *testing.T) {
+	created := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"btcDepositAddress":"bc1qexample","createdAt":"` + created + `"}`))
+	})
+
+	age, err := wallet.Age(context.Background())
+	if err != nil {
+		t.Fatalf("Age failed: %v", err)
+	} else if age < 47*time.Hour || age > 49*time.Hour {
+		t.Fatalf("expected age of about 48 hours, got %s", age)
+	}
+
+	wallet = mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"btcDepositAddress":"bc1qexample"}`))
+	})
+	if _, err := wallet.Age(context.Background()); !errors.Is(err, ErrCreationTimeUnavailable) {
+		t.Fatalf("expected ErrCreationTimeUnavailable, got %v", err)
+	}
+}
+
+func TestCreateWalletCreationInfo(t *testing.T) {
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"apiSecret": "secret",
+			"apiToken": "token",
+			"btcDepositAddress": "bc1qnew",
+			"lightningAddress": "new@walletofsatoshi.com",
+			"createdAt": "2024-05-01T12:00:00Z",
+			"accountId": "acct-1",
+			"region": "DE"
+		}`))
+	})
+
+	wallet, creds, err := CreateWallet(context.Background(), httpClient)
+	if err != nil {
+		t.Fatalf("CreateWallet failed: %v", err)
+	} else if creds.APIToken != "token" || creds.APISecret != "secret" {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+
+	info := wallet.CreationInfo()
+	if info == nil {
+		t.Fatalf("expected creation info")
+	} else if info.OnChain != "bc1qnew" || info.Lightning != "new@walletofsatoshi.com" {
+		t.Fatalf("unexpected addresses: %+v", info.Addresses)
+	} else if !info.CreatedAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected creation time: %v", info.CreatedAt)
+	} else if info.AccountID != "acct-1" || info.Region != "DE" {
+		t.Fatalf("unexpected account ID %q or region %q", info.AccountID, info.Region)
+	} else if info.ReceivedAt.IsZero() {
+		t.Fatalf("expected ReceivedAt to be set")
+	} else if bytes.Contains(info.Raw, []byte("secret")) || bytes.Contains(info.Raw, []byte("token")) {
+		t.Fatalf("expected credentials to be removed from raw response: %s", info.Raw)
+	} else if !bytes.Contains(info.Raw, []byte("bc1qnew")) {
+		t.Fatalf("expected raw response to keep other fields: %s", info.Raw)
+	}
+
+	if mockWallet(nil).CreationInfo() != nil {
+		t.Fatalf("expected no creation info for an opened wallet")
+	}
+}
@@ -0,0 +1,138 @@
+package wos
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrMalformedAmount is returned when parsing an amount which is not a valid
+	// non-negative number, or which has a unit or symbol that is not supported.
+	ErrMalformedAmount = errors.New("malformed amount")
+
+	// ErrAmbiguousAmount is returned by [ParseAmount] for a bare number, which
+	// could be meant as BTC, sats or fiat.
+	ErrAmbiguousAmount = errors.New("ambiguous amount: specify a unit")
+
+	// ErrRateRequired is returned by [ParseAmount] for a fiat amount when no
+	// exchange rate is given.
+	ErrRateRequired = errors.New("exchange rate required for fiat amount")
+)
+
+// ParseBTCAmount parses a decimal BTC amount such as "0.001" into satoshis. The amount
+// is parsed exactly, without floating point error, and may not be more precise than
+// one satoshi. Returns an error wrapping [ErrMalformedAmount] if it is not a valid
+// non-negative amount.
+func ParseBTCAmount(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrMalformedAmount, s)
+	} else if len(frac) > 8 {
+		return 0, fmt.Errorf("%w: %q is more precise than one satoshi", ErrMalformedAmount, s)
+	}
+
+	digits := strings.TrimLeft(whole+frac+strings.Repeat("0", 8-len(frac)), "0")
+	if digits == "" {
+		return 0, nil
+	}
+	sats, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || sats > maxBitcoinSupply*100_000_000 {
+		return 0, fmt.Errorf("%w: %.8f BTC", ErrAmountTooLarge, float64(sats)/100_000_000)
+	}
+	return sats, nil
+}
+
+// ParseSatsAmount parses a whole number of satoshis such as "1000". Returns an error
+// wrapping [ErrMalformedAmount] if it is not a valid non-negative integer.
+func ParseSatsAmount(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || !isDigits(s) {
+		return 0, fmt.Errorf("%w: %q", ErrMalformedAmount, s)
+	}
+	sats, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || sats > maxBitcoinSupply*100_000_000 {
+		return 0, fmt.Errorf("%w: %s sats", ErrAmountTooLarge, s)
+	}
+	return sats, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// fiatSymbols are the currency symbols [ParseAmount] accepts as a prefix for fiat
+// amounts. Symbols shared by currencies with very different values, such as ¥ for
+// both JPY and CNY, are deliberately omitted, as the rate is likely to be wrong.
+var fiatSymbols = []string{"$", "€", "£"}
+
+// ParseAmount parses an amount entered in a payment form into satoshis, detecting its
+// unit from a symbol or suffix. Accepted forms include:
+//
+//   - BTC: "₿0.001", "0.001 BTC" or "0.001btc", parsed with [ParseBTCAmount].
+//   - Sats: "100 sats", "100 sat" or "100sats", parsed with [ParseSatsAmount].
+//   - Fiat: "$5", "€5", "£5", or an ISO 4217 code from [WoSDisplayCurrencies]
+//     such as "5 USD". rate must give the price of one bitcoin in that currency,
+//     and the amount is rounded to the nearest satoshi.
+//
+// Returns an error wrapping [ErrAmbiguousAmount] for a bare number, [ErrRateRequired]
+// for a fiat amount if rate is nil, and [ErrMalformedAmount] for anything else which
+// cannot be parsed, including unsupported symbols.
+func ParseAmount(s string, rate *float64) (int64, error) {
+	s = strings.TrimSpace(s)
+
+	if number, ok := strings.CutPrefix(s, "₿"); ok {
+		return ParseBTCAmount(number)
+	}
+	for _, symbol := range fiatSymbols {
+		if number, ok := strings.CutPrefix(s, symbol); ok {
+			return parseFiatAmount(number, rate)
+		}
+	}
+
+	end := strings.LastIndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) + 1
+	number, unit := strings.TrimSpace(s[:end]), s[end:]
+	switch upper := strings.ToUpper(unit); {
+	case upper == "BTC":
+		return ParseBTCAmount(number)
+	case upper == "SAT" || upper == "SATS":
+		return ParseSatsAmount(number)
+	case slices.Contains(WoSDisplayCurrencies, upper):
+		return parseFiatAmount(number, rate)
+	case unit == "":
+		if _, err := strconv.ParseFloat(number, 64); err == nil && isDigits(strings.Replace(number, ".", "", 1)) {
+			return 0, fmt.Errorf("%w: %q", ErrAmbiguousAmount, s)
+		}
+	}
+	return 0, fmt.Errorf("%w: unsupported unit in %q", ErrMalformedAmount, s)
+}
+
+// parseFiatAmount converts a decimal fiat amount to satoshis at the given rate.
+func parseFiatAmount(number string, rate *float64) (int64, error) {
+	if rate == nil {
+		return 0, ErrRateRequired
+	}
+	number = strings.TrimSpace(number)
+	fiat, err := strconv.ParseFloat(number, 64)
+	if err != nil || fiat < 0 || math.IsInf(fiat, 0) || math.IsNaN(fiat) ||
+		!isDigits(strings.Replace(number, ".", "", 1)) {
+		return 0, fmt.Errorf("%w: %q", ErrMalformedAmount, number)
+	} else if fiat == 0 {
+		return 0, nil
+	}
+
+	btc, err := fiatToBTC(fiat, *rate)
+	if err != nil {
+		return 0, err
+	}
+	return toSats(btc), nil
+}
@@ -0,0 +1,57 @@
+package wos
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	rate := 50_000.0
+	valid := map[string]int64{
+		"₿0.001":        100_000,
+		"0.001 BTC":     100_000,
+		"0.00000001btc": 1,
+		"1 BTC":         100_000_000,
+		"100 sats":      100,
+		"1sat":          1,
+		"$5":            10_000,
+		"€ 2.50":        5_000,
+		"5 USD":         10_000,
+		"5 eur":         10_000,
+	}
+	for input, want := range valid {
+		sats, err := ParseAmount(input, &rate)
+		if err != nil {
+			t.Errorf("ParseAmount(%q) failed: %v", input, err)
+		} else if sats != want {
+			t.Errorf("ParseAmount(%q): expected %d sats, got %d", input, want, sats)
+		}
+	}
+
+	invalid := map[string]error{
+		"0.001":           ErrAmbiguousAmount,
+		"100":             ErrAmbiguousAmount,
+		"¥500":            ErrMalformedAmount,
+		"5 XYZ":           ErrMalformedAmount,
+		"1.5 sats":        ErrMalformedAmount,
+		"0.000000001 BTC": ErrMalformedAmount,
+		"-1 BTC":          ErrMalformedAmount,
+		"$-5":             ErrMalformedAmount,
+		"1e3 sats":        ErrMalformedAmount,
+		"BTC":             ErrMalformedAmount,
+		"":                ErrMalformedAmount,
+		"30000000 BTC":    ErrAmountTooLarge,
+	}
+	for input, want := range invalid {
+		if _, err := ParseAmount(input, &rate); !errors.Is(err, want) {
+			t.Errorf("ParseAmount(%q): expected %v, got %v", input, want, err)
+		}
+	}
+
+	if _, err := ParseAmount("$5", nil); !errors.Is(err, ErrRateRequired) {
+		t.Errorf("expected ErrRateRequired without a rate, got %v", err)
+	}
+	if sats, err := ParseAmount("100 sats", nil); err != nil || sats != 100 {
+		t.Errorf("expected sats to parse without a rate, got %d, %v", sats, err)
+	}
+}
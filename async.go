@@ -0,0 +1,43 @@
+package wos
+
+import (
+	"context"
+	"fmt"
+)
+
+// PaymentResult is the outcome of an asynchronous payment, as delivered by
+// [Wallet.PayInvoiceAsync]. Exactly one of Payment and Err is set.
+type PaymentResult struct {
+	Payment *Payment
+	Err     error
+}
+
+// PayInvoiceAsync is like [Wallet.PayInvoice], but returns without waiting for WoS to
+// respond. The eventual payment or error is delivered on the returned channel, which
+// receives exactly one result and is then closed. The channel is buffered, so callers
+// which do not care about the outcome can ignore it without leaking the goroutine.
+//
+// This lets high-throughput senders submit many payments without blocking on each one.
+// Payments still respect the limit set by [Wallet.SetMaxConcurrentPayments], so they
+// may queue before being sent. There are no guarantees about the order in which
+// concurrently submitted payments are sent or their results delivered.
+//
+// The invoice is checked before returning, so an error is returned immediately if it
+// is invalid. Cancelling ctx abandons payments which are still queued, but a payment
+// already submitted to WoS may still succeed.
+func (wallet *Wallet) PayInvoiceAsync(
+	ctx context.Context,
+	invoice, description string,
+) (<-chan PaymentResult, error) {
+	if _, err := parseInvoiceAmount(invoice); err != nil {
+		return nil, fmt.Errorf("PayInvoiceAsync: %w", err)
+	}
+
+	results := make(chan PaymentResult, 1)
+	go func() {
+		defer close(results)
+		payment, err := wallet.PayInvoice(ctx, invoice, description)
+		results <- PaymentResult{Payment: payment, Err: err}
+	}()
+	return results, nil
+}
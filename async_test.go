@@ -0,0 +1,47 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestPayInvoiceAsync(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		var req sendPaymentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid payment request: %v", err)
+		}
+		fmt.Fprintf(w, `{"id":%q}`, req.Description)
+	})
+	wallet.SetMaxConcurrentPayments(2)
+
+	const n = 5
+	var results []<-chan PaymentResult
+	for i := 0; i < n; i++ {
+		result, err := wallet.PayInvoiceAsync(context.Background(), testInvoiceCoffee, strconv.Itoa(i))
+		if err != nil {
+			t.Fatalf("PayInvoiceAsync failed: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	for i, result := range results {
+		r := <-result
+		if r.Err != nil {
+			t.Fatalf("payment %d failed: %v", i, r.Err)
+		} else if r.Payment.ID != strconv.Itoa(i) {
+			t.Fatalf("payment %d got result for payment %s", i, r.Payment.ID)
+		}
+		if _, ok := <-result; ok {
+			t.Fatalf("expected result channel to be closed after one result")
+		}
+	}
+
+	if _, err := wallet.PayInvoiceAsync(context.Background(), "lnbc1invalid", ""); err == nil {
+		t.Fatalf("expected invalid invoice to be rejected immediately")
+	}
+}
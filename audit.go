@@ -0,0 +1,138 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AuditedPayment is a sent payment listed by [Reader.OutgoingAudit].
+// Amounts are in satoshis.
+type AuditedPayment struct {
+	Payment Payment
+
+	// Amount is the amount sent, and Fee the fee WoS charged for it, or zero
+	// if WoS did not report a fee.
+	Amount int64
+	Fee    int64
+}
+
+// AuditTotals sums the payments listed in an [OutgoingAudit].
+// Amounts are in satoshis.
+type AuditTotals struct {
+	Count  int
+	Amount int64
+	Fees   int64
+}
+
+// OutgoingAudit lists every payment a wallet sent in a time window, with fee totals,
+// as returned by [Reader.OutgoingAudit].
+type OutgoingAudit struct {
+	// From and To bound the window, which includes From but excludes To.
+	From time.Time
+	To   time.Time
+
+	// Payments lists the payments sent in the window, ordered from oldest to newest.
+	Payments []AuditedPayment
+
+	// Totals sums all payments in the window, and ByCurrency sums them separately
+	// for on-chain and lightning payments.
+	Totals     AuditTotals
+	ByCurrency map[PaymentCurrency]AuditTotals
+}
+
+// OutgoingAudit lists every payment the wallet sent in the window from from, inclusive,
+// to to, exclusive, with the fees paid for each, for answering questions such as
+// "everything we sent last month and the total fees". Received payments are ignored.
+//
+// WoS does not document whether it reports fees in the payment history. Payments
+// without a reported fee are counted with a fee of zero, so fee totals are a lower
+// bound. The WoS API cannot filter payments by time, so this scans the full history.
+func (rdr *Reader) OutgoingAudit(ctx context.Context, from, to time.Time) (*OutgoingAudit, error) {
+	audit := &OutgoingAudit{
+		From:       from,
+		To:         to,
+		ByCurrency: make(map[PaymentCurrency]AuditTotals),
+	}
+
+	err := rdr.WalkPayments(ctx, func(payment *Payment) bool {
+		if payment.Type != PaymentTypeDebit || payment.Time.Before(from) || !payment.Time.Before(to) {
+			return true
+		}
+
+		audited := AuditedPayment{
+			Payment: *payment,
+			Amount:  toSats(payment.Amount),
+			Fee:     toSats(payment.Fee),
+		}
+		audit.Payments = append(audit.Payments, audited)
+		audit.Totals.add(audited)
+
+		totals := audit.ByCurrency[payment.Currency]
+		totals.add(audited)
+		audit.ByCurrency[payment.Currency] = totals
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("OutgoingAudit: %w", err)
+	}
+	return audit, nil
+}
+
+func (totals *AuditTotals) add(payment AuditedPayment) {
+	totals.Count++
+	totals.Amount += payment.Amount
+	totals.Fees += payment.Fee
+}
+
+// ErrLedgerMismatch is returned by [Reader.VerifyLedger] when the balance reconstructed
+// from the payment history does not match the wallet's live balance.
+var ErrLedgerMismatch = errors.New("payment history does not add up to balance")
+
+// LedgerTolerance is the largest discrepancy, in satoshis, which [Reader.VerifyLedger]
+// accepts between the reconstructed and live balances, to allow for WoS rounding
+// amounts and fees to whole satoshis.
+var LedgerTolerance int64 = 1
+
+// VerifyLedger checks the integrity of the wallet's payment history by reconstructing
+// the balance from it, as credits minus debits minus fees, and comparing the result
+// to the live confirmed balance. Returns an error wrapping [ErrLedgerMismatch] which
+// describes the discrepancy if they differ by more than [LedgerTolerance] satoshis.
+// A mismatch suggests missing history or fees which WoS did not report.
+//
+// Pending credits, such as on-chain deposits which are still confirming, are not yet
+// part of the confirmed balance and so are left out. Pending debits are counted, as
+// WoS deducts them up front. Payments which occur while the check is running may
+// cause a spurious mismatch, so it should be retried before being acted upon.
+func (rdr *Reader) VerifyLedger(ctx context.Context) error {
+	var credits, debits, fees int64
+	err := rdr.WalkPayments(ctx, func(payment *Payment) bool {
+		switch {
+		case payment.Type == PaymentTypeCredit && !payment.IsPending():
+			credits += toSats(payment.Amount)
+		case payment.Type == PaymentTypeDebit:
+			debits += toSats(payment.Amount)
+			fees += toSats(payment.Fee)
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("VerifyLedger: %w", err)
+	}
+	expected := credits - debits - fees
+
+	balance, err := rdr.Balance(ctx)
+	if err != nil {
+		return fmt.Errorf("VerifyLedger: %w", err)
+	}
+	actual := toSats(balance.Confirmed)
+
+	if diff := actual - expected; diff > LedgerTolerance || diff < -LedgerTolerance {
+		return fmt.Errorf(
+			"VerifyLedger: %w: history implies %d sats (%d in, %d out, %d fees) but balance is %d sats, off by %+d sats",
+			ErrLedgerMismatch, expected, credits, debits, fees, actual, diff,
+		)
+	}
+	return nil
+}
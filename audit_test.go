@@ -0,0 +1,80 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOutgoingAudit(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id":"before","type":"DEBIT","currency":"LIGHTNING","amount":0.001,"fee":0.00001,"time":"2024-01-31T23:59:59Z"},
+			{"id":"ln","type":"DEBIT","currency":"LIGHTNING","amount":0.0005,"fee":0.000002,"time":"2024-02-01T00:00:00Z"},
+			{"id":"credit","type":"CREDIT","currency":"LIGHTNING","amount":0.01,"time":"2024-02-05T00:00:00Z"},
+			{"id":"chain","type":"DEBIT","currency":"BTC","amount":0.002,"fee":0.00002,"time":"2024-02-10T00:00:00Z"},
+			{"id":"nofee","type":"DEBIT","currency":"LIGHTNING","amount":0.0001,"time":"2024-02-20T00:00:00Z"},
+			{"id":"after","type":"DEBIT","currency":"BTC","amount":0.003,"fee":0.00002,"time":"2024-03-01T00:00:00Z"}
+		]`))
+	}))
+
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	audit, err := rdr.OutgoingAudit(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("OutgoingAudit failed: %v", err)
+	}
+
+	var ids []string
+	for _, payment := range audit.Payments {
+		ids = append(ids, payment.Payment.ID)
+	}
+	if len(ids) != 3 || ids[0] != "ln" || ids[1] != "chain" || ids[2] != "nofee" {
+		t.Fatalf("unexpected payments in window: %v", ids)
+	}
+
+	if want := (AuditTotals{Count: 3, Amount: 260_000, Fees: 2_200}); audit.Totals != want {
+		t.Fatalf("expected totals %+v, got %+v", want, audit.Totals)
+	}
+	if want := (AuditTotals{Count: 2, Amount: 60_000, Fees: 200}); audit.ByCurrency[PaymentCurrencyLightning] != want {
+		t.Fatalf("expected lightning totals %+v, got %+v", want, audit.ByCurrency[PaymentCurrencyLightning])
+	}
+	if want := (AuditTotals{Count: 1, Amount: 200_000, Fees: 2_000}); audit.ByCurrency[PaymentCurrencyBitcoin] != want {
+		t.Fatalf("expected on-chain totals %+v, got %+v", want, audit.ByCurrency[PaymentCurrencyBitcoin])
+	}
+}
+
+func TestVerifyLedger(t *testing.T) {
+	// 0.01 + 0.02 in, less 0.005 + 0.00001 out, with a 0.1 deposit still confirming.
+	history := `[
+		{"id":"a","type":"CREDIT","currency":"LIGHTNING","status":"PAID","amount":0.01},
+		{"id":"b","type":"DEBIT","currency":"LIGHTNING","status":"PAID","amount":0.005,"fee":0.00001},
+		{"id":"c","type":"CREDIT","currency":"BTC","status":"PAID","amount":0.02},
+		{"id":"d","type":"CREDIT","currency":"BTC","status":"PENDING","amount":0.1}
+	]`
+	for _, test := range []struct {
+		balance  string
+		mismatch bool
+	}{
+		{"0.02499", false},
+		{"0.02499001", false},
+		{"0.02399", true},
+	} {
+		rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v1/wallet/balance" {
+				w.Write([]byte(`{"btc":` + test.balance + `}`))
+				return
+			}
+			w.Write([]byte(history))
+		}))
+
+		err := rdr.VerifyLedger(context.Background())
+		if test.mismatch != errors.Is(err, ErrLedgerMismatch) {
+			t.Fatalf("balance %s: unexpected result %v", test.balance, err)
+		} else if !test.mismatch && err != nil {
+			t.Fatalf("balance %s: VerifyLedger failed: %v", test.balance, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultInvoicePollInterval is the interval at which [Wallet.WaitForInvoice] polls
+// an invoice if no interval is given.
+const DefaultInvoicePollInterval = 5 * time.Second
+
+// WaitForInvoiceOptions customizes [Wallet.WaitForInvoiceWith].
+type WaitForInvoiceOptions struct {
+	// PollInterval is the interval between checks of the invoice, which
+	// defaults to [DefaultInvoicePollInterval].
+	PollInterval time.Duration
+
+	// Settled, if not nil, is an external signal that the invoice has probably been
+	// paid, such as from a webhook. When it is closed or receives a value, the invoice
+	// is checked immediately instead of waiting for the next poll.
+	Settled <-chan struct{}
+}
+
+// WaitForInvoice blocks until the given invoice is paid, polling [Reader.IsInvoicePaid]
+// every pollInterval, or [DefaultInvoicePollInterval] if pollInterval is zero, and
+// returns the payment which settled it.
+//
+// Returns an error wrapping [ErrInvoiceExpired] if the invoice expires unpaid, or
+// [ErrInvoiceNotFound] if WoS does not recognize it. Other errors from individual
+// polls are not fatal; waiting continues until ctx is cancelled, and the last poll
+// error is returned alongside the context's error.
+func (wallet *Wallet) WaitForInvoice(
+	ctx context.Context,
+	invoice *Invoice,
+	pollInterval time.Duration,
+) (*Payment, error) {
+	return wallet.WaitForInvoiceWith(ctx, invoice, &WaitForInvoiceOptions{PollInterval: pollInterval})
+}
+
+// WaitForInvoiceWith is like [Wallet.WaitForInvoice], but accepts [WaitForInvoiceOptions],
+// such as an external settlement signal which short-circuits polling. opts can be nil.
+//
+// The signal is only a hint: it may arrive before WoS considers the invoice paid, or
+// be spoofed. So a signal always triggers a confirming check, and the invoice is only
+// reported paid if WoS agrees. If it does not, polling continues as normal, ignoring
+// any further signals, since a closed channel would otherwise trigger a check in a
+// tight loop.
+func (wallet *Wallet) WaitForInvoiceWith(
+	ctx context.Context,
+	invoice *Invoice,
+	opts *WaitForInvoiceOptions,
+) (*Payment, error) {
+	if opts == nil {
+		opts = &WaitForInvoiceOptions{}
+	}
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultInvoicePollInterval
+	}
+
+	clock := clockOrDefault(wallet.clock)
+	settled := opts.Settled
+	var lastErr error
+	for {
+		paid, payment, err := wallet.reader.IsInvoicePaid(ctx, invoice.ID)
+		if errors.Is(err, ErrInvoiceNotFound) {
+			return nil, fmt.Errorf("WaitForInvoice: %w", err)
+		} else if err == nil && paid {
+			return payment, nil
+		} else if err == nil && !invoice.Expires.IsZero() && !clock.Now().Before(invoice.Expires) {
+			return nil, fmt.Errorf("WaitForInvoice: %w: %s", ErrInvoiceExpired, invoice.ID)
+		}
+		lastErr = err
+
+		select {
+		case <-clock.After(pollInterval):
+		case <-settled:
+			settled = nil
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("WaitForInvoice: %w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return nil, fmt.Errorf("WaitForInvoice: %w", ctx.Err())
+		}
+	}
+}
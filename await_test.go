@@ -0,0 +1,69 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForInvoiceSettledSignal(t *testing.T) {
+	settled := make(chan struct{})
+	var polls atomic.Int32
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		select {
+		case <-settled:
+			w.Write([]byte(`{"id":"payment","status":"PAID"}`))
+		default:
+			w.Write([]byte(`{"id":"payment","status":"PENDING"}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		payment, err := wallet.WaitForInvoiceWith(ctx, &Invoice{ID: "inv1"}, &WaitForInvoiceOptions{
+			PollInterval: time.Hour,
+			Settled:      settled,
+		})
+		if err == nil && payment.ID != "payment" {
+			t.Errorf("unexpected payment: %+v", payment)
+		}
+		done <- err
+	}()
+
+	for polls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(settled)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WaitForInvoice failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("WaitForInvoice did not return promptly after the settled signal")
+	}
+	if n := polls.Load(); n != 2 {
+		t.Fatalf("expected 2 polls, got %d", n)
+	}
+}
+
+func TestWaitForInvoiceExpired(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"payment","status":"PENDING"}`))
+	})
+	clock := newFakeClock()
+	wallet.SetClock(clock)
+
+	invoice := &Invoice{ID: "inv1", Expires: clock.Now().Add(time.Minute)}
+	if _, err := wallet.WaitForInvoice(context.Background(), invoice, 0); !errors.Is(err, ErrInvoiceExpired) {
+		t.Fatalf("expected ErrInvoiceExpired, got %v", err)
+	}
+}
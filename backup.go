@@ -0,0 +1,255 @@
+package wos
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrDecryptionFailed is returned when sealed data cannot be decrypted, either
+	// because the passphrase is wrong or the data has been corrupted.
+	ErrDecryptionFailed = errors.New("decryption failed: wrong passphrase or corrupted data")
+
+	// ErrSecretUnavailable is returned by [Wallet.ExportBackup] if the wallet's
+	// [Signer] does not expose its APISecret, as is the case for remote signers.
+	ErrSecretUnavailable = errors.New("wallet signer does not expose its API secret")
+)
+
+const (
+	sealVersion    = 1
+	sealSaltSize   = 16
+	sealIterations = 600_000
+)
+
+// seal encrypts plaintext with a key derived from passphrase, using PBKDF2-HMAC-SHA256
+// and AES-256-GCM. The output is formatted as:
+//
+//	version (1 byte) || salt (16 bytes) || nonce (12 bytes) || ciphertext
+//
+// The version and salt are authenticated as additional data.
+func seal(plaintext []byte, passphrase string) ([]byte, error) {
+	header := make([]byte, 1+sealSaltSize)
+	header[0] = sealVersion
+	if _, err := rand.Read(header[1:]); err != nil {
+		return nil, fmt.Errorf("generating salt: %w", err)
+	}
+
+	aead, err := sealCipher(passphrase, header[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	sealed := append(header, nonce...)
+	return aead.Seal(sealed, nonce, plaintext, header), nil
+}
+
+// unseal decrypts data produced by seal.
+func unseal(sealed []byte, passphrase string) ([]byte, error) {
+	if len(sealed) < 1+sealSaltSize {
+		return nil, ErrDecryptionFailed
+	} else if sealed[0] != sealVersion {
+		return nil, fmt.Errorf("unsupported sealed data version %d", sealed[0])
+	}
+
+	header := sealed[:1+sealSaltSize]
+	aead, err := sealCipher(passphrase, header[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	rest := sealed[len(header):]
+	if len(rest) < aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func sealCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key := pbkdf2SHA256([]byte(passphrase), salt, sealIterations, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 implements PBKDF2 (RFC 8018) with HMAC-SHA256 as the PRF.
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	key := make([]byte, 0, keyLen)
+
+	var blockIndex [4]byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		binary.BigEndian.PutUint32(blockIndex[:], block)
+
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(blockIndex[:])
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// Seal encrypts the credentials with a key derived from the given passphrase, so
+// that they can be stored safely at rest. Use [OpenSealedCredentials] to decrypt them.
+func (creds Credentials) Seal(passphrase string) ([]byte, error) {
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return nil, err
+	}
+	return seal(plaintext, passphrase)
+}
+
+// OpenSealedCredentials decrypts credentials encrypted by [Credentials.Seal].
+//
+// Returns [ErrDecryptionFailed] if the passphrase is incorrect.
+func OpenSealedCredentials(sealed []byte, passphrase string) (*Credentials, error) {
+	plaintext, err := unseal(sealed, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, fmt.Errorf("invalid sealed credentials: %w", err)
+	}
+	return &creds, nil
+}
+
+const backupVersion = 1
+
+// walletBackup is the plaintext content of a wallet backup.
+type walletBackup struct {
+	Version          int         `json:"version"`
+	Credentials      Credentials `json:"credentials"`
+	LightningAddress string      `json:"lightningAddress"`
+	OnChainAddress   string      `json:"onChainAddress"`
+	ExportedAt       time.Time   `json:"exportedAt"`
+}
+
+// ExportBackup writes an encrypted backup of the wallet to w, including its
+// [Credentials] and cached addresses. The backup is encrypted and authenticated
+// with a key derived from passphrase. Use [ImportBackup] to restore it.
+//
+// Returns [ErrSecretUnavailable] if the wallet was not opened with a [SimpleSigner],
+// since other signers do not expose the APISecret needed to restore the wallet.
+func (wallet *Wallet) ExportBackup(w io.Writer, passphrase string) error {
+	signer, ok := wallet.signer.(*SimpleSigner)
+	if !ok {
+		return fmt.Errorf("ExportBackup: %w", ErrSecretUnavailable)
+	}
+
+	wallet.addressMu.RLock()
+	onChainAddress := wallet.onChainAddress
+	wallet.addressMu.RUnlock()
+
+	plaintext, err := json.Marshal(walletBackup{
+		Version: backupVersion,
+		Credentials: Credentials{
+			APISecret: signer.apiSecret,
+			APIToken:  wallet.reader.apiToken,
+		},
+		LightningAddress: wallet.LightningAddress().String(),
+		OnChainAddress:   onChainAddress,
+		ExportedAt:       time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("ExportBackup: %w", err)
+	}
+
+	sealed, err := seal(plaintext, passphrase)
+	if err != nil {
+		return fmt.Errorf("ExportBackup: %w", err)
+	}
+
+	if _, err := w.Write(sealed); err != nil {
+		return fmt.Errorf("ExportBackup: %w", err)
+	}
+	return nil
+}
+
+// ImportBackup decrypts a backup written by [Wallet.ExportBackup] and re-opens the
+// wallet, using the given [http.Client] for all API calls. Re-opening the wallet
+// verifies that the backed-up credentials are still accepted by WoS, and ctx bounds
+// the requests made to do so.
+//
+// The addresses WoS returns on re-opening are cached as usual, being the most up to
+// date. Any address WoS does not return is restored from the backup instead.
+//
+// Returns an error wrapping [ErrDecryptionFailed] if the passphrase is incorrect.
+func ImportBackup(
+	ctx context.Context,
+	r io.Reader,
+	passphrase string,
+	httpClient *http.Client,
+) (*Wallet, error) {
+	sealed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ImportBackup: %w", err)
+	}
+
+	plaintext, err := unseal(sealed, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("ImportBackup: %w", err)
+	}
+
+	var backup walletBackup
+	if err := json.Unmarshal(plaintext, &backup); err != nil {
+		return nil, fmt.Errorf("ImportBackup: invalid backup: %w", err)
+	} else if backup.Version != backupVersion {
+		return nil, fmt.Errorf("ImportBackup: unsupported backup version %d", backup.Version)
+	}
+
+	wallet, err := backup.Credentials.OpenWallet(ctx, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("ImportBackup: %w", err)
+	}
+
+	lnAddress, err := parseProvisionedLightningAddress(backup.LightningAddress)
+	if err != nil {
+		return nil, fmt.Errorf("ImportBackup: invalid backup: %w", err)
+	}
+
+	wallet.addressMu.Lock()
+	defer wallet.addressMu.Unlock()
+	if wallet.lightningAddress == (LightningAddress{}) {
+		wallet.lightningAddress = lnAddress
+	}
+	if wallet.onChainAddress == "" {
+		wallet.onChainAddress = backup.OnChainAddress
+	}
+	return wallet, nil
+}
@@ -0,0 +1,63 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// Test vector from RFC 7914, section 11.
+	key := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+		"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if hex.EncodeToString(key) != want {
+		t.Fatalf("unexpected PBKDF2 output: %x", key)
+	}
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	lightningAddress := "user@walletofsatoshi.com"
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Api-Token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"btcDepositAddress":"bc1qexample","lightningAddress":"` + lightningAddress + `"}`))
+	})
+
+	var buf bytes.Buffer
+	if err := wallet.ExportBackup(&buf, "correct horse"); err != nil {
+		t.Fatalf("ExportBackup failed: %v", err)
+	}
+	backup := buf.Bytes()
+
+	restored, err := ImportBackup(context.Background(), bytes.NewReader(backup), "correct horse", wallet.httpClient)
+	if err != nil {
+		t.Fatalf("ImportBackup failed: %v", err)
+	}
+	if restored.reader.apiToken != "token" || restored.signer.(*SimpleSigner).apiSecret != "secret" {
+		t.Fatalf("credentials not restored correctly")
+	}
+	if restored.LightningAddress() != wallet.LightningAddress() {
+		t.Fatalf("expected lightning address %s, got %s", wallet.LightningAddress(), restored.LightningAddress())
+	}
+
+	// Addresses WoS no longer returns are restored from the backup.
+	lightningAddress = ""
+	restored, err = ImportBackup(context.Background(), bytes.NewReader(backup), "correct horse", wallet.httpClient)
+	if err != nil {
+		t.Fatalf("ImportBackup failed: %v", err)
+	}
+	if restored.LightningAddress() != wallet.LightningAddress() {
+		t.Fatalf("expected cached lightning address %s, got %s", wallet.LightningAddress(), restored.LightningAddress())
+	}
+
+	_, err = ImportBackup(context.Background(), bytes.NewReader(backup), "wrong horse", wallet.httpClient)
+	if !errors.Is(err, ErrDecryptionFailed) {
+		t.Fatalf("expected ErrDecryptionFailed for wrong passphrase, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrBatchExists is returned by [Wallet.PayBatch] when a batch with the same ID was
+// already saved in the store. Use [Wallet.ResumeBatch] to continue it instead.
+var ErrBatchExists = errors.New("batch already exists")
+
+// BatchItem is a single payment within a batch paid by [Wallet.PayBatch].
+type BatchItem struct {
+	// Destination is anything accepted by [Wallet.Pay].
+	Destination string  `json:"destination"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description,omitempty"`
+}
+
+// BatchItemStatus records how far a [BatchItem] has progressed.
+type BatchItemStatus string
+
+const (
+	// BatchItemUnstarted means the item's payment has not been attempted yet.
+	BatchItemUnstarted BatchItemStatus = "UNSTARTED"
+
+	// BatchItemStarted means the item's payment was sent, but its outcome was never
+	// recorded or is unknown, such as when the process crashed mid-payment, or the
+	// request was cancelled or failed without a response from WoS. The payment may or may
+	// not have been made, so it must be reconciled against the payment history by
+	// hand, and is never retried automatically.
+	BatchItemStarted BatchItemStatus = "STARTED"
+
+	// BatchItemPaid means the item's payment was made.
+	BatchItemPaid BatchItemStatus = "PAID"
+
+	// BatchItemFailed means WoS rejected the item's payment.
+	BatchItemFailed BatchItemStatus = "FAILED"
+)
+
+// BatchItemResult records the outcome of a [BatchItem].
+type BatchItemResult struct {
+	BatchItem
+	Status BatchItemStatus `json:"status"`
+
+	// Payment is the payment made for the item, if its status is BatchItemPaid.
+	Payment *Payment `json:"payment,omitempty"`
+
+	// Error describes why the item failed, if its status is BatchItemFailed, or why
+	// its outcome is unknown, if its status is BatchItemStarted.
+	Error string `json:"error,omitempty"`
+}
+
+// BatchState is the progress of a batch, as persisted by a [BatchStore] under the batch's ID.
+type BatchState struct {
+	Items []BatchItemResult `json:"items"`
+}
+
+// PayBatch pays each of the given items in turn, recording each item's result in store
+// under batchID as it completes, so that an interrupted batch can be continued with
+// [Wallet.ResumeBatch] without paying any item twice. Before each payment is sent, the
+// item is recorded as [BatchItemStarted], so a crash mid-payment leaves the item
+// flagged for reconciliation rather than retried.
+//
+// A failed payment is recorded as [BatchItemFailed], and does not stop the batch. The
+// returned results are in the order of items. An error is only returned if the batch
+// could not be saved, or ctx is done before the batch completes, in which case the
+// results so far are returned alongside it. Returns [ErrBatchExists] if a batch was
+// already saved under batchID.
+func (wallet *Wallet) PayBatch(ctx context.Context, store BatchStore, batchID string, items []BatchItem) ([]BatchItemResult, error) {
+	if _, err := store.LoadBatch(ctx, batchID); err == nil {
+		return nil, fmt.Errorf("PayBatch: %w: %s", ErrBatchExists, batchID)
+	} else if !errors.Is(err, ErrNotStored) {
+		return nil, fmt.Errorf("PayBatch: %w", err)
+	}
+
+	state := &BatchState{Items: make([]BatchItemResult, len(items))}
+	for i, item := range items {
+		state.Items[i] = BatchItemResult{BatchItem: item, Status: BatchItemUnstarted}
+	}
+	if err := store.SaveBatch(ctx, batchID, *state); err != nil {
+		return nil, fmt.Errorf("PayBatch: %w", err)
+	}
+
+	if err := wallet.runBatch(ctx, store, batchID, state); err != nil {
+		return state.Items, fmt.Errorf("PayBatch: %w", err)
+	}
+	return state.Items, nil
+}
+
+// ResumeBatch continues a batch started by [Wallet.PayBatch] which was interrupted,
+// paying only the items which are still [BatchItemUnstarted]. Items which were paid,
+// failed, or whose outcome is unknown are left as they are. Returns the results of
+// every item in the batch, or an error wrapping [ErrNotStored] if there is no batch
+// saved under batchID.
+func (wallet *Wallet) ResumeBatch(ctx context.Context, store BatchStore, batchID string) ([]BatchItemResult, error) {
+	state, err := store.LoadBatch(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("ResumeBatch: %w", err)
+	}
+	if err := wallet.runBatch(ctx, store, batchID, state); err != nil {
+		return state.Items, fmt.Errorf("ResumeBatch: %w", err)
+	}
+	return state.Items, nil
+}
+
+// runBatch pays every unstarted item in state, saving state after each step.
+func (wallet *Wallet) runBatch(ctx context.Context, store BatchStore, batchID string, state *BatchState) error {
+	for i := range state.Items {
+		result := &state.Items[i]
+		if result.Status != BatchItemUnstarted {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result.Status = BatchItemStarted
+		if err := store.SaveBatch(ctx, batchID, *state); err != nil {
+			result.Status = BatchItemUnstarted
+			return err
+		}
+
+		payment, err := wallet.Pay(ctx, result.Destination, result.Amount, result.Description)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+			result.Status = BatchItemFailed
+			result.Error = err.Error()
+		} else if err != nil {
+			// The payment may have gone through, so the item stays started.
+			result.Error = err.Error()
+		} else {
+			result.Status = BatchItemPaid
+			result.Payment = payment
+		}
+
+		if err := store.SaveBatch(ctx, batchID, *state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestResumeBatch(t *testing.T) {
+	var paid []string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		var req sendPaymentRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		paid = append(paid, req.Address)
+		if req.Address == "bc1qfails" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"INVALID_ADDRESS"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"` + req.Address + `","status":"PAID"}`))
+	})
+	ctx := context.Background()
+
+	// A batch which was interrupted after paying its first item, and while paying
+	// its second.
+	store := &MemoryStore{}
+	store.SaveBatch(ctx, "payroll", BatchState{Items: []BatchItemResult{
+		{BatchItem: BatchItem{Destination: "bc1qalice", Amount: 0.001}, Status: BatchItemPaid},
+		{BatchItem: BatchItem{Destination: "bc1qbob", Amount: 0.001}, Status: BatchItemStarted},
+		{BatchItem: BatchItem{Destination: "bc1qcarol", Amount: 0.001}, Status: BatchItemUnstarted},
+		{BatchItem: BatchItem{Destination: "bc1qfails", Amount: 0.001}, Status: BatchItemUnstarted},
+	}})
+
+	results, err := wallet.ResumeBatch(ctx, store, "payroll")
+	if err != nil {
+		t.Fatalf("ResumeBatch failed: %v", err)
+	}
+	if len(paid) != 2 || paid[0] != "bc1qcarol" || paid[1] != "bc1qfails" {
+		t.Fatalf("expected only unstarted items to be paid, got %v", paid)
+	}
+
+	expected := []BatchItemStatus{BatchItemPaid, BatchItemStarted, BatchItemPaid, BatchItemFailed}
+	for i, want := range expected {
+		if results[i].Status != want {
+			t.Errorf("item %d: expected status %s, got %s", i, want, results[i].Status)
+		}
+	}
+	if results[2].Payment == nil || results[2].Payment.ID != "bc1qcarol" || results[3].Error == "" {
+		t.Fatalf("unexpected item results: %+v", results)
+	}
+
+	saved, _ := store.LoadBatch(ctx, "payroll")
+	if saved.Items[2].Status != BatchItemPaid || saved.Items[3].Status != BatchItemFailed {
+		t.Fatalf("batch progress was not saved: %+v", saved.Items)
+	}
+
+	// Resuming a finished batch pays nothing.
+	paid = nil
+	if _, err := wallet.ResumeBatch(ctx, store, "payroll"); err != nil || len(paid) != 0 {
+		t.Fatalf("expected nothing to be paid, got %v (err=%v)", paid, err)
+	}
+	if _, err := wallet.PayBatch(ctx, store, "payroll", nil); !errors.Is(err, ErrBatchExists) {
+		t.Fatalf("expected ErrBatchExists, got %v", err)
+	}
+	if _, err := wallet.ResumeBatch(ctx, store, "unknown"); !errors.Is(err, ErrNotStored) {
+		t.Fatalf("expected ErrNotStored, got %v", err)
+	}
+}
+
+func TestPayBatch(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"payment","status":"PAID"}`))
+	})
+	ctx := context.Background()
+	store := &MemoryStore{}
+
+	items := []BatchItem{
+		{Destination: "bc1qalice", Amount: 0.001},
+		{Destination: "bc1qbob", Amount: 0.002},
+	}
+	results, err := wallet.PayBatch(ctx, store, "batch", items)
+	if err != nil {
+		t.Fatalf("PayBatch failed: %v", err)
+	}
+	for i, result := range results {
+		if result.Status != BatchItemPaid || result.BatchItem != items[i] {
+			t.Fatalf("unexpected result %d: %+v", i, result)
+		}
+	}
+}
+
+func TestPayBatchCancelledMidPayment(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	})
+	store := &MemoryStore{}
+
+	items := []BatchItem{{Destination: "bc1qalice", Amount: 0.001}, {Destination: "bc1qbob", Amount: 0.001}}
+	results, err := wallet.PayBatch(ctx, store, "payroll", items)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if results[0].Status != BatchItemStarted || results[1].Status != BatchItemUnstarted {
+		t.Fatalf("expected the cancelled item to stay started, got %+v", results)
+	}
+
+	saved, _ := store.LoadBatch(context.Background(), "payroll")
+	if saved.Items[0].Status != BatchItemStarted {
+		t.Fatalf("expected the started status to be saved, got %s", saved.Items[0].Status)
+	}
+}
@@ -236,10 +236,10 @@ func decodeNoLimit(bech string) (string, []byte, Version, error) {
 		writeBech32Checksum(hrp, payload, &expectedBldr, Version0)
 		expectedVersion0 := expectedBldr.String()
 
-		var b strings.Builder
-		b.Grow(6)
-		writeBech32Checksum(hrp, payload, &expectedBldr, VersionM)
-		expectedVersionM := expectedBldr.String()
+		var expectedBldrM strings.Builder
+		expectedBldrM.Grow(6)
+		writeBech32Checksum(hrp, payload, &expectedBldrM, VersionM)
+		expectedVersionM := expectedBldrM.String()
 
 		err = ErrInvalidChecksum{
 			Expected:  expectedVersion0,
@@ -266,6 +266,14 @@ func DecodeNoLimit(bech string) (string, []byte, error) {
 	return hrp, data, err
 }
 
+// DecodeNoLimitWithVersion is identical to DecodeNoLimit, but will also return
+// the bech32 version that matches the decoded checksum. This method should be
+// used when decoding payment requests, which must use the original bech32
+// checksum rather than bech32m.
+func DecodeNoLimitWithVersion(bech string) (string, []byte, Version, error) {
+	return decodeNoLimit(bech)
+}
+
 // Decode decodes a bech32 encoded string, returning the human-readable part and
 // the data part excluding the checksum.
 //
@@ -0,0 +1,232 @@
+// Copyright (c) 2017 The btcsuite developers
+// Copyright (c) 2019 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bech32
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestBech32 tests the BIP173 and BIP350 test vectors, along with some edge cases.
+func TestBech32(t *testing.T) {
+	tests := []struct {
+		str     string
+		version Version
+		err     error
+	}{
+		// BIP173 valid bech32 strings.
+		{"A12UEL5L", Version0, nil},
+		{"a12uel5l", Version0, nil},
+		{"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs", Version0, nil},
+		{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", Version0, nil},
+		{"11" + strings.Repeat("q", 82) + "c8247j", Version0, nil},
+		{"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", Version0, nil},
+		{"?1ezyfcl", Version0, nil},
+
+		// BIP173 invalid bech32 strings.
+		{"\x201nwldj5", VersionUnknown, ErrInvalidCharacter(0x20)},
+		{"\x7f1axkwrx", VersionUnknown, ErrInvalidCharacter(0x7f)},
+		{"\x801eym55h", VersionUnknown, ErrInvalidCharacter(0x80)},
+		{"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx", VersionUnknown, ErrInvalidLength(91)},
+		{"pzry9x0s0muk", VersionUnknown, ErrInvalidSeparatorIndex(-1)},
+		{"1pzry9x0s0muk", VersionUnknown, ErrInvalidSeparatorIndex(0)},
+		{"x1b4n0q5v", VersionUnknown, ErrNonCharsetChar('b')},
+		{"li1dgmt3", VersionUnknown, ErrInvalidSeparatorIndex(2)},
+		{"de1lg7wt\xff", VersionUnknown, ErrInvalidCharacter(0xff)},
+		{"A1G7SGD8", VersionUnknown, ErrInvalidChecksum{}},
+		{"10a06t8", VersionUnknown, ErrInvalidLength(7)},
+		{"1qzzfhee", VersionUnknown, ErrInvalidSeparatorIndex(0)},
+
+		// BIP350 valid bech32m strings.
+		{"A1LQFN3A", VersionM, nil},
+		{"a1lqfn3a", VersionM, nil},
+		{"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6", VersionM, nil},
+		{"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx", VersionM, nil},
+		{"11" + strings.Repeat("l", 82) + "ludsr8", VersionM, nil},
+		{"split1checkupstagehandshakeupstreamerranterredcaperredlc445v", VersionM, nil},
+		{"?1v759aa", VersionM, nil},
+
+		// BIP350 invalid bech32m strings.
+		{"\x201xj0phk", VersionUnknown, ErrInvalidCharacter(0x20)},
+		{"\x7f1g6xzxy", VersionUnknown, ErrInvalidCharacter(0x7f)},
+		{"\x801vctc34", VersionUnknown, ErrInvalidCharacter(0x80)},
+		{"an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4", VersionUnknown, ErrInvalidLength(91)},
+		{"qyrz8wqd2c9m", VersionUnknown, ErrInvalidSeparatorIndex(-1)},
+		{"1qyrz8wqd2c9m", VersionUnknown, ErrInvalidSeparatorIndex(0)},
+		{"y1b0jsk6g", VersionUnknown, ErrNonCharsetChar('b')},
+		{"lt1igcx5c0", VersionUnknown, ErrNonCharsetChar('i')},
+		{"in1muywd", VersionUnknown, ErrInvalidSeparatorIndex(2)},
+		{"mm1crxm3i", VersionUnknown, ErrNonCharsetChar('i')},
+		{"au1s5cgom", VersionUnknown, ErrNonCharsetChar('o')},
+		{"M1VUXWEZ", VersionUnknown, ErrInvalidChecksum{}},
+		{"16plkw9", VersionUnknown, ErrInvalidLength(7)},
+		{"1p2gdwpf", VersionUnknown, ErrInvalidSeparatorIndex(0)},
+
+		// Edge cases.
+		{"A12uEL5L", VersionUnknown, ErrMixedCase{}},
+		{"a12uel5m", VersionUnknown, ErrInvalidChecksum{}},
+		{"a12uel5", VersionUnknown, ErrInvalidLength(7)},
+		{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxx", VersionUnknown, ErrInvalidChecksum{}},
+		{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw ", VersionUnknown, ErrInvalidCharacter(' ')},
+	}
+
+	for _, test := range tests {
+		hrp, data, version, err := DecodeGeneric(test.str)
+		if test.err != nil {
+			if reflect.TypeOf(err) != reflect.TypeOf(test.err) {
+				t.Errorf("%q: expected error of type %T, got %v", test.str, test.err, err)
+			} else if _, isChecksum := err.(ErrInvalidChecksum); !isChecksum && err != test.err {
+				t.Errorf("%q: expected error %v, got %v", test.str, test.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.str, err)
+			continue
+		} else if version != test.version {
+			t.Errorf("%q: expected version %d, got %d", test.str, test.version, version)
+		}
+
+		// Re-encoding must give back the lowercase form of the original string.
+		var encoded string
+		if version == VersionM {
+			encoded, err = EncodeM(hrp, data)
+		} else {
+			encoded, err = Encode(hrp, data)
+		}
+		if err != nil {
+			t.Errorf("%q: encoding failed: %v", test.str, err)
+		} else if encoded != strings.ToLower(test.str) {
+			t.Errorf("%q: expected to re-encode as %q, got %q", test.str, strings.ToLower(test.str), encoded)
+		}
+	}
+}
+
+// TestChecksumOffByOne checks that changing any single character of a valid string,
+// in its HRP, data or checksum, is detected.
+func TestChecksumOffByOne(t *testing.T) {
+	for _, valid := range []string{
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+	} {
+		for i := 0; i < len(valid); i++ {
+			if valid[i] == '1' {
+				continue
+			}
+			next := charset[(strings.IndexByte(charset, valid[i])+1)%len(charset)]
+			if i < strings.LastIndexByte(valid, '1') {
+				next = valid[i] + 1
+			}
+			corrupted := valid[:i] + string(next) + valid[i+1:]
+			if _, _, err := DecodeNoLimit(corrupted); err == nil {
+				t.Errorf("expected %q to be rejected", corrupted)
+			}
+		}
+	}
+}
+
+// TestInvalidChecksumError checks that an invalid checksum error reports the checksums
+// which would make the string valid in each version.
+func TestInvalidChecksumError(t *testing.T) {
+	const str = "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxx"
+	_, _, err := DecodeNoLimit(str)
+
+	var checksumErr ErrInvalidChecksum
+	if !errors.As(err, &checksumErr) {
+		t.Fatalf("expected ErrInvalidChecksum, got %v", err)
+	} else if checksumErr.Actual != "mqqqxx" {
+		t.Fatalf("expected actual checksum mqqqxx, got %q", checksumErr.Actual)
+	}
+
+	prefix := str[:len(str)-6]
+	for expected, want := range map[string]Version{
+		checksumErr.Expected:  Version0,
+		checksumErr.ExpectedM: VersionM,
+	} {
+		if len(expected) != 6 {
+			t.Fatalf("expected 6 character checksum, got %q", expected)
+		}
+		_, _, version, err := DecodeNoLimitWithVersion(prefix + expected)
+		if err != nil {
+			t.Fatalf("expected checksum %q to be valid: %v", expected, err)
+		} else if version != want {
+			t.Fatalf("expected checksum %q to be version %d, got %d", expected, want, version)
+		}
+	}
+}
+
+// TestDecodeLengthLimit checks that Decode enforces the 90 character limit of BIP173,
+// while DecodeNoLimit accepts longer strings, as used by lightning invoices.
+func TestDecodeLengthLimit(t *testing.T) {
+	for _, n := range []int{90 - 8, 91 - 8, 500} {
+		encoded, err := Encode("a", make([]byte, n))
+		if err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+
+		_, _, err = Decode(encoded)
+		if len(encoded) <= 90 && err != nil {
+			t.Errorf("expected %d character string to decode: %v", len(encoded), err)
+		} else if len(encoded) > 90 && err != ErrInvalidLength(len(encoded)) {
+			t.Errorf("expected %d character string to be too long, got %v", len(encoded), err)
+		}
+
+		if _, data, err := DecodeNoLimit(encoded); err != nil {
+			t.Errorf("DecodeNoLimit failed on %d character string: %v", len(encoded), err)
+		} else if len(data) != n {
+			t.Errorf("expected %d data words, got %d", n, len(data))
+		}
+	}
+}
+
+// TestEncodeInvalidData checks that data words of more than 5 bits are rejected.
+func TestEncodeInvalidData(t *testing.T) {
+	if _, err := Encode("a", []byte{0, 32}); err != ErrInvalidDataByte(32) {
+		t.Fatalf("expected ErrInvalidDataByte, got %v", err)
+	}
+}
+
+// TestConvertBits tests converting between 8 and 5 bit groups, with and without padding.
+func TestConvertBits(t *testing.T) {
+	tests := []struct {
+		input    string
+		fromBits uint8
+		toBits   uint8
+		pad      bool
+		output   string
+		err      error
+	}{
+		{"", 8, 5, true, "", nil},
+		{"ff", 8, 5, true, "1f1c", nil},
+		{"ff", 8, 5, false, "", ErrInvalidIncompleteGroup{}},
+		{"1f1c", 5, 8, false, "ff", nil},
+		{"1f1f", 5, 8, false, "", ErrInvalidIncompleteGroup{}},
+		{"00443214c74254b635cf84653a56d7c675be77df", 8, 5, true, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", nil},
+		{"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", 5, 8, false, "00443214c74254b635cf84653a56d7c675be77df", nil},
+		{"ff", 0, 5, true, "", ErrInvalidBitGroups{}},
+		{"ff", 8, 9, true, "", ErrInvalidBitGroups{}},
+	}
+
+	for _, test := range tests {
+		input, _ := hex.DecodeString(test.input)
+		output, err := ConvertBits(input, test.fromBits, test.toBits, test.pad)
+		if err != test.err {
+			t.Errorf("ConvertBits(%s, %d, %d, %v): expected error %v, got %v",
+				test.input, test.fromBits, test.toBits, test.pad, test.err, err)
+			continue
+		}
+		want, _ := hex.DecodeString(test.output)
+		if err == nil && !bytes.Equal(output, want) {
+			t.Errorf("ConvertBits(%s, %d, %d, %v): expected %x, got %x",
+				test.input, test.fromBits, test.toBits, test.pad, want, output)
+		}
+	}
+}
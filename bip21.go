@@ -0,0 +1,139 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedAddressType is returned by [Wallet.OnChainRequest] when the wallet's
+// deposit address is not a recognizable mainnet address.
+var ErrUnsupportedAddressType = errors.New("unsupported on-chain address type")
+
+// OnChainRequest builds a BIP21 payment request for the wallet's current on-chain
+// deposit address, and renders it as a QR code in PNG format. This is the on-chain
+// counterpart to showing a lightning invoice as a QR code.
+//
+// The amount is in BTC, and is omitted from the URI if zero, letting the payer choose.
+// The label is omitted if empty.
+//
+// Returns an error wrapping [ErrUnsupportedRegion] if WoS does not offer on-chain
+// deposits in the wallet's region, or [ErrUnsupportedAddressType] if the deposit
+// address is not a recognizable mainnet address. Returns an error wrapping
+// [ErrQRTooLong] if the URI is longer than 213 bytes, which can happen with a long
+// label once it is percent-encoded.
+func (wallet *Wallet) OnChainRequest(
+	ctx context.Context,
+	amount float64,
+	label string,
+) (uri string, qr []byte, err error) {
+	if amount < 0 {
+		return "", nil, fmt.Errorf("OnChainRequest: %w: amount %v is negative", ErrMalformedAmount, amount)
+	}
+
+	address, err := wallet.reader.OnChainAddress(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("OnChainRequest: %w", err)
+	}
+	if _, ok := DetectAddressType(address); !ok {
+		return "", nil, fmt.Errorf("OnChainRequest: %w: %s", ErrUnsupportedAddressType, address)
+	}
+
+	uri = bip21URI(address, amount, label)
+	code, err := encodeQR([]byte(uri))
+	if err != nil {
+		return "", nil, fmt.Errorf("OnChainRequest: %w", err)
+	}
+	qr, err = code.png()
+	if err != nil {
+		return "", nil, fmt.Errorf("OnChainRequest: %w", err)
+	}
+	return uri, qr, nil
+}
+
+// UnifiedInvoice is a lightning invoice combined with an on-chain fallback, created by
+// [Wallet.NewInvoiceWithOnChainFallback].
+type UnifiedInvoice struct {
+	// Invoice is the lightning invoice.
+	*Invoice
+
+	// OnChainAddress is the wallet's on-chain deposit address, which the payer
+	// can pay instead if lightning fails.
+	OnChainAddress string
+
+	// URI is a BIP21 URI for OnChainAddress, carrying the invoice in its lightning
+	// parameter. Wallets which understand the parameter pay the invoice, and others
+	// pay on-chain. This is what should be displayed to the payer or encoded in QR codes.
+	URI string
+}
+
+// NewInvoiceWithOnChainFallback creates a lightning invoice as with [Wallet.NewInvoice],
+// and combines it with the wallet's on-chain deposit address in a single payment
+// request, so that a payer whose lightning payment fails can pay on-chain instead.
+//
+// BOLT11 has a fallback address field for this purpose, but WoS creates and signs
+// invoices itself, and does not accept a fallback address for them, so the fallback
+// cannot be embedded in the invoice. Instead, the request is a BIP21 URI with the
+// invoice in its lightning parameter, as widely supported by bitcoin wallets. The
+// invoice's amount and description become the URI's amount and label.
+//
+// Note that the on-chain address does not expire with the invoice, and a payment made
+// to it is not linked to the invoice in the wallet's history. Callers accepting either
+// must watch for both a lightning payment to the invoice and an on-chain deposit.
+//
+// Returns an error wrapping [ErrUnsupportedRegion] if WoS does not offer on-chain
+// deposits in the wallet's region, or [ErrUnsupportedAddressType] if the deposit
+// address is not a recognizable mainnet address. In either case, no invoice is created.
+func (wallet *Wallet) NewInvoiceWithOnChainFallback(ctx context.Context, opts *InvoiceOptions) (*UnifiedInvoice, error) {
+	if opts == nil {
+		opts = &InvoiceOptions{}
+	}
+
+	address, err := wallet.reader.OnChainAddress(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("NewInvoiceWithOnChainFallback: %w", err)
+	}
+	if _, ok := DetectAddressType(address); !ok {
+		return nil, fmt.Errorf("NewInvoiceWithOnChainFallback: %w: %s", ErrUnsupportedAddressType, address)
+	}
+
+	invoice, err := wallet.NewInvoice(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("NewInvoiceWithOnChainFallback: %w", err)
+	}
+
+	uri := bip21URI(address, opts.Amount, opts.Description)
+	if strings.Contains(uri, "?") {
+		uri += "&lightning=" + invoice.Bolt11
+	} else {
+		uri += "?lightning=" + invoice.Bolt11
+	}
+	return &UnifiedInvoice{
+		Invoice:        invoice,
+		OnChainAddress: address,
+		URI:            uri,
+	}, nil
+}
+
+// bip21URI formats a BIP21 URI, omitting the amount if zero and the label if empty.
+func bip21URI(address string, amount float64, label string) string {
+	var params []string
+	if sats := toSats(amount); sats > 0 {
+		btc := strconv.FormatFloat(float64(sats)/100_000_000, 'f', 8, 64)
+		btc = strings.TrimRight(strings.TrimRight(btc, "0"), ".")
+		params = append(params, "amount="+btc)
+	}
+	if label != "" {
+		// BIP21 requires spaces to be percent-encoded rather than written as '+'.
+		params = append(params, "label="+strings.ReplaceAll(url.QueryEscape(label), "+", "%20"))
+	}
+
+	uri := "bitcoin:" + address
+	if len(params) > 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri
+}
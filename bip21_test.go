@@ -0,0 +1,123 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBIP21URI(t *testing.T) {
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	tests := []struct {
+		amount float64
+		label  string
+		want   string
+	}{
+		{0, "", "bitcoin:" + address},
+		{0.001, "", "bitcoin:" + address + "?amount=0.001"},
+		{1, "", "bitcoin:" + address + "?amount=1"},
+		{0.00012345, "Coffee & cake", "bitcoin:" + address + "?amount=0.00012345&label=Coffee%20%26%20cake"},
+		{0, "rent", "bitcoin:" + address + "?label=rent"},
+	}
+	for _, test := range tests {
+		if got := bip21URI(address, test.amount, test.label); got != test.want {
+			t.Errorf("bip21URI(%v, %q): expected %q, got %q", test.amount, test.label, test.want, got)
+		}
+	}
+}
+
+func TestOnChainRequest(t *testing.T) {
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"btcDepositAddress":"` + address + `","lightningAddress":"user@walletofsatoshi.com"}`))
+	})
+
+	uri, qr, err := wallet.OnChainRequest(context.Background(), 0.001, "invoice 42")
+	if err != nil {
+		t.Fatalf("OnChainRequest failed: %v", err)
+	}
+	if want := "bitcoin:" + address + "?amount=0.001&label=invoice%2042"; uri != want {
+		t.Fatalf("expected URI %q, got %q", want, uri)
+	}
+
+	img, err := png.Decode(bytes.NewReader(qr))
+	if err != nil {
+		t.Fatalf("failed to decode QR code PNG: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != bounds.Dy() || bounds.Dx()%qrModuleSize != 0 {
+		t.Fatalf("unexpected QR code dimensions %v", bounds)
+	}
+
+	if _, _, err := wallet.OnChainRequest(context.Background(), 0.001, strings.Repeat("long label ", 20)); !errors.Is(err, ErrQRTooLong) {
+		t.Fatalf("expected ErrQRTooLong for an overly long label, got %v", err)
+	}
+}
+
+func TestOnChainRequestUnsupportedRegion(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"btcDepositAddress":"","lightningAddress":"user@walletofsatoshi.com"}`))
+	})
+
+	_, _, err := wallet.OnChainRequest(context.Background(), 0.001, "")
+	if !errors.Is(err, ErrUnsupportedRegion) {
+		t.Fatalf("expected ErrUnsupportedRegion, got %v", err)
+	}
+}
+
+func TestOnChainRequestUnsupportedAddressType(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"btcDepositAddress":"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"}`))
+	})
+
+	_, _, err := wallet.OnChainRequest(context.Background(), 0, "")
+	if !errors.Is(err, ErrUnsupportedAddressType) {
+		t.Fatalf("expected ErrUnsupportedAddressType, got %v", err)
+	}
+}
+
+func TestNewInvoiceWithOnChainFallback(t *testing.T) {
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/wallet/createInvoice" {
+			w.Write([]byte(`{"id":"i1","invoice":"` + testInvoiceCoffee + `","btcAmount":0.0025}`))
+			return
+		}
+		w.Write([]byte(`{"btcDepositAddress":"` + address + `","lightningAddress":"user@walletofsatoshi.com"}`))
+	})
+
+	unified, err := wallet.NewInvoiceWithOnChainFallback(context.Background(), &InvoiceOptions{
+		Amount:      0.0025,
+		Description: "coffee",
+	})
+	if err != nil {
+		t.Fatalf("NewInvoiceWithOnChainFallback failed: %v", err)
+	} else if unified.Bolt11 != testInvoiceCoffee || unified.OnChainAddress != address {
+		t.Fatalf("unexpected result: %+v", unified)
+	}
+
+	uri, err := url.Parse(unified.URI)
+	if err != nil {
+		t.Fatalf("invalid URI %q: %v", unified.URI, err)
+	} else if uri.Scheme != "bitcoin" || uri.Opaque != address {
+		t.Fatalf("unexpected URI %q", unified.URI)
+	}
+	query := uri.Query()
+	amount, _ := strconv.ParseFloat(query.Get("amount"), 64)
+	if amount != 0.0025 || query.Get("label") != "coffee" {
+		t.Fatalf("unexpected URI parameters: %v", query)
+	}
+
+	// The request is payable on-chain, or over lightning.
+	if err := ValidatePaymentInput(uri.Opaque, amount); err != nil {
+		t.Fatalf("on-chain fallback is not payable: %v", err)
+	}
+	if err := ValidatePaymentInput(query.Get("lightning"), amount); err != nil {
+		t.Fatalf("lightning invoice is not payable: %v", err)
+	}
+}
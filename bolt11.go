@@ -1,11 +1,15 @@
 package wos
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/conduition/wos/bech32"
 )
@@ -23,63 +27,116 @@ var (
 )
 
 // decodeAmount returns the amount encoded by the provided string in
-// millisatoshi.
-func decodeAmount(amount string) (uint64, error) {
+// millisatoshi. The amount must be a positive decimal number without leading
+// zeros, optionally followed by a single multiplier character.
+//
+// One millisatoshi is 10 pBTC, so pico amounts which are not a multiple of 10
+// cannot be paid exactly. BOLT11 requires such invoices to be rejected, but
+// some issuers produce them anyway, so they are rounded to the nearest msat,
+// with halves rounded up so that the payee receives at least what they asked
+// for. Amounts which round to zero are rejected.
+func decodeAmount(amount string) (Msat, error) {
 	if len(amount) < 1 {
 		return 0, fmt.Errorf("amount must be non-empty")
 	}
 
-	// If last character is a digit, then the amount can just be
-	// interpreted as BTC.
-	lastHRPChar := amount[len(amount)-1]
-	digit := lastHRPChar - '0'
-	if digit >= 0 && digit <= 9 {
-		btc, err := strconv.ParseUint(amount, 10, 64)
-		if err != nil {
-			return 0, err
-		}
-		return btc * 100_000_000 * 1000, nil
+	num := amount
+	multiplier := byte(0)
+	if last := amount[len(amount)-1]; last < '0' || last > '9' {
+		num = amount[:len(amount)-1]
+		multiplier = last
 	}
-
-	num := amount[:len(amount)-1]
 	if len(num) < 1 {
 		return 0, fmt.Errorf("number must be non-empty")
 	}
+	for i := 0; i < len(num); i++ {
+		if num[i] < '0' || num[i] > '9' {
+			return 0, fmt.Errorf("unexpected character %q in amount %q", num[i], amount)
+		}
+	}
+	if num[0] == '0' {
+		return 0, fmt.Errorf("amount %q must not have leading zeros", amount)
+	}
 
 	am, err := strconv.ParseUint(num, 10, 64)
 	if err != nil {
 		return 0, err
 	}
 
-	// If not a digit, it must be part of the known units.
-	switch lastHRPChar {
+	// The number of msat per unit of the amount, for all multipliers but pico.
+	var msatPerUnit uint64
+	switch multiplier {
+	case 0:
+		msatPerUnit = 100_000_000_000
+	case 'm':
+		msatPerUnit = 100_000_000
+	case 'u':
+		msatPerUnit = 100_000
+	case 'n':
+		msatPerUnit = 100
+
 	case 'p':
-		if am < 10 {
-			return 0, fmt.Errorf("minimum amount is 10p")
+		msat := am / 10
+		if am%10 >= 5 {
+			msat++
 		}
-		if am%10 != 0 {
-			return 0, fmt.Errorf("amount %d pBTC not expressible in msat", am)
+		if msat == 0 {
+			return 0, fmt.Errorf("amount %dp rounds to zero msat: minimum amount is 5p", am)
 		}
-		return am / 10, nil
-
-	case 'n':
-		return am * 100, nil
-	case 'u':
-		return am * 100_000, nil
-	case 'm':
-		return am * 100_000_000, nil
+		return Msat(msat), nil
 
 	default:
-		return 0, fmt.Errorf("unknown multiplier %c", lastHRPChar)
+		return 0, fmt.Errorf("unknown multiplier %q", multiplier)
 	}
+
+	if am > math.MaxUint64/msatPerUnit {
+		return 0, fmt.Errorf("amount %q is too large", amount)
+	}
+	return Msat(am * msatPerUnit), nil
 }
 
+// parseInvoiceAmount returns the BTC amount of a BOLT11 invoice. The whole invoice
+// is decoded and its checksum verified first, so that the amount of a truncated or
+// corrupted invoice, which could never be paid, is not trusted.
 func parseInvoiceAmount(invoice string) (float64, error) {
-	hrp, _, err := bech32.DecodeNoLimit(invoice)
+	if hrp, ok := decodedInvoices.hrp(invoice); ok {
+		return parseInvoiceHRP(hrp)
+	}
+	hrp, _, err := decodeInvoiceBech32(invoice)
+	if err != nil {
+		return 0, err
+	}
+	return parseInvoiceHRP(hrp)
+}
+
+// decodeInvoiceBech32 decodes the bech32 encoding of a BOLT11 invoice, verifying its
+// checksum, and checking the data is long enough to hold a timestamp and signature.
+// Returns an error wrapping [ErrInvalidInvoice] and any [bech32.ErrInvalidChecksum].
+func decodeInvoiceBech32(invoice string) (hrp string, data []byte, err error) {
+	hrp, data, version, err := bech32.DecodeNoLimitWithVersion(invoice)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidInvoice, err)
+	} else if version != bech32.Version0 {
+		return "", nil, fmt.Errorf("%w: invoice uses a bech32m checksum", ErrInvalidInvoice)
+	} else if len(data) < invoiceTimestampWords+invoiceSignatureWords {
+		return "", nil, fmt.Errorf("%w: invoice data too short", ErrInvalidInvoice)
+	}
+	return hrp, data, nil
+}
+
+// parseInvoiceHRP parses the human-readable part of a BOLT11 invoice, returning
+// the BTC amount it encodes, rounded to the nearest satoshi.
+func parseInvoiceHRP(hrp string) (float64, error) {
+	msat, err := parseInvoiceHRPMsat(hrp)
 	if err != nil {
-		return 0, fmt.Errorf("%w: %s", ErrInvalidInvoice, err)
+		return 0, err
 	}
+	return math.Round(float64(msat)/1000) / 100_000_000, nil
+}
 
+// parseInvoiceHRPMsat parses the human-readable part of a BOLT11 invoice, returning
+// the exact amount it encodes.
+func parseInvoiceHRPMsat(hrp string) (Msat, error) {
 	if len(hrp) < 3 {
 		return 0, ErrInvalidInvoice
 	}
@@ -90,19 +147,436 @@ func parseInvoiceAmount(invoice string) (float64, error) {
 
 	firstNumber := strings.IndexAny(hrp, "1234567890")
 	if firstNumber == -1 {
-		return 0, ErrNoAmount
+		firstNumber = len(hrp)
 	}
 
 	chainPrefix := strings.ToLower(hrp[2:firstNumber])
 	if chainPrefix != "bc" {
 		return 0, fmt.Errorf("%w: invoice is not for bitcoin mainnet", ErrInvalidInvoice)
+	} else if firstNumber == len(hrp) {
+		return 0, ErrNoAmount
 	}
 
 	msat, err := decodeAmount(hrp[firstNumber:])
 	if err != nil {
 		return 0, fmt.Errorf("%w: invalid amount: %s", ErrInvalidInvoice, err)
 	}
+	return msat, nil
+}
+
+const (
+	// invoiceTimestampWords is the number of 5-bit words used to encode an invoice timestamp.
+	invoiceTimestampWords = 7
+
+	// invoiceSignatureWords is the number of 5-bit words used to encode an invoice signature.
+	invoiceSignatureWords = 104
+
+	// defaultInvoiceExpiry is the expiry of an invoice which has no 'x' field, per BOLT11.
+	defaultInvoiceExpiry = time.Hour
+
+	// defaultMinFinalCLTVExpiry is the min_final_cltv_expiry_delta of an invoice
+	// which has no 'c' field, per BOLT11.
+	defaultMinFinalCLTVExpiry = 18
+)
+
+// Tagged field types used in BOLT11 invoices.
+const (
+	invoiceFieldPaymentHash     = 1  // p
+	invoiceFieldDescription     = 13 // d
+	invoiceFieldPayee           = 19 // n
+	invoiceFieldDescriptionHash = 23 // h
+	invoiceFieldExpiry          = 6  // x
+	invoiceFieldMinFinalCLTV    = 24 // c
+	invoiceFieldRouteHint       = 3  // r
+	invoiceFieldFeatures        = 5  // 9
+)
+
+// hopHintSize is the size of each serialized hop in an 'r' field of a BOLT11 invoice.
+const hopHintSize = 51
+
+// DecodedInvoice holds the fields of a decoded [BOLT11] invoice.
+//
+// [BOLT11]: https://github.com/lightning/bolts/blob/master/11-payment-encoding.md
+type DecodedInvoice struct {
+	// Amount is the BTC amount requested by the invoice, rounded to the nearest
+	// satoshi, or zero if the invoice does not specify an amount.
+	Amount float64
+
+	// AmountMsat is the exact amount requested by the invoice, including any
+	// fraction of a satoshi, or zero if the invoice does not specify an amount.
+	AmountMsat Msat
+
+	// PaymentHash is the SHA256 hash of the payment preimage.
+	PaymentHash []byte
+
+	// Description is the plaintext description of the purpose of the payment, if any.
+	Description string
+
+	// DescriptionHash is the SHA256 hash of a description which is too long
+	// to fit in the invoice, if any.
+	DescriptionHash []byte
+
+	// Payee is the public key of the payee node. If the invoice does not
+	// give it explicitly, it is recovered from the invoice signature.
+	Payee []byte
+
+	// Expiry is how long after its creation the invoice remains payable.
+	// Defaults to one hour if the invoice does not specify an expiry. Expiries too
+	// long to represent, over about 292 years, are clamped to that limit.
+	Expiry time.Duration
+
+	// CreatedAt is the time at which the invoice was created, as given by its
+	// timestamp, and ExpiresAt is CreatedAt plus Expiry. Both are in UTC. As Expiry is
+	// clamped rather than overflowing, ExpiresAt is never before CreatedAt.
+	CreatedAt time.Time
+	ExpiresAt time.Time
+
+	// MinFinalCLTVExpiry is the min_final_cltv_expiry_delta of the invoice, in blocks.
+	// Defaults to 18 if the invoice does not specify one.
+	MinFinalCLTVExpiry uint64
+
+	// RouteHints lists private routes which can be used to reach the payee.
+	RouteHints []RouteHint
+
+	// Features holds the invoice's feature bits, which are all unset if the
+	// invoice has no features field.
+	Features Features
+
+	// MinAmount and MaxAmount are the range of BTC amounts which may be paid to
+	// the invoice. A MaxAmount of zero means there is no upper limit.
+	//
+	// BOLT11 has no field for amount limits, so these are derived from the amount
+	// alone: an amountless invoice accepts any amount of at least one millisatoshi,
+	// and a fixed-amount invoice accepts between its amount and twice its amount,
+	// as BOLT11 tells payers not to overpay by more than that. Any other minimum
+	// the payee has in mind must be communicated out of band, for example by the
+	// minSendable field of an LNURL-pay response.
+	MinAmount float64
+	MaxAmount float64
+}
+
+// Age returns how long before now the invoice was created, for displaying
+// messages such as "invoice created 5 minutes ago".
+func (decoded *DecodedInvoice) Age(now time.Time) time.Duration {
+	return now.Sub(decoded.CreatedAt)
+}
+
+// IsExpired reports whether the invoice is no longer payable at the given time.
+func (decoded *DecodedInvoice) IsExpired(now time.Time) bool {
+	return !now.Before(decoded.ExpiresAt)
+}
+
+// ErrDescriptionMismatch is returned when a description does not match an invoice's description hash.
+var ErrDescriptionMismatch = errors.New("description does not match invoice")
+
+// VerifyDescription checks that the given description matches the invoice. If the invoice
+// commits to a description hash, the description must hash to it. Otherwise the description
+// must equal the invoice's plaintext description. Returns an error wrapping
+// [ErrDescriptionMismatch] if not.
+func (decoded *DecodedInvoice) VerifyDescription(description string) error {
+	if decoded.DescriptionHash != nil {
+		hash := sha256.Sum256([]byte(description))
+		if !bytes.Equal(hash[:], decoded.DescriptionHash) {
+			return fmt.Errorf("%w: hash is %x, expected %x", ErrDescriptionMismatch, hash, decoded.DescriptionHash)
+		}
+	} else if description != decoded.Description {
+		return fmt.Errorf("%w: expected %q", ErrDescriptionMismatch, decoded.Description)
+	}
+	return nil
+}
+
+// ErrInvalidAmount is returned when an amount cannot be paid to an invoice.
+var ErrInvalidAmount = errors.New("invalid amount for invoice")
+
+// CheckAmount returns an error wrapping [ErrInvalidAmount] if the given BTC
+// amount is outside the invoice's MinAmount and MaxAmount.
+func (decoded *DecodedInvoice) CheckAmount(amount float64) error {
+	if amount < decoded.MinAmount {
+		return fmt.Errorf("%w: %.11f BTC is below minimum of %.11f BTC", ErrInvalidAmount, amount, decoded.MinAmount)
+	} else if decoded.MaxAmount > 0 && amount > decoded.MaxAmount {
+		return fmt.Errorf("%w: %.11f BTC exceeds maximum of %.11f BTC", ErrInvalidAmount, amount, decoded.MaxAmount)
+	}
+	return nil
+}
+
+// RouteHint is a private route which can be used to reach the payee of an invoice,
+// given as a sequence of hops ending at the payee.
+type RouteHint []HopHint
+
+// HopHint describes a single channel hop within a [RouteHint].
+type HopHint struct {
+	// PubKey is the public key of the node at the start of the channel.
+	PubKey []byte
 
-	btc := math.Round(float64(msat)/1000) / 100_000_000
-	return btc, nil
+	// ShortChannelID identifies the channel.
+	ShortChannelID uint64
+
+	// FeeBaseMsat is the base fee charged to route through the channel, in millisatoshis.
+	FeeBaseMsat uint32
+
+	// FeeProportionalMillionths is the proportional fee charged to route through the
+	// channel, in millionths of the amount forwarded.
+	FeeProportionalMillionths uint32
+
+	// CLTVExpiryDelta is the number of blocks the channel adds to the payment's timelock.
+	CLTVExpiryDelta uint16
+}
+
+// DecodeInvoice decodes a [BOLT11] lightning invoice.
+//
+// Returns an error wrapping [ErrInvalidInvoice] if the invoice is not valid.
+// Unlike [Wallet.PayInvoice], it is not an error for the invoice to have no amount.
+//
+// The invoice signature is not verified, but it is used to recover the payee's
+// public key if the invoice does not specify one.
+//
+// Recently decoded invoices are cached, so decoding the same invoice again is cheap.
+// See [SetInvoiceCacheSize].
+//
+// [BOLT11]: https://github.com/lightning/bolts/blob/master/11-payment-encoding.md
+func DecodeInvoice(invoice string) (*DecodedInvoice, error) {
+	if decoded, ok := decodedInvoices.get(invoice); ok {
+		return decoded, nil
+	}
+
+	hrp, decoded, err := decodeInvoice(invoice)
+	if err != nil {
+		return nil, err
+	}
+	decodedInvoices.put(invoice, hrp, decoded)
+	return decoded, nil
+}
+
+// decodeInvoice decodes a BOLT11 invoice without consulting the cache, returning its
+// human-readable part along with the decoded invoice.
+func decodeInvoice(invoice string) (string, *DecodedInvoice, error) {
+	hrp, data, err := decodeInvoiceBech32(invoice)
+	if err != nil {
+		return "", nil, err
+	}
+
+	amountMsat, err := parseInvoiceHRPMsat(hrp)
+	if err != nil && !errors.Is(err, ErrNoAmount) {
+		return "", nil, err
+	}
+	amount := math.Round(float64(amountMsat)/1000) / 100_000_000
+
+	decoded := &DecodedInvoice{
+		Amount:             amount,
+		AmountMsat:         amountMsat,
+		CreatedAt:          time.Unix(int64(wordsToUint64(data[:invoiceTimestampWords])), 0).UTC(),
+		Expiry:             defaultInvoiceExpiry,
+		MinFinalCLTVExpiry: defaultMinFinalCLTVExpiry,
+		MinAmount:          amount,
+		MaxAmount:          amount * 2,
+	}
+	if amount == 0 {
+		decoded.MinAmount = fromMillisat(1)
+	}
+
+	fields := data[invoiceTimestampWords : len(data)-invoiceSignatureWords]
+	for len(fields) > 0 {
+		if len(fields) < 3 {
+			return "", nil, fmt.Errorf("%w: truncated tagged field", ErrInvalidInvoice)
+		}
+
+		fieldType := fields[0]
+		fieldLen := int(wordsToUint64(fields[1:3]))
+		if len(fields) < 3+fieldLen {
+			return "", nil, fmt.Errorf("%w: truncated tagged field", ErrInvalidInvoice)
+		}
+		fieldData := fields[3 : 3+fieldLen]
+		fields = fields[3+fieldLen:]
+
+		if err := decoded.decodeField(fieldType, fieldData); err != nil {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidInvoice, err)
+		}
+	}
+
+	if decoded.PaymentHash == nil {
+		return "", nil, fmt.Errorf("%w: missing payment hash", ErrInvalidInvoice)
+	}
+	decoded.ExpiresAt = decoded.CreatedAt.Add(decoded.Expiry)
+
+	if decoded.Payee == nil {
+		// Recovery only fails if the signature is malformed. As the signature is
+		// not otherwise verified, leave the payee unknown in that case.
+		decoded.Payee, _ = recoverInvoicePayee(hrp, data)
+	}
+
+	return hrp, decoded, nil
+}
+
+// recoverInvoicePayee recovers the public key of the node which signed an invoice,
+// given its human-readable part and 5-bit data words including the signature.
+func recoverInvoicePayee(hrp string, data []byte) ([]byte, error) {
+	signedWords := data[:len(data)-invoiceSignatureWords]
+	sig, err := bech32.ConvertBits(data[len(data)-invoiceSignatureWords:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+
+	signedData, err := bech32.ConvertBits(signedWords, 5, 8, true)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(append([]byte(hrp), signedData...))
+	return recoverPubKey(hash[:], sig[:64], sig[64])
+}
+
+// decodeField decodes a single tagged field of an invoice. As per BOLT11,
+// fields with unknown types or unexpected lengths are skipped.
+func (decoded *DecodedInvoice) decodeField(fieldType byte, fieldData []byte) error {
+	switch fieldType {
+	case invoiceFieldPaymentHash:
+		if len(fieldData) != 52 || decoded.PaymentHash != nil {
+			return nil
+		}
+		hash, err := bech32.ConvertBits(fieldData, 5, 8, false)
+		if err != nil {
+			return err
+		}
+		decoded.PaymentHash = hash
+
+	case invoiceFieldDescriptionHash:
+		if len(fieldData) != 52 {
+			return nil
+		}
+		hash, err := bech32.ConvertBits(fieldData, 5, 8, false)
+		if err != nil {
+			return err
+		}
+		decoded.DescriptionHash = hash
+
+	case invoiceFieldPayee:
+		if len(fieldData) != 53 {
+			return nil
+		}
+		payee, err := bech32.ConvertBits(fieldData, 5, 8, false)
+		if err != nil {
+			return err
+		}
+		decoded.Payee = payee
+
+	case invoiceFieldDescription:
+		description, err := bech32.ConvertBits(fieldData, 5, 8, false)
+		if err != nil {
+			return err
+		}
+		decoded.Description = string(description)
+
+	case invoiceFieldExpiry:
+		seconds := wordsToUint64(fieldData)
+		if seconds > uint64(maxDecodedExpiry/time.Second) {
+			decoded.Expiry = maxDecodedExpiry
+		} else {
+			decoded.Expiry = time.Duration(seconds) * time.Second
+		}
+
+	case invoiceFieldMinFinalCLTV:
+		decoded.MinFinalCLTVExpiry = wordsToUint64(fieldData)
+
+	case invoiceFieldRouteHint:
+		// Malformed route hints are skipped like any other field of unexpected
+		// length, rather than rejecting an invoice which may still be payable.
+		hops, err := bech32.ConvertBits(fieldData, 5, 8, false)
+		if err != nil || len(hops) == 0 || len(hops)%hopHintSize != 0 {
+			return nil
+		}
+
+		route := make(RouteHint, 0, len(hops)/hopHintSize)
+		for ; len(hops) > 0; hops = hops[hopHintSize:] {
+			route = append(route, HopHint{
+				PubKey:                    append([]byte(nil), hops[:33]...),
+				ShortChannelID:            binary.BigEndian.Uint64(hops[33:41]),
+				FeeBaseMsat:               binary.BigEndian.Uint32(hops[41:45]),
+				FeeProportionalMillionths: binary.BigEndian.Uint32(hops[45:49]),
+				CLTVExpiryDelta:           binary.BigEndian.Uint16(hops[49:51]),
+			})
+		}
+		decoded.RouteHints = append(decoded.RouteHints, route)
+
+	case invoiceFieldFeatures:
+		decoded.Features = decodeFeatures(fieldData)
+	}
+
+	return nil
+}
+
+// maxDecodedExpiry is the longest [DecodedInvoice.Expiry]: the longest whole number of
+// seconds a [time.Duration] can hold. Longer expiries are clamped to it.
+const maxDecodedExpiry = math.MaxInt64 / time.Second * time.Second
+
+// wordsToUint64 interprets a slice of 5-bit words as a big-endian integer. Values too
+// large for a uint64 saturate at [math.MaxUint64].
+func wordsToUint64(words []byte) uint64 {
+	var n uint64
+	for _, w := range words {
+		if n > math.MaxUint64>>5 {
+			return math.MaxUint64
+		}
+		n = n<<5 | uint64(w)
+	}
+	return n
+}
+
+// ErrRiskyInvoice is returned by [DecodedInvoice.CheckRisk] when paying an invoice
+// could lock up funds for an abnormally long time if the payment gets stuck.
+//
+// This is a warning, not a fatal error. Callers may choose to pay the invoice anyway.
+// [Wallet.PayInvoice] and the other invoice payment methods pay such invoices, but add
+// a [WarningRiskyInvoice] wrapping this error to [Payment.Warnings].
+var ErrRiskyInvoice = errors.New("risky invoice")
+
+// InvoiceRiskThresholds configures the limits used by [DecodedInvoice.CheckRisk].
+type InvoiceRiskThresholds struct {
+	// MaxMinFinalCLTVExpiry is the largest min_final_cltv_expiry_delta, in blocks,
+	// which is considered safe.
+	MaxMinFinalCLTVExpiry uint64
+
+	// MaxExpiry is the longest invoice expiry which is considered safe.
+	MaxExpiry time.Duration
+}
+
+// DefaultInvoiceRiskThresholds are the thresholds used by [DecodedInvoice.CheckRisk]
+// if none are given. They may be modified to change the package-wide defaults.
+var DefaultInvoiceRiskThresholds = InvoiceRiskThresholds{
+	MaxMinFinalCLTVExpiry: 1008, // one week of blocks
+	MaxExpiry:             7 * 24 * time.Hour,
+}
+
+// CheckRisk checks whether the invoice could lock up funds for an abnormally long time,
+// such as via a griefing attack where the payee deliberately holds a payment in-flight.
+//
+// If thresholds is nil, [DefaultInvoiceRiskThresholds] are used. Returns an error wrapping
+// [ErrRiskyInvoice] if any threshold is exceeded, or nil otherwise.
+func (decoded *DecodedInvoice) CheckRisk(thresholds *InvoiceRiskThresholds) error {
+	if thresholds == nil {
+		thresholds = &DefaultInvoiceRiskThresholds
+	}
+
+	if decoded.MinFinalCLTVExpiry > thresholds.MaxMinFinalCLTVExpiry {
+		return fmt.Errorf(
+			"%w: min_final_cltv_expiry_delta of %d blocks exceeds %d",
+			ErrRiskyInvoice, decoded.MinFinalCLTVExpiry, thresholds.MaxMinFinalCLTVExpiry,
+		)
+	} else if decoded.Expiry > thresholds.MaxExpiry {
+		return fmt.Errorf(
+			"%w: expiry of %s exceeds %s",
+			ErrRiskyInvoice, decoded.Expiry, thresholds.MaxExpiry,
+		)
+	}
+
+	return nil
+}
+
+// riskWarning returns a [WarningRiskyInvoice] if the invoice exceeds the given
+// thresholds, as checked by [DecodedInvoice.CheckRisk], or nil otherwise.
+func (decoded *DecodedInvoice) riskWarning(thresholds *InvoiceRiskThresholds) *Warning {
+	if err := decoded.CheckRisk(thresholds); err != nil {
+		warning := newWarning(WarningRiskyInvoice, err)
+		return &warning
+	}
+	return nil
 }
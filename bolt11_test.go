@@ -0,0 +1,547 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduition/wos/bech32"
+)
+
+// Test vectors from the BOLT11 specification.
+const (
+	// Donation to the payee node with a plaintext description.
+	testInvoiceDonation = "lnbc1pvjluezsp5zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygspp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqdpl2pkx2ctnv5sxxmmwwd5kgetjypeh2ursdae8g6twvus8g6rfwvs8qun0dfjkxaq9qrsgq357wnc5r2ueh7ck6q93dj32dlqnls087fxdwk8qakdyafkq3yap9us6v52vjjsrvywa6rt52cm9r9zqt8r2t7mlcwspyetp5h2tztugp9lfyql"
+
+	// 2500 uBTC for a cup of coffee, expiring within one minute.
+	testInvoiceCoffee = "lnbc2500u1pvjluezsp5zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygspp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqdq5xysxxatsyp3k7enxv4jsxqzpu9qrsgquk0rl77nj30yxdy8j9vdx85fkpmdla2087ne0xh8nhedh8w27kyke0lp53ut353s06fv3qfegext0eh0ymjpf39tuven09sam30g4vgpfna3rh"
+
+	// 20 mBTC with a hashed description.
+	testInvoiceHashedDescription = "lnbc20m1pvjluezsp5zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygspp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqhp58yjmdan79s6qqdhdzgynm4zwqd5d7xmw5fk98klysy043l2ahrqs9qrsgq7ea976txfraylvgzuxs8kgcw23ezlrszfnh8r6qtfpr6cxga50aj6txm9rxrydzd06dfeawfk6swupvz4erwnyutnjq7x39ymw6j38gp7ynn44"
+
+	// 20 mBTC with a hashed description, an on-chain fallback
+	// address, and a two-hop private route hint.
+	testInvoiceRouteHints = "lnbc20m1pvjluezsp5zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygspp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqhp58yjmdan79s6qqdhdzgynm4zwqd5d7xmw5fk98klysy043l2ahrqsfpp3qjmp7lwpagxun9pygexvgpjdc4jdj85fr9yq20q82gphp2nflc7jtzrcazrra7wwgzxqc8u7754cdlpfrmccae92qgzqvzq2ps8pqqqqqqpqqqqq9qqqvpeuqafqxu92d8lr6fvg0r5gv0heeeqgcrqlnm6jhphu9y00rrhy4grqszsvpcgpy9qqqqqqgqqqqq7qqzq9qrsgqdfjcdk6w3ak5pca9hwfwfh63zrrz06wwfya0ydlzpgzxkn5xagsqz7x9j4jwe7yj7vaf2k9lqsdk45kts2fd0fkr28am0u4w95tt2nsq76cqw0"
+)
+
+var testPaymentHash, _ = hex.DecodeString("0001020304050607080900010203040506070809000102030405060708090102")
+
+// testInvoiceField is a BOLT11 tagged field used to build synthetic invoices.
+type testInvoiceField struct {
+	fieldType byte
+	words     []byte
+}
+
+func testUintField(fieldType byte, n uint64) testInvoiceField {
+	var words []byte
+	for ; n > 0; n >>= 5 {
+		words = append([]byte{byte(n & 31)}, words...)
+	}
+	return testInvoiceField{fieldType, words}
+}
+
+func testBytesField(fieldType byte, data []byte) testInvoiceField {
+	words, _ := bech32.ConvertBits(data, 8, 5, true)
+	return testInvoiceField{fieldType, words}
+}
+
+// buildTestInvoice encodes a synthetic invoice with the given human-readable part,
+// timestamp, and tagged fields. The signature is left blank. A payment hash field
+// is always included.
+func buildTestInvoice(t *testing.T, hrp string, timestamp uint64, fields ...testInvoiceField) string {
+	t.Helper()
+
+	data := make([]byte, invoiceTimestampWords)
+	for i := invoiceTimestampWords - 1; i >= 0; i-- {
+		data[i] = byte(timestamp & 31)
+		timestamp >>= 5
+	}
+
+	fields = append([]testInvoiceField{testBytesField(invoiceFieldPaymentHash, testPaymentHash)}, fields...)
+	for _, field := range fields {
+		data = append(data, field.fieldType, byte(len(field.words)>>5), byte(len(field.words)&31))
+		data = append(data, field.words...)
+	}
+	data = append(data, make([]byte, invoiceSignatureWords)...)
+
+	invoice, err := bech32.Encode(hrp, data)
+	if err != nil {
+		t.Fatalf("failed to encode test invoice: %v", err)
+	}
+	return invoice
+}
+
+func TestDecodeInvoice(t *testing.T) {
+	decoded, err := DecodeInvoice(testInvoiceDonation)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	if decoded.Amount != 0 {
+		t.Errorf("expected no amount, got %.8f", decoded.Amount)
+	}
+	if !bytes.Equal(decoded.PaymentHash, testPaymentHash) {
+		t.Errorf("unexpected payment hash: %x", decoded.PaymentHash)
+	}
+	if decoded.Description != "Please consider supporting this project" {
+		t.Errorf("unexpected description: %q", decoded.Description)
+	}
+	if decoded.Expiry != time.Hour || decoded.MinFinalCLTVExpiry != 18 {
+		t.Errorf("expected default expiry and CLTV, got %s and %d", decoded.Expiry, decoded.MinFinalCLTVExpiry)
+	}
+
+	decoded, err = DecodeInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	if decoded.Amount != 0.0025 {
+		t.Errorf("expected amount 0.0025, got %.8f", decoded.Amount)
+	}
+	if decoded.Description != "1 cup coffee" {
+		t.Errorf("unexpected description: %q", decoded.Description)
+	}
+	if decoded.Expiry != time.Minute {
+		t.Errorf("expected 1 minute expiry, got %s", decoded.Expiry)
+	}
+
+	decoded, err = DecodeInvoice(testInvoiceHashedDescription)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	if hex.EncodeToString(decoded.DescriptionHash) != "3925b6f67e2c340036ed12093dd44e0368df1b6ea26c53dbe4811f58fd5db8c1" {
+		t.Errorf("unexpected description hash: %x", decoded.DescriptionHash)
+	}
+}
+
+func TestDecodedInvoiceCheckRisk(t *testing.T) {
+	decoded, err := DecodeInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	} else if err := decoded.CheckRisk(nil); err != nil {
+		t.Fatalf("expected ordinary invoice to pass risk check, got %v", err)
+	}
+
+	invoice := buildTestInvoice(t, "lnbc10u", 1700000000, testUintField(invoiceFieldMinFinalCLTV, 5000))
+	decoded, err = DecodeInvoice(invoice)
+	if err != nil {
+		t.Fatalf("failed to decode high-CLTV invoice: %v", err)
+	} else if decoded.MinFinalCLTVExpiry != 5000 {
+		t.Fatalf("expected CLTV delta 5000, got %d", decoded.MinFinalCLTVExpiry)
+	}
+
+	if err := decoded.CheckRisk(nil); !errors.Is(err, ErrRiskyInvoice) {
+		t.Fatalf("expected ErrRiskyInvoice, got %v", err)
+	}
+	if err := decoded.CheckRisk(&InvoiceRiskThresholds{MaxMinFinalCLTVExpiry: 10000, MaxExpiry: time.Hour}); err != nil {
+		t.Fatalf("expected custom thresholds to permit invoice, got %v", err)
+	}
+	// Expiries too long for a time.Duration must not overflow and evade the check.
+	for _, seconds := range []uint64{10_000_000_000, math.MaxUint64} {
+		invoice := buildTestInvoice(t, "lnbc10u", 1700000000, testUintField(invoiceFieldExpiry, seconds))
+		decoded, err := DecodeInvoice(invoice)
+		if err != nil {
+			t.Fatalf("failed to decode long-expiry invoice: %v", err)
+		} else if decoded.Expiry <= 0 {
+			t.Fatalf("expiry of %d seconds overflowed to %s", seconds, decoded.Expiry)
+		} else if err := decoded.CheckRisk(nil); !errors.Is(err, ErrRiskyInvoice) {
+			t.Fatalf("expected expiry of %d seconds to be risky, got %v", seconds, err)
+		}
+	}
+	if decoded, err := DecodeInvoice(buildTestInvoice(t, "lnbc10u", 1700000000,
+		testUintField(invoiceFieldExpiry, math.MaxUint64))); err != nil || decoded.Expiry != maxDecodedExpiry {
+		t.Fatalf("expected expiry to be clamped to maxDecodedExpiry, got %v (%v)", decoded.Expiry, err)
+	}
+}
+
+func TestPayRiskyInvoiceWarning(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"pay1","status":"PAID"}`))
+	})
+	invoice := buildTestInvoice(t, "lnbc10u", 1700000000, testUintField(invoiceFieldMinFinalCLTV, 5000))
+
+	payment, err := wallet.Pay(context.Background(), invoice, 0, "")
+	if err != nil {
+		t.Fatalf("expected risky invoice to be paid, got %v", err)
+	} else if len(payment.Warnings) != 1 || payment.Warnings[0].Code != WarningRiskyInvoice {
+		t.Fatalf("expected a risky invoice warning, got %v", payment.Warnings)
+	} else if !errors.Is(payment.Warnings[0], ErrRiskyInvoice) {
+		t.Fatalf("expected warning to wrap ErrRiskyInvoice, got %v", payment.Warnings[0])
+	}
+
+	opts := &PayInvoiceOptions{
+		RiskThresholds: &InvoiceRiskThresholds{MaxMinFinalCLTVExpiry: 10000, MaxExpiry: time.Hour},
+	}
+	if payment, err := wallet.PayInvoiceWith(context.Background(), invoice, "", opts); err != nil {
+		t.Fatalf("PayInvoiceWith failed: %v", err)
+	} else if len(payment.Warnings) != 0 {
+		t.Fatalf("expected custom thresholds to permit invoice, got %v", payment.Warnings)
+	}
+
+	if payment, err := wallet.PayInvoice(context.Background(), testInvoiceCoffee, ""); err != nil {
+		t.Fatalf("PayInvoice failed: %v", err)
+	} else if len(payment.Warnings) != 0 {
+		t.Fatalf("unexpected warnings for ordinary invoice: %v", payment.Warnings)
+	}
+}
+
+func TestDecodeInvoiceRouteHints(t *testing.T) {
+	decoded, err := DecodeInvoice(testInvoiceRouteHints)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+
+	if len(decoded.RouteHints) != 1 {
+		t.Fatalf("expected 1 route hint, got %d", len(decoded.RouteHints))
+	}
+
+	route := decoded.RouteHints[0]
+	if len(route) != 2 {
+		t.Fatalf("expected 2 hops, got %d", len(route))
+	}
+
+	expected := []struct {
+		pubkey string
+		scid   uint64
+		base   uint32
+		prop   uint32
+		cltv   uint16
+	}{
+		{"029e03a901b85534ff1e92c43c74431f7ce72046060fcf7a95c37e148f78c77255", 0x0102030405060708, 1, 20, 3},
+		{"039e03a901b85534ff1e92c43c74431f7ce72046060fcf7a95c37e148f78c77255", 0x030405060708090a, 2, 30, 4},
+	}
+
+	for i, want := range expected {
+		hop := route[i]
+		if hex.EncodeToString(hop.PubKey) != want.pubkey {
+			t.Errorf("hop %d: unexpected pubkey %x", i, hop.PubKey)
+		}
+		if hop.ShortChannelID != want.scid {
+			t.Errorf("hop %d: expected scid %x, got %x", i, want.scid, hop.ShortChannelID)
+		}
+		if hop.FeeBaseMsat != want.base || hop.FeeProportionalMillionths != want.prop {
+			t.Errorf("hop %d: unexpected fees %d/%d", i, hop.FeeBaseMsat, hop.FeeProportionalMillionths)
+		}
+		if hop.CLTVExpiryDelta != want.cltv {
+			t.Errorf("hop %d: expected CLTV delta %d, got %d", i, want.cltv, hop.CLTVExpiryDelta)
+		}
+	}
+}
+
+func TestDecodeInvoiceSkipsMalformedRouteHints(t *testing.T) {
+	invoice := buildTestInvoice(t, "lnbc", 1700000000,
+		testBytesField(invoiceFieldRouteHint, make([]byte, hopHintSize-1)),
+		testBytesField(invoiceFieldDescription, []byte("coffee")),
+	)
+	decoded, err := DecodeInvoice(invoice)
+	if err != nil {
+		t.Fatalf("expected malformed route hint to be skipped, got %v", err)
+	}
+	if len(decoded.RouteHints) != 0 || decoded.Description != "coffee" {
+		t.Fatalf("unexpected decoded invoice: %+v", decoded)
+	}
+}
+
+func TestDecodeInvoiceRecoversPayee(t *testing.T) {
+	const specPayee = "03e7156ae33b0a208d0744199163177e909e80176e55d97a2f221ede0f934dd9ad"
+
+	for _, invoice := range []string{testInvoiceDonation, testInvoiceCoffee, testInvoiceRouteHints} {
+		decoded, err := DecodeInvoice(invoice)
+		if err != nil {
+			t.Fatalf("failed to decode invoice: %v", err)
+		} else if hex.EncodeToString(decoded.Payee) != specPayee {
+			t.Fatalf("expected payee %s, got %x", specPayee, decoded.Payee)
+		}
+	}
+}
+
+func TestDecodeInvoiceAmountConstraints(t *testing.T) {
+	decoded, err := DecodeInvoice(testInvoiceDonation)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	if decoded.MinAmount != fromMillisat(1) || decoded.MaxAmount != 0 {
+		t.Fatalf("unexpected amountless constraints: min %.11f, max %.11f", decoded.MinAmount, decoded.MaxAmount)
+	}
+	if err := decoded.CheckAmount(0.0001); err != nil {
+		t.Fatalf("expected amount to be accepted: %v", err)
+	}
+	if err := decoded.CheckAmount(0); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount for zero amount, got %v", err)
+	}
+
+	decoded, err = DecodeInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	if decoded.MinAmount != 0.0025 || decoded.MaxAmount != 0.005 {
+		t.Fatalf("unexpected fixed-amount constraints: min %.11f, max %.11f", decoded.MinAmount, decoded.MaxAmount)
+	}
+}
+
+func TestDecodeAmountPico(t *testing.T) {
+	valid := map[string]Msat{
+		"5p":    1, // Rounds up from 0.5 msat.
+		"10p":   1,
+		"14p":   1,
+		"15p":   2, // Rounds up from 1.5 msat.
+		"1000p": 100,
+	}
+	for amount, want := range valid {
+		msat, err := decodeAmount(amount)
+		if err != nil {
+			t.Fatalf("failed to decode %s: %v", amount, err)
+		} else if msat != want {
+			t.Fatalf("expected %s to decode to %d msat, got %d", amount, want, msat)
+		}
+	}
+
+	for _, amount := range []string{"0p", "4p", "p"} {
+		if _, err := decodeAmount(amount); err == nil {
+			t.Fatalf("expected error decoding %s", amount)
+		}
+	}
+}
+
+func TestParseInvoiceAmountChecksum(t *testing.T) {
+	amount, err := parseInvoiceAmount(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("failed to parse valid invoice: %v", err)
+	} else if amount != 0.0025 {
+		t.Fatalf("expected 0.0025 BTC, got %.8f", amount)
+	}
+
+	// Corrupt the last character of the checksum.
+	last := testInvoiceCoffee[len(testInvoiceCoffee)-1]
+	replacement := byte('q')
+	if last == replacement {
+		replacement = 'p'
+	}
+	corrupted := testInvoiceCoffee[:len(testInvoiceCoffee)-1] + string(replacement)
+
+	var checksumErr bech32.ErrInvalidChecksum
+	if _, err := parseInvoiceAmount(corrupted); !errors.Is(err, ErrInvalidInvoice) || !errors.As(err, &checksumErr) {
+		t.Fatalf("expected ErrInvalidInvoice with checksum error, got %v", err)
+	}
+
+	// A well-formed encoding which is too short to hold a signature.
+	truncated, _ := bech32.Encode("lnbc2500u", make([]byte, invoiceTimestampWords))
+	if _, err := parseInvoiceAmount(truncated); !errors.Is(err, ErrInvalidInvoice) {
+		t.Fatalf("expected ErrInvalidInvoice for truncated invoice, got %v", err)
+	}
+
+	bech32m, _ := bech32.EncodeM("lnbc2500u", make([]byte, invoiceTimestampWords+invoiceSignatureWords))
+	if _, err := parseInvoiceAmount(bech32m); !errors.Is(err, ErrInvalidInvoice) {
+		t.Fatalf("expected ErrInvalidInvoice for bech32m checksum, got %v", err)
+	}
+}
+
+func TestDecodeInvoiceAmountMsat(t *testing.T) {
+	// 15 nanobitcoin is 1500 msat: one and a half satoshis.
+	invoice := buildTestInvoice(t, "lnbc15n", 1700000000)
+	decoded, err := DecodeInvoice(invoice)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	if decoded.AmountMsat != 1500 {
+		t.Fatalf("expected 1500 msat, got %s", decoded.AmountMsat)
+	}
+	if decoded.AmountMsat.Sats() != 1 || decoded.AmountMsat.BTC() != 0.000000015 {
+		t.Fatalf("unexpected conversions of %s", decoded.AmountMsat)
+	}
+	if MsatFromBTC(decoded.AmountMsat.BTC()) != 1500 {
+		t.Fatalf("msat did not survive a round trip through BTC")
+	}
+}
+
+func TestParseInvoiceHRPMalformed(t *testing.T) {
+	for _, hrp := range []string{
+		"lnbc10m5u",
+		"lnbc1mm",
+		"lnbc010u",
+		"lnbcu",
+		"lnbc1x",
+		"lnbc99999999999999999m",
+		"lnbc999999999999",
+		"lntb10u",
+		"lnxyz",
+	} {
+		if _, err := parseInvoiceHRP(hrp); !errors.Is(err, ErrInvalidInvoice) {
+			t.Errorf("expected ErrInvalidInvoice for HRP %q, got %v", hrp, err)
+		}
+	}
+
+	if _, err := parseInvoiceHRP("lnbc"); !errors.Is(err, ErrNoAmount) {
+		t.Errorf("expected ErrNoAmount for amountless HRP, got %v", err)
+	}
+	if amount, err := parseInvoiceHRP("lnbc2500u"); err != nil || amount != 0.0025 {
+		t.Errorf("expected 0.0025 BTC, got %.8f (err=%v)", amount, err)
+	}
+}
+
+func FuzzParseInvoiceAmount(f *testing.F) {
+	for _, seed := range []string{"lnbc", "lnbc2500u", "lnbc10m5u", "lnbc5p", "lntb1", "ln", "lnbc0", "lnbc18446744073709551615p"} {
+		f.Add(seed)
+	}
+	data := make([]byte, invoiceTimestampWords+invoiceSignatureWords)
+
+	f.Fuzz(func(t *testing.T, hrp string) {
+		if _, err := parseInvoiceHRP(hrp); err != nil && !errors.Is(err, ErrInvalidInvoice) && !errors.Is(err, ErrNoAmount) {
+			t.Fatalf("unexpected error for HRP %q: %v", hrp, err)
+		}
+
+		invoice, err := bech32.Encode(strings.ToLower(hrp), data)
+		if err != nil {
+			return
+		}
+		amount, err := parseInvoiceAmount(invoice)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidInvoice) && !errors.Is(err, ErrNoAmount) {
+				t.Fatalf("unexpected error for invoice %q: %v", invoice, err)
+			}
+		} else if amount < 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+			t.Fatalf("nonsensical amount %v for invoice %q", amount, invoice)
+		}
+	})
+}
+
+func TestDecodeInvoiceTimestamp(t *testing.T) {
+	// The BOLT11 test vectors were all created at this time.
+	created := time.Unix(1496314658, 0).UTC()
+
+	decoded, err := DecodeInvoice(testInvoiceDonation)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	} else if !decoded.CreatedAt.Equal(created) {
+		t.Fatalf("expected creation time %s, got %s", created, decoded.CreatedAt)
+	} else if !decoded.ExpiresAt.Equal(created.Add(time.Hour)) {
+		t.Fatalf("expected default expiry of one hour, got %s", decoded.ExpiresAt)
+	}
+
+	decoded, err = DecodeInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	} else if !decoded.ExpiresAt.Equal(created.Add(time.Minute)) {
+		t.Fatalf("expected expiry one minute after creation, got %s", decoded.ExpiresAt)
+	}
+
+	now := created.Add(5 * time.Minute)
+	if age := decoded.Age(now); age != 5*time.Minute {
+		t.Fatalf("expected age of 5 minutes, got %s", age)
+	} else if !decoded.IsExpired(now) || decoded.IsExpired(created.Add(30*time.Second)) {
+		t.Fatalf("unexpected expiry status")
+	}
+
+	// An expiry too long for a time.Duration must not wrap ExpiresAt into the past.
+	invoice := buildTestInvoice(t, "lnbc10u", 1700000000, testUintField(invoiceFieldExpiry, 10_000_000_000))
+	decoded, err = DecodeInvoice(invoice)
+	if err != nil {
+		t.Fatalf("failed to decode long-expiry invoice: %v", err)
+	} else if !decoded.ExpiresAt.After(decoded.CreatedAt.AddDate(290, 0, 0)) {
+		t.Fatalf("expected expiry centuries after creation, got %s", decoded.ExpiresAt)
+	} else if decoded.IsExpired(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected long-expiry invoice not to be expired")
+	}
+}
+
+func TestDecodeInvoiceCache(t *testing.T) {
+	defer SetInvoiceCacheSize(DefaultInvoiceCacheSize)
+	SetInvoiceCacheSize(0)
+	SetInvoiceCacheSize(1)
+
+	first, err := DecodeInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("DecodeInvoice failed: %v", err)
+	}
+	first.PaymentHash[0] ^= 0xFF
+
+	hits := decodedInvoices.hits
+	second, err := DecodeInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("DecodeInvoice failed: %v", err)
+	} else if decodedInvoices.hits != hits+1 {
+		t.Fatalf("expected second decode to hit the cache")
+	} else if second.PaymentHash[0] == first.PaymentHash[0] {
+		t.Fatalf("modifying a decoded invoice changed the cached copy")
+	}
+	if amount, err := parseInvoiceAmount(testInvoiceCoffee); err != nil || amount != 0.0025 {
+		t.Fatalf("expected cached amount 0.0025, got %v, %v", amount, err)
+	} else if decodedInvoices.hits != hits+2 {
+		t.Fatalf("expected parseInvoiceAmount to hit the cache")
+	}
+
+	// Decoding another invoice evicts the least recently used one.
+	if _, err := DecodeInvoice(testInvoiceDonation); err != nil {
+		t.Fatalf("DecodeInvoice failed: %v", err)
+	}
+	if _, ok := decodedInvoices.get(testInvoiceCoffee); ok {
+		t.Fatalf("expected invoice to be evicted from a cache of size 1")
+	}
+}
+
+func BenchmarkDecodeInvoice(b *testing.B) {
+	defer SetInvoiceCacheSize(DefaultInvoiceCacheSize)
+	for _, size := range []int{0, DefaultInvoiceCacheSize} {
+		SetInvoiceCacheSize(size)
+		name := "Uncached"
+		if size > 0 {
+			name = "Cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := DecodeInvoice(testInvoiceCoffee); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeInvoiceFeatures(t *testing.T) {
+	// The BOLT11 test vectors require var_onion_optin and payment_secret.
+	decoded, err := DecodeInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	features := decoded.Features
+	if !features.VarOnionOptin || !features.PaymentSecret || features.BasicMPP {
+		t.Fatalf("unexpected features: %+v", features)
+	} else if !features.Requires(FeaturePaymentSecret) || !features.Requires(FeatureVarOnionOptin) {
+		t.Fatalf("expected payment_secret and var_onion_optin to be required")
+	}
+
+	// Optional payment_secret and basic_mpp, mandatory unknown bit 100.
+	words := make([]byte, 21)
+	words[len(words)-1-15/5] |= 1 << (15 % 5)
+	words[len(words)-1-17/5] |= 1 << (17 % 5)
+	words[len(words)-1-100/5] |= 1 << (100 % 5)
+	invoice := buildTestInvoice(t, "lnbc10u", 1700000000, testInvoiceField{invoiceFieldFeatures, words})
+
+	decoded, err = DecodeInvoice(invoice)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	features = decoded.Features
+	if !features.PaymentSecret || !features.BasicMPP || features.VarOnionOptin || features.PaymentMetadata {
+		t.Fatalf("unexpected features: %+v", features)
+	} else if features.Requires(FeaturePaymentSecret) || features.Requires(FeatureBasicMPP) {
+		t.Fatalf("expected payment_secret and basic_mpp to be optional")
+	} else if !features.IsSet(15) || !features.IsSet(17) || features.IsSet(16) {
+		t.Fatalf("unexpected raw bits: %x", features.Raw)
+	} else if len(features.Raw) != 13 {
+		t.Fatalf("expected 13 raw bytes, got %d", len(features.Raw))
+	} else if unknown := features.UnknownRequired(); len(unknown) != 1 || unknown[0] != 100 {
+		t.Fatalf("expected unknown required bit 100, got %v", unknown)
+	}
+
+	decoded, err = DecodeInvoice(buildTestInvoice(t, "lnbc10u", 1700000000))
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	} else if decoded.Features.Raw != nil || decoded.Features.Supports(FeatureBasicMPP) {
+		t.Fatalf("expected no features, got %+v", decoded.Features)
+	}
+}
@@ -0,0 +1,77 @@
+package wos
+
+import (
+	"context"
+	"fmt"
+)
+
+// minSendAmount is the smallest BTC amount WoS sends: a single satoshi.
+const minSendAmount = 0.00000001
+
+// AmountBounds is the range of BTC amounts which may be sent to a destination, as
+// returned by [Wallet.AmountBounds], for constraining an amount picker. A Max of zero
+// means there is no upper limit.
+type AmountBounds struct {
+	Min float64
+	Max float64
+}
+
+// AmountBounds returns the range of BTC amounts which may be sent to the given
+// destination, which may be anything accepted by [Wallet.Pay]. The destination's own
+// bounds are narrowed by the limits WoS and the wallet impose:
+//
+//   - BOLT11 has no field for amount bounds (see [DecodedInvoice.MinAmount]), so an
+//     amountless invoice is bounded only by WoS's one satoshi minimum and the wallet's
+//     payment caps. A fixed-amount invoice can only be paid its own amount.
+//   - A lightning address is bounded by the minSendable and maxSendable its LNURL-pay
+//     service reports, which requires fetching its parameters through WoS.
+//   - An on-chain address is bounded by the on-chain minimum cached by
+//     [Reader.OnChainMinimum], or [DefaultMinOnChainAmount] if not yet fetched.
+//
+// The wallet's payment caps are [MaxPaymentAmount] and [Wallet.SetMaxPaymentAmount].
+// The wallet's balance is not taken into account.
+func (wallet *Wallet) AmountBounds(ctx context.Context, destination string) (*AmountBounds, error) {
+	destination, kind, err := NormalizeDestination(destination)
+	if err != nil {
+		return nil, fmt.Errorf("AmountBounds: %w", err)
+	}
+
+	var bounds AmountBounds
+	switch kind {
+	case DestinationInvoice:
+		decoded, err := DecodeInvoice(destination)
+		if err != nil {
+			return nil, fmt.Errorf("AmountBounds: %w", err)
+		} else if decoded.Amount != 0 {
+			return &AmountBounds{Min: decoded.Amount, Max: decoded.Amount}, nil
+		}
+		bounds = AmountBounds{Min: max(decoded.MinAmount, minSendAmount), Max: decoded.MaxAmount}
+
+	case DestinationLightningAddress:
+		lnAddress, err := ParseLightningAddress(destination)
+		if err != nil {
+			return nil, fmt.Errorf("AmountBounds: %w", err)
+		} else if err := wallet.domainPolicy.check(lnAddress.Domain); err != nil {
+			return nil, fmt.Errorf("AmountBounds: %w", err)
+		}
+		resp, err := wallet.fetchLNURL(ctx, lnAddress.LNURL())
+		if err != nil {
+			return nil, fmt.Errorf("AmountBounds: %w", err)
+		}
+		params, err := parseLNURLPayParams(resp)
+		if err != nil {
+			return nil, fmt.Errorf("AmountBounds: %w", err)
+		}
+		bounds = AmountBounds{Min: max(params.MinSendable, minSendAmount), Max: params.MaxSendable}
+
+	default:
+		bounds = AmountBounds{Min: wallet.reader.minOnChainAmount()}
+	}
+
+	for _, limit := range []float64{MaxPaymentAmount, wallet.maxPaymentAmount} {
+		if limit > 0 && (bounds.Max == 0 || limit < bounds.Max) {
+			bounds.Max = limit
+		}
+	}
+	return &bounds, nil
+}
@@ -0,0 +1,41 @@
+package wos
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestAmountBounds(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag":"payRequest","callback":"https://getalby.com/cb",` +
+			`"minSendable":10000,"maxSendable":100000000,"metadata":"[]"}`))
+	})
+	ctx := context.Background()
+
+	bounds, err := wallet.AmountBounds(ctx, "bob@getalby.com")
+	if err != nil {
+		t.Fatalf("AmountBounds failed: %v", err)
+	} else if *bounds != (AmountBounds{Min: 0.0000001, Max: 0.001}) {
+		t.Fatalf("expected LNURL bounds, got %+v", *bounds)
+	}
+
+	wallet.SetMaxPaymentAmount(0.0005)
+	if bounds, err := wallet.AmountBounds(ctx, "bob@getalby.com"); err != nil || bounds.Max != 0.0005 {
+		t.Fatalf("expected wallet cap to narrow the maximum, got %+v, %v", bounds, err)
+	}
+
+	bounds, err = wallet.AmountBounds(ctx, testInvoiceDonation)
+	if err != nil {
+		t.Fatalf("AmountBounds failed: %v", err)
+	} else if *bounds != (AmountBounds{Min: minSendAmount, Max: 0.0005}) {
+		t.Fatalf("expected amountless invoice to take WoS limits, got %+v", *bounds)
+	}
+
+	bounds, err = wallet.AmountBounds(ctx, testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("AmountBounds failed: %v", err)
+	} else if bounds.Min != 0.0025 || bounds.Max != 0.0025 {
+		t.Fatalf("expected fixed invoice amount, got %+v", *bounds)
+	}
+}
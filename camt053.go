@@ -0,0 +1,183 @@
+package wos
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// camt053Namespace is the XML namespace of the camt.053 version produced by [WriteCamt053].
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// camt053DateTime is the ISO 8601 date-time format used in camt.053 statements.
+const camt053DateTime = "2006-01-02T15:04:05Z07:00"
+
+// Camt053Options customizes the statement produced by [WriteCamt053].
+type Camt053Options struct {
+	// AccountID identifies the wallet in the statement, such as its lightning address.
+	AccountID string
+
+	// StatementID identifies the statement. Defaults to one derived from CreatedAt.
+	StatementID string
+
+	// Currency is the currency code used for amounts. Bitcoin has no ISO 4217 code,
+	// so this defaults to "XBT", which accounting systems commonly accept for it.
+	Currency string
+
+	// From and To restrict the statement to payments made at or after From, and
+	// before To. Either may be zero to leave the range open on that side.
+	From time.Time
+	To   time.Time
+
+	// CreatedAt is the creation time recorded in the statement. Defaults to now.
+	CreatedAt time.Time
+}
+
+// camt053Document is a simplified ISO 20022 camt.053 bank-to-customer statement.
+type camt053Document struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Statement struct {
+		GroupHeader struct {
+			MessageID string `xml:"MsgId"`
+			CreatedAt string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Statement camt053Statement `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camt053Statement struct {
+	ID        string         `xml:"Id"`
+	CreatedAt string         `xml:"CreDtTm"`
+	Period    *camt053Period `xml:"FrToDt,omitempty"`
+	Account   struct {
+		ID       string `xml:"Id>Othr>Id"`
+		Currency string `xml:"Ccy"`
+	} `xml:"Acct"`
+	Summary struct {
+		Credits camt053Totals `xml:"TtlCdtNtries"`
+		Debits  camt053Totals `xml:"TtlDbtNtries"`
+	} `xml:"TxsSummry"`
+	Entries []camt053Entry `xml:"Ntry"`
+}
+
+type camt053Period struct {
+	From string `xml:"FrDtTm,omitempty"`
+	To   string `xml:"ToDtTm,omitempty"`
+}
+
+type camt053Totals struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camt053Entry struct {
+	Reference         string        `xml:"NtryRef"`
+	Amount            camt053Amount `xml:"Amt"`
+	Direction         string        `xml:"CdtDbtInd"`
+	Status            string        `xml:"Sts"`
+	Booked            string        `xml:"BookgDt>DtTm"`
+	Value             string        `xml:"ValDt>DtTm"`
+	ServicerReference string        `xml:"AcctSvcrRef,omitempty"`
+	Details           struct {
+		EndToEndID string `xml:"TxDtls>Refs>EndToEndId"`
+		Remittance string `xml:"TxDtls>RmtInf>Ustrd,omitempty"`
+	} `xml:"NtryDtls"`
+}
+
+type camt053Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// formatCamt053Amount formats an amount in satoshis as a BTC decimal.
+func formatCamt053Amount(sats int64) string {
+	return strconv.FormatFloat(float64(sats)/100_000_000, 'f', 8, 64)
+}
+
+// WriteCamt053 writes the given payments to w as a simplified ISO 20022 camt.053
+// bank statement, for import into accounting systems which expect bank statements.
+// Received payments are booked as credits and sent payments as debits, referenced
+// by their payment IDs, with their descriptions as remittance information. Pending
+// payments are included with a pending status.
+//
+// Only the statement structure accounting tools commonly read is produced: balances
+// and bank identifiers, which have no meaning for a WoS wallet, are omitted.
+func WriteCamt053(w io.Writer, payments []Payment, opts Camt053Options) error {
+	if opts.Currency == "" {
+		opts.Currency = "XBT"
+	}
+	if opts.CreatedAt.IsZero() {
+		opts.CreatedAt = time.Now()
+	}
+	if opts.StatementID == "" {
+		opts.StatementID = "WOS-" + opts.CreatedAt.UTC().Format("20060102150405")
+	}
+
+	doc := camt053Document{Namespace: camt053Namespace}
+	doc.Statement.GroupHeader.MessageID = opts.StatementID
+	doc.Statement.GroupHeader.CreatedAt = opts.CreatedAt.Format(camt053DateTime)
+
+	stmt := &doc.Statement.Statement
+	stmt.ID = opts.StatementID
+	stmt.CreatedAt = opts.CreatedAt.Format(camt053DateTime)
+	stmt.Account.ID = opts.AccountID
+	stmt.Account.Currency = opts.Currency
+	if !opts.From.IsZero() || !opts.To.IsZero() {
+		stmt.Period = &camt053Period{}
+		if !opts.From.IsZero() {
+			stmt.Period.From = opts.From.Format(camt053DateTime)
+		}
+		if !opts.To.IsZero() {
+			stmt.Period.To = opts.To.Format(camt053DateTime)
+		}
+	}
+
+	var credits, debits int64
+	for _, payment := range payments {
+		if (!opts.From.IsZero() && payment.Time.Before(opts.From)) ||
+			(!opts.To.IsZero() && !payment.Time.Before(opts.To)) {
+			continue
+		}
+
+		sats := toSats(payment.Amount)
+		entry := camt053Entry{
+			Reference:         payment.ID,
+			Amount:            camt053Amount{Currency: opts.Currency, Value: formatCamt053Amount(sats)},
+			Direction:         "DBIT",
+			Status:            "BOOK",
+			Booked:            payment.Time.Format(camt053DateTime),
+			Value:             payment.Time.Format(camt053DateTime),
+			ServicerReference: payment.Txid,
+		}
+		entry.Details.EndToEndID = payment.ID
+		entry.Details.Remittance = payment.Description
+
+		if payment.Type == PaymentTypeCredit {
+			entry.Direction = "CRDT"
+			stmt.Summary.Credits.Count++
+			credits += sats
+		} else {
+			stmt.Summary.Debits.Count++
+			debits += sats
+		}
+		if payment.IsPending() {
+			entry.Status = "PDNG"
+		}
+		stmt.Entries = append(stmt.Entries, entry)
+	}
+	stmt.Summary.Credits.Sum = formatCamt053Amount(credits)
+	stmt.Summary.Debits.Sum = formatCamt053Amount(debits)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("WriteCamt053: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("WriteCamt053: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package wos
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCamt053(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	payments := []Payment{
+		{ID: "early", Amount: 0.5, Type: PaymentTypeCredit, Time: t0.AddDate(0, -1, 0)},
+		{ID: "in", Amount: 0.001, Type: PaymentTypeCredit, Time: t0, Description: "Order <42>", Status: PaymentStatusPaid},
+		{ID: "out", Amount: 0.0002, Type: PaymentTypeDebit, Time: t0.Add(time.Hour), Txid: "abcd", Status: PaymentStatusPending},
+		{ID: "late", Amount: 0.5, Type: PaymentTypeDebit, Time: t0.AddDate(0, 1, 0)},
+	}
+
+	var buf bytes.Buffer
+	err := WriteCamt053(&buf, payments, Camt053Options{
+		AccountID: "user@walletofsatoshi.com",
+		From:      t0.AddDate(0, 0, -1),
+		To:        t0.AddDate(0, 0, 1),
+		CreatedAt: t0.AddDate(0, 0, 2),
+	})
+	if err != nil {
+		t.Fatalf("WriteCamt053 failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Fatalf("expected XML header")
+	}
+
+	var doc camt053Document
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+	}
+	if doc.XMLName.Space != camt053Namespace || doc.XMLName.Local != "Document" {
+		t.Fatalf("unexpected root element: %+v", doc.XMLName)
+	}
+
+	stmt := doc.Statement.Statement
+	if doc.Statement.GroupHeader.MessageID != "WOS-20240303120000" || stmt.ID != "WOS-20240303120000" {
+		t.Fatalf("unexpected statement ID: %q", stmt.ID)
+	}
+	if stmt.Account.ID != "user@walletofsatoshi.com" || stmt.Account.Currency != "XBT" {
+		t.Fatalf("unexpected account: %+v", stmt.Account)
+	}
+	if stmt.Period == nil || stmt.Period.From != "2024-02-29T12:00:00Z" || stmt.Period.To != "2024-03-02T12:00:00Z" {
+		t.Fatalf("unexpected period: %+v", stmt.Period)
+	}
+	if len(stmt.Entries) != 2 {
+		t.Fatalf("expected 2 entries within the date range, got %d", len(stmt.Entries))
+	}
+
+	credit, debit := stmt.Entries[0], stmt.Entries[1]
+	if credit.Reference != "in" || credit.Direction != "CRDT" || credit.Status != "BOOK" ||
+		credit.Amount.Value != "0.00100000" || credit.Amount.Currency != "XBT" ||
+		credit.Booked != "2024-03-01T12:00:00Z" || credit.Details.Remittance != "Order <42>" {
+		t.Fatalf("unexpected credit entry: %+v", credit)
+	}
+	if debit.Reference != "out" || debit.Direction != "DBIT" || debit.Status != "PDNG" ||
+		debit.Amount.Value != "0.00020000" || debit.ServicerReference != "abcd" {
+		t.Fatalf("unexpected debit entry: %+v", debit)
+	}
+
+	summary := stmt.Summary
+	if summary.Credits.Count != 1 || summary.Credits.Sum != "0.00100000" ||
+		summary.Debits.Count != 1 || summary.Debits.Sum != "0.00020000" {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
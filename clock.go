@@ -0,0 +1,82 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Clock tells the time. It can be replaced with [Wallet.SetClock], so that
+// time-dependent behavior, such as invoice expiry countdowns, can be tested
+// without waiting in real time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current
+	// time on the returned channel, like [time.After].
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the default [Clock], which uses the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clockOrDefault returns c, or the system clock if c is nil.
+func clockOrDefault(c Clock) Clock {
+	if c == nil {
+		return systemClock{}
+	}
+	return c
+}
+
+// SetClock replaces the clock used by the wallet and the invoices it creates.
+// Passing nil restores the system clock.
+func (wallet *Wallet) SetClock(c Clock) {
+	wallet.clock = c
+}
+
+// ErrNoServerTime is returned by [Reader.ServerTime] when the WoS response has no
+// valid Date header.
+var ErrNoServerTime = errors.New("server did not report its time")
+
+// ServerTime returns the WoS server's current time, as given by the Date header of an
+// API response. The header only has a resolution of one second.
+func (rdr *Reader) ServerTime(ctx context.Context) (time.Time, error) {
+	resp, err := rdr.GetRequestRaw(ctx, "/api/v1/wallet/balance")
+	if resp == nil {
+		return time.Time{}, fmt.Errorf("ServerTime: %w", err)
+	}
+
+	// Error responses are still dated, so the time can be read regardless of status.
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ServerTime: %w", ErrNoServerTime)
+	}
+	return serverTime, nil
+}
+
+// ClockSkew returns how far the WoS server's clock is ahead of the local clock, or a
+// negative duration if it is behind. Invoice expiry times are set by WoS, so a large skew
+// makes local expiry checks and countdowns inaccurate; callers may want to log a warning
+// if it exceeds a few seconds.
+//
+// The server's time only has a resolution of one second, so the skew is accurate to
+// within about a second, plus any asymmetry in network latency.
+func (rdr *Reader) ClockSkew(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	serverTime, err := rdr.ServerTime(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ClockSkew: %w", err)
+	}
+	elapsed := time.Since(start)
+
+	// The Date header is truncated to the second, so on average the server's
+	// true time was half a second later than reported.
+	serverTime = serverTime.Add(500 * time.Millisecond)
+	return serverTime.Sub(start.Add(elapsed / 2)), nil
+}
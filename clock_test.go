@@ -0,0 +1,39 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClockSkew(t *testing.T) {
+	offset := 90 * time.Second
+	withDate := true
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		if withDate {
+			w.Header().Set("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+		}
+		w.Write([]byte(`{"btc":0,"btcUnconfirmed":0}`))
+	}))
+
+	serverTime, err := rdr.ServerTime(context.Background())
+	if err != nil {
+		t.Fatalf("ServerTime failed: %v", err)
+	} else if diff := serverTime.Sub(time.Now().Add(offset)); diff > time.Second || diff < -2*time.Second {
+		t.Fatalf("unexpected server time %s", serverTime)
+	}
+
+	skew, err := rdr.ClockSkew(context.Background())
+	if err != nil {
+		t.Fatalf("ClockSkew failed: %v", err)
+	} else if diff := skew - offset; diff > time.Second || diff < -time.Second {
+		t.Fatalf("expected skew of about %s, got %s", offset, skew)
+	}
+
+	withDate = false
+	if _, err := rdr.ClockSkew(context.Background()); !errors.Is(err, ErrNoServerTime) {
+		t.Fatalf("expected ErrNoServerTime, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package wos
+
+import (
+	"sync"
+	"time"
+)
+
+// coalescer deduplicates concurrent identical requests, so that callers asking
+// for the same key at the same time share a single in-flight request. Completed
+// responses are reused for a short window afterwards.
+type coalescer struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done     chan struct{}
+	data     []byte
+	err      error
+	finished time.Time
+}
+
+func newCoalescer(window time.Duration) *coalescer {
+	return &coalescer{
+		window: window,
+		calls:  make(map[string]*coalescedCall),
+	}
+}
+
+// do executes fn, unless another call with the same key is already in flight or
+// completed within the coalescing window, in which case that call's result is
+// returned instead. Failed calls are never reused once complete.
+func (c *coalescer) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		select {
+		case <-call.done:
+			if call.err == nil && time.Since(call.finished) < c.window {
+				c.mu.Unlock()
+				return call.data, nil
+			}
+		default:
+			c.mu.Unlock()
+			<-call.done
+			return call.data, call.err
+		}
+	}
+
+	// Drop completed calls which have outlived the window, so that callers
+	// using many distinct keys do not grow the map without bound.
+	for k, old := range c.calls {
+		select {
+		case <-old.done:
+			if time.Since(old.finished) >= c.window {
+				delete(c.calls, k)
+			}
+		default:
+		}
+	}
+
+	call := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.data, call.err = fn()
+	call.finished = time.Now()
+	close(call.done)
+
+	if call.err != nil || c.window <= 0 {
+		c.mu.Lock()
+		if c.calls[key] == call {
+			delete(c.calls, key)
+		}
+		c.mu.Unlock()
+	}
+
+	return call.data, call.err
+}
@@ -0,0 +1,198 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// CostBreakdown describes how much will leave a wallet when sending a payment,
+// as returned by [Wallet.TotalCost].
+type CostBreakdown struct {
+	// Amount is the BTC amount received by the destination.
+	Amount float64
+
+	// Fee is the estimated lightning routing fee for lightning destinations,
+	// or the fixed on-chain fee (which covers the miner fee) for on-chain destinations.
+	Fee float64
+
+	// Commission is the percentage-based commission WoS charges on on-chain sends.
+	// Always zero for lightning destinations.
+	Commission float64
+
+	// Total is the sum of Amount, Fee and Commission.
+	Total float64
+
+	// FeeProportion is the fraction of Amount which will be spent on Fee and Commission.
+	FeeProportion float64
+
+	// HighFeeWarning is set for on-chain destinations when FeeProportion exceeds
+	// [FeeEstimate.BtcSendFeeWarningPercent], meaning fees make up an unusually
+	// large part of the payment. Consider sending a larger amount, or using lightning.
+	// A [WarningHighFee] is also added to Warnings.
+	HighFeeWarning bool
+
+	// Warnings lists advisories about the payment, such as [WarningHighFee].
+	Warnings []Warning
+
+	// FeeEstimate is the raw estimate which the breakdown was computed from.
+	FeeEstimate *FeeEstimate
+}
+
+// FeeFiat returns the combined Fee and Commission converted to fiat at the given
+// price of one bitcoin, so a confirmation screen can show the fee in the user's
+// currency. Returns zero if the rate is zero or invalid, in which case the fiat
+// figure should be omitted from display.
+func (cost *CostBreakdown) FeeFiat(rate float64) float64 {
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return 0
+	}
+	return (cost.Fee + cost.Commission) * rate
+}
+
+// TotalCost estimates how much BTC will leave the wallet if amount is sent to the given
+// destination, which may be anything accepted by [Wallet.Pay]. For fixed-amount invoices,
+// amount may be zero, in which case the invoice amount is used.
+//
+// The breakdown is an estimate: the lightning fee actually paid may differ, up to
+// [FeeEstimate.MaxLightningFee].
+func (wallet *Wallet) TotalCost(ctx context.Context, destination string, amount float64) (*CostBreakdown, error) {
+	destination, kind, err := NormalizeDestination(destination)
+	if err != nil {
+		return nil, fmt.Errorf("TotalCost: %w", err)
+	}
+
+	if kind == DestinationInvoice {
+		invoiceAmount, err := parseInvoiceAmount(destination)
+		if err == nil {
+			if amount != 0 && amount != invoiceAmount {
+				return nil, fmt.Errorf(
+					"TotalCost: %w: invoice requests %.8f BTC, not %.8f BTC",
+					ErrFixedAmount, invoiceAmount, amount,
+				)
+			}
+			amount = invoiceAmount
+		} else if !errors.Is(err, ErrNoAmount) {
+			return nil, fmt.Errorf("TotalCost: %w", err)
+		}
+	}
+
+	fees, err := wallet.FeeEstimate(ctx, destination)
+	if err != nil {
+		return nil, fmt.Errorf("TotalCost: %w", err)
+	}
+
+	cost := &CostBreakdown{
+		Amount:      amount,
+		FeeEstimate: fees,
+	}
+	if kind == DestinationOnChain {
+		cost.Fee = fees.BtcFixedFee
+		cost.Commission = fees.CommissionOn(amount)
+	} else {
+		cost.Fee = fees.LightningFee
+	}
+	cost.Total = cost.Amount + cost.Fee + cost.Commission
+
+	if cost.Amount > 0 {
+		cost.FeeProportion = (cost.Fee + cost.Commission) / cost.Amount
+	}
+	if kind == DestinationOnChain {
+		if warning := highFeeWarning(fees, cost.Amount, cost.Fee+cost.Commission); warning != nil {
+			cost.HighFeeWarning = true
+			cost.Warnings = append(cost.Warnings, *warning)
+		}
+	}
+	return cost, nil
+}
+
+// highFeeWarning returns a [WarningHighFee] if an on-chain payment of amount costing
+// fee exceeds the fee proportion which WoS itself would warn about.
+func highFeeWarning(fees *FeeEstimate, amount, fee float64) *Warning {
+	if fees.BtcSendFeeWarningPercent <= 0 || amount <= 0 {
+		return nil
+	}
+	proportion := fee / amount
+	if proportion <= fees.BtcSendFeeWarningPercent {
+		return nil
+	}
+	warning := newWarning(WarningHighFee, fmt.Errorf(
+		"%w: fees of %.8f BTC are %.2f%% of %.8f BTC sent",
+		ErrHighFee, fee, proportion*100, amount,
+	))
+	return &warning
+}
+
+// ErrConcurrentActivity is the error wrapped by [WarningConcurrentActivity] warnings.
+var ErrConcurrentActivity = errors.New("other payments occurred during the measurement")
+
+// measureFeeHistoryWindow is how many recent payments [Wallet.PayAndMeasureFee] compares
+// before and after sending to detect concurrent activity.
+const measureFeeHistoryWindow = 10
+
+// PayAndMeasureFee sends a payment as with [Wallet.Pay], and measures the fee WoS
+// actually charged by diffing the wallet's confirmed balance before and after sending:
+// the fee is before - after - amount. This gives real fee data where WoS does not
+// report a fee on the payment. For fixed-amount invoices, amount may be zero, in which
+// case the amount of the returned payment is used.
+//
+// Any other payment sent or received while measuring skews the result, so the recent
+// payment history is compared before and after sending; if other payments appeared, a
+// [WarningConcurrentActivity] is added to the payment's Warnings and the fee should not
+// be trusted. If the payment was sent but the fee could not be measured, the payment is
+// returned along with the error.
+func (wallet *Wallet) PayAndMeasureFee(
+	ctx context.Context,
+	destination string,
+	amount float64,
+	description string,
+) (*Payment, float64, error) {
+	before, err := wallet.Balance(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("PayAndMeasureFee: %w", err)
+	}
+	recent, err := wallet.reader.paymentPage(ctx, 0, measureFeeHistoryWindow)
+	if err != nil {
+		return nil, 0, fmt.Errorf("PayAndMeasureFee: %w", err)
+	}
+
+	payment, err := wallet.Pay(ctx, destination, amount, description)
+	if err != nil {
+		return nil, 0, fmt.Errorf("PayAndMeasureFee: %w", err)
+	}
+
+	after, err := wallet.Balance(ctx)
+	if err != nil {
+		return payment, 0, fmt.Errorf("PayAndMeasureFee: payment sent, but failed to measure fee: %w", err)
+	}
+	if amount == 0 {
+		amount = payment.Amount
+	}
+	feeSats := toSats(before.Confirmed) - toSats(after.Confirmed) - toSats(amount)
+	fee := float64(feeSats) / 100_000_000
+
+	seen := make(map[string]bool, len(recent)+1)
+	for _, p := range recent {
+		seen[p.ID] = true
+	}
+	seen[payment.ID] = true
+
+	latest, err := wallet.reader.paymentPage(ctx, 0, measureFeeHistoryWindow)
+	if err != nil {
+		return payment, fee, fmt.Errorf("PayAndMeasureFee: failed to check for concurrent payments: %w", err)
+	}
+	var others int
+	for _, p := range latest {
+		if !seen[p.ID] {
+			others++
+		}
+	}
+	if others > 0 || feeSats < 0 {
+		payment.Warnings = append(payment.Warnings, newWarning(WarningConcurrentActivity, fmt.Errorf(
+			"%w: measured fee of %.8f BTC may be inaccurate (%d other payments)",
+			ErrConcurrentActivity, fee, others,
+		)))
+	}
+	return payment, fee, nil
+}
@@ -0,0 +1,123 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestTotalCost(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("address") == "bc1qdestination" {
+			w.Write([]byte(`{"btcFixedFee":0.00002,"btcSendCommissionPercent":0.01,"lightningFee":0}`))
+		} else {
+			w.Write([]byte(`{"btcFixedFee":0.00002,"btcSendCommissionPercent":0.01,"lightningFee":0.000001}`))
+		}
+	})
+
+	approx := func(a, b float64) bool { return math.Abs(a-b) < 1e-12 }
+
+	cost, err := wallet.TotalCost(context.Background(), testInvoiceCoffee, 0)
+	if err != nil {
+		t.Fatalf("TotalCost failed for invoice: %v", err)
+	}
+	if !approx(cost.Amount, 0.0025) || !approx(cost.Fee, 0.000001) ||
+		cost.Commission != 0 || !approx(cost.Total, 0.002501) {
+		t.Fatalf("unexpected lightning breakdown: %+v", cost)
+	}
+
+	cost, err = wallet.TotalCost(context.Background(), "bitcoin:bc1qdestination", 0.01)
+	if err != nil {
+		t.Fatalf("TotalCost failed for on-chain address: %v", err)
+	}
+	if !approx(cost.Amount, 0.01) || !approx(cost.Fee, 0.00002) ||
+		!approx(cost.Commission, 0.0001) || !approx(cost.Total, 0.01012) {
+		t.Fatalf("unexpected on-chain breakdown: %+v", cost)
+	}
+}
+
+func TestTotalCostHighFeeWarning(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"btcFixedFee":0.00002,"btcSendCommissionPercent":0.01,"btcSendFeeWarningPercent":0.05}`))
+	})
+
+	// Fees are 0.00002 + 0.000001 on 0.0001 BTC: 21%.
+	cost, err := wallet.TotalCost(context.Background(), "bc1qdestination", 0.0001)
+	if err != nil {
+		t.Fatalf("TotalCost failed: %v", err)
+	} else if !cost.HighFeeWarning || math.Abs(cost.FeeProportion-0.21) > 1e-9 {
+		t.Fatalf("expected high fee warning at 21%%, got %+v", cost)
+	} else if len(cost.Warnings) != 1 || cost.Warnings[0].Code != WarningHighFee {
+		t.Fatalf("expected a WarningHighFee, got %v", cost.Warnings)
+	}
+
+	// Fees are 0.00002 + 0.0001 on 0.01 BTC: 1.2%.
+	cost, err = wallet.TotalCost(context.Background(), "bc1qdestination", 0.01)
+	if err != nil {
+		t.Fatalf("TotalCost failed: %v", err)
+	} else if cost.HighFeeWarning || len(cost.Warnings) != 0 {
+		t.Fatalf("expected no fee warning at %.4f, got %+v", cost.FeeProportion, cost)
+	}
+}
+
+func TestFeeFiat(t *testing.T) {
+	onChain := FeeEstimate{BtcFixedFee: 0.00002, BtcSendCommissionPercent: 0.01, Destination: "bc1qdestination"}
+	if fiat := onChain.FeeFiat(50_000, 0.001); math.Abs(fiat-1.5) > 1e-9 {
+		t.Fatalf("expected on-chain fee of $1.50, got %f", fiat)
+	}
+
+	lightning := FeeEstimate{LightningFee: 0.00000002, BtcFixedFee: 0.00002, Destination: testInvoiceCoffee}
+	if fiat := lightning.FeeFiat(50_000, 0.0025); math.Abs(fiat-0.001) > 1e-12 {
+		t.Fatalf("expected lightning fee of $0.001, got %f", fiat)
+	}
+
+	if fiat := lightning.FeeFiat(0, 0.0025); fiat != 0 {
+		t.Fatalf("expected zero fiat fee without a rate, got %f", fiat)
+	}
+
+	cost := CostBreakdown{Fee: 0.00002, Commission: 0.00001}
+	if fiat := cost.FeeFiat(60_000); math.Abs(fiat-1.8) > 1e-9 {
+		t.Fatalf("expected breakdown fee of $1.80, got %f", fiat)
+	}
+}
+
+func TestPayAndMeasureFee(t *testing.T) {
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	for _, concurrent := range []bool{false, true} {
+		sent := false
+		wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/api/v1/wallet/balance" && !sent:
+				w.Write([]byte(`{"btc":0.01}`))
+			case r.URL.Path == "/api/v1/wallet/balance":
+				w.Write([]byte(`{"btc":0.00897}`))
+			case r.Method == http.MethodPost:
+				sent = true
+				w.Write([]byte(`{"id":"ours","amount":0.001,"status":"PENDING"}`))
+			case !sent:
+				w.Write([]byte(`[{"id":"old"}]`))
+			case concurrent:
+				w.Write([]byte(`[{"id":"other"},{"id":"ours"},{"id":"old"}]`))
+			default:
+				w.Write([]byte(`[{"id":"ours"},{"id":"old"}]`))
+			}
+		})
+
+		payment, fee, err := wallet.PayAndMeasureFee(context.Background(), address, 0.001, "")
+		if err != nil {
+			t.Fatalf("PayAndMeasureFee failed: %v", err)
+		} else if payment.ID != "ours" {
+			t.Fatalf("unexpected payment: %+v", payment)
+		}
+		if math.Abs(fee-0.00003) > 1e-12 {
+			t.Fatalf("expected fee of 0.00003 BTC, got %.8f", fee)
+		}
+
+		warned := len(payment.Warnings) == 1 && errors.Is(payment.Warnings[0], ErrConcurrentActivity)
+		if warned != concurrent {
+			t.Fatalf("concurrent=%v: unexpected warnings %v", concurrent, payment.Warnings)
+		}
+	}
+}
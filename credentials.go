@@ -0,0 +1,93 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrCredentialsMismatch is returned by [Wallet.CheckCredentials] and [Credentials.Validate]
+// when WoS accepts the API token but rejects request signatures made with the API secret.
+// This usually means the token and secret belong to different wallets.
+var ErrCredentialsMismatch = errors.New("API token and secret do not belong to the same wallet")
+
+// ErrOpenFailed is returned by [Credentials.OpenAndVerify] when the wallet cannot be
+// opened, such as when WoS rejects the API token or cannot be reached.
+var ErrOpenFailed = errors.New("failed to open wallet")
+
+// ErrReadFailed is returned by [Credentials.OpenAndVerify] when the wallet opens but
+// its balance cannot be read.
+var ErrReadFailed = errors.New("failed to read wallet")
+
+// isSignatureRejection returns true if err is an [*APIError] indicating WoS rejected the
+// signature of a request. WoS does not document how it rejects signatures, so this
+// matches an authorization failure status, or an error message mentioning the signature.
+func isSignatureRejection(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized ||
+		apiErr.StatusCode == http.StatusForbidden ||
+		strings.Contains(strings.ToLower(apiErr.Message), "signature")
+}
+
+// CheckCredentials makes a harmless signed request to check that WoS accepts the
+// wallet's request signatures. If the wallet was opened with a token and a secret from
+// different wallets, reads succeed but every payment fails; CheckCredentials detects
+// this up front, returning an error wrapping [ErrCredentialsMismatch].
+//
+// The check asks WoS to resolve an empty LNURL, which it refuses without side effects.
+// Any response other than a signature rejection means the signature was accepted.
+func (wallet *Wallet) CheckCredentials(ctx context.Context) error {
+	_, err := wallet.PostRequest(ctx, "/api/v1/wallet/lnurl", map[string]any{
+		"address": "",
+	})
+
+	var apiErr *APIError
+	if isSignatureRejection(err) {
+		return fmt.Errorf("CheckCredentials: %w: %w", ErrCredentialsMismatch, err)
+	} else if err != nil && !errors.As(err, &apiErr) {
+		return fmt.Errorf("CheckCredentials: %w", err)
+	}
+	return nil
+}
+
+// Validate opens the wallet and checks that both the APIToken and APISecret are
+// accepted by WoS, using [Wallet.CheckCredentials]. If the token and secret belong to
+// different wallets, it returns an error wrapping [ErrCredentialsMismatch].
+func (creds Credentials) Validate(ctx context.Context, httpClient *http.Client) error {
+	wallet, err := creds.OpenWallet(ctx, httpClient)
+	if err != nil {
+		return fmt.Errorf("Validate: %w", err)
+	}
+	return wallet.CheckCredentials(ctx)
+}
+
+// OpenAndVerify opens the wallet and checks that it is fully usable: that its addresses
+// and balance can be read, and that WoS accepts its request signatures, as checked by
+// [Wallet.CheckCredentials]. This is intended for a single startup check in setup flows.
+//
+// Each failure mode returns a distinct error: one wrapping [ErrOpenFailed] if the wallet
+// cannot be opened, [ErrReadFailed] if its balance cannot be read, or
+// [ErrCredentialsMismatch] if WoS rejects its signatures. The underlying error is also
+// wrapped. If the check fails after the wallet was opened, the wallet is closed.
+func (creds Credentials) OpenAndVerify(ctx context.Context, httpClient *http.Client) (*Wallet, error) {
+	wallet, err := creds.OpenWallet(ctx, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAndVerify: %w: %w", ErrOpenFailed, err)
+	}
+
+	if _, err := wallet.Balance(ctx); err != nil {
+		wallet.Close()
+		return nil, fmt.Errorf("OpenAndVerify: %w: %w", ErrReadFailed, err)
+	}
+
+	if err := wallet.CheckCredentials(ctx); err != nil {
+		wallet.Close()
+		return nil, fmt.Errorf("OpenAndVerify: %w", err)
+	}
+	return wallet, nil
+}
@@ -0,0 +1,88 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// currenciesEndpoint is an undocumented endpoint which may list the currencies
+// WoS can display balances in.
+const currenciesEndpoint = "/api/v1/wallet/currencies"
+
+// WoSDisplayCurrencies is a curated list of the ISO 4217 codes of fiat currencies the
+// WoS app offers for displaying balances, as returned by [Reader.SupportedDisplayCurrencies]
+// when WoS does not report its own list. WoS adds currencies from time to time, so
+// applications can append to or replace this list, but it must not be modified while
+// it is in use.
+var WoSDisplayCurrencies = []string{
+	"AED", "ARS", "AUD", "BGN", "BRL", "CAD", "CHF", "CLP", "CNY", "COP",
+	"CZK", "DKK", "EUR", "GBP", "GHS", "HKD", "HUF", "IDR", "ILS", "INR",
+	"ISK", "JPY", "KES", "KRW", "MXN", "MYR", "NGN", "NOK", "NZD", "PEN",
+	"PHP", "PLN", "RON", "SAR", "SEK", "SGD", "THB", "TRY", "TWD", "UAH",
+	"USD", "VND", "ZAR",
+}
+
+// SupportedDisplayCurrencies returns the ISO 4217 codes of the fiat currencies WoS can
+// display balances in, such as for a currency picker. WoS does not document this list,
+// so it is fetched from an undocumented endpoint if it exists, and otherwise defaults
+// to [WoSDisplayCurrencies]. The list is cached for the lifetime of the Reader; if it
+// cannot be fetched, the defaults are returned along with the error, and fetching is
+// retried on the next call.
+func (rdr *Reader) SupportedDisplayCurrencies(ctx context.Context) ([]string, error) {
+	rdr.currenciesMu.Lock()
+	defer rdr.currenciesMu.Unlock()
+	if rdr.currencies != nil {
+		return append([]string(nil), rdr.currencies...), nil
+	}
+
+	defaults := append([]string(nil), WoSDisplayCurrencies...)
+
+	respData, err := rdr.GetRequest(ctx, currenciesEndpoint)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		// No currencies endpoint, so use the curated list for good.
+		rdr.currencies = defaults
+		return append([]string(nil), defaults...), nil
+	} else if err != nil {
+		return defaults, fmt.Errorf("SupportedDisplayCurrencies: %w", err)
+	}
+
+	currencies, err := parseCurrencyList(respData)
+	if err != nil {
+		return defaults, fmt.Errorf("invalid SupportedDisplayCurrencies response: %w", err)
+	} else if len(currencies) == 0 {
+		currencies = defaults
+	}
+
+	rdr.currencies = currencies
+	return append([]string(nil), currencies...), nil
+}
+
+// parseCurrencyList decodes a list of currency codes, given either as strings
+// or as objects with a code field, returning them in upper case.
+func parseCurrencyList(data []byte) ([]string, error) {
+	var codes []string
+	if err := json.Unmarshal(data, &codes); err != nil {
+		var objects []struct {
+			Code string `json:"code"`
+		}
+		if json.Unmarshal(data, &objects) != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			codes = append(codes, object.Code)
+		}
+	}
+
+	currencies := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			currencies = append(currencies, code)
+		}
+	}
+	return currencies, nil
+}
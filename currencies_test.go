@@ -0,0 +1,45 @@
+package wos
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSupportedDisplayCurrencies(t *testing.T) {
+	var requests int
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[{"code":"usd"},{"code":"EUR"},{"code":"JPY"}]`))
+	}))
+
+	for i := 0; i < 2; i++ {
+		currencies, err := rdr.SupportedDisplayCurrencies(context.Background())
+		if err != nil {
+			t.Fatalf("SupportedDisplayCurrencies failed: %v", err)
+		} else if !reflect.DeepEqual(currencies, []string{"USD", "EUR", "JPY"}) {
+			t.Fatalf("unexpected currencies: %v", currencies)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected currencies to be cached, made %d requests", requests)
+	}
+
+	requests = 0
+	rdr = NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	for i := 0; i < 2; i++ {
+		currencies, err := rdr.SupportedDisplayCurrencies(context.Background())
+		if err != nil {
+			t.Fatalf("SupportedDisplayCurrencies failed: %v", err)
+		} else if !reflect.DeepEqual(currencies, WoSDisplayCurrencies) {
+			t.Fatalf("expected curated list, got %v", currencies)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected curated list to be cached, made %d requests", requests)
+	}
+}
@@ -0,0 +1,78 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Dashboard combines the information a wallet dashboard typically displays,
+// as fetched by [Reader.Dashboard].
+type Dashboard struct {
+	// Balance is the wallet's current balance, or nil if it could not be fetched.
+	Balance *Balance
+
+	// RecentPayments lists the wallet's most recent payments, ordered from oldest
+	// to newest. It is nil if they could not be fetched.
+	RecentPayments []Payment
+
+	// Addresses are the wallet's deposit addresses, or nil if they could not be fetched.
+	Addresses *Addresses
+
+	// Warnings lists a [WarningPartialResult] for each error which prevented any
+	// of the above from being fetched.
+	Warnings []Warning
+}
+
+// Dashboard fetches the wallet's balance, its recentN most recent payments, and its
+// deposit addresses concurrently, saving the round trips of fetching each in turn.
+//
+// If some of these fail to be fetched, the rest are still returned, and the failures
+// are listed in [Dashboard.Warnings]. An error is only returned if all of them fail.
+func (rdr *Reader) Dashboard(ctx context.Context, recentN int) (*Dashboard, error) {
+	balanceChan := make(chan *Balance, 1)
+	paymentsChan := make(chan []Payment, 1)
+	addressesChan := make(chan *Addresses, 1)
+	errChan := make(chan error, 3)
+
+	go func() {
+		balance, err := rdr.Balance(ctx)
+		if err != nil {
+			errChan <- err
+		}
+		balanceChan <- balance
+	}()
+
+	go func() {
+		payments, err := rdr.RecentPayments(ctx, recentN)
+		if err != nil {
+			errChan <- err
+		}
+		paymentsChan <- payments
+	}()
+
+	go func() {
+		addresses, err := rdr.Addresses(ctx)
+		if err != nil {
+			errChan <- err
+		}
+		addressesChan <- addresses
+	}()
+
+	dashboard := &Dashboard{
+		Balance:        <-balanceChan,
+		RecentPayments: <-paymentsChan,
+		Addresses:      <-addressesChan,
+	}
+	close(errChan)
+	var errs []error
+	for err := range errChan {
+		errs = append(errs, err)
+		dashboard.Warnings = append(dashboard.Warnings, newWarning(WarningPartialResult, err))
+	}
+
+	if len(errs) == 3 {
+		return nil, fmt.Errorf("Dashboard: %w", errors.Join(errs...))
+	}
+	return dashboard, nil
+}
@@ -0,0 +1,76 @@
+package wos
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDashboard(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	var failHistory atomic.Bool
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":0.5}`))
+		case "/api/v1/wallet/account":
+			w.Write([]byte(`{"btcDepositAddress":"bc1qexample","lightningAddress":"user@walletofsatoshi.com"}`))
+		case "/api/v1/wallet/payment":
+			if failHistory.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`[{"id":"b","time":"2024-01-02T00:00:00Z"},{"id":"a","time":"2024-01-01T00:00:00Z"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	dashboard, err := rdr.Dashboard(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Dashboard failed: %v", err)
+	}
+	if len(dashboard.Warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", dashboard.Warnings)
+	}
+	if dashboard.Balance.Confirmed != 0.5 || dashboard.Addresses.OnChain != "bc1qexample" {
+		t.Fatalf("unexpected dashboard: %+v", dashboard)
+	}
+	if len(dashboard.RecentPayments) != 2 || dashboard.RecentPayments[0].ID != "a" {
+		t.Fatalf("unexpected recent payments: %+v", dashboard.RecentPayments)
+	}
+	if max := maxInFlight.Load(); max != 3 {
+		t.Fatalf("expected 3 concurrent requests, got %d", max)
+	}
+
+	failHistory.Store(true)
+	dashboard, err = rdr.Dashboard(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Dashboard failed on partial failure: %v", err)
+	}
+	if len(dashboard.Warnings) != 1 || dashboard.Warnings[0].Code != WarningPartialResult || dashboard.RecentPayments != nil {
+		t.Fatalf("expected a single warning for the failed history, got %+v", dashboard)
+	}
+	if dashboard.Balance == nil || dashboard.Addresses == nil {
+		t.Fatalf("expected successful parts to be returned: %+v", dashboard)
+	}
+
+	failing := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	if _, err := failing.Dashboard(context.Background(), 2); err == nil {
+		t.Fatalf("expected error when everything fails")
+	}
+}
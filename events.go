@@ -0,0 +1,190 @@
+package wos
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultEventPollInterval is the interval at which [Wallet.Events] polls the
+// wallet's payment history.
+const DefaultEventPollInterval = 10 * time.Second
+
+// DefaultEventBufferSize is the number of events buffered for each [EventBus]
+// subscriber when no buffer size is given.
+const DefaultEventBufferSize = 16
+
+// WalletEvent is a notification from [Wallet.Events]: either a payment which newly
+// appeared in the wallet's history, or an error from a failed poll.
+type WalletEvent struct {
+	// Payment is the new payment. Zero if Err is set.
+	Payment Payment
+
+	// Err is set if polling the history failed. Polling continues regardless.
+	Err error
+}
+
+// EventOptions customizes [Wallet.Events].
+type EventOptions struct {
+	// PollInterval is how often the payment history is polled.
+	// Defaults to [DefaultEventPollInterval].
+	PollInterval time.Duration
+
+	// Cursor resumes events from a previously saved [HistoryCursor], so that payments
+	// since then are reported. If nil, the history at the time of the first poll is
+	// taken as a baseline, and only payments after it are reported.
+	Cursor *HistoryCursor
+}
+
+// Events polls the wallet's payment history in a background goroutine, sending a
+// [WalletEvent] on the returned channel for each new payment, ordered from oldest to
+// newest. The channel is closed once ctx is cancelled. If opts is nil, the defaults
+// are used.
+//
+// The channel is unbuffered, and polling waits for each event to be received. To
+// deliver events to several consumers without each running its own poller, pass the
+// channel to [EventBus.Run].
+func (wallet *Wallet) Events(ctx context.Context, opts *EventOptions) <-chan WalletEvent {
+	if opts == nil {
+		opts = &EventOptions{}
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultEventPollInterval
+	}
+
+	events := make(chan WalletEvent)
+	go func() {
+		defer close(events)
+
+		clock := clockOrDefault(wallet.clock)
+		history := NewHistorySync(wallet.reader, opts.Cursor)
+		baseline := opts.Cursor == nil
+		for {
+			payments, err := history.Poll(ctx)
+			if err != nil {
+				select {
+				case events <- WalletEvent{Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			} else if baseline {
+				baseline = false
+			} else {
+				for _, payment := range payments {
+					select {
+					case events <- WalletEvent{Payment: payment}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			select {
+			case <-clock.After(interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// EventBus fans out [WalletEvent] notifications to any number of subscribers, so that
+// several components can react to payments from a single [Wallet.Events] poller.
+//
+// Each subscriber has its own buffer. Publishing never blocks: if a subscriber's buffer
+// is full, the event is dropped for that subscriber alone, so a slow subscriber cannot
+// hold up the others. Dropped events are counted by [EventBus.Dropped].
+//
+// An EventBus is safe for concurrent use.
+type EventBus struct {
+	bufferSize int
+	dropped    atomic.Uint64
+
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[<-chan WalletEvent]chan WalletEvent
+}
+
+// NewEventBus returns an EventBus which buffers up to bufferSize events for each
+// subscriber. If bufferSize is not positive, [DefaultEventBufferSize] is used.
+func NewEventBus(bufferSize int) *EventBus {
+	if bufferSize <= 0 {
+		bufferSize = DefaultEventBufferSize
+	}
+	return &EventBus{
+		bufferSize:  bufferSize,
+		subscribers: make(map[<-chan WalletEvent]chan WalletEvent),
+	}
+}
+
+// Subscribe registers a new subscriber, returning the channel its events are sent on.
+// The channel is closed by [EventBus.Unsubscribe] or [EventBus.Close]. If the bus is
+// already closed, the returned channel is closed.
+func (bus *EventBus) Subscribe() <-chan WalletEvent {
+	ch := make(chan WalletEvent, bus.bufferSize)
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if bus.closed {
+		close(ch)
+		return ch
+	}
+	bus.subscribers[ch] = ch
+	return ch
+}
+
+// Unsubscribe removes the subscriber with the given channel and closes it. Events
+// already buffered can still be received from the channel. Does nothing if ch is not
+// subscribed.
+func (bus *EventBus) Unsubscribe(ch <-chan WalletEvent) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if sub, ok := bus.subscribers[ch]; ok {
+		delete(bus.subscribers, ch)
+		close(sub)
+	}
+}
+
+// Publish sends event to every subscriber, dropping it for any subscriber whose
+// buffer is full.
+func (bus *EventBus) Publish(event WalletEvent) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	for _, sub := range bus.subscribers {
+		select {
+		case sub <- event:
+		default:
+			bus.dropped.Add(1)
+		}
+	}
+}
+
+// Run publishes every event received from events, such as the channel returned by
+// [Wallet.Events], until it is closed, and then closes the bus.
+func (bus *EventBus) Run(events <-chan WalletEvent) {
+	for event := range events {
+		bus.Publish(event)
+	}
+	bus.Close()
+}
+
+// Close unsubscribes and closes every subscriber, and makes later subscribers
+// receive a closed channel. Close is safe to call more than once.
+func (bus *EventBus) Close() {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.closed = true
+	for ch, sub := range bus.subscribers {
+		delete(bus.subscribers, ch)
+		close(sub)
+	}
+}
+
+// Dropped returns the total number of events dropped because a subscriber's buffer
+// was full.
+func (bus *EventBus) Dropped() uint64 {
+	return bus.dropped.Load()
+}
@@ -0,0 +1,83 @@
+package wos
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventBusFanOut(t *testing.T) {
+	bus := NewEventBus(2)
+	first := bus.Subscribe()
+	second := bus.Subscribe()
+
+	bus.Publish(WalletEvent{Payment: Payment{ID: "a"}})
+	for _, sub := range []<-chan WalletEvent{first, second} {
+		if event := <-sub; event.Payment.ID != "a" {
+			t.Fatalf("expected event for payment a, got %+v", event)
+		}
+	}
+
+	bus.Unsubscribe(second)
+	if _, ok := <-second; ok {
+		t.Fatalf("expected unsubscribed channel to be closed")
+	}
+	bus.Unsubscribe(second)
+
+	bus.Publish(WalletEvent{Payment: Payment{ID: "b"}})
+	if event := <-first; event.Payment.ID != "b" {
+		t.Fatalf("expected remaining subscriber to receive payment b, got %+v", event)
+	}
+
+	// A subscriber which stops reading does not block publishing.
+	for i := 0; i < 5; i++ {
+		bus.Publish(WalletEvent{})
+	}
+	if dropped := bus.Dropped(); dropped != 3 {
+		t.Fatalf("expected 3 dropped events, got %d", dropped)
+	}
+
+	bus.Close()
+	if _, ok := <-bus.Subscribe(); ok {
+		t.Fatalf("expected subscribing to a closed bus to return a closed channel")
+	}
+}
+
+func TestWalletEvents(t *testing.T) {
+	var polls atomic.Int32
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) == 1 {
+			w.Write([]byte(`[{"id":"old","time":"2024-01-01T00:00:00Z"}]`))
+			return
+		}
+		w.Write([]byte(`[
+			{"id":"old","time":"2024-01-01T00:00:00Z"},
+			{"id":"new","time":"2024-01-01T00:01:00Z"}
+		]`))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := NewEventBus(0)
+	first, second := bus.Subscribe(), bus.Subscribe()
+	go bus.Run(wallet.Events(ctx, &EventOptions{PollInterval: time.Millisecond}))
+
+	for _, sub := range []<-chan WalletEvent{first, second} {
+		select {
+		case event := <-sub:
+			if event.Err != nil || event.Payment.ID != "new" {
+				t.Fatalf("expected event for the new payment only, got %+v", event)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event")
+		}
+	}
+
+	cancel()
+	for _, sub := range []<-chan WalletEvent{first, second} {
+		for range sub {
+		}
+	}
+}
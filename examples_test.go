@@ -87,7 +87,7 @@ type RemoteSigner struct {
 
 func (rs RemoteSigner) SignRequest(
 	ctx context.Context,
-	endpoint, nonce, requestBody, apiToken string,
+	endpoint, nonce, apiToken, requestBody string,
 ) ([]byte, error) {
 	bodyBytes, err := json.Marshal(map[string]string{
 		"endpoint": endpoint,
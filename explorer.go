@@ -0,0 +1,284 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoBlockExplorer is returned when checking on-chain address activity
+	// without a [BlockExplorer] configured with [Reader.SetBlockExplorer].
+	ErrNoBlockExplorer = errors.New("no block explorer configured")
+
+	// ErrAddressReused is wrapped by the [WarningAddressReused] privacy warning, returned
+	// by [Reader.CheckedOnChainAddress] when the wallet's on-chain deposit address has
+	// already received or sent funds.
+	// Sharing a reused address lets observers link the payments made to it.
+	ErrAddressReused = errors.New("on-chain address has prior activity")
+)
+
+// BlockExplorer looks up public information about on-chain addresses and transactions.
+type BlockExplorer interface {
+	// AddressTxCount returns the number of transactions involving the given address,
+	// including unconfirmed transactions.
+	AddressTxCount(ctx context.Context, address string) (int, error)
+
+	// TxConfirmations returns the number of confirmations of the transaction with the
+	// given ID, or zero if it is unconfirmed.
+	TxConfirmations(ctx context.Context, txid string) (int, error)
+}
+
+// EsploraExplorer is a [BlockExplorer] which queries an [Esplora] HTTP API, such
+// as those run by mempool.space and blockstream.info.
+//
+// Note that looking up an address reveals your interest in it to the explorer's
+// operator. Use your own Esplora instance for the best privacy.
+//
+// [Esplora]: https://github.com/Blockstream/esplora/blob/master/API.md
+type EsploraExplorer struct {
+	// BaseURL is the root of the API, such as "https://mempool.space/api".
+	BaseURL string
+
+	// HTTPClient is used for requests. Uses [http.DefaultClient] if nil.
+	HTTPClient *http.Client
+}
+
+// get fetches the given API path from the explorer.
+func (explorer *EsploraExplorer) get(ctx context.Context, path string) ([]byte, error) {
+	httpClient := explorer.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx, httpClient)
+	defer cancel()
+
+	endpoint := strings.TrimSuffix(explorer.BaseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("block explorer request failed: %w", err)
+	}
+	body, err := bufferResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("block explorer: failed to read body: %w", err)
+	} else if err := checkHTTPResponse(resp, body); err != nil {
+		return nil, fmt.Errorf("block explorer: %w", err)
+	}
+	return body, nil
+}
+
+// AddressTxCount implements [BlockExplorer].
+func (explorer *EsploraExplorer) AddressTxCount(ctx context.Context, address string) (int, error) {
+	body, err := explorer.get(ctx, "/address/"+url.PathEscape(address))
+	if err != nil {
+		return 0, err
+	}
+
+	var stats struct {
+		ChainStats struct {
+			TxCount int `json:"tx_count"`
+		} `json:"chain_stats"`
+		MempoolStats struct {
+			TxCount int `json:"tx_count"`
+		} `json:"mempool_stats"`
+	}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return 0, fmt.Errorf("invalid block explorer response: %w", err)
+	}
+	return stats.ChainStats.TxCount + stats.MempoolStats.TxCount, nil
+}
+
+// TxConfirmations implements [BlockExplorer].
+func (explorer *EsploraExplorer) TxConfirmations(ctx context.Context, txid string) (int, error) {
+	body, err := explorer.get(ctx, "/tx/"+url.PathEscape(txid)+"/status")
+	if err != nil {
+		return 0, err
+	}
+
+	var status struct {
+		Confirmed   bool `json:"confirmed"`
+		BlockHeight int  `json:"block_height"`
+	}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return 0, fmt.Errorf("invalid block explorer response: %w", err)
+	} else if !status.Confirmed {
+		return 0, nil
+	}
+
+	body, err = explorer.get(ctx, "/blocks/tip/height")
+	if err != nil {
+		return 0, err
+	}
+	tipHeight, err := strconv.Atoi(strings.TrimSpace(string(body)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid block explorer tip height: %q", body)
+	}
+
+	// The tip may lag the transaction's block if the explorer's backends disagree.
+	if tipHeight < status.BlockHeight {
+		return 1, nil
+	}
+	return tipHeight - status.BlockHeight + 1, nil
+}
+
+// SetBlockExplorer configures the [BlockExplorer] used to check on-chain address
+// activity. Pass nil to disable address activity checks.
+func (rdr *Reader) SetBlockExplorer(explorer BlockExplorer) {
+	rdr.explorer = explorer
+}
+
+// AddressHasActivity reports whether any transactions involve the given on-chain
+// address, using the Reader's [BlockExplorer]. Returns [ErrNoBlockExplorer] if
+// none is configured.
+func (rdr *Reader) AddressHasActivity(ctx context.Context, address string) (bool, error) {
+	if rdr.explorer == nil {
+		return false, ErrNoBlockExplorer
+	}
+	count, err := rdr.explorer.AddressTxCount(ctx, address)
+	if err != nil {
+		return false, fmt.Errorf("AddressHasActivity: %w", err)
+	}
+	return count > 0, nil
+}
+
+// CheckedOnChainAddress is like [Reader.OnChainAddress], but also checks whether the
+// address has been used before, if a [BlockExplorer] is configured. If so, the address
+// is returned along with a [WarningAddressReused] privacy warning. Failure to check the
+// address is not an error; the address is returned without warnings.
+func (rdr *Reader) CheckedOnChainAddress(ctx context.Context) (address string, warnings []Warning, err error) {
+	address, err = rdr.OnChainAddress(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if rdr.explorer != nil {
+		if used, err := rdr.AddressHasActivity(ctx, address); err == nil && used {
+			warnings = append(warnings, newWarning(WarningAddressReused, fmt.Errorf("%w: %s", ErrAddressReused, address)))
+		}
+	}
+	return address, warnings, nil
+}
+
+// OnChainConfirmations returns the number of confirmations of the on-chain transaction
+// with the given ID, such as the [Payment.Txid] of an on-chain payment, using the
+// Reader's [BlockExplorer]. Returns [ErrNoBlockExplorer] if none is configured.
+func (rdr *Reader) OnChainConfirmations(ctx context.Context, txid string) (int, error) {
+	if rdr.explorer == nil {
+		return 0, ErrNoBlockExplorer
+	}
+	confirmations, err := rdr.explorer.TxConfirmations(ctx, txid)
+	if err != nil {
+		return 0, fmt.Errorf("OnChainConfirmations: %w", err)
+	}
+	return confirmations, nil
+}
+
+// DefaultConfirmationPollInterval is the interval at which [Wallet.WaitForConfirmations]
+// polls the block explorer if no interval is given. Blocks arrive every ten minutes
+// on average, so there is little to gain from polling much more often.
+const DefaultConfirmationPollInterval = time.Minute
+
+// WaitForConfirmations blocks until the given on-chain payment's transaction has at
+// least n confirmations, polling the wallet's [BlockExplorer] every pollInterval, or
+// [DefaultConfirmationPollInterval] if pollInterval is zero. This gives stronger
+// settlement assurance for large payments than [PaymentStatusPaid], which WoS may
+// report after fewer confirmations than a merchant wants.
+//
+// Returns [ErrNoBlockExplorer] if none is configured. Errors from individual polls
+// are not fatal; waiting continues until ctx is cancelled, and the last poll error
+// is returned alongside the context's error.
+func (wallet *Wallet) WaitForConfirmations(
+	ctx context.Context,
+	payment *Payment,
+	n int,
+	pollInterval time.Duration,
+) error {
+	if payment.Currency != PaymentCurrencyBitcoin || payment.Txid == "" {
+		return fmt.Errorf("WaitForConfirmations: payment %s is not an on-chain transaction", payment.ID)
+	}
+	if wallet.reader.explorer == nil {
+		return ErrNoBlockExplorer
+	}
+	if pollInterval <= 0 {
+		pollInterval = DefaultConfirmationPollInterval
+	}
+
+	clock := clockOrDefault(wallet.clock)
+	var lastErr error
+	for {
+		confirmations, err := wallet.reader.OnChainConfirmations(ctx, payment.Txid)
+		if err == nil && confirmations >= n {
+			return nil
+		}
+		lastErr = err
+
+		select {
+		case <-clock.After(pollInterval):
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("WaitForConfirmations: %w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return fmt.Errorf("WaitForConfirmations: %w", ctx.Err())
+		}
+	}
+}
+
+// BalanceWithMinConf returns the wallet's balance, counting on-chain deposits as
+// confirmed only once they have at least minConf confirmations, as reported by explorer.
+// This gives a stricter figure for spendable funds than [Reader.Balance], for services
+// which want deposits buried under several blocks before crediting them. If explorer
+// is nil, the Reader's [BlockExplorer] is used, or [ErrNoBlockExplorer] returned if
+// none is configured.
+//
+// Deposits WoS already reports as pending are left unconfirmed. Other on-chain
+// deposits are checked from newest to oldest, moving any with fewer than minConf
+// confirmations from Confirmed to Unconfirmed, and stopping at the first deposit
+// which is confirmed deeply enough, since older deposits are at least as deep.
+func (rdr *Reader) BalanceWithMinConf(ctx context.Context, minConf int, explorer BlockExplorer) (*Balance, error) {
+	if explorer == nil {
+		explorer = rdr.explorer
+	}
+	if explorer == nil {
+		return nil, ErrNoBlockExplorer
+	}
+
+	balance, err := rdr.Balance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("BalanceWithMinConf: %w", err)
+	}
+	payments, err := rdr.ListPayments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("BalanceWithMinConf: %w", err)
+	}
+
+	for i := len(payments) - 1; i >= 0; i-- {
+		payment := payments[i]
+		if payment.Type != PaymentTypeCredit || payment.Currency != PaymentCurrencyBitcoin ||
+			payment.Txid == "" || payment.IsPending() {
+			continue
+		}
+
+		confirmations, err := explorer.TxConfirmations(ctx, payment.Txid)
+		if err != nil {
+			return nil, fmt.Errorf("BalanceWithMinConf: %w", err)
+		} else if confirmations >= minConf {
+			break
+		}
+		moved := min(payment.Amount, balance.Confirmed)
+		balance.Confirmed -= moved
+		balance.Unconfirmed += moved
+	}
+	return balance, nil
+}
@@ -0,0 +1,174 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// mockExplorer is a [BlockExplorer] whose transaction gains a confirmation
+// every time it is queried.
+type mockExplorer struct {
+	polls int
+}
+
+func (explorer *mockExplorer) AddressTxCount(ctx context.Context, address string) (int, error) {
+	return 0, nil
+}
+
+func (explorer *mockExplorer) TxConfirmations(ctx context.Context, txid string) (int, error) {
+	confirmations := explorer.polls
+	explorer.polls++
+	return confirmations, nil
+}
+
+func TestCheckedOnChainAddress(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"btcDepositAddress":"bc1qreused","lightningAddress":"user@walletofsatoshi.com"}`))
+	}))
+
+	address, warnings, err := rdr.CheckedOnChainAddress(context.Background())
+	if err != nil {
+		t.Fatalf("CheckedOnChainAddress failed: %v", err)
+	} else if address != "bc1qreused" || len(warnings) != 0 {
+		t.Fatalf("expected no warnings without an explorer, got %v", warnings)
+	}
+	if _, err := rdr.AddressHasActivity(context.Background(), address); !errors.Is(err, ErrNoBlockExplorer) {
+		t.Fatalf("expected ErrNoBlockExplorer, got %v", err)
+	}
+
+	var queried string
+	rdr.SetBlockExplorer(&EsploraExplorer{
+		BaseURL: "https://mempool.space/api/",
+		HTTPClient: mockClient(func(w http.ResponseWriter, r *http.Request) {
+			queried = r.URL.String()
+			w.Write([]byte(`{"chain_stats":{"tx_count":2},"mempool_stats":{"tx_count":0}}`))
+		}),
+	})
+
+	address, warnings, err = rdr.CheckedOnChainAddress(context.Background())
+	if err != nil {
+		t.Fatalf("CheckedOnChainAddress failed: %v", err)
+	}
+	if queried != "https://mempool.space/api/address/bc1qreused" {
+		t.Fatalf("unexpected explorer query: %s", queried)
+	}
+	if address != "bc1qreused" || len(warnings) != 1 || warnings[0].Code != WarningAddressReused ||
+		!errors.Is(warnings[0], ErrAddressReused) {
+		t.Fatalf("expected ErrAddressReused warning, got %v", warnings)
+	}
+}
+
+func TestEsploraTxConfirmations(t *testing.T) {
+	explorer := &EsploraExplorer{
+		BaseURL: "https://mempool.space/api",
+		HTTPClient: mockClient(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/tx/confirmed/status":
+				w.Write([]byte(`{"confirmed":true,"block_height":800000}`))
+			case "/api/tx/unconfirmed/status":
+				w.Write([]byte(`{"confirmed":false}`))
+			case "/api/blocks/tip/height":
+				w.Write([]byte("800005"))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	}
+
+	for txid, want := range map[string]int{"confirmed": 6, "unconfirmed": 0} {
+		confirmations, err := explorer.TxConfirmations(context.Background(), txid)
+		if err != nil {
+			t.Fatalf("TxConfirmations failed: %v", err)
+		} else if confirmations != want {
+			t.Fatalf("expected %d confirmations for %s, got %d", want, txid, confirmations)
+		}
+	}
+
+	if _, err := explorer.TxConfirmations(context.Background(), "missing"); err == nil {
+		t.Fatalf("expected error for unknown transaction")
+	}
+}
+
+func TestWaitForConfirmations(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {})
+	wallet.SetClock(newFakeClock())
+	payment := &Payment{ID: "abc", Currency: PaymentCurrencyBitcoin, Txid: "deadbeef"}
+
+	if err := wallet.WaitForConfirmations(context.Background(), payment, 3, 0); !errors.Is(err, ErrNoBlockExplorer) {
+		t.Fatalf("expected ErrNoBlockExplorer, got %v", err)
+	}
+
+	explorer := &mockExplorer{}
+	wallet.reader.SetBlockExplorer(explorer)
+	if err := wallet.WaitForConfirmations(context.Background(), payment, 3, 0); err != nil {
+		t.Fatalf("WaitForConfirmations failed: %v", err)
+	}
+	if explorer.polls != 4 {
+		t.Fatalf("expected to stop polling at 3 confirmations, polled %d times", explorer.polls)
+	}
+
+	lightning := &Payment{ID: "ln", Currency: PaymentCurrencyLightning, Txid: "hash"}
+	if err := wallet.WaitForConfirmations(context.Background(), lightning, 1, 0); err == nil {
+		t.Fatalf("expected error waiting on lightning payment")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	wallet.SetClock(nil)
+	if err := wallet.WaitForConfirmations(ctx, payment, 100, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+type confirmationsExplorer map[string]int
+
+func (explorer confirmationsExplorer) AddressTxCount(ctx context.Context, address string) (int, error) {
+	return 0, nil
+}
+
+func (explorer confirmationsExplorer) TxConfirmations(ctx context.Context, txid string) (int, error) {
+	return explorer[txid], nil
+}
+
+func TestBalanceWithMinConf(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":0.006,"btcUnconfirmed":0.0005}`))
+		case "/api/v1/wallet/payment":
+			w.Write([]byte(`[
+				{"id":"old","type":"CREDIT","currency":"BTC","amount":0.001,"status":"PAID","transactionId":"tx0","time":"2024-01-01T00:00:00Z"},
+				{"id":"deep","type":"CREDIT","currency":"BTC","amount":0.002,"status":"PAID","transactionId":"tx1","time":"2024-01-02T00:00:00Z"},
+				{"id":"shallow","type":"CREDIT","currency":"BTC","amount":0.003,"status":"PAID","transactionId":"tx2","time":"2024-01-03T00:00:00Z"},
+				{"id":"ln","type":"CREDIT","currency":"LIGHTNING","amount":0.004,"status":"PAID","transactionId":"hash","time":"2024-01-04T00:00:00Z"},
+				{"id":"pending","type":"CREDIT","currency":"BTC","amount":0.0005,"status":"PENDING","transactionId":"tx3","time":"2024-01-05T00:00:00Z"}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	if _, err := rdr.BalanceWithMinConf(ctx, 6, nil); !errors.Is(err, ErrNoBlockExplorer) {
+		t.Fatalf("expected ErrNoBlockExplorer, got %v", err)
+	}
+
+	explorer := confirmationsExplorer{"tx1": 6, "tx2": 2, "tx3": 0}
+	balance, err := rdr.BalanceWithMinConf(ctx, 6, explorer)
+	if err != nil {
+		t.Fatalf("BalanceWithMinConf failed: %v", err)
+	} else if math.Abs(balance.Confirmed-0.003) > 1e-12 || math.Abs(balance.Unconfirmed-0.0035) > 1e-12 {
+		t.Fatalf("expected 0.003 confirmed and 0.0035 unconfirmed, got %+v", balance)
+	}
+
+	balance, err = rdr.BalanceWithMinConf(ctx, 1, explorer)
+	if err != nil {
+		t.Fatalf("BalanceWithMinConf failed: %v", err)
+	} else if balance.Confirmed != 0.006 || balance.Unconfirmed != 0.0005 {
+		t.Fatalf("expected WoS balance unchanged at 1 confirmation, got %+v", balance)
+	}
+}
@@ -0,0 +1,113 @@
+package wos
+
+import "bytes"
+
+// Feature bits which may be set in the features field of a BOLT11 invoice, as defined by
+// [BOLT9]. Each is the even, mandatory bit of a pair: the odd bit one above it marks the
+// same feature as optional.
+//
+// [BOLT9]: https://github.com/lightning/bolts/blob/master/09-features.md
+const (
+	FeatureVarOnionOptin   = 8
+	FeaturePaymentSecret   = 14
+	FeatureBasicMPP        = 16
+	FeaturePaymentMetadata = 48
+)
+
+// knownFeatures lists the feature pairs understood by this package.
+var knownFeatures = []int{
+	FeatureVarOnionOptin,
+	FeaturePaymentSecret,
+	FeatureBasicMPP,
+	FeaturePaymentMetadata,
+}
+
+// Features holds the feature bits of a BOLT11 invoice, which tell the payer which
+// capabilities the payee supports or requires.
+//
+// The named booleans report whether a feature is set at all, whether as mandatory
+// or optional. Use [Features.Requires] to tell the two apart.
+type Features struct {
+	// VarOnionOptin is set if the payee supports variable-length onions.
+	VarOnionOptin bool
+
+	// PaymentSecret is set if the payee supports payment secrets.
+	PaymentSecret bool
+
+	// BasicMPP is set if the payee accepts multi-part payments.
+	BasicMPP bool
+
+	// PaymentMetadata is set if the payee supports payment metadata.
+	PaymentMetadata bool
+
+	// Raw is the feature bitfield as given by the invoice, as big-endian bytes with
+	// leading zero bytes removed, so that bit 0 is the lowest bit of the last byte.
+	// It is nil if the invoice has no feature bits set.
+	Raw []byte
+}
+
+// decodeFeatures decodes the 5-bit words of an invoice's features field.
+func decodeFeatures(words []byte) Features {
+	raw := make([]byte, (len(words)*5+7)/8)
+	for i := range words {
+		word := words[len(words)-1-i]
+		for j := 0; j < 5; j++ {
+			if word&(1<<j) != 0 {
+				bit := i*5 + j
+				raw[len(raw)-1-bit/8] |= 1 << (bit % 8)
+			}
+		}
+	}
+	raw = bytes.TrimLeft(raw, "\x00")
+	if len(raw) == 0 {
+		raw = nil
+	}
+
+	features := Features{Raw: raw}
+	features.VarOnionOptin = features.Supports(FeatureVarOnionOptin)
+	features.PaymentSecret = features.Supports(FeaturePaymentSecret)
+	features.BasicMPP = features.Supports(FeatureBasicMPP)
+	features.PaymentMetadata = features.Supports(FeaturePaymentMetadata)
+	return features
+}
+
+// IsSet reports whether the given bit is set.
+func (features Features) IsSet(bit int) bool {
+	if bit < 0 || bit/8 >= len(features.Raw) {
+		return false
+	}
+	return features.Raw[len(features.Raw)-1-bit/8]&(1<<(bit%8)) != 0
+}
+
+// Supports reports whether either bit of the pair containing the given feature bit is set,
+// meaning the payee supports the feature.
+func (features Features) Supports(feature int) bool {
+	even := feature &^ 1
+	return features.IsSet(even) || features.IsSet(even+1)
+}
+
+// Requires reports whether the mandatory (even) bit of the pair containing the given
+// feature bit is set, meaning the invoice cannot be paid without the feature.
+func (features Features) Requires(feature int) bool {
+	return features.IsSet(feature &^ 1)
+}
+
+// UnknownRequired returns the mandatory bits which are set but not understood by this
+// package. BOLT11 says payers must not attempt to pay such an invoice, although WoS may
+// understand features this package does not.
+func (features Features) UnknownRequired() []int {
+	var unknown []int
+	for bit := 0; bit < len(features.Raw)*8; bit += 2 {
+		if !features.IsSet(bit) {
+			continue
+		}
+		known := false
+		for _, feature := range knownFeatures {
+			known = known || feature == bit
+		}
+		if !known {
+			unknown = append(unknown, bit)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,82 @@
+package wos
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// feeCache is a read-through cache of fee estimates, keyed by destination and amount.
+type feeCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]feeCacheEntry
+}
+
+type feeCacheEntry struct {
+	estimate FeeEstimate
+	fetched  time.Time
+}
+
+func newFeeCache(ttl time.Duration) *feeCache {
+	return &feeCache{
+		ttl:     ttl,
+		entries: make(map[string]feeCacheEntry),
+	}
+}
+
+// feeCacheKey includes the amount as well as the destination, because the fees for
+// variable-amount destinations such as lightning addresses depend on the amount sent.
+func feeCacheKey(addressOrInvoice string, amount float64) string {
+	return addressOrInvoice + "|" + strconv.FormatFloat(amount, 'f', 11, 64)
+}
+
+// get returns a copy of the cached estimate for key, if one was stored within the TTL.
+func (c *feeCache) get(key string) (*FeeEstimate, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(entry.fetched) >= c.ttl {
+		delete(c.entries, key)
+		return nil, false
+	}
+	estimate := entry.estimate
+	return &estimate, true
+}
+
+func (c *feeCache) put(key string, estimate *FeeEstimate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired entries, so that a long-lived Reader estimating fees for
+	// many distinct destinations does not grow the cache without bound.
+	for k, entry := range c.entries {
+		if time.Since(entry.fetched) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = feeCacheEntry{estimate: *estimate, fetched: time.Now()}
+}
+
+// SetFeeEstimateCacheTTL makes the Reader cache fee estimates for the given TTL, so that
+// repeated calls to [Reader.FeeEstimate] or [Reader.BalanceAndFee] for the same destination
+// and amount within the TTL do not hit the API again. Balances are never cached.
+//
+// A TTL of zero, the default, disables caching and discards any cached estimates.
+func (rdr *Reader) SetFeeEstimateCacheTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		rdr.feeCache = nil
+		return
+	}
+	rdr.feeCache = newFeeCache(ttl)
+}
+
+// SetFeeEstimateCacheTTL caches the wallet's fee estimates. See [Reader.SetFeeEstimateCacheTTL].
+func (wallet *Wallet) SetFeeEstimateCacheTTL(ttl time.Duration) {
+	wallet.reader.SetFeeEstimateCacheTTL(ttl)
+}
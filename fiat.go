@@ -0,0 +1,147 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrSubSatoshiAmount is returned when a fiat amount converts to less than one satoshi.
+var ErrSubSatoshiAmount = errors.New("amount is less than one satoshi")
+
+// RateProvider supplies exchange rates between bitcoin and fiat currencies.
+type RateProvider interface {
+	// BTCPrice returns the price of one bitcoin in the given fiat currency,
+	// identified by its ISO 4217 code such as "USD".
+	BTCPrice(ctx context.Context, currency string) (float64, error)
+}
+
+// FiatPayment is the result of [Wallet.PayFiat].
+type FiatPayment struct {
+	// Payment is the payment which was sent.
+	Payment *Payment
+
+	// FiatCurrency and FiatAmount are the currency and amount the caller asked to pay.
+	FiatCurrency string
+	FiatAmount   float64
+
+	// Rate is the price of one bitcoin in FiatCurrency used for the conversion.
+	Rate float64
+
+	// Amount is the BTC amount which was sent, rounded to the nearest satoshi.
+	Amount float64
+}
+
+// fiatToBTC converts a fiat amount to BTC at the given rate, rounding
+// to the nearest satoshi.
+func fiatToBTC(fiatAmount, rate float64) (float64, error) {
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return 0, fmt.Errorf("invalid exchange rate: %f", rate)
+	}
+
+	sats := math.Round(fiatAmount / rate * 100_000_000)
+	if sats < 1 {
+		return 0, ErrSubSatoshiAmount
+	}
+	return sats / 100_000_000, nil
+}
+
+// PayFiat sends a payment worth fiatAmount in the given fiat currency to a destination,
+// using the current exchange rate from provider. The destination may be anything accepted
+// by [Wallet.Pay].
+//
+// The BTC amount is rounded to the nearest satoshi. Returns an error wrapping
+// [ErrSubSatoshiAmount] if the converted amount rounds to zero satoshis.
+func (wallet *Wallet) PayFiat(
+	ctx context.Context,
+	provider RateProvider,
+	destination string,
+	fiat string,
+	fiatAmount float64,
+	description string,
+) (*FiatPayment, error) {
+	rate, err := provider.BTCPrice(ctx, fiat)
+	if err != nil {
+		return nil, fmt.Errorf("PayFiat: failed to fetch %s exchange rate: %w", fiat, err)
+	}
+
+	amount, err := fiatToBTC(fiatAmount, rate)
+	if err != nil {
+		return nil, fmt.Errorf("PayFiat: %w", err)
+	}
+
+	payment, err := wallet.Pay(ctx, destination, amount, description)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FiatPayment{
+		Payment:      payment,
+		FiatCurrency: fiat,
+		FiatAmount:   fiatAmount,
+		Rate:         rate,
+		Amount:       amount,
+	}
+	return result, nil
+}
+
+// FiatInvoice is an invoice priced in a fiat currency, as returned by [Wallet.NewFiatInvoice].
+// It records the exchange rate quoted when the invoice was created, so that reconciliation
+// can use the rate the payer was actually charged, rather than the rate at the time of
+// reconciliation. It can be marshaled to JSON, or persisted with [FiatInvoiceStore.SaveFiatInvoice].
+type FiatInvoice struct {
+	// Invoice is the invoice which was created.
+	Invoice *Invoice `json:"invoice"`
+
+	// FiatCurrency and FiatAmount are the currency and amount the invoice is priced at.
+	FiatCurrency string  `json:"fiatCurrency"`
+	FiatAmount   float64 `json:"fiatAmount"`
+
+	// Rate is the price of one bitcoin in FiatCurrency used for the conversion.
+	Rate float64 `json:"rate"`
+
+	// QuotedAt is the time at which the rate was fetched.
+	QuotedAt time.Time `json:"quotedAt"`
+}
+
+// NewFiatInvoice creates an invoice worth fiatAmount in the given fiat currency, using
+// the current exchange rate from provider, and records the rate for later reconciliation.
+// The BTC amount is rounded to the nearest satoshi. Returns an error wrapping
+// [ErrSubSatoshiAmount] if the converted amount rounds to zero satoshis.
+func (wallet *Wallet) NewFiatInvoice(
+	ctx context.Context,
+	provider RateProvider,
+	fiat string,
+	fiatAmount float64,
+	description string,
+) (*FiatInvoice, error) {
+	rate, err := provider.BTCPrice(ctx, fiat)
+	if err != nil {
+		return nil, fmt.Errorf("NewFiatInvoice: failed to fetch %s exchange rate: %w", fiat, err)
+	}
+	quotedAt := clockOrDefault(wallet.clock).Now()
+
+	amount, err := fiatToBTC(fiatAmount, rate)
+	if err != nil {
+		return nil, fmt.Errorf("NewFiatInvoice: %w", err)
+	}
+
+	invoice, err := wallet.NewInvoice(ctx, &InvoiceOptions{
+		Amount:      amount,
+		Description: description,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FiatInvoice{
+		Invoice:      invoice,
+		FiatCurrency: fiat,
+		FiatAmount:   fiatAmount,
+		Rate:         rate,
+		QuotedAt:     quotedAt,
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type staticRates map[string]float64
+
+func (rates staticRates) BTCPrice(ctx context.Context, currency string) (float64, error) {
+	rate, ok := rates[currency]
+	if !ok {
+		return 0, errors.New("unknown currency")
+	}
+	return rate, nil
+}
+
+func TestPayFiatToLightningAddress(t *testing.T) {
+	var sentMsat uint64
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/lnurl":
+			w.Write([]byte(`{"callback":"https://getalby.com/cb","minSendable":1000,"maxSendable":100000000000}`))
+		case "/api/v1/wallet/lnPay":
+			var body struct {
+				Amount uint64 `json:"amount"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			sentMsat = body.Amount
+			w.Write([]byte(`{"id":"p1","status":"PAID","currency":"LIGHTNING"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	rates := staticRates{"USD": 60_000}
+	result, err := wallet.PayFiat(context.Background(), rates, "someone@getalby.com", "USD", 5, "coffee")
+	if err != nil {
+		t.Fatalf("PayFiat failed: %v", err)
+	}
+
+	// $5 at $60,000/BTC is 8333.33 sats, rounded to 8333.
+	if result.Amount != 0.00008333 {
+		t.Fatalf("expected 0.00008333 BTC, got %.8f", result.Amount)
+	} else if result.Rate != 60_000 {
+		t.Fatalf("expected rate 60000, got %f", result.Rate)
+	} else if sentMsat != 8_333_000 {
+		t.Fatalf("expected 8333000 msat to be sent, got %d", sentMsat)
+	} else if result.Payment.ID != "p1" {
+		t.Fatalf("unexpected payment: %+v", result.Payment)
+	}
+
+	_, err = wallet.PayFiat(context.Background(), rates, "someone@getalby.com", "USD", 0.0001, "")
+	if !errors.Is(err, ErrSubSatoshiAmount) {
+		t.Fatalf("expected ErrSubSatoshiAmount, got %v", err)
+	}
+}
+
+func TestNewFiatInvoiceStoresQuote(t *testing.T) {
+	var requested createInvoiceRequest
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requested)
+		w.Write([]byte(`{"id":"inv1","invoice":"lnbc1","btcAmount":0.0001}`))
+	})
+	wallet.SetClock(newFakeClock())
+	ctx := context.Background()
+
+	rates := staticRates{"USD": 50_000}
+	quoted, err := wallet.NewFiatInvoice(ctx, rates, "USD", 5, "coffee")
+	if err != nil {
+		t.Fatalf("NewFiatInvoice failed: %v", err)
+	} else if requested.Amount != 0.0001 {
+		t.Fatalf("expected invoice for 0.0001 BTC, requested %.8f", requested.Amount)
+	} else if quoted.Rate != 50_000 || !quoted.QuotedAt.Equal(newFakeClock().Now()) {
+		t.Fatalf("unexpected quote: %+v", quoted)
+	}
+
+	store := &MemoryStore{}
+	if err := store.SaveFiatInvoice(ctx, quoted.Invoice.ID, *quoted); err != nil {
+		t.Fatalf("SaveFiatInvoice failed: %v", err)
+	}
+
+	// Reconciling later uses the quoted rate, not the current one.
+	rates["USD"] = 70_000
+	loaded, err := store.LoadFiatInvoice(ctx, "inv1")
+	if err != nil {
+		t.Fatalf("LoadFiatInvoice failed: %v", err)
+	} else if loaded.Rate != 50_000 || loaded.FiatAmount != 5 || loaded.Invoice.Bolt11 != "lnbc1" {
+		t.Fatalf("unexpected stored quote: %+v", loaded)
+	}
+	if current, _ := rates.BTCPrice(ctx, "USD"); current == loaded.Rate {
+		t.Fatalf("expected stored rate to differ from the current rate")
+	}
+}
@@ -0,0 +1,66 @@
+package wos
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// BalanceUnit is the unit in which [Balance.Format] renders an amount.
+type BalanceUnit int
+
+const (
+	// BalanceUnitBTC renders amounts in bitcoin, such as "0.00012000 BTC".
+	BalanceUnitBTC BalanceUnit = iota
+
+	// BalanceUnitSats renders amounts in whole satoshis, such as "12000 sats".
+	BalanceUnitSats
+)
+
+// BalanceFormatOptions customizes how [Balance.Format] renders a balance.
+// The zero value renders the confirmed balance in BTC with all 8 decimals.
+type BalanceFormatOptions struct {
+	// Unit is the unit to render the balance in. Defaults to BTC.
+	Unit BalanceUnit
+
+	// TrimZeros removes trailing zero decimals from BTC amounts, rendering
+	// "0.00012 BTC" rather than "0.00012000 BTC". Ignored for sats.
+	TrimZeros bool
+
+	// IncludeUnconfirmed renders [Balance.Total] rather than the confirmed balance.
+	IncludeUnconfirmed bool
+
+	// FiatRate and FiatCurrency, if both set, append the balance's value in fiat,
+	// given the price of one bitcoin, such as "0.00012000 BTC (4.80 USD)".
+	FiatRate     float64
+	FiatCurrency string
+}
+
+// Format renders the balance as a string for display, according to opts.
+func (b Balance) Format(opts BalanceFormatOptions) string {
+	amount := b.Confirmed
+	if opts.IncludeUnconfirmed {
+		amount = b.Total()
+	}
+
+	buf := make([]byte, 0, 48)
+	if opts.Unit == BalanceUnitSats {
+		buf = strconv.AppendInt(buf, toSats(amount), 10)
+		buf = append(buf, " sats"...)
+	} else {
+		buf = strconv.AppendFloat(buf, amount, 'f', 8, 64)
+		if opts.TrimZeros {
+			buf = bytes.TrimRight(buf, "0")
+			buf = bytes.TrimSuffix(buf, []byte("."))
+		}
+		buf = append(buf, " BTC"...)
+	}
+
+	if opts.FiatRate > 0 && opts.FiatCurrency != "" {
+		buf = append(buf, " ("...)
+		buf = strconv.AppendFloat(buf, amount*opts.FiatRate, 'f', 2, 64)
+		buf = append(buf, ' ')
+		buf = append(buf, opts.FiatCurrency...)
+		buf = append(buf, ')')
+	}
+	return string(buf)
+}
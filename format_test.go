@@ -0,0 +1,30 @@
+package wos
+
+import "testing"
+
+func TestBalanceFormat(t *testing.T) {
+	balance := Balance{Confirmed: 0.00012, Unconfirmed: 0.00003}
+
+	tests := []struct {
+		opts BalanceFormatOptions
+		want string
+	}{
+		{BalanceFormatOptions{}, "0.00012000 BTC"},
+		{BalanceFormatOptions{TrimZeros: true}, "0.00012 BTC"},
+		{BalanceFormatOptions{Unit: BalanceUnitSats}, "12000 sats"},
+		{BalanceFormatOptions{Unit: BalanceUnitSats, IncludeUnconfirmed: true}, "15000 sats"},
+		{BalanceFormatOptions{FiatRate: 40_000, FiatCurrency: "USD"}, "0.00012000 BTC (4.80 USD)"},
+	}
+	for _, test := range tests {
+		if got := balance.Format(test.opts); got != test.want {
+			t.Errorf("Format(%+v): expected %q, got %q", test.opts, test.want, got)
+		}
+	}
+
+	if got := (Balance{Confirmed: 1}).Format(BalanceFormatOptions{TrimZeros: true}); got != "1 BTC" {
+		t.Errorf("expected whole bitcoin to trim to %q, got %q", "1 BTC", got)
+	}
+	if got := (Balance{}).Format(BalanceFormatOptions{TrimZeros: true}); got != "0 BTC" {
+		t.Errorf("expected zero balance to trim to %q, got %q", "0 BTC", got)
+	}
+}
@@ -0,0 +1,122 @@
+package wos
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortKey selects the field used to order payments in [SortPayments].
+type SortKey int
+
+const (
+	SortByTime   SortKey = iota // Order by payment time, oldest first.
+	SortByAmount                // Order by payment amount, smallest first.
+	SortByType                  // Order by payment type, credits before debits.
+)
+
+// SortPayments sorts payments in place, in ascending order of the given key.
+//
+// Payments which compare equal by the key are ordered by their ID, so the
+// result is deterministic regardless of the order in which the payments were
+// originally returned by the WoS API.
+func SortPayments(payments []Payment, by SortKey) {
+	sort.SliceStable(payments, func(i, j int) bool {
+		a, b := payments[i], payments[j]
+
+		switch by {
+		case SortByTime:
+			if !a.Time.Equal(b.Time) {
+				return a.Time.Before(b.Time)
+			}
+		case SortByAmount:
+			if a.Amount != b.Amount {
+				return a.Amount < b.Amount
+			}
+		case SortByType:
+			if a.Type != b.Type {
+				return a.Type < b.Type
+			}
+		}
+
+		return a.ID < b.ID
+	})
+}
+
+// IsPending reports whether the payment has not yet completed, such as an on-chain
+// payment which is still confirming. Any status WoS reports which includes the word
+// PENDING is treated as pending, in case WoS adds more specific pending statuses.
+func (p Payment) IsPending() bool {
+	return strings.Contains(string(p.Status), string(PaymentStatusPending))
+}
+
+// InvoiceDescription decodes the description of the lightning invoice a payment was made to,
+// which may differ from the sender's memo stored in [Payment.Description]. Returns false
+// if the payment's address is not a BOLT11 invoice, or if the invoice only commits to a
+// description hash.
+func (p Payment) InvoiceDescription() (string, bool) {
+	if p.Currency != PaymentCurrencyLightning {
+		return "", false
+	}
+	decoded, err := DecodeInvoice(p.Address)
+	if err != nil || decoded.DescriptionHash != nil {
+		return "", false
+	}
+	return decoded.Description, true
+}
+
+// Invoice returns the full BOLT11 invoice a lightning payment was made to, so that it
+// can be displayed again, such as in a QR code. For debits this is the invoice which was
+// paid, and for credits it is the wallet's own invoice. Returns false for on-chain
+// payments, and for payments made to a lightning address, for which WoS does not
+// record the invoice.
+func (p Payment) Invoice() (string, bool) {
+	if p.Currency != PaymentCurrencyLightning {
+		return "", false
+	}
+	invoice := strings.ToLower(strings.TrimSpace(p.Address))
+	if _, err := DecodeInvoice(invoice); err != nil {
+		return "", false
+	}
+	return invoice, true
+}
+
+// WoSLightningDomain is the domain of the lightning addresses issued by Wallet of Satoshi.
+const WoSLightningDomain = "walletofsatoshi.com"
+
+// IsInternal reports whether the payment is likely a transfer between two WoS wallets.
+// Such transfers are settled internally by WoS, and so are free and instant.
+//
+// The WoS API does not flag internal transfers explicitly, so this is a heuristic:
+// a lightning payment is considered internal if its address is a lightning address
+// on the [WoSLightningDomain]. Transfers between WoS wallets made by paying a BOLT11
+// invoice cannot be detected this way, and will be reported as external.
+func (p Payment) IsInternal() bool {
+	if p.Currency != PaymentCurrencyLightning {
+		return false
+	}
+	lnAddress, err := ParseLightningAddress(strings.ToLower(p.Address))
+	return err == nil && lnAddress.Domain == WoSLightningDomain
+}
+
+// FilterPayments returns the payments for which keep returns true, in their original order.
+func FilterPayments(payments []Payment, keep func(Payment) bool) []Payment {
+	var filtered []Payment
+	for _, p := range payments {
+		if keep(p) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// InternalPayments returns the payments which are likely transfers between WoS wallets.
+// See [Payment.IsInternal].
+func InternalPayments(payments []Payment) []Payment {
+	return FilterPayments(payments, Payment.IsInternal)
+}
+
+// ExternalPayments returns the payments which are not likely transfers between WoS wallets.
+// See [Payment.IsInternal].
+func ExternalPayments(payments []Payment) []Payment {
+	return FilterPayments(payments, func(p Payment) bool { return !p.IsInternal() })
+}
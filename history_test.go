@@ -0,0 +1,119 @@
+package wos
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortPayments(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+
+	payments := []Payment{
+		{ID: "d", Time: t1, Amount: 0.1, Type: PaymentTypeDebit},
+		{ID: "c", Time: t0, Amount: 0.3, Type: PaymentTypeCredit},
+		{ID: "a", Time: t1, Amount: 0.2, Type: PaymentTypeCredit},
+		{ID: "b", Time: t0, Amount: 0.1, Type: PaymentTypeDebit},
+	}
+
+	expectOrder := func(want ...string) {
+		t.Helper()
+		for i, id := range want {
+			if payments[i].ID != id {
+				t.Fatalf("expected order %v, got payment %q at index %d", want, payments[i].ID, i)
+			}
+		}
+	}
+
+	SortPayments(payments, SortByTime)
+	expectOrder("b", "c", "a", "d")
+
+	// Re-sorting a shuffled copy must produce the same order.
+	payments[0], payments[3] = payments[3], payments[0]
+	SortPayments(payments, SortByTime)
+	expectOrder("b", "c", "a", "d")
+
+	SortPayments(payments, SortByAmount)
+	expectOrder("b", "d", "a", "c")
+
+	SortPayments(payments, SortByType)
+	expectOrder("a", "c", "b", "d")
+}
+
+func TestPaymentIsInternal(t *testing.T) {
+	payments := []Payment{
+		{ID: "internal", Currency: PaymentCurrencyLightning, Address: "SmallWillow98@walletofsatoshi.com"},
+		{ID: "external", Currency: PaymentCurrencyLightning, Address: "someone@getalby.com"},
+		{ID: "invoice", Currency: PaymentCurrencyLightning, Address: testInvoiceCoffee},
+		{ID: "onchain", Currency: PaymentCurrencyBitcoin, Address: "bc1qexample"},
+	}
+
+	internal := InternalPayments(payments)
+	if len(internal) != 1 || internal[0].ID != "internal" {
+		t.Fatalf("expected only the WoS lightning address payment to be internal, got %+v", internal)
+	}
+
+	external := ExternalPayments(payments)
+	if len(external) != 3 || external[0].ID != "external" {
+		t.Fatalf("unexpected external payments: %+v", external)
+	}
+}
+
+func TestPaymentInvoiceDescription(t *testing.T) {
+	payment := Payment{
+		Address:     testInvoiceCoffee,
+		Currency:    PaymentCurrencyLightning,
+		Description: "my private memo",
+	}
+	if desc, ok := payment.InvoiceDescription(); !ok || desc != "1 cup coffee" {
+		t.Fatalf("expected invoice description %q, got %q (ok=%v)", "1 cup coffee", desc, ok)
+	}
+	if payment.Description != "my private memo" {
+		t.Fatalf("sender memo was not preserved")
+	}
+
+	if _, ok := (Payment{Address: testInvoiceHashedDescription, Currency: PaymentCurrencyLightning}).InvoiceDescription(); ok {
+		t.Fatalf("expected no description for hashed-description invoice")
+	}
+	if _, ok := (Payment{Address: "bob@getalby.com", Currency: PaymentCurrencyLightning}).InvoiceDescription(); ok {
+		t.Fatalf("expected no description for lightning address")
+	}
+}
+
+func TestPaymentInvoice(t *testing.T) {
+	debit := Payment{
+		Address:  strings.ToUpper(testInvoiceCoffee),
+		Currency: PaymentCurrencyLightning,
+		Type:     PaymentTypeDebit,
+	}
+	if invoice, ok := debit.Invoice(); !ok || invoice != testInvoiceCoffee {
+		t.Fatalf("expected invoice %q, got %q (ok=%v)", testInvoiceCoffee, invoice, ok)
+	}
+
+	if _, ok := (Payment{Address: "bob@getalby.com", Currency: PaymentCurrencyLightning}).Invoice(); ok {
+		t.Fatalf("expected no invoice for lightning address")
+	}
+	if _, ok := (Payment{Address: "bc1qexample", Currency: PaymentCurrencyBitcoin}).Invoice(); ok {
+		t.Fatalf("expected no invoice for on-chain payment")
+	}
+}
+
+func TestPaymentFiatFields(t *testing.T) {
+	var payments []Payment
+	err := json.Unmarshal([]byte(`[
+		{"id":"a","amount":0.001,"fiatAmount":65.43,"fiatCurrency":"USD","fiatRate":65430},
+		{"id":"b","amount":0.002}
+	]`), &payments)
+	if err != nil {
+		t.Fatalf("failed to decode payments: %v", err)
+	}
+
+	if p := payments[0]; p.FiatAmount != 65.43 || p.FiatCurrency != "USD" || p.FiatRate != 65430 {
+		t.Fatalf("unexpected fiat fields: %+v", p)
+	}
+	if p := payments[1]; p.FiatAmount != 0 || p.FiatCurrency != "" || p.FiatRate != 0 {
+		t.Fatalf("expected absent fiat fields to be zero: %+v", p)
+	}
+}
@@ -0,0 +1,134 @@
+package wos
+
+import (
+	"bytes"
+	"container/list"
+	"sync"
+)
+
+// DefaultInvoiceCacheSize is the number of decoded invoices cached by [DecodeInvoice]
+// unless changed with [SetInvoiceCacheSize].
+const DefaultInvoiceCacheSize = 64
+
+// decodedInvoices caches invoices decoded by DecodeInvoice, so that an invoice checked
+// several times on its way to being paid, such as for its amount, expiry and fees,
+// only has its bech32 encoding and signature decoded once.
+var decodedInvoices = newInvoiceCache(DefaultInvoiceCacheSize)
+
+// SetInvoiceCacheSize sets how many decoded invoices [DecodeInvoice] caches, evicting
+// the least recently used invoice once the cache is full. A size of zero or less
+// disables the cache. The cache is shared by the whole process, and starts with
+// [DefaultInvoiceCacheSize] entries.
+func SetInvoiceCacheSize(size int) {
+	decodedInvoices.resize(size)
+}
+
+// invoiceCache is a least-recently-used cache of decoded invoices, keyed by the
+// invoice string. It is safe for concurrent use.
+type invoiceCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Front is most recently used.
+	entries map[string]*list.Element
+
+	hits uint64
+}
+
+type invoiceCacheEntry struct {
+	invoice string
+	hrp     string
+	decoded *DecodedInvoice
+}
+
+func newInvoiceCache(size int) *invoiceCache {
+	return &invoiceCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *invoiceCache) lookup(invoice string) (*invoiceCacheEntry, bool) {
+	elem, ok := c.entries[invoice]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	c.hits++
+	return elem.Value.(*invoiceCacheEntry), true
+}
+
+// get returns a copy of the cached decoding of invoice, so that callers may
+// modify it freely.
+func (c *invoiceCache) get(invoice string) (*DecodedInvoice, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.lookup(invoice)
+	if !ok {
+		return nil, false
+	}
+	return entry.decoded.clone(), true
+}
+
+// hrp returns the human-readable part of invoice, if it is cached.
+func (c *invoiceCache) hrp(invoice string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.lookup(invoice)
+	if !ok {
+		return "", false
+	}
+	return entry.hrp, true
+}
+
+func (c *invoiceCache) put(invoice, hrp string, decoded *DecodedInvoice) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size <= 0 {
+		return
+	}
+	if elem, ok := c.entries[invoice]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	entry := &invoiceCacheEntry{invoice: invoice, hrp: hrp, decoded: decoded.clone()}
+	c.entries[invoice] = c.order.PushFront(entry)
+	c.evict()
+}
+
+func (c *invoiceCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = size
+	c.evict()
+}
+
+// evict drops the least recently used entries until the cache fits its size.
+func (c *invoiceCache) evict() {
+	for c.order.Len() > max(c.size, 0) {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*invoiceCacheEntry).invoice)
+	}
+}
+
+// clone returns a deep copy of the decoded invoice.
+func (decoded *DecodedInvoice) clone() *DecodedInvoice {
+	cloned := *decoded
+	cloned.PaymentHash = bytes.Clone(decoded.PaymentHash)
+	cloned.DescriptionHash = bytes.Clone(decoded.DescriptionHash)
+	cloned.Payee = bytes.Clone(decoded.Payee)
+	cloned.Features.Raw = bytes.Clone(decoded.Features.Raw)
+	if decoded.RouteHints != nil {
+		cloned.RouteHints = make([]RouteHint, len(decoded.RouteHints))
+		for i, hint := range decoded.RouteHints {
+			cloned.RouteHints[i] = make(RouteHint, len(hint))
+			for j, hop := range hint {
+				hop.PubKey = bytes.Clone(hop.PubKey)
+				cloned.RouteHints[i][j] = hop
+			}
+		}
+	}
+	return &cloned
+}
@@ -0,0 +1,111 @@
+package wos
+
+import "sync"
+
+// LedgerEntry is local bookkeeping information attached to a payment by a [PaymentLedger].
+type LedgerEntry struct {
+	// Category groups payments for bookkeeping, such as "rent" or "coffee".
+	Category string `json:"category,omitempty"`
+
+	// Note is free-form text about the payment.
+	Note string `json:"note,omitempty"`
+}
+
+// LedgerStore persists [LedgerEntry] values keyed by [Payment.ID], on behalf of a
+// [PaymentLedger]. Implementations must be safe for concurrent use.
+type LedgerStore interface {
+	// Get returns the entry for a payment ID, and whether one exists.
+	Get(paymentID string) (LedgerEntry, bool, error)
+
+	// Put stores the entry for a payment ID, replacing any existing entry.
+	Put(paymentID string, entry LedgerEntry) error
+
+	// Delete removes the entry for a payment ID, if any.
+	Delete(paymentID string) error
+}
+
+// MemoryLedgerStore is a [LedgerStore] which keeps entries in memory.
+type MemoryLedgerStore struct {
+	mu      sync.RWMutex
+	entries map[string]LedgerEntry
+}
+
+// NewMemoryLedgerStore returns an empty in-memory [LedgerStore].
+func NewMemoryLedgerStore() *MemoryLedgerStore {
+	return &MemoryLedgerStore{entries: make(map[string]LedgerEntry)}
+}
+
+// Get implements [LedgerStore].
+func (store *MemoryLedgerStore) Get(paymentID string) (LedgerEntry, bool, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	entry, ok := store.entries[paymentID]
+	return entry, ok, nil
+}
+
+// Put implements [LedgerStore].
+func (store *MemoryLedgerStore) Put(paymentID string, entry LedgerEntry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.entries[paymentID] = entry
+	return nil
+}
+
+// Delete implements [LedgerStore].
+func (store *MemoryLedgerStore) Delete(paymentID string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.entries, paymentID)
+	return nil
+}
+
+// PaymentLedger associates local categories and notes with payments, which WoS
+// itself has no way to store.
+type PaymentLedger struct {
+	store LedgerStore
+}
+
+// NewPaymentLedger returns a PaymentLedger backed by the given store.
+// Uses a new [MemoryLedgerStore] if store is nil.
+func NewPaymentLedger(store LedgerStore) *PaymentLedger {
+	if store == nil {
+		store = NewMemoryLedgerStore()
+	}
+	return &PaymentLedger{store: store}
+}
+
+// Tag sets the category and note of the payment with the given ID.
+func (ledger *PaymentLedger) Tag(paymentID, category, note string) error {
+	return ledger.store.Put(paymentID, LedgerEntry{Category: category, Note: note})
+}
+
+// Untag removes any category and note from the payment with the given ID.
+func (ledger *PaymentLedger) Untag(paymentID string) error {
+	return ledger.store.Delete(paymentID)
+}
+
+// Entry returns the ledger entry for the payment with the given ID, and whether one exists.
+func (ledger *PaymentLedger) Entry(paymentID string) (LedgerEntry, bool, error) {
+	return ledger.store.Get(paymentID)
+}
+
+// CategorizedPayment is a [Payment] joined with its [LedgerEntry], if any.
+type CategorizedPayment struct {
+	Payment
+	LedgerEntry
+}
+
+// Join attaches ledger entries to a list of payments, such as one returned by
+// [Reader.ListPayments], for export. Payments without an entry have an empty
+// LedgerEntry. The order of payments is preserved.
+func (ledger *PaymentLedger) Join(payments []Payment) ([]CategorizedPayment, error) {
+	joined := make([]CategorizedPayment, len(payments))
+	for i, payment := range payments {
+		entry, _, err := ledger.store.Get(payment.ID)
+		if err != nil {
+			return nil, err
+		}
+		joined[i] = CategorizedPayment{payment, entry}
+	}
+	return joined, nil
+}
@@ -0,0 +1,33 @@
+package wos
+
+import "testing"
+
+func TestPaymentLedgerJoin(t *testing.T) {
+	ledger := NewPaymentLedger(nil)
+	if err := ledger.Tag("p1", "rent", "October"); err != nil {
+		t.Fatalf("failed to tag payment: %v", err)
+	}
+	ledger.Tag("p3", "coffee", "")
+	ledger.Tag("p2", "groceries", "")
+	ledger.Untag("p2")
+
+	payments := []Payment{{ID: "p1", Amount: 0.01}, {ID: "p2"}, {ID: "p3"}}
+	joined, err := ledger.Join(payments)
+	if err != nil {
+		t.Fatalf("failed to join ledger: %v", err)
+	}
+
+	expected := []LedgerEntry{{"rent", "October"}, {}, {"coffee", ""}}
+	if len(joined) != len(expected) {
+		t.Fatalf("expected %d payments, got %d", len(expected), len(joined))
+	}
+	for i, cp := range joined {
+		if cp.ID != payments[i].ID || cp.LedgerEntry != expected[i] {
+			t.Errorf("payment %d: expected %s with %+v, got %s with %+v",
+				i, payments[i].ID, expected[i], cp.ID, cp.LedgerEntry)
+		}
+	}
+	if joined[0].Amount != 0.01 {
+		t.Errorf("payment fields not preserved")
+	}
+}
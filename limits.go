@@ -0,0 +1,117 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// DefaultMinInvoiceAmount is the minimum invoice amount assumed when WoS does not
+// report one: a single satoshi, the smallest amount WoS displays. This is deliberately
+// permissive, so that local validation never rejects an invoice WoS would accept.
+const DefaultMinInvoiceAmount = 0.00000001
+
+// DefaultMinOnChainAmount is the minimum on-chain send amount assumed when WoS does not
+// report one: 294 satoshis, the dust limit of a segwit output, below which bitcoin nodes
+// will not relay a transaction. Like [DefaultMinInvoiceAmount] it is deliberately
+// permissive, as the minimum WoS enforces may be higher and vary by region.
+const DefaultMinOnChainAmount = 0.00000294
+
+// ErrAmountTooSmall is returned when sending less than the minimum amount WoS accepts.
+var ErrAmountTooSmall = errors.New("amount below minimum")
+
+// limitsEndpoint is an undocumented endpoint which may report the wallet's limits.
+const limitsEndpoint = "/api/v1/wallet/limits"
+
+// Limits describes amount limits WoS enforces on a wallet, as returned by [Reader.Limits].
+type Limits struct {
+	// MinInvoiceAmount is the smallest BTC amount WoS will create an invoice for.
+	MinInvoiceAmount float64 `json:"minInvoiceAmount"`
+
+	// MinOnChainAmount is the smallest BTC amount WoS will send on-chain.
+	MinOnChainAmount float64 `json:"minOnChainAmount"`
+}
+
+// Limits returns the amount limits WoS enforces on the wallet. WoS does not document
+// its limits, so they are fetched from an undocumented endpoint if it exists, and
+// otherwise default to [DefaultMinInvoiceAmount]. Fetched limits are cached for the
+// lifetime of the Reader; if they cannot be fetched, the defaults are returned along
+// with the error, and fetching is retried on the next call.
+//
+// Once fetched, the limits are used by [Wallet.NewInvoice] and [Wallet.PayOnChain] to
+// reject amounts WoS would refuse without making an API call. Until then they check
+// against the defaults.
+func (rdr *Reader) Limits(ctx context.Context) (*Limits, error) {
+	rdr.limitsMu.Lock()
+	defer rdr.limitsMu.Unlock()
+	if rdr.limits != nil {
+		limits := *rdr.limits
+		return &limits, nil
+	}
+
+	limits := Limits{
+		MinInvoiceAmount: DefaultMinInvoiceAmount,
+		MinOnChainAmount: DefaultMinOnChainAmount,
+	}
+
+	respData, err := rdr.GetRequest(ctx, limitsEndpoint)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		// No limits endpoint: WoS does not report its limits, so use the defaults for good.
+		rdr.limits = &limits
+		result := limits
+		return &result, nil
+	} else if err != nil {
+		return &limits, fmt.Errorf("Limits: %w", err)
+	}
+
+	var reported Limits
+	if err := json.Unmarshal(respData, &reported); err != nil {
+		return &limits, fmt.Errorf("invalid Limits response: %w", err)
+	}
+	if reported.MinInvoiceAmount > 0 {
+		limits.MinInvoiceAmount = reported.MinInvoiceAmount
+	}
+	if reported.MinOnChainAmount > 0 {
+		limits.MinOnChainAmount = reported.MinOnChainAmount
+	}
+
+	rdr.limits = &limits
+	result := limits
+	return &result, nil
+}
+
+// minInvoiceAmount returns the minimum invoice amount from the limits cached by
+// [Reader.Limits], or [DefaultMinInvoiceAmount] if they have not been fetched.
+func (rdr *Reader) minInvoiceAmount() float64 {
+	rdr.limitsMu.Lock()
+	defer rdr.limitsMu.Unlock()
+	if rdr.limits == nil {
+		return DefaultMinInvoiceAmount
+	}
+	return rdr.limits.MinInvoiceAmount
+}
+
+// OnChainMinimum returns the smallest BTC amount WoS will send on-chain from the wallet,
+// which may vary by region. It is read from [Reader.Limits], and so defaults to
+// [DefaultMinOnChainAmount] if WoS does not report it.
+func (rdr *Reader) OnChainMinimum(ctx context.Context) (float64, error) {
+	limits, err := rdr.Limits(ctx)
+	if err != nil {
+		return limits.MinOnChainAmount, fmt.Errorf("OnChainMinimum: %w", err)
+	}
+	return limits.MinOnChainAmount, nil
+}
+
+// minOnChainAmount returns the minimum on-chain send amount from the limits cached by
+// [Reader.Limits], or [DefaultMinOnChainAmount] if they have not been fetched.
+func (rdr *Reader) minOnChainAmount() float64 {
+	rdr.limitsMu.Lock()
+	defer rdr.limitsMu.Unlock()
+	if rdr.limits == nil {
+		return DefaultMinOnChainAmount
+	}
+	return rdr.limits.MinOnChainAmount
+}
@@ -2,6 +2,7 @@ package wos
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -9,6 +10,11 @@ import (
 // ErrInvalidLightningAddress is returned when parsing an invalid lightning address.
 var ErrInvalidLightningAddress = errors.New("invalid lightning address")
 
+// ErrNotALightningAddress is returned when paying a lightning address whose domain does
+// not serve LNURL-pay requests. The address is usually a regular email address pasted
+// by mistake.
+var ErrNotALightningAddress = errors.New("address is not a lightning address")
+
 // This misses some edgecases. Might need to adjust in future.
 // https://stackoverflow.com/a/67686133
 var emailRegex = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
@@ -21,11 +27,30 @@ type LightningAddress struct {
 	Domain   string
 }
 
-// String returns the user@domain.tld format of the address.
+// String returns the user@domain.tld format of the address, or an empty
+// string if the address is zero.
 func (a LightningAddress) String() string {
+	if a.IsZero() {
+		return ""
+	}
 	return a.Username + "@" + a.Domain
 }
 
+// IsZero returns true if a is the zero LightningAddress, such as the address
+// of a brand new wallet which WoS has not yet provisioned one for.
+func (a LightningAddress) IsZero() bool {
+	return a == LightningAddress{}
+}
+
+// parseProvisionedLightningAddress is like [ParseLightningAddress], but returns the zero
+// LightningAddress for an empty address, which WoS has not yet provisioned.
+func parseProvisionedLightningAddress(lnAddress string) (LightningAddress, error) {
+	if lnAddress == "" {
+		return LightningAddress{}, nil
+	}
+	return ParseLightningAddress(lnAddress)
+}
+
 // LNURL returns the HTTPS URL used for LNURL payRequest, as per LUD-16.
 //
 // https://github.com/lnurl/luds/blob/luds/16.md
@@ -33,6 +58,43 @@ func (a LightningAddress) LNURL() string {
 	return "https://" + a.Domain + "/.well-known/lnurlp/" + a.Username
 }
 
+// LNURLBech32 returns the LNURL-pay URL of the address in the bech32 `lnurl1...` form,
+// for wallets which do not support lightning addresses directly. This is the inverse
+// of [ParseLNURL].
+func (a LightningAddress) LNURLBech32() (string, error) {
+	return EncodeLNURL(a.LNURL())
+}
+
+// QRContent returns the string to encode in a QR code so that wallets can scan
+// it to pay the address. This is the upper-case bech32 LNURL with a `LIGHTNING:`
+// URI scheme, which QR encoders can pack efficiently in alphanumeric mode.
+//
+// Use [LightningAddress.QR] to render it, or pass it to the QR library of your choice.
+func (a LightningAddress) QRContent() (string, error) {
+	lnurl, err := a.LNURLBech32()
+	if err != nil {
+		return "", err
+	}
+	return "LIGHTNING:" + strings.ToUpper(lnurl), nil
+}
+
+// QR renders the address as a QR code in PNG format, encoding [LightningAddress.QRContent],
+// so that receivers can display it for payers to scan.
+//
+// Returns an error wrapping [ErrQRTooLong] if the address is too long to fit, which
+// only happens if the username and domain together exceed 91 characters.
+func (a LightningAddress) QR() ([]byte, error) {
+	content, err := a.QRContent()
+	if err != nil {
+		return nil, err
+	}
+	code, err := encodeQR([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	return code.png()
+}
+
 // ParseLightningAddress parses a [LightningAddress] from a string, returning
 // ErrInvalidLightningAddress if the address is not a valid identifier.
 func ParseLightningAddress(lnAddress string) (LightningAddress, error) {
@@ -50,3 +112,64 @@ func ParseLightningAddress(lnAddress string) (LightningAddress, error) {
 
 	return addr, nil
 }
+
+// ErrDomainNotAllowed is returned when paying a lightning address or LNURL-pay service
+// whose domain is not permitted by the wallet's [DomainPolicy].
+var ErrDomainNotAllowed = errors.New("lightning address domain not allowed")
+
+// DomainPolicy restricts which lightning address and LNURL-pay domains a wallet may pay,
+// as set by [Wallet.SetDomainPolicy]. Unlike a [Signer] policy, it applies no matter how
+// requests are signed, and is checked before the address or LNURL is resolved, so
+// disallowed domains are never contacted.
+//
+// Patterns are domain names such as "walletofsatoshi.com", matched case-insensitively.
+// A pattern starting with "*." matches any subdomain, at any depth, but not the domain
+// itself: "*.example.com" matches "pay.example.com" but not "example.com".
+type DomainPolicy struct {
+	// Allow lists the only domains which may be paid. If empty, any domain not
+	// denied may be paid.
+	Allow []string
+
+	// Deny lists domains which may not be paid, even if allowed.
+	Deny []string
+}
+
+// SetDomainPolicy restricts which domains [Wallet.PayLightningAddress], [Wallet.PayLNURL]
+// and [Wallet.HandleLNURL] may pay, returning an error wrapping [ErrDomainNotAllowed] for any other domain. The
+// zero DomainPolicy, the default, allows every domain.
+func (wallet *Wallet) SetDomainPolicy(policy DomainPolicy) {
+	wallet.domainPolicy = policy
+}
+
+// Allows reports whether the policy permits paying lightning addresses on domain.
+func (policy DomainPolicy) Allows(domain string) bool {
+	return policy.check(domain) == nil
+}
+
+func (policy DomainPolicy) check(domain string) error {
+	for _, pattern := range policy.Deny {
+		if matchDomain(pattern, domain) {
+			return fmt.Errorf("%w: %s is denied", ErrDomainNotAllowed, domain)
+		}
+	}
+	if len(policy.Allow) == 0 {
+		return nil
+	}
+	for _, pattern := range policy.Allow {
+		if matchDomain(pattern, domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in the allowlist", ErrDomainNotAllowed, domain)
+}
+
+// matchDomain reports whether domain matches pattern, which may start with "*."
+// to match any subdomain.
+func matchDomain(pattern, domain string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(domain, "."+suffix)
+	}
+	return domain == pattern
+}
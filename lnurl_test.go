@@ -0,0 +1,230 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseLNURL(t *testing.T) {
+	const (
+		lnurl = "LNURL1DP68GURN8GHJ7UM9WFMXJCM99E3K7MF0V9CXJ0M385EKVCENXC6R2C35XVUKXEFCV5MKVV34X5EKZD3EV56NYD3HXQURZEPEXEJXXEPNXSCRVWFNV9NXZCN9XQ6XYEFHVGCXXCMYXYMNSERXFQ5FNS"
+		want  = "https://service.com/api?q=3fc3645b439ce8e7f2553a69e5267081d96dcd340693afabe04be7b0ccd178df"
+	)
+
+	for _, input := range []string{lnurl, strings.ToLower(lnurl), "lightning:" + lnurl} {
+		decoded, err := ParseLNURL(input)
+		if err != nil {
+			t.Fatalf("failed to parse LNURL %q: %v", input, err)
+		} else if decoded != want {
+			t.Fatalf("expected %q, got %q", want, decoded)
+		}
+	}
+
+	if _, err := ParseLNURL(testInvoiceDonation); !errors.Is(err, ErrInvalidLNURL) {
+		t.Fatalf("expected ErrInvalidLNURL for invoice, got %v", err)
+	}
+}
+
+func TestLightningAddressLNURLBech32(t *testing.T) {
+	addr := LightningAddress{"satoshi", "walletofsatoshi.com"}
+
+	encoded, err := addr.LNURLBech32()
+	if err != nil {
+		t.Fatalf("failed to encode LNURL: %v", err)
+	} else if !strings.HasPrefix(encoded, "lnurl1") {
+		t.Fatalf("unexpected encoding: %s", encoded)
+	}
+
+	decoded, err := ParseLNURL(encoded)
+	if err != nil {
+		t.Fatalf("failed to parse encoded LNURL: %v", err)
+	} else if decoded != addr.LNURL() {
+		t.Fatalf("round trip mismatch: expected %q, got %q", addr.LNURL(), decoded)
+	}
+
+	qr, err := addr.QRContent()
+	if err != nil {
+		t.Fatalf("failed to get QR content: %v", err)
+	} else if qr != "LIGHTNING:"+strings.ToUpper(encoded) {
+		t.Fatalf("unexpected QR content: %s", qr)
+	}
+
+	img, err := addr.QR()
+	if err != nil {
+		t.Fatalf("failed to render QR code: %v", err)
+	} else if _, err := png.Decode(bytes.NewReader(img)); err != nil {
+		t.Fatalf("failed to decode QR code PNG: %v", err)
+	}
+
+	longest := LightningAddress{strings.Repeat("a", 70), strings.Repeat("b", 17) + ".com"}
+	if _, err := longest.QR(); err != nil {
+		t.Fatalf("expected a 91-character address to fit, got %v", err)
+	}
+	longest.Username += "a"
+	if _, err := longest.QR(); !errors.Is(err, ErrQRTooLong) {
+		t.Fatalf("expected ErrQRTooLong, got %v", err)
+	}
+}
+
+func mustEncodeLNURL(t *testing.T, rawURL string) string {
+	t.Helper()
+	encoded, err := EncodeLNURL(rawURL)
+	if err != nil {
+		t.Fatalf("failed to encode LNURL: %v", err)
+	}
+	return encoded
+}
+
+func TestExtractLNURL(t *testing.T) {
+	const service = "https://service.com/api?q=abc"
+	encoded := mustEncodeLNURL(t, service)
+	login := "https://service.com/auth?tag=login&k1=" + strings.Repeat("ab", 32)
+
+	tests := []struct {
+		input    string
+		wantURL  string
+		wantType LNURLType
+	}{
+		{encoded, service, ""},
+		{"lightning:" + strings.ToUpper(encoded), service, ""},
+		{"https://service.com/lnurl?q=" + encoded, service, ""},
+		{"https://wallet.example/send?LIGHTNING=" + strings.ToUpper(encoded), service, ""},
+		{"https://wallet.example/send?lnurl=" + encoded + "&other=1", service, ""},
+		{mustEncodeLNURL(t, login), login, LNURLTypeAuth},
+		{"lnurlp://service.com/pay/1", "https://service.com/pay/1", LNURLTypePay},
+		{"lnurlw://service.com/withdraw?id=1", "https://service.com/withdraw?id=1", LNURLTypeWithdraw},
+		{"lnurlc://service.com/channel", "https://service.com/channel", LNURLTypeChannel},
+		{"keyauth://service.com/auth?k1=00", "https://service.com/auth?k1=00", LNURLTypeAuth},
+		{"lnurlp://abcdef.onion/pay", "http://abcdef.onion/pay", LNURLTypePay},
+	}
+
+	for _, test := range tests {
+		rawURL, kind, err := ExtractLNURL(test.input)
+		if err != nil {
+			t.Fatalf("failed to extract LNURL from %q: %v", test.input, err)
+		}
+		if rawURL != test.wantURL || kind != test.wantType {
+			t.Fatalf("ExtractLNURL(%q): expected (%q, %q), got (%q, %q)",
+				test.input, test.wantURL, test.wantType, rawURL, kind)
+		}
+	}
+
+	for _, input := range []string{"", "https://service.com/pay", "bob@getalby.com", testInvoiceDonation} {
+		if _, _, err := ExtractLNURL(input); !errors.Is(err, ErrInvalidLNURL) {
+			t.Fatalf("expected ErrInvalidLNURL for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestHandleLNURL(t *testing.T) {
+	var tag LNURLType
+	var paid, withdrawn string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/lnurl":
+			fmt.Fprintf(w, `{"tag":%q,"callback":"https://service.com/cb","k1":"secret",`+
+				`"minSendable":1000,"maxSendable":100000000,"minWithdrawable":1000,"maxWithdrawable":5000000}`, tag)
+		case "/api/v1/wallet/lnPay":
+			paid = r.URL.Path
+			w.Write([]byte(`{"id":"payment"}`))
+		case "/api/v1/wallet/createInvoice":
+			fmt.Fprintf(w, `{"id":"invoice","invoice":%q,"btcAmount":0.00005}`, testInvoiceDonation)
+		case "/cb":
+			withdrawn = r.URL.Query().Get("k1") + " " + r.URL.Query().Get("pr")
+			w.Write([]byte(`{"status":"OK"}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL)
+		}
+	})
+
+	ctx := context.Background()
+	lnurl := mustEncodeLNURL(t, "https://service.com/lnurl")
+
+	tag = LNURLTypePay
+	result, err := wallet.HandleLNURL(ctx, lnurl, "", 0.0001)
+	if err != nil {
+		t.Fatalf("failed to handle LNURL-pay: %v", err)
+	} else if result.Type != LNURLTypePay || result.Payment == nil || result.Payment.ID != "payment" || paid == "" {
+		t.Fatalf("unexpected LNURL-pay result: %+v", result)
+	}
+
+	tag = LNURLTypeWithdraw
+	result, err = wallet.HandleLNURL(ctx, lnurl, "", 0)
+	if err != nil {
+		t.Fatalf("failed to handle LNURL-withdraw: %v", err)
+	} else if result.Type != LNURLTypeWithdraw || result.Invoice == nil || result.Invoice.ID != "invoice" {
+		t.Fatalf("unexpected LNURL-withdraw result: %+v", result)
+	} else if withdrawn != "secret "+testInvoiceDonation {
+		t.Fatalf("unexpected withdraw callback parameters: %q", withdrawn)
+	}
+
+	tag = LNURLTypeChannel
+	if _, err := wallet.HandleLNURL(ctx, lnurl, "", 0.0001); !errors.Is(err, ErrUnsupportedLNURLType) {
+		t.Fatalf("expected ErrUnsupportedLNURLType for LNURL-channel, got %v", err)
+	}
+
+	// These are rejected without contacting the service.
+	for _, input := range []string{"keyauth://service.com/auth?k1=00", "lnurlc://service.com/channel"} {
+		if _, err := wallet.HandleLNURL(ctx, input, "", 0.0001); !errors.Is(err, ErrUnsupportedLNURLType) {
+			t.Fatalf("expected ErrUnsupportedLNURLType for %q, got %v", input, err)
+		}
+	}
+
+	tag = LNURLTypeWithdraw
+	if _, err := wallet.PayLNURL(ctx, lnurl, 0.0001); !errors.Is(err, ErrUnsupportedLNURLType) {
+		t.Fatalf("expected PayLNURL to reject LNURL-withdraw, got %v", err)
+	}
+}
+
+func TestLNURLAuth(t *testing.T) {
+	k1 := strings.Repeat("e2", 32)
+	callback := "https://site.com/auth?tag=login&k1=" + k1 + "&action=login"
+
+	for _, input := range []string{mustEncodeLNURL(t, callback), "keyauth://site.com/auth?k1=" + k1} {
+		req, err := ParseLNURLAuth(input)
+		if err != nil {
+			t.Fatalf("failed to parse LNURL-auth %q: %v", input, err)
+		}
+		if req.Domain != "site.com" || hex.EncodeToString(req.K1) != k1 {
+			t.Fatalf("unexpected LNURL-auth request: %+v", req)
+		}
+	}
+
+	req, _ := ParseLNURLAuth(mustEncodeLNURL(t, callback))
+	if req.URL != callback || req.Action != "login" {
+		t.Fatalf("unexpected LNURL-auth request: %+v", req)
+	}
+
+	invalid := map[string]error{
+		"https://site.com/auth?tag=login&k1=" + k1[:62]:              ErrInvalidLNURL,
+		"https://site.com/auth?tag=login&k1=" + k1 + "zz":            ErrInvalidLNURL,
+		"https://site.com/auth?tag=login":                            ErrInvalidLNURL,
+		"https://site.com/auth?tag=login&k1=" + k1 + "&action=steal": ErrInvalidLNURL,
+		"https://site.com/auth?tag=withdrawRequest&k1=" + k1:         ErrUnsupportedLNURLType,
+		"https://site.com/auth?k1=" + k1:                             ErrUnsupportedLNURLType,
+	}
+	for rawURL, wantErr := range invalid {
+		if _, err := ParseLNURLAuth(mustEncodeLNURL(t, rawURL)); !errors.Is(err, wantErr) {
+			t.Fatalf("expected %v for %q, got %v", wantErr, rawURL, err)
+		}
+	}
+
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+	})
+	err := wallet.LNURLAuth(context.Background(), mustEncodeLNURL(t, callback))
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+	err = wallet.LNURLAuth(context.Background(), "keyauth://site.com/auth?k1=00")
+	if !errors.Is(err, ErrInvalidLNURL) {
+		t.Fatalf("expected invalid challenge to be rejected, got %v", err)
+	}
+}
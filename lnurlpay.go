@@ -0,0 +1,179 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidLNURLImage is returned when an LNURL-pay service's metadata contains
+// an image which is malformed, of an unsupported type, or too large.
+var ErrInvalidLNURLImage = errors.New("invalid LNURL-pay image")
+
+// MaxLNURLImageSize is the largest decoded image, in bytes, accepted in LNURL-pay
+// metadata. Payee avatars are small thumbnails, so anything bigger is surely abuse.
+const MaxLNURLImageSize = 128 * 1024
+
+// lnurlImageSignatures maps the image types allowed in LNURL-pay metadata by LUD-06
+// to the magic bytes which every image of that type starts with.
+var lnurlImageSignatures = map[string][]byte{
+	"image/png":  []byte("\x89PNG\r\n\x1a\n"),
+	"image/jpeg": []byte("\xff\xd8\xff"),
+}
+
+// LNURLImage is an image, typically the payee's avatar, given in LNURL-pay metadata.
+type LNURLImage struct {
+	// MIMEType is either "image/png" or "image/jpeg".
+	MIMEType string
+
+	// Data is the raw image file.
+	Data []byte
+}
+
+// LNURLPayParams describes an LNURL-pay service, as returned by [Wallet.LNURLPayParams].
+// This is enough to show the payee to a user before they choose an amount to pay.
+type LNURLPayParams struct {
+	// Callback is the URL which is called to request an invoice.
+	Callback string
+
+	// MinSendable and MaxSendable are the smallest and largest BTC amounts
+	// the service accepts.
+	MinSendable float64
+	MaxSendable float64
+
+	// CommentAllowed is the maximum length of a LUD-12 comment, or zero
+	// if the service does not accept comments.
+	CommentAllowed int
+
+	// Description is the short plaintext description of the payment, and
+	// LongDescription an optional longer one.
+	Description     string
+	LongDescription string
+
+	// Identifier is the payee's lightning address or other LUD-16 identifier, if given.
+	Identifier string
+
+	// Image is the payee's avatar, or nil if none was given.
+	Image *LNURLImage
+
+	// Metadata is the raw metadata string, whose hash the service commits to
+	// in the description hash of its invoices.
+	Metadata string
+}
+
+// LNURLPayParams fetches the parameters of an LNURL-pay service, given an LNURL in any
+// form accepted by [ExtractLNURL], without paying it. The request is proxied through
+// WoS so that the service does not see your IP address.
+//
+// Returns an error wrapping [ErrUnsupportedLNURLType] if the LNURL is not LNURL-pay, or
+// [ErrInvalidLNURLImage] if the service's metadata contains a malformed image.
+func (wallet *Wallet) LNURLPayParams(ctx context.Context, lnurl string) (*LNURLPayParams, error) {
+	rawURL, kind, err := ExtractLNURL(lnurl)
+	if err != nil {
+		return nil, fmt.Errorf("LNURLPayParams: %w", err)
+	} else if kind != "" && kind != LNURLTypePay {
+		return nil, fmt.Errorf("LNURLPayParams: %w: %s", ErrUnsupportedLNURLType, kind)
+	}
+
+	resp, err := wallet.fetchLNURL(ctx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("LNURLPayParams: %w", err)
+	} else if resp.Tag != LNURLTypePay {
+		return nil, fmt.Errorf("LNURLPayParams: %w: %q", ErrUnsupportedLNURLType, resp.Tag)
+	}
+
+	params, err := parseLNURLPayParams(resp)
+	if err != nil {
+		return nil, fmt.Errorf("LNURLPayParams: %w", err)
+	}
+	return params, nil
+}
+
+// parseLNURLPayParams parses an LNURL-pay response, including its metadata, which is
+// a JSON-encoded array of [type, content] pairs as per LUD-06.
+func parseLNURLPayParams(resp *lnurlResponse) (*LNURLPayParams, error) {
+	params := &LNURLPayParams{
+		Callback:       resp.Callback,
+		MinSendable:    fromMillisat(resp.MinSendable),
+		MaxSendable:    fromMillisat(resp.MaxSendable),
+		CommentAllowed: resp.CommentAllowed,
+		Metadata:       resp.Metadata,
+	}
+	if resp.Metadata == "" {
+		return params, nil
+	}
+
+	var entries [][]any
+	if err := json.Unmarshal([]byte(resp.Metadata), &entries); err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+	for _, entry := range entries {
+		if len(entry) < 2 {
+			continue
+		}
+		kind, _ := entry[0].(string)
+		content, ok := entry[1].(string)
+		if !ok {
+			continue
+		}
+
+		switch kind {
+		case "text/plain":
+			params.Description = content
+		case "text/long-desc":
+			params.LongDescription = content
+		case "text/identifier", "text/email":
+			params.Identifier = content
+		case "image/png;base64", "image/jpeg;base64":
+			image, err := ParseLNURLImage(kind, content)
+			if err != nil {
+				return nil, err
+			}
+			params.Image = image
+		}
+	}
+	return params, nil
+}
+
+// ParseLNURLImage decodes and validates an image entry from LNURL-pay metadata, given
+// its type, such as "image/png;base64", and its base64 content. For leniency, the content
+// may also be a complete data URI, such as "data:image/png;base64,...", so long as its
+// type matches.
+//
+// The image must be a PNG or JPEG of at most [MaxLNURLImageSize] bytes, whose content
+// matches its declared type, otherwise an error wrapping [ErrInvalidLNURLImage] is
+// returned. The image is not otherwise decoded, so UIs should still render it with
+// a decoder which is robust to malicious input.
+func ParseLNURLImage(kind, content string) (*LNURLImage, error) {
+	mimeType, ok := strings.CutSuffix(strings.ToLower(kind), ";base64")
+	signature := lnurlImageSignatures[mimeType]
+	if !ok || signature == nil {
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidLNURLImage, kind)
+	}
+
+	if dataURI, isURI := strings.CutPrefix(content, "data:"); isURI {
+		header, data, found := strings.Cut(dataURI, ",")
+		if !found || !strings.EqualFold(header, mimeType+";base64") {
+			return nil, fmt.Errorf("%w: malformed data URI", ErrInvalidLNURLImage)
+		}
+		content = data
+	}
+
+	// Check the size before decoding, so oversized images are never allocated.
+	if base64.StdEncoding.DecodedLen(len(content)) > MaxLNURLImageSize+2 {
+		return nil, fmt.Errorf("%w: image exceeds %d bytes", ErrInvalidLNURLImage, MaxLNURLImageSize)
+	}
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidLNURLImage, err)
+	} else if len(data) > MaxLNURLImageSize {
+		return nil, fmt.Errorf("%w: image exceeds %d bytes", ErrInvalidLNURLImage, MaxLNURLImageSize)
+	} else if !bytes.HasPrefix(data, signature) {
+		return nil, fmt.Errorf("%w: content is not %s", ErrInvalidLNURLImage, mimeType)
+	}
+	return &LNURLImage{MIMEType: mimeType, Data: data}, nil
+}
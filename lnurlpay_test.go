@@ -0,0 +1,71 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLNURLPayParams(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nfake image data")
+	metadata, _ := json.Marshal([][]string{
+		{"text/plain", "Pay Satoshi"},
+		{"text/identifier", "satoshi@service.com"},
+		{"image/png;base64", base64.StdEncoding.EncodeToString(png)},
+	})
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag":"payRequest","callback":"https://service.com/cb",`+
+			`"minSendable":1000,"maxSendable":100000000,"metadata":%q}`, metadata)
+	})
+
+	params, err := wallet.LNURLPayParams(context.Background(), mustEncodeLNURL(t, "https://service.com/lnurl"))
+	if err != nil {
+		t.Fatalf("LNURLPayParams failed: %v", err)
+	} else if params.Description != "Pay Satoshi" || params.Identifier != "satoshi@service.com" {
+		t.Fatalf("unexpected params: %+v", params)
+	} else if params.MinSendable != 0.00000001 || params.MaxSendable != 0.001 {
+		t.Fatalf("unexpected amount range: %v - %v", params.MinSendable, params.MaxSendable)
+	} else if params.Image == nil || params.Image.MIMEType != "image/png" || !bytes.Equal(params.Image.Data, png) {
+		t.Fatalf("unexpected image: %+v", params.Image)
+	}
+}
+
+func TestParseLNURLImage(t *testing.T) {
+	jpeg := base64.StdEncoding.EncodeToString([]byte("\xff\xd8\xff\xe0jpeg"))
+	if image, err := ParseLNURLImage("image/jpeg;base64", "data:image/jpeg;base64,"+jpeg); err != nil {
+		t.Fatalf("failed to parse data URI: %v", err)
+	} else if image.MIMEType != "image/jpeg" {
+		t.Fatalf("unexpected MIME type %q", image.MIMEType)
+	}
+
+	oversized := base64.StdEncoding.EncodeToString(
+		append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, MaxLNURLImageSize)...),
+	)
+	invalid := []struct{ kind, content string }{
+		{"image/png;base64", oversized},
+		{"image/png;base64", "not base64!"},
+		{"image/png;base64", jpeg},
+		{"image/gif;base64", jpeg},
+		{"image/jpeg", jpeg},
+		{"image/jpeg;base64", "data:image/png;base64," + jpeg},
+		{"image/jpeg;base64", "data:image/jpeg;base64" + jpeg},
+		{"image/jpeg;base64", ""},
+	}
+	for _, test := range invalid {
+		_, err := ParseLNURLImage(test.kind, test.content)
+		if !errors.Is(err, ErrInvalidLNURLImage) {
+			t.Errorf("expected ErrInvalidLNURLImage for %s %.20q, got %v", test.kind, test.content, err)
+		}
+	}
+
+	metadata := `[["text/plain","hi"],["image/png;base64","` + strings.Repeat("A", 12) + `"]]`
+	if _, err := parseLNURLPayParams(&lnurlResponse{Metadata: metadata}); !errors.Is(err, ErrInvalidLNURLImage) {
+		t.Fatalf("expected malformed image to be rejected, got %v", err)
+	}
+}
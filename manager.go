@@ -0,0 +1,134 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrManagerClosed is returned by [WalletManager.Get] after the manager is closed.
+var ErrManagerClosed = errors.New("wallet manager is closed")
+
+// WalletManager opens and caches wallets whose [Credentials] are kept in a [Store],
+// for services which act on behalf of many users. Each managed wallet has a context,
+// derived from the manager's, which is cancelled when the wallet is evicted or the
+// manager is closed, along with all of the wallet's in-flight requests.
+//
+// A WalletManager is safe for concurrent use.
+type WalletManager struct {
+	store      Store
+	httpClient *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	wallets map[string]*managedWallet
+}
+
+type managedWallet struct {
+	wallet *Wallet
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewWalletManager returns a WalletManager which loads credentials from store, and
+// opens wallets using httpClient, or [http.DefaultClient] if httpClient is nil.
+func NewWalletManager(store Store, httpClient *http.Client) *WalletManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WalletManager{
+		store:      store,
+		httpClient: httpClient,
+		ctx:        ctx,
+		cancel:     cancel,
+		wallets:    make(map[string]*managedWallet),
+	}
+}
+
+// Get returns the wallet whose credentials are saved in the store under name,
+// opening it on first use.
+func (m *WalletManager) Get(ctx context.Context, name string) (*Wallet, error) {
+	m.mu.Lock()
+	managed, ok := m.wallets[name]
+	m.mu.Unlock()
+	if ok {
+		return managed.wallet, nil
+	} else if m.ctx.Err() != nil {
+		return nil, fmt.Errorf("WalletManager: %w", ErrManagerClosed)
+	}
+
+	creds, err := m.store.LoadCredentials(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("WalletManager: %w", err)
+	}
+	wallet, err := creds.OpenWallet(ctx, m.httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("WalletManager: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx.Err() != nil {
+		wallet.Close()
+		return nil, fmt.Errorf("WalletManager: %w", ErrManagerClosed)
+	} else if existing, ok := m.wallets[name]; ok {
+		// Another caller opened the wallet concurrently.
+		wallet.Close()
+		return existing.wallet, nil
+	}
+
+	walletCtx, cancel := context.WithCancel(m.ctx)
+	m.wallets[name] = &managedWallet{wallet: wallet, ctx: walletCtx, cancel: cancel}
+	return wallet, nil
+}
+
+// Context returns the context of the managed wallet with the given name, which is
+// cancelled when the wallet is evicted or the manager is closed. Long-running work
+// on the wallet, such as a [Scheduler], should run under this context so that it
+// stops with the wallet. Returns false if no wallet is managed under name.
+func (m *WalletManager) Context(name string) (context.Context, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	managed, ok := m.wallets[name]
+	if !ok {
+		return nil, false
+	}
+	return managed.ctx, true
+}
+
+// Evict stops managing the wallet with the given name, cancelling its context and
+// closing it with [Wallet.Close], so its in-flight requests fail with an error
+// wrapping [ErrWalletClosed]. The next call to [WalletManager.Get] opens it afresh.
+// Does nothing if no wallet is managed under name.
+func (m *WalletManager) Evict(name string) {
+	m.mu.Lock()
+	managed, ok := m.wallets[name]
+	delete(m.wallets, name)
+	m.mu.Unlock()
+	if ok {
+		managed.close()
+	}
+}
+
+// Close evicts every managed wallet, cancelling all in-flight operations, and makes
+// future calls to [WalletManager.Get] fail with [ErrManagerClosed]. Close always
+// returns nil, and is safe to call more than once.
+func (m *WalletManager) Close() error {
+	m.mu.Lock()
+	m.cancel()
+	wallets := m.wallets
+	m.wallets = make(map[string]*managedWallet)
+	m.mu.Unlock()
+
+	for _, managed := range wallets {
+		managed.close()
+	}
+	return nil
+}
+
+func (managed *managedWallet) close() {
+	managed.cancel()
+	managed.wallet.Close()
+}
@@ -0,0 +1,60 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWalletManagerClose(t *testing.T) {
+	started := make(chan struct{})
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/account":
+			w.Write([]byte(`{"btcDepositAddress":"bc1qexample","lightningAddress":"user@walletofsatoshi.com"}`))
+		case "/api/v1/wallet/balance":
+			close(started)
+			<-r.Context().Done()
+		}
+	})
+
+	store := &MemoryStore{}
+	store.SaveCredentials(context.Background(), "alice", Credentials{APIToken: "token", APISecret: "secret"})
+	manager := NewWalletManager(store, httpClient)
+
+	wallet, err := manager.Get(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	} else if again, _ := manager.Get(context.Background(), "alice"); again != wallet {
+		t.Fatalf("expected the wallet to be cached")
+	}
+	walletCtx, ok := manager.Context("alice")
+	if !ok {
+		t.Fatalf("expected a context for the managed wallet")
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := wallet.Balance(context.Background())
+		errs <- err
+	}()
+	<-started
+	manager.Close()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrWalletClosed) {
+			t.Fatalf("expected in-flight request to fail with ErrWalletClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("in-flight request was not cancelled by closing the manager")
+	}
+	if walletCtx.Err() == nil {
+		t.Fatalf("expected wallet context to be cancelled")
+	}
+	if _, err := manager.Get(context.Background(), "alice"); !errors.Is(err, ErrManagerClosed) {
+		t.Fatalf("expected ErrManagerClosed, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported is returned by methods which the WoS API does not support.
+var ErrUnsupported = errors.New("not supported by the WoS API")
+
+// lightningMessagePrefix is prepended to messages before signing by lightning node
+// implementations such as LND and CLN.
+const lightningMessagePrefix = "Lightning Signed Message:"
+
+// SignMessage would sign a message with the wallet's key, to prove ownership of the
+// wallet. WoS is a custodial wallet which does not expose any message signing endpoint,
+// so this always returns [ErrUnsupported]. To prove ownership of a WoS wallet, ask the
+// verifier for an invoice to pay, or create an invoice whose description they choose.
+func (wallet *Wallet) SignMessage(ctx context.Context, message string) (string, error) {
+	return "", fmt.Errorf("SignMessage: %w", ErrUnsupported)
+}
+
+// VerifyMessage verifies a message signed by a lightning node using the zbase32-encoded
+// signature format of LND's and CLN's signmessage commands. nodePubKey is the hex-encoded
+// public key of the node expected to have signed the message, such as the one returned
+// by [Wallet.NodeInfo].
+//
+// Returns false if the signature is valid but was made by a different node, and an
+// error if the signature is malformed.
+func VerifyMessage(nodePubKey, message, signature string) (bool, error) {
+	pubKey, err := hex.DecodeString(nodePubKey)
+	if err != nil || len(pubKey) != 33 {
+		return false, fmt.Errorf("invalid node public key: %q", nodePubKey)
+	}
+
+	sig, err := zbase32Decode(signature)
+	if err != nil {
+		return false, fmt.Errorf("invalid signature encoding: %w", err)
+	} else if len(sig) != 65 || sig[0] < 31 || sig[0] > 34 {
+		return false, errors.New("invalid compact signature")
+	}
+
+	recovered, err := recoverPubKey(lightningMessageHash(message), sig[1:], sig[0]-31)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(recovered, pubKey), nil
+}
+
+// lightningMessageHash returns the double-SHA256 hash which lightning nodes sign
+// for a given message.
+func lightningMessageHash(message string) []byte {
+	first := sha256.Sum256([]byte(lightningMessagePrefix + message))
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+const zbase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+func zbase32Encode(data []byte) string {
+	var out strings.Builder
+	var buffer, bits uint
+	for _, b := range data {
+		buffer = buffer<<8 | uint(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out.WriteByte(zbase32Alphabet[(buffer>>bits)&31])
+		}
+	}
+	if bits > 0 {
+		out.WriteByte(zbase32Alphabet[(buffer<<(5-bits))&31])
+	}
+	return out.String()
+}
+
+func zbase32Decode(s string) ([]byte, error) {
+	var out []byte
+	var buffer, bits uint
+	for _, c := range []byte(s) {
+		value := strings.IndexByte(zbase32Alphabet, c)
+		if value < 0 {
+			return nil, fmt.Errorf("invalid zbase32 character %q", c)
+		}
+		buffer = buffer<<5 | uint(value)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			out = append(out, byte(buffer>>bits))
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package wos
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// testSignMessage signs a message like a lightning node would, with a fixed
+// private key and nonce. Never do this with real keys.
+func testSignMessage(privKey, nonce *big.Int, message string) string {
+	g := curvePoint{secp256k1Gx, secp256k1Gy}
+	e := new(big.Int).SetBytes(lightningMessageHash(message))
+
+	bigR := linearCombination(nonce, g, new(big.Int), g)
+	r := new(big.Int).Mod(bigR.x, secp256k1N)
+	s := new(big.Int).Mul(r, privKey)
+	s.Add(s, e).Mul(s, new(big.Int).ModInverse(nonce, secp256k1N)).Mod(s, secp256k1N)
+
+	recoveryID := byte(bigR.y.Bit(0))
+	if s.Cmp(new(big.Int).Rsh(secp256k1N, 1)) > 0 {
+		s.Sub(secp256k1N, s)
+		recoveryID ^= 1
+	}
+
+	sig := make([]byte, 65)
+	sig[0] = 31 + recoveryID
+	r.FillBytes(sig[1:33])
+	s.FillBytes(sig[33:])
+	return zbase32Encode(sig)
+}
+
+func TestVerifyMessage(t *testing.T) {
+	privKey := big.NewInt(0xC0FFEE)
+	g := curvePoint{secp256k1Gx, secp256k1Gy}
+	pubKey := linearCombination(privKey, g, new(big.Int), g).compressed()
+	nodePubKey := hex.EncodeToString(pubKey)
+
+	signature := testSignMessage(privKey, big.NewInt(123456789), "I own this node")
+
+	ok, err := VerifyMessage(nodePubKey, "I own this node", signature)
+	if err != nil {
+		t.Fatalf("VerifyMessage failed: %v", err)
+	} else if !ok {
+		t.Fatalf("expected signature to verify")
+	}
+
+	if ok, _ := VerifyMessage(nodePubKey, "I own a different node", signature); ok {
+		t.Fatalf("expected signature over a different message not to verify")
+	}
+	if _, err := VerifyMessage(nodePubKey, "I own this node", "not-zbase32!"); err == nil {
+		t.Fatalf("expected error for malformed signature")
+	}
+
+	wallet := mockWallet(nil)
+	if _, err := wallet.SignMessage(context.Background(), "hello"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported from SignMessage, got %v", err)
+	}
+}
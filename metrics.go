@@ -0,0 +1,132 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// PaymentOutcome describes a payment attempt, as reported to [PaymentMetrics].
+type PaymentOutcome struct {
+	// Method is the wallet method which sent the payment, such as "PayInvoice".
+	Method string
+
+	// Currency is how the payment was sent.
+	Currency PaymentCurrency
+
+	// Amount is the BTC amount requested, which is zero when paying a fixed-amount invoice.
+	Amount float64
+
+	// Success reports whether WoS accepted the payment.
+	Success bool
+
+	// Sentinel is the sentinel error the failure matches, such as [ErrInsufficientFunds],
+	// for use as a low-cardinality metric label. It is nil on success, and also if the
+	// error matches none of the sentinels in [PaymentErrorSentinels]. Err is the full error.
+	Sentinel error
+	Err      error
+
+	// Duration is how long the attempt took.
+	Duration time.Duration
+}
+
+// PaymentMetrics records the outcome of every payment a wallet attempts, for
+// business-level observability such as counts of failed payments by currency and
+// reason, or histograms of amounts sent. Set it with [Wallet.SetPaymentMetrics].
+//
+// RecordPayment is called synchronously once each attempt completes, so it should not
+// block. It must be safe for concurrent use.
+//
+// This package does not depend on any metrics library. An OpenTelemetry adapter, for
+// example, can be written in a few lines:
+//
+//	type otelPaymentMetrics struct {
+//		payments metric.Int64Counter
+//		amounts  metric.Float64Histogram
+//	}
+//
+//	func (m otelPaymentMetrics) RecordPayment(ctx context.Context, outcome wos.PaymentOutcome) {
+//		reason := "none"
+//		if outcome.Sentinel != nil {
+//			reason = outcome.Sentinel.Error()
+//		} else if outcome.Err != nil {
+//			reason = "other"
+//		}
+//		attrs := metric.WithAttributes(
+//			attribute.String("method", outcome.Method),
+//			attribute.String("currency", string(outcome.Currency)),
+//			attribute.Bool("success", outcome.Success),
+//			attribute.String("reason", reason),
+//		)
+//		m.payments.Add(ctx, 1, attrs)
+//		m.amounts.Record(ctx, outcome.Amount, attrs)
+//	}
+type PaymentMetrics interface {
+	RecordPayment(ctx context.Context, outcome PaymentOutcome)
+}
+
+// NoopPaymentMetrics is a [PaymentMetrics] which records nothing. It is the default.
+type NoopPaymentMetrics struct{}
+
+// RecordPayment implements PaymentMetrics.
+func (NoopPaymentMetrics) RecordPayment(context.Context, PaymentOutcome) {}
+
+// PaymentErrorSentinels lists the sentinel errors which failed payments are classified
+// by in [PaymentOutcome.Sentinel], in order of precedence.
+var PaymentErrorSentinels = []error{
+	ErrInsufficientFunds,
+	ErrLowFee,
+	ErrNoRoute,
+	ErrInvoiceExpired,
+	ErrAlreadyPaid,
+	ErrInvalidDestination,
+	ErrWalletFrozen,
+	ErrUnsupportedRegion,
+	ErrRateLimited,
+	ErrMaintenance,
+	ErrAmountExceedsCap,
+	ErrSignerTimeout,
+	ErrWalletClosed,
+	context.Canceled,
+	context.DeadlineExceeded,
+}
+
+// SetPaymentMetrics sets the hook which records the outcome of every payment the wallet
+// attempts: those sent by [Wallet.PayInvoice], [Wallet.PayOnChain] and the other payment
+// methods, including lightning address payments. Payments rejected by local validation,
+// such as an invalid invoice, are not attempted and so not recorded. A nil metrics
+// restores the default [NoopPaymentMetrics].
+func (wallet *Wallet) SetPaymentMetrics(metrics PaymentMetrics) {
+	wallet.paymentMetrics = metrics
+}
+
+// recordPayment reports a payment attempt which started at start to the wallet's
+// payment metrics, if any.
+func (wallet *Wallet) recordPayment(
+	ctx context.Context,
+	method string,
+	currency PaymentCurrency,
+	amount float64,
+	start time.Time,
+	err error,
+) {
+	if wallet.paymentMetrics == nil {
+		return
+	}
+
+	outcome := PaymentOutcome{
+		Method:   method,
+		Currency: currency,
+		Amount:   amount,
+		Success:  err == nil,
+		Err:      err,
+		Duration: time.Since(start),
+	}
+	for _, sentinel := range PaymentErrorSentinels {
+		if errors.Is(err, sentinel) {
+			outcome.Sentinel = sentinel
+			break
+		}
+	}
+	wallet.paymentMetrics.RecordPayment(ctx, outcome)
+}
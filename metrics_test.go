@@ -0,0 +1,64 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+type recordingPaymentMetrics struct {
+	mu       sync.Mutex
+	outcomes []PaymentOutcome
+}
+
+func (m *recordingPaymentMetrics) RecordPayment(ctx context.Context, outcome PaymentOutcome) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outcomes = append(m.outcomes, outcome)
+}
+
+func TestPaymentMetrics(t *testing.T) {
+	fail := false
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"INSUFFICIENT_FUNDS"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"payment"}`))
+	})
+	metrics := new(recordingPaymentMetrics)
+	wallet.SetPaymentMetrics(metrics)
+	ctx := context.Background()
+
+	if _, err := wallet.PayInvoice(ctx, testInvoiceCoffee, ""); err != nil {
+		t.Fatalf("PayInvoice failed: %v", err)
+	}
+
+	fail = true
+	if _, err := wallet.PayOnChain(ctx, "bc1qexample", 0.001, ""); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds, got %v", err)
+	}
+
+	if len(metrics.outcomes) != 2 {
+		t.Fatalf("expected 2 recorded outcomes, got %d", len(metrics.outcomes))
+	}
+
+	success := metrics.outcomes[0]
+	if !success.Success || success.Err != nil || success.Sentinel != nil {
+		t.Fatalf("expected successful outcome, got %+v", success)
+	} else if success.Method != "PayInvoice" || success.Currency != PaymentCurrencyLightning {
+		t.Fatalf("unexpected successful outcome labels: %+v", success)
+	}
+
+	failure := metrics.outcomes[1]
+	if failure.Success || failure.Err == nil {
+		t.Fatalf("expected failed outcome, got %+v", failure)
+	} else if failure.Sentinel != ErrInsufficientFunds {
+		t.Fatalf("expected sentinel ErrInsufficientFunds, got %v", failure.Sentinel)
+	} else if failure.Currency != PaymentCurrencyBitcoin || failure.Amount != 0.001 {
+		t.Fatalf("unexpected failed outcome labels: %+v", failure)
+	}
+}
@@ -0,0 +1,69 @@
+package wos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// handlerTransport is an [http.RoundTripper] which serves every request
+// with an in-process [http.Handler], so that tests never touch the real
+// WoS API.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// mockClient returns an [http.Client] whose requests are all served by handler.
+func mockClient(handler http.HandlerFunc) *http.Client {
+	return &http.Client{Transport: handlerTransport{handler}}
+}
+
+// mockWallet returns a [Wallet] whose requests are all served by handler.
+func mockWallet(handler http.HandlerFunc) *Wallet {
+	httpClient := mockClient(handler)
+	return &Wallet{
+		reader:           NewReader("token", httpClient),
+		signer:           NewSimpleSigner("secret"),
+		httpClient:       httpClient,
+		onChainAddress:   "bc1qexample",
+		lightningAddress: LightningAddress{"user", "walletofsatoshi.com"},
+	}
+}
+
+// fakeClock is a [Clock] whose time only moves when it is waited on: After
+// advances the clock by the given duration and fires immediately.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
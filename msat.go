@@ -0,0 +1,36 @@
+package wos
+
+import (
+	"math"
+	"strconv"
+)
+
+// Msat is an amount of millisatoshis, the smallest unit of lightning payments.
+// One satoshi is 1000 millisatoshis. Unlike BTC amounts given as float64, an Msat
+// is exact, so lightning-native callers can work with invoice amounts which are
+// not a whole number of satoshis without losing precision.
+type Msat uint64
+
+// MsatFromBTC converts a BTC amount to millisatoshis, rounding to the nearest
+// millisatoshi. Negative amounts convert to zero.
+func MsatFromBTC(btc float64) Msat {
+	if btc <= 0 {
+		return 0
+	}
+	return Msat(math.Round(btc * 100_000_000 * 1_000))
+}
+
+// BTC returns the amount in BTC. Very large amounts may lose precision.
+func (msat Msat) BTC() float64 {
+	return float64(msat) / 100_000_000_000
+}
+
+// Sats returns the amount in whole satoshis, rounded down.
+func (msat Msat) Sats() uint64 {
+	return uint64(msat) / 1000
+}
+
+// String returns the amount as a number of millisatoshis, such as "1500 msat".
+func (msat Msat) String() string {
+	return strconv.FormatUint(uint64(msat), 10) + " msat"
+}
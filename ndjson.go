@@ -0,0 +1,51 @@
+package wos
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// ndjsonFlushInterval is the number of lines [WritePaymentsNDJSON] buffers
+// before flushing them to the underlying writer.
+const ndjsonFlushInterval = 100
+
+// WritePaymentsNDJSON writes payments to w as newline-delimited JSON, one [Payment]
+// object per line, for piping into data pipelines. Payments are consumed from the
+// iterator one at a time, such as from [Reader.AllPayments], so that huge histories
+// are never buffered in memory. Output is flushed to w every few lines, and once
+// all payments are written.
+//
+// Writing stops at the first error, whether from the iterator, from writing to w,
+// or from ctx being done. Lines already flushed to w are not retracted.
+func WritePaymentsNDJSON(ctx context.Context, w io.Writer, payments iter.Seq2[Payment, error]) error {
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	lines := 0
+	for payment, err := range payments {
+		if err != nil {
+			return fmt.Errorf("WritePaymentsNDJSON: %w", err)
+		} else if err := ctx.Err(); err != nil {
+			return fmt.Errorf("WritePaymentsNDJSON: %w", err)
+		}
+
+		if err := enc.Encode(&payment); err != nil {
+			return fmt.Errorf("WritePaymentsNDJSON: %w", err)
+		}
+		if lines++; lines%ndjsonFlushInterval == 0 {
+			if err := buf.Flush(); err != nil {
+				return fmt.Errorf("WritePaymentsNDJSON: %w", err)
+			}
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("WritePaymentsNDJSON: %w", err)
+	}
+	return nil
+}
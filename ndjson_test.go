@@ -0,0 +1,56 @@
+package wos
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestWritePaymentsNDJSON(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testPaymentsJSON(5))
+	}))
+
+	var out bytes.Buffer
+	if err := WritePaymentsNDJSON(context.Background(), &out, rdr.AllPayments(context.Background())); err != nil {
+		t.Fatalf("WritePaymentsNDJSON failed: %v", err)
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var payment Payment
+		if err := json.Unmarshal(scanner.Bytes(), &payment); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, payment.ID)
+	}
+	if len(ids) != 5 || ids[0] != "payment-0" || ids[4] != "payment-4" {
+		t.Fatalf("unexpected payments written: %v", ids)
+	}
+}
+
+func TestWritePaymentsNDJSONIteratorError(t *testing.T) {
+	errBroken := errors.New("broken stream")
+	payments := func(yield func(Payment, error) bool) {
+		if yield(Payment{ID: "a"}, nil) {
+			yield(Payment{}, errBroken)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WritePaymentsNDJSON(context.Background(), &out, payments); !errors.Is(err, errBroken) {
+		t.Fatalf("expected iterator error to be propagated, got %v", err)
+	}
+
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	if err := WritePaymentsNDJSON(context.Background(), &out, rdr.AllPayments(context.Background())); err == nil {
+		t.Fatalf("expected error when history cannot be fetched")
+	}
+}
@@ -0,0 +1,94 @@
+package wos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Network identifies a bitcoin network.
+type Network string
+
+const (
+	NetworkMainnet Network = "mainnet"
+	NetworkTestnet Network = "testnet"
+	NetworkRegtest Network = "regtest"
+)
+
+// ErrWrongNetwork is returned when sending to an on-chain address for a different
+// bitcoin network than WoS operates on, such as a testnet address.
+var ErrWrongNetwork = errors.New("address is for the wrong bitcoin network")
+
+// AddressNetwork detects the network an on-chain address belongs to from its prefix:
+// the human-readable part of bech32 addresses, or the leading character of base58
+// addresses. Testnet and regtest share base58 prefixes, which are reported as testnet.
+//
+// The address is not otherwise validated. Returns false if the prefix is not recognized.
+func AddressNetwork(address string) (Network, bool) {
+	lower := strings.ToLower(address)
+	switch {
+	case strings.HasPrefix(lower, "bcrt1"):
+		return NetworkRegtest, true
+	case strings.HasPrefix(lower, "bc1"):
+		return NetworkMainnet, true
+	case strings.HasPrefix(lower, "tb1"):
+		return NetworkTestnet, true
+	}
+
+	if address == "" {
+		return "", false
+	}
+	switch address[0] {
+	case '1', '3':
+		return NetworkMainnet, true
+	case 'm', 'n', '2':
+		return NetworkTestnet, true
+	}
+	return "", false
+}
+
+// checkAddressNetwork returns an error wrapping [ErrWrongNetwork] if address is
+// recognizably not a mainnet address, the only network WoS operates on. Addresses
+// whose network cannot be detected are left for WoS to validate.
+func checkAddressNetwork(address string) error {
+	network, ok := AddressNetwork(address)
+	if ok && network != NetworkMainnet {
+		return fmt.Errorf("%w: %s is a %s address, expected %s", ErrWrongNetwork, address, network, NetworkMainnet)
+	}
+	return nil
+}
+
+// AddressType identifies the script type of an on-chain address.
+type AddressType string
+
+const (
+	AddressP2PKH  AddressType = "p2pkh"
+	AddressP2SH   AddressType = "p2sh"
+	AddressP2WPKH AddressType = "p2wpkh"
+	AddressP2WSH  AddressType = "p2wsh"
+	AddressP2TR   AddressType = "p2tr"
+)
+
+// DetectAddressType detects the script type of a mainnet on-chain address from its
+// prefix and length. Like [AddressNetwork], the checksum is not validated. Returns
+// false if the address is not a recognizable mainnet address.
+func DetectAddressType(address string) (AddressType, bool) {
+	if network, ok := AddressNetwork(address); !ok || network != NetworkMainnet {
+		return "", false
+	}
+
+	lower := strings.ToLower(address)
+	switch {
+	case strings.HasPrefix(lower, "bc1q") && len(lower) == 42:
+		return AddressP2WPKH, true
+	case strings.HasPrefix(lower, "bc1q") && len(lower) == 62:
+		return AddressP2WSH, true
+	case strings.HasPrefix(lower, "bc1p") && len(lower) == 62:
+		return AddressP2TR, true
+	case strings.HasPrefix(address, "1") && len(address) >= 26 && len(address) <= 34:
+		return AddressP2PKH, true
+	case strings.HasPrefix(address, "3") && len(address) >= 26 && len(address) <= 35:
+		return AddressP2SH, true
+	}
+	return "", false
+}
@@ -0,0 +1,45 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAddressNetwork(t *testing.T) {
+	tests := map[string]Network{
+		"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq":   NetworkMainnet,
+		"BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ":   NetworkMainnet,
+		"1BoatSLRHtKNngkdXEeobR76b53LETtpyT":           NetworkMainnet,
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy":           NetworkMainnet,
+		"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx":   NetworkTestnet,
+		"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn":           NetworkTestnet,
+		"bcrt1qs758ursh4q9z627kt3pp5yysm78ddny6txaqgw": NetworkRegtest,
+	}
+	for address, want := range tests {
+		if got, ok := AddressNetwork(address); !ok || got != want {
+			t.Errorf("AddressNetwork(%q): expected %s, got %s", address, want, got)
+		}
+	}
+	if _, ok := AddressNetwork("xyz"); ok {
+		t.Errorf("expected unrecognized prefix to be undetected")
+	}
+}
+
+func TestPayOnChainWrongNetwork(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+	})
+	ctx := context.Background()
+	const testnet = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+
+	_, err := wallet.PayOnChain(ctx, testnet, 0.001, "")
+	if !errors.Is(err, ErrWrongNetwork) || !strings.Contains(err.Error(), "testnet") {
+		t.Fatalf("expected ErrWrongNetwork naming testnet, got %v", err)
+	}
+	if _, err := wallet.SweepOnChain(ctx, testnet, ""); !errors.Is(err, ErrWrongNetwork) {
+		t.Fatalf("expected ErrWrongNetwork from SweepOnChain, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// NodeInfo describes the lightning node which serves a WoS wallet.
+//
+// The WoS API does not expose any information about its lightning nodes directly,
+// so only details which can be derived from invoices issued by WoS are available.
+type NodeInfo struct {
+	// PubKey is the public key of the lightning node which issues the wallet's invoices.
+	PubKey []byte
+
+	// RouteHints lists any private routes to the node advertised in its invoices.
+	RouteHints []RouteHint
+}
+
+// NodeInfo determines which lightning node serves the wallet, by creating a fresh
+// variable-amount invoice and decoding the payee from it.
+//
+// Note that this creates a new (unpaid) invoice in the wallet's history each time
+// it is called, so callers should cache the result.
+func (wallet *Wallet) NodeInfo(ctx context.Context) (*NodeInfo, error) {
+	invoice, err := wallet.NewInvoice(ctx, &InvoiceOptions{Expiry: MinInvoiceExpiry})
+	if err != nil {
+		return nil, fmt.Errorf("NodeInfo: %w", err)
+	}
+
+	decoded, err := DecodeInvoice(invoice.Bolt11)
+	if err != nil {
+		return nil, fmt.Errorf("NodeInfo: %w", err)
+	} else if decoded.Payee == nil {
+		return nil, fmt.Errorf("NodeInfo: %w: unable to determine payee", ErrInvalidInvoice)
+	}
+
+	info := &NodeInfo{
+		PubKey:     decoded.Payee,
+		RouteHints: decoded.RouteHints,
+	}
+	return info, nil
+}
+
+// WoSNodePubKeys lists the hex-encoded public keys of the lightning nodes known to be
+// operated by WoS, as used by [IsLikelyWoSInvoice]. WoS may add or rotate nodes at any
+// time, so applications can append to or replace this list, for example with keys
+// learned from [Wallet.NodeInfo]. It should not be modified while invoices are being
+// checked concurrently.
+var WoSNodePubKeys = []string{
+	"035e4ff418fc8b5554c5d9eea66396c227bd429a3251c8cbc711002ba215bfc226", // WalletOfSatoshi.com
+}
+
+// IsLikelyWoSInvoice reports whether a lightning invoice was probably issued by a WoS
+// wallet, without making any network requests, by checking whether its payee, or any
+// node in its route hints, is listed in [WoSNodePubKeys]. Payments between WoS wallets
+// settle internally, so this allows routing decisions to be made offline.
+//
+// This is only a heuristic: unlike [FeeEstimate.IsWosInvoice], it can be fooled by an
+// out-of-date [WoSNodePubKeys]. Returns an error wrapping [ErrInvalidInvoice] if the
+// invoice cannot be decoded.
+func IsLikelyWoSInvoice(invoice string) (bool, error) {
+	decoded, err := DecodeInvoice(strings.ToLower(strings.TrimSpace(invoice)))
+	if err != nil {
+		return false, err
+	}
+
+	if isWoSNode(decoded.Payee) {
+		return true, nil
+	}
+	for _, route := range decoded.RouteHints {
+		for _, hop := range route {
+			if isWoSNode(hop.PubKey) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// isWoSNode reports whether pubkey is listed in [WoSNodePubKeys].
+func isWoSNode(pubkey []byte) bool {
+	if len(pubkey) == 0 {
+		return false
+	}
+	for _, known := range WoSNodePubKeys {
+		if known, err := hex.DecodeString(known); err == nil && bytes.Equal(known, pubkey) {
+			return true
+		}
+	}
+	return false
+}
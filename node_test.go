@@ -0,0 +1,55 @@
+package wos
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestWalletNodeInfo(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"inv1","invoice":"` + testInvoiceDonation + `"}`))
+	})
+
+	info, err := wallet.NodeInfo(context.Background())
+	if err != nil {
+		t.Fatalf("NodeInfo failed: %v", err)
+	}
+
+	const want = "03e7156ae33b0a208d0744199163177e909e80176e55d97a2f221ede0f934dd9ad"
+	if hex.EncodeToString(info.PubKey) != want {
+		t.Fatalf("expected node id %s, got %x", want, info.PubKey)
+	}
+}
+
+func TestIsLikelyWoSInvoice(t *testing.T) {
+	defer func(original []string) { WoSNodePubKeys = original }(WoSNodePubKeys)
+
+	isWoS, err := IsLikelyWoSInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("IsLikelyWoSInvoice failed: %v", err)
+	} else if isWoS {
+		t.Fatalf("expected invoice from unknown node not to be a WoS invoice")
+	}
+
+	// Recognized by payee.
+	WoSNodePubKeys = append(WoSNodePubKeys, "03e7156ae33b0a208d0744199163177e909e80176e55d97a2f221ede0f934dd9ad")
+	if isWoS, _ := IsLikelyWoSInvoice(testInvoiceCoffee); !isWoS {
+		t.Fatalf("expected invoice from known node to be a WoS invoice")
+	}
+
+	// Recognized by route hint.
+	WoSNodePubKeys = []string{"039e03a901b85534ff1e92c43c74431f7ce72046060fcf7a95c37e148f78c77255"}
+	if isWoS, _ := IsLikelyWoSInvoice(testInvoiceRouteHints); !isWoS {
+		t.Fatalf("expected invoice routed through known node to be a WoS invoice")
+	}
+	if isWoS, _ := IsLikelyWoSInvoice(testInvoiceCoffee); isWoS {
+		t.Fatalf("expected invoice without known route hints not to be a WoS invoice")
+	}
+
+	if _, err := IsLikelyWoSInvoice("lnbc1garbage"); !errors.Is(err, ErrInvalidInvoice) {
+		t.Fatalf("expected ErrInvalidInvoice, got %v", err)
+	}
+}
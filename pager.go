@@ -0,0 +1,82 @@
+package wos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultHistoryPageSize is the page size used by [Reader.HistoryPager] if none is given.
+const DefaultHistoryPageSize = 20
+
+// HistoryPager fetches a wallet's payment history one page at a time, from newest to
+// oldest, as suits "infinite scroll" UIs which show recent payments first and load
+// older ones on demand. Create one with [Reader.HistoryPager].
+//
+// WoS pages by offset from the newest payment, so payments made while paging shift
+// later pages. HistoryPager drops payments repeated from the previous page because of
+// such a shift, so each payment is returned at most once, but payments newer than the
+// first page are not returned; use [HistorySync] or a new pager to pick those up.
+//
+// A HistoryPager is safe for concurrent use, though concurrent calls to Next are
+// serialized.
+type HistoryPager struct {
+	reader   *Reader
+	pageSize int
+
+	mu       sync.Mutex
+	skip     int
+	done     bool
+	previous map[string]bool
+}
+
+// HistoryPager returns a [HistoryPager] which fetches pageSize payments at a time, or
+// [DefaultHistoryPageSize] if pageSize is not positive.
+func (rdr *Reader) HistoryPager(pageSize int) *HistoryPager {
+	if pageSize <= 0 {
+		pageSize = DefaultHistoryPageSize
+	}
+	return &HistoryPager{reader: rdr, pageSize: pageSize}
+}
+
+// Next fetches the next page of older payments, ordered from newest to oldest, and
+// advances the pager past them. Once the oldest payment has been returned, Next returns
+// nil and [HistoryPager.HasMore] reports false. If an error occurs, the pager is unchanged
+// and Next may be retried.
+func (pager *HistoryPager) Next(ctx context.Context) ([]Payment, error) {
+	pager.mu.Lock()
+	defer pager.mu.Unlock()
+
+	if pager.done {
+		return nil, nil
+	}
+
+	page, total, err := pager.reader.PaymentsPage(ctx, pager.skip, pager.pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("HistoryPager: %w", err)
+	}
+
+	pager.skip += len(page)
+	if len(page) < pager.pageSize || (total >= 0 && pager.skip >= total) {
+		pager.done = true
+	}
+
+	payments := make([]Payment, 0, len(page))
+	current := make(map[string]bool, len(page))
+	for _, payment := range page {
+		current[payment.ID] = true
+		if !pager.previous[payment.ID] {
+			payments = append(payments, payment)
+		}
+	}
+	pager.previous = current
+	return payments, nil
+}
+
+// HasMore reports whether there may be older payments left to fetch with
+// [HistoryPager.Next]. It is true until Next reaches the end of the history.
+func (pager *HistoryPager) HasMore() bool {
+	pager.mu.Lock()
+	defer pager.mu.Unlock()
+	return !pager.done
+}
@@ -0,0 +1,74 @@
+package wos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHistoryPager(t *testing.T) {
+	// Five payments, p4 being the newest.
+	ids := []string{"p4", "p3", "p2", "p1", "p0"}
+	var requests int
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query := r.URL.Query()
+		if query.Get("reverse") != "true" {
+			t.Errorf("expected descending order, got query %v", query)
+		}
+		skip, _ := strconv.Atoi(query.Get("skip"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+
+		var records []string
+		for i := skip; i < skip+limit && i < len(ids); i++ {
+			records = append(records, fmt.Sprintf(`{"id":%q}`, ids[i]))
+		}
+		w.Write([]byte("[" + strings.Join(records, ",") + "]"))
+	}))
+	ctx := context.Background()
+
+	pager := rdr.HistoryPager(3)
+	if !pager.HasMore() {
+		t.Fatalf("expected HasMore before the first page")
+	}
+
+	page, err := pager.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	} else if len(page) != 3 || page[0].ID != "p4" || page[2].ID != "p2" {
+		t.Fatalf("unexpected first page: %+v", page)
+	} else if !pager.HasMore() {
+		t.Fatalf("expected HasMore after a full page")
+	}
+
+	page, err = pager.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	} else if len(page) != 2 || page[0].ID != "p1" || page[1].ID != "p0" {
+		t.Fatalf("unexpected second page: %+v", page)
+	} else if pager.HasMore() {
+		t.Fatalf("expected HasMore to be false after the last page")
+	}
+
+	if page, err := pager.Next(ctx); err != nil || page != nil {
+		t.Fatalf("expected no more pages, got %+v, %v", page, err)
+	} else if requests != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests)
+	}
+
+	// A payment arriving between pages shifts the history, repeating p2.
+	pager = rdr.HistoryPager(3)
+	if _, err := pager.Next(ctx); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	ids = append([]string{"p5"}, ids...)
+	page, err = pager.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	} else if len(page) != 2 || page[0].ID != "p1" || page[1].ID != "p0" {
+		t.Fatalf("expected repeated payment to be dropped, got %+v", page)
+	}
+}
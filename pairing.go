@@ -0,0 +1,73 @@
+package wos
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidPairingCode is returned by [ParsePairingCode] when given a string which is
+// not a pairing code, or which holds malformed credentials.
+var ErrInvalidPairingCode = errors.New("invalid pairing code")
+
+// base58Alphabet is the Bitcoin base58 alphabet, used by WoS API secrets.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// PairingCode encodes the credentials as a pairing code, which can be shown as a QR code
+// or opened as a deep link, so that another device can be set up by scanning it with
+// [ParsePairingCode] instead of copying the token and secret by hand. The code has the
+// form:
+//
+//	wos://pair?token=<APIToken>&secret=<APISecret>
+//
+// WoS does not publish a pairing format of its own, so this is specific to this package.
+//
+// A pairing code grants full control of the wallet, exactly like the credentials it
+// holds. Only display it to the wallet's owner, and never log it.
+func (creds Credentials) PairingCode() string {
+	query := url.Values{}
+	query.Set("token", creds.APIToken)
+	query.Set("secret", creds.APISecret)
+	code := url.URL{
+		Scheme:   "wos",
+		Host:     "pair",
+		RawQuery: query.Encode(),
+	}
+	return code.String()
+}
+
+// ParsePairingCode decodes credentials from a pairing code made by [Credentials.PairingCode].
+// Surrounding whitespace is ignored, and the scheme is case-insensitive, as QR scanners
+// may upper-case it.
+//
+// Returns an error wrapping [ErrInvalidPairingCode] if s is not a pairing code, or if the
+// token or secret is missing or malformed.
+func ParsePairingCode(s string) (*Credentials, error) {
+	code, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPairingCode, err)
+	} else if !strings.EqualFold(code.Scheme, "wos") || !strings.EqualFold(code.Host, "pair") {
+		return nil, fmt.Errorf("%w: expected wos://pair URI", ErrInvalidPairingCode)
+	}
+
+	query, err := url.ParseQuery(code.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPairingCode, err)
+	}
+	creds := &Credentials{
+		APIToken:  query.Get("token"),
+		APISecret: query.Get("secret"),
+	}
+
+	if creds.APIToken == "" {
+		return nil, fmt.Errorf("%w: missing token", ErrInvalidPairingCode)
+	} else if strings.IndexFunc(creds.APIToken, func(r rune) bool { return r <= ' ' || r > '~' }) >= 0 {
+		return nil, fmt.Errorf("%w: token contains invalid characters", ErrInvalidPairingCode)
+	} else if creds.APISecret == "" {
+		return nil, fmt.Errorf("%w: missing secret", ErrInvalidPairingCode)
+	} else if strings.Trim(creds.APISecret, base58Alphabet) != "" {
+		return nil, fmt.Errorf("%w: secret is not base58", ErrInvalidPairingCode)
+	}
+	return creds, nil
+}
@@ -0,0 +1,222 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DestinationKind classifies the destination of a payment.
+type DestinationKind int
+
+const (
+	DestinationOnChain          DestinationKind = iota // An on-chain bitcoin address.
+	DestinationInvoice                                 // A BOLT11 lightning invoice.
+	DestinationLightningAddress                        // A user@domain lightning address.
+)
+
+// ErrInvalidDestination is returned by [NormalizeDestination] when a payment
+// destination is empty or malformed.
+var ErrInvalidDestination = errors.New("invalid payment destination")
+
+// NormalizeDestination cleans up a payment destination pasted or scanned by a user, and
+// determines what kind of destination it is. It trims whitespace, strips any "lightning:"
+// or "bitcoin:" URI scheme and query parameters, and lowercases invoices, lightning
+// addresses and bech32 on-chain addresses, which are case-insensitive. Base58 on-chain
+// addresses are case-sensitive, and are left as they are.
+//
+// Returns an error wrapping [ErrInvalidDestination] if the destination is empty or
+// obviously malformed, or [ErrInvalidInvoice] if it is an invalid invoice. On-chain
+// addresses are not otherwise validated.
+func NormalizeDestination(s string) (string, DestinationKind, error) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+
+	onChainScheme := false
+	if strings.HasPrefix(lower, "lightning:") {
+		s = s[len("lightning:"):]
+	} else if strings.HasPrefix(lower, "bitcoin:") {
+		s = s[len("bitcoin:"):]
+		onChainScheme = true
+	}
+	s = strings.TrimPrefix(s, "//")
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	lower = strings.ToLower(s)
+
+	if s == "" || strings.ContainsAny(s, " \t\r\n") {
+		return "", 0, fmt.Errorf("%w: %q", ErrInvalidDestination, s)
+	}
+
+	switch {
+	case onChainScheme:
+		// Handled below.
+
+	case strings.HasPrefix(lower, "ln") && !strings.Contains(lower, "@"):
+		if _, err := parseInvoiceAmount(lower); err != nil && !errors.Is(err, ErrNoAmount) {
+			return "", 0, err
+		}
+		return lower, DestinationInvoice, nil
+
+	case strings.Contains(lower, "@"):
+		if _, err := ParseLightningAddress(lower); err != nil {
+			return "", 0, fmt.Errorf("%w: %w", ErrInvalidDestination, err)
+		}
+		return lower, DestinationLightningAddress, nil
+	}
+
+	for _, hrp := range []string{"bc1", "tb1", "bcrt1"} {
+		if strings.HasPrefix(lower, hrp) {
+			return lower, DestinationOnChain, nil
+		}
+	}
+	return s, DestinationOnChain, nil
+}
+
+// Pay sends amount BTC to the given destination, which may be a lightning invoice,
+// a lightning address, or an on-chain address. The destination is first cleaned up with
+// [NormalizeDestination]. The description is stored in the WoS payment history.
+//
+// A zero amount means "pay whatever the destination asks for", so it is only accepted
+// for fixed-amount invoices, for which it pays the invoice amount. A non-zero amount
+// must match the invoice amount, otherwise an error wrapping [ErrFixedAmount] is
+// returned. Every other destination, including amountless invoices, lightning
+// addresses and on-chain addresses, has no amount of its own, so a zero amount
+// returns an error wrapping [ErrAmountRequired] rather than attempting to pay nothing.
+func (wallet *Wallet) Pay(
+	ctx context.Context,
+	destination string,
+	amount float64,
+	description string,
+) (*Payment, error) {
+	destination, kind, err := NormalizeDestination(destination)
+	if err != nil {
+		return nil, fmt.Errorf("Pay: %w", err)
+	}
+
+	switch kind {
+	case DestinationInvoice:
+		invoiceAmount, err := parseInvoiceAmount(destination)
+		if errors.Is(err, ErrNoAmount) {
+			if amount == 0 {
+				return nil, fmt.Errorf("Pay: %w: invoice does not specify an amount", ErrAmountRequired)
+			}
+			return wallet.PayVariableInvoice(ctx, destination, description, amount)
+		} else if err != nil {
+			return nil, fmt.Errorf("Pay: %w", err)
+		} else if amount != 0 && amount != invoiceAmount {
+			return nil, fmt.Errorf(
+				"Pay: %w: invoice requests %.8f BTC, not %.8f BTC",
+				ErrFixedAmount, invoiceAmount, amount,
+			)
+		}
+		return wallet.PayInvoice(ctx, destination, description)
+	}
+
+	if amount == 0 {
+		return nil, fmt.Errorf("Pay: %w", ErrAmountRequired)
+	}
+
+	switch kind {
+	case DestinationLightningAddress:
+		lnAddress, err := ParseLightningAddress(destination)
+		if err != nil {
+			return nil, fmt.Errorf("Pay: %w", err)
+		}
+		return wallet.PayLightningAddress(ctx, lnAddress, description, amount)
+
+	default:
+		return wallet.PayOnChain(ctx, destination, amount, description)
+	}
+}
+
+var (
+	// ErrAmountRequired is returned by [ValidatePaymentInput] when no amount is given
+	// for a destination which needs one.
+	ErrAmountRequired = errors.New("amount required")
+
+	// ErrNegativeAmount is returned by [ValidatePaymentInput] for negative or non-finite amounts.
+	ErrNegativeAmount = errors.New("amount must be a positive number")
+
+	// ErrAmountBelowDust is returned by [ValidatePaymentInput] for on-chain amounts below
+	// [DustLimit], which the bitcoin network will not relay.
+	ErrAmountBelowDust = errors.New("amount is below the dust limit")
+
+	// ErrAmountTooLarge is returned by [ValidatePaymentInput] for amounts exceeding
+	// the total bitcoin supply, which are surely a mistake.
+	ErrAmountTooLarge = errors.New("amount exceeds the bitcoin supply")
+)
+
+// DustLimit is the smallest BTC amount which can be sent on-chain, below which
+// outputs are considered dust and not relayed by the bitcoin network.
+const DustLimit = 0.00000546
+
+// maxBitcoinSupply is the total number of bitcoins which will ever exist.
+const maxBitcoinSupply = 21_000_000
+
+// ValidatePaymentInput checks a destination and BTC amount entered by a user, as would
+// be passed to [Wallet.Pay], without making any network requests. This lets a form or
+// CLI reject bad input before submitting it. The destination is classified as with
+// [NormalizeDestination], and the amount is checked against it:
+//
+//   - Fixed-amount invoices need an amount of zero, or exactly the invoice amount,
+//     otherwise an error wrapping [ErrFixedAmount] is returned.
+//   - Other destinations need an amount, or [ErrAmountRequired] is returned.
+//   - Negative amounts wrap [ErrNegativeAmount], and amounts larger than the bitcoin
+//     supply wrap [ErrAmountTooLarge].
+//   - On-chain amounts below [DustLimit] wrap [ErrAmountBelowDust].
+//
+// Passing validation does not guarantee that a payment will succeed: on-chain addresses
+// are not fully validated, and the wallet's balance and fees are not checked.
+func ValidatePaymentInput(destination string, amount float64) error {
+	destination, kind, err := NormalizeDestination(destination)
+	if err != nil {
+		return err
+	}
+
+	if amount < 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return fmt.Errorf("%w: %v", ErrNegativeAmount, amount)
+	} else if amount > maxBitcoinSupply {
+		return fmt.Errorf("%w: %.8f BTC", ErrAmountTooLarge, amount)
+	}
+
+	switch kind {
+	case DestinationInvoice:
+		decoded, err := DecodeInvoice(destination)
+		if err != nil {
+			return err
+		}
+		if decoded.Amount != 0 {
+			if amount != 0 && amount != decoded.Amount {
+				return fmt.Errorf(
+					"%w: invoice requests %.8f BTC, not %.8f BTC",
+					ErrFixedAmount, decoded.Amount, amount,
+				)
+			}
+			return nil
+		}
+		if amount == 0 {
+			return fmt.Errorf("%w: invoice does not specify an amount", ErrAmountRequired)
+		}
+		return decoded.CheckAmount(amount)
+
+	case DestinationOnChain:
+		if amount == 0 {
+			return ErrAmountRequired
+		} else if amount < DustLimit {
+			return fmt.Errorf("%w: %.8f BTC is less than %.8f BTC", ErrAmountBelowDust, amount, DustLimit)
+		}
+
+	default:
+		if amount == 0 {
+			return ErrAmountRequired
+		} else if amount < fromMillisat(1) {
+			return fmt.Errorf("%w: %.11f BTC is less than one millisatoshi", ErrInvalidAmount, amount)
+		}
+	}
+	return nil
+}
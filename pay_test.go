@@ -0,0 +1,128 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeDestination(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		kind  DestinationKind
+	}{
+		{"  " + testInvoiceCoffee + "\n", testInvoiceCoffee, DestinationInvoice},
+		{"lightning:" + strings.ToUpper(testInvoiceCoffee), testInvoiceCoffee, DestinationInvoice},
+		{"LIGHTNING:" + testInvoiceDonation, testInvoiceDonation, DestinationInvoice},
+		{"Bob@GetAlby.com", "bob@getalby.com", DestinationLightningAddress},
+		{"lightning:bob@getalby.com ", "bob@getalby.com", DestinationLightningAddress},
+		{"bitcoin:BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ?amount=0.001&label=x", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", DestinationOnChain},
+		{"bitcoin://1BoatSLRHtKNngkdXEeobR76b53LETtpyT", "1BoatSLRHtKNngkdXEeobR76b53LETtpyT", DestinationOnChain},
+		{"\t3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy ", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", DestinationOnChain},
+	}
+
+	for _, test := range tests {
+		got, kind, err := NormalizeDestination(test.input)
+		if err != nil {
+			t.Errorf("NormalizeDestination(%q) failed: %v", test.input, err)
+		} else if got != test.want || kind != test.kind {
+			t.Errorf("NormalizeDestination(%q): expected %q (%d), got %q (%d)",
+				test.input, test.want, test.kind, got, kind)
+		}
+	}
+
+	for _, input := range []string{"", "   ", "lightning:", "bitcoin:?amount=1", "not an address", "bob@"} {
+		if _, _, err := NormalizeDestination(input); !errors.Is(err, ErrInvalidDestination) {
+			t.Errorf("NormalizeDestination(%q): expected ErrInvalidDestination, got %v", input, err)
+		}
+	}
+}
+
+func TestValidatePaymentInput(t *testing.T) {
+	valid := []struct {
+		destination string
+		amount      float64
+	}{
+		{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0.001},
+		{"bitcoin:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq?amount=0.001", DustLimit},
+		{"bob@getalby.com", 0.00000001},
+		{testInvoiceCoffee, 0},
+		{testInvoiceCoffee, 0.0025},
+		{"lightning:" + testInvoiceDonation, 0.0001},
+	}
+	for _, input := range valid {
+		if err := ValidatePaymentInput(input.destination, input.amount); err != nil {
+			t.Fatalf("expected (%q, %.8f) to be valid, got %v", input.destination, input.amount, err)
+		}
+	}
+
+	invalid := []struct {
+		destination string
+		amount      float64
+		wantErr     error
+	}{
+		{"", 0.001, ErrInvalidDestination},
+		{"bob@", 0.001, ErrInvalidDestination},
+		{"lnbc1invalid", 0, ErrInvalidInvoice},
+		{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0, ErrAmountRequired},
+		{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0.000001, ErrAmountBelowDust},
+		{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", -1, ErrNegativeAmount},
+		{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 22_000_000, ErrAmountTooLarge},
+		{"bob@getalby.com", 0, ErrAmountRequired},
+		{"bob@getalby.com", 1e-12, ErrInvalidAmount},
+		{testInvoiceCoffee, 0.003, ErrFixedAmount},
+		{testInvoiceDonation, 0, ErrAmountRequired},
+		{testInvoiceDonation, math.NaN(), ErrNegativeAmount},
+	}
+	for _, input := range invalid {
+		if err := ValidatePaymentInput(input.destination, input.amount); !errors.Is(err, input.wantErr) {
+			t.Fatalf("expected %v for (%q, %.8f), got %v", input.wantErr, input.destination, input.amount, err)
+		}
+	}
+}
+
+func TestPayZeroAmount(t *testing.T) {
+	var requests int
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/api/v1/wallet/lnurl":
+			w.Write([]byte(`{"callback":"https://getalby.com/cb","minSendable":1000,"maxSendable":100000000000}`))
+		default:
+			w.Write([]byte(`{"id":"payment"}`))
+		}
+	})
+
+	tests := []struct {
+		destination string
+		amount      float64
+		err         error
+	}{
+		{testInvoiceCoffee, 0, nil},
+		{testInvoiceCoffee, 0.0025, nil},
+		{testInvoiceCoffee, 0.001, ErrFixedAmount},
+		{testInvoiceDonation, 0, ErrAmountRequired},
+		{testInvoiceDonation, 0.0001, nil},
+		{"someone@getalby.com", 0, ErrAmountRequired},
+		{"someone@getalby.com", 0.0001, nil},
+		{"bc1qexample", 0, ErrAmountRequired},
+		{"bc1qexample", 0.001, nil},
+	}
+	for _, test := range tests {
+		requests = 0
+		payment, err := wallet.Pay(context.Background(), test.destination, test.amount, "")
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("Pay(%.20s, %v): expected %v, got %v", test.destination, test.amount, test.err, err)
+			} else if requests != 0 {
+				t.Errorf("Pay(%.20s, %v): made %d requests before failing", test.destination, test.amount, requests)
+			}
+		} else if err != nil || payment.ID != "payment" {
+			t.Errorf("Pay(%.20s, %v) failed: %v", test.destination, test.amount, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package wos
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// PayoutConcurrency is the maximum number of recipients [Wallet.Payout] pays at once.
+const PayoutConcurrency = 4
+
+// PayoutResult is the outcome of paying a single recipient in [Wallet.Payout].
+type PayoutResult struct {
+	Recipient LightningAddress
+	Amount    float64
+
+	// Payment is the payment made to the recipient, or nil if paying them failed.
+	Payment *Payment
+
+	// Err is the reason paying the recipient failed, or nil if it succeeded.
+	Err error
+}
+
+// Payout pays each recipient lightning address its own BTC amount, such as for affiliate
+// commissions or payroll. Each recipient's LNURL-pay service is resolved, and an invoice
+// requested from it and paid, as with [Wallet.PayLightningAddress]. Up to
+// [PayoutConcurrency] recipients are paid at once. The description is stored in the WoS
+// payment history for each payment.
+//
+// Failures are never retried, as the outcome of a failed payment may be unknown. The
+// returned results are ordered by recipient address, and report success or failure for
+// each recipient; check each result's Err. Unlike [Wallet.PayBatch], progress is not
+// persisted. If ctx is done before every recipient is paid, the recipients not yet
+// paid fail with the context's error, which is also returned.
+func (wallet *Wallet) Payout(
+	ctx context.Context,
+	recipients map[LightningAddress]float64,
+	description string,
+) ([]PayoutResult, error) {
+	results := make([]PayoutResult, 0, len(recipients))
+	for recipient, amount := range recipients {
+		results = append(results, PayoutResult{Recipient: recipient, Amount: amount})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Recipient.String() < results[j].Recipient.String()
+	})
+
+	slots := make(chan struct{}, PayoutConcurrency)
+	var wg sync.WaitGroup
+	for i := range results {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *PayoutResult) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result.Payment, result.Err = wallet.PayLightningAddress(ctx, result.Recipient, description, result.Amount)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results, ctx.Err()
+}
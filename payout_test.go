@@ -0,0 +1,52 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPayout(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/lnurl":
+			var body struct{ Address string }
+			json.NewDecoder(r.Body).Decode(&body)
+			if strings.Contains(body.Address, "down.example") {
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte(`{"message":"LNURL service unavailable"}`))
+				return
+			}
+			w.Write([]byte(`{"tag":"payRequest","callback":"https://getalby.com/cb","minSendable":1000,"maxSendable":100000000}`))
+		case "/api/v1/wallet/lnPay":
+			var body struct{ Amount uint64 }
+			json.NewDecoder(r.Body).Decode(&body)
+			json.NewEncoder(w).Encode(Payment{ID: "paid", Amount: fromMillisat(body.Amount)})
+		default:
+			// The failed recipient's domain is probed, and is unreachable too.
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+
+	alice := LightningAddress{"alice", "getalby.com"}
+	bob := LightningAddress{"bob", "down.example"}
+	results, err := wallet.Payout(context.Background(), map[LightningAddress]float64{
+		alice: 0.0001,
+		bob:   0.0002,
+	}, "commission")
+	if err != nil {
+		t.Fatalf("Payout failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0].Recipient != alice || results[1].Recipient != bob {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[0].Err != nil || results[0].Payment == nil || results[0].Payment.Amount != 0.0001 {
+		t.Fatalf("expected alice to be paid 0.0001 BTC, got %+v", results[0])
+	}
+	if results[1].Err == nil || results[1].Payment != nil || results[1].Amount != 0.0002 {
+		t.Fatalf("expected paying bob to fail, got %+v", results[1])
+	}
+}
@@ -0,0 +1,103 @@
+package wos
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrCertificatePinMismatch is returned when a TLS connection to the WoS API presents
+// no certificate whose public key matches any pin given to [NewPinnedTransport].
+var ErrCertificatePinMismatch = errors.New("TLS certificate does not match any pinned public key")
+
+// SPKIHash returns the pin of cert's public key: the base64-encoded SHA256 hash of its
+// DER-encoded SubjectPublicKeyInfo, in the same format as HPKP's pin-sha256.
+//
+// The pins of a live server can be computed with openssl:
+//
+//	openssl s_client -connect www.livingroomofsatoshi.com:443 </dev/null 2>/dev/null |
+//	  openssl x509 -pubkey -noout |
+//	  openssl pkey -pubin -outform der |
+//	  openssl dgst -sha256 -binary | base64
+func SPKIHash(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// NewPinnedTransport returns an [http.Transport] which, on top of the usual certificate
+// verification, requires TLS connections to the WoS API host to present a certificate
+// chain containing at least one public key whose [SPKIHash] is among pins. Connections
+// failing this check are closed before any request is sent, and the request fails with
+// an error wrapping [ErrCertificatePinMismatch]. This applies to connections tunnelled
+// through a proxy too. Connections to other hosts, such as LNURL services, are verified
+// as usual but not pinned.
+//
+// Use it as the Transport of the [http.Client] passed to [NewReader]:
+//
+//	transport, err := wos.NewPinnedTransport(pins...)
+//	if err != nil {
+//		return err
+//	}
+//	reader := wos.NewReader(apiToken, &http.Client{Transport: transport})
+//
+// Pinning protects against a compromised or coerced certificate authority, at the cost
+// of maintenance: when WoS rotates its key, every request fails until the pins are
+// updated. To reduce this risk, pin an intermediate or root CA key as well as the leaf,
+// keep a backup pin for the next expected key, and make the pins configurable so they
+// can be changed without rebuilding.
+func NewPinnedTransport(pins ...string) (*http.Transport, error) {
+	base, err := url.Parse(BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return newPinnedTransport(base.Hostname(), nil, pins)
+}
+
+// newPinnedTransport returns a transport which pins connections to host. Certificates are
+// verified against roots, or the system roots if roots is nil.
+func newPinnedTransport(host string, roots *x509.CertPool, pins []string) (*http.Transport, error) {
+	if len(pins) == 0 {
+		return nil, errors.New("NewPinnedTransport: no pins given")
+	}
+	pinSet := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		if hash, err := base64.StdEncoding.DecodeString(pin); err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("NewPinnedTransport: invalid pin %q: expected base64 SHA256 hash", pin)
+		}
+		pinSet[pin] = true
+	}
+
+	// The pins are checked while verifying the TLS handshake rather than in a custom
+	// dialer, because net/http bypasses custom TLS dialers when tunnelling through an
+	// HTTPS proxy, such as one set by the HTTPS_PROXY environment variable.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs: roots,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if !strings.EqualFold(state.ServerName, host) {
+				return nil
+			}
+			return checkPins(state, pinSet)
+		},
+	}
+	return transport, nil
+}
+
+// checkPins returns an error wrapping [ErrCertificatePinMismatch] unless a certificate in
+// one of the verified chains of state has a public key in pins.
+func checkPins(state tls.ConnectionState, pins map[string]bool) error {
+	for _, chain := range state.VerifiedChains {
+		for _, cert := range chain {
+			if pins[SPKIHash(cert)] {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrCertificatePinMismatch, state.ServerName)
+}
@@ -0,0 +1,114 @@
+package wos
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPinnedTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	// The test certificate is valid for example.com, which is resolved to the test
+	// server, because TLS does not report IP addresses as the server name.
+	const host = "example.com"
+	serverURL, _ := url.Parse(server.URL)
+	serverAddr := serverURL.Host
+	serverURL.Host = host + ":" + serverURL.Port()
+	newTransport := func(pinnedHost string, pins ...string) *http.Transport {
+		transport, err := newPinnedTransport(pinnedHost, roots, pins)
+		if err != nil {
+			t.Fatalf("failed to create transport: %v", err)
+		}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if strings.HasPrefix(addr, host+":") {
+				addr = serverAddr
+			}
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		}
+		return transport
+	}
+
+	goodPin := SPKIHash(server.Certificate())
+	badPin := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+	get := func(transport *http.Transport) error {
+		resp, err := (&http.Client{Transport: transport}).Get(serverURL.String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(newTransport(host, badPin, goodPin)); err != nil {
+		t.Fatalf("expected matching pin to be accepted: %v", err)
+	}
+	if err := get(newTransport(host, badPin)); !errors.Is(err, ErrCertificatePinMismatch) {
+		t.Fatalf("expected ErrCertificatePinMismatch, got %v", err)
+	}
+
+	// Other hosts are not pinned.
+	if err := get(newTransport("www.example.com", badPin)); err != nil {
+		t.Fatalf("expected unpinned host to be accepted: %v", err)
+	}
+
+	// Pins are still checked when tunnelling through a proxy.
+	proxy := httptest.NewServer(connectProxy(serverAddr))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	transport := newTransport(host, badPin)
+	transport.Proxy = http.ProxyURL(proxyURL)
+	if err := get(transport); !errors.Is(err, ErrCertificatePinMismatch) {
+		t.Fatalf("expected ErrCertificatePinMismatch through a proxy, got %v", err)
+	}
+	transport = newTransport(host, goodPin)
+	transport.Proxy = http.ProxyURL(proxyURL)
+	if err := get(transport); err != nil {
+		t.Fatalf("expected matching pin to be accepted through a proxy: %v", err)
+	}
+
+	if _, err := NewPinnedTransport("not a pin"); err == nil {
+		t.Fatalf("expected invalid pin to be rejected")
+	}
+}
+
+// connectProxy returns a minimal HTTP proxy which tunnels every CONNECT request to target.
+func connectProxy(target string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		upstream, err := net.Dial("tcp", target)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}
+}
@@ -0,0 +1,117 @@
+package wos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultPreparedSendTTL is how long a send prepared with [Wallet.PrepareSend] can be
+// confirmed for, unless changed with [Wallet.SetPreparedSendTTL].
+const DefaultPreparedSendTTL = 2 * time.Minute
+
+// ErrInvalidSendToken is returned by [Wallet.ConfirmSend] when the token is unknown,
+// has expired, or has already been used.
+var ErrInvalidSendToken = errors.New("send token is invalid, expired or already used")
+
+// PreparedSend describes a payment prepared by [Wallet.PrepareSend], which is not
+// sent until its Token is passed to [Wallet.ConfirmSend].
+type PreparedSend struct {
+	// Token confirms the send when passed to [Wallet.ConfirmSend]. It can be used once.
+	Token string
+
+	// Destination is the normalized payment destination.
+	Destination string
+
+	// Cost is the estimated cost of the payment, to show the user before they confirm.
+	Cost *CostBreakdown
+
+	// Expires is the time after which Token is no longer accepted.
+	Expires time.Time
+}
+
+// SetPreparedSendTTL sets how long sends prepared with [Wallet.PrepareSend] can be
+// confirmed for. A TTL of zero or less restores [DefaultPreparedSendTTL].
+func (wallet *Wallet) SetPreparedSendTTL(ttl time.Duration) {
+	wallet.preparedMu.Lock()
+	defer wallet.preparedMu.Unlock()
+	wallet.preparedTTL = ttl
+}
+
+// PrepareSend estimates the cost of sending amount to destination, which may be
+// anything accepted by [Wallet.Pay], without sending anything. The payment is only
+// made once the returned token is passed to [Wallet.ConfirmSend], enforcing a
+// deliberate two-step flow for high-value sends.
+//
+// The token expires after [DefaultPreparedSendTTL], or the TTL set with
+// [Wallet.SetPreparedSendTTL], and can only be confirmed once.
+func (wallet *Wallet) PrepareSend(ctx context.Context, destination string, amount float64) (*PreparedSend, error) {
+	destination, _, err := NormalizeDestination(destination)
+	if err != nil {
+		return nil, fmt.Errorf("PrepareSend: %w", err)
+	}
+
+	cost, err := wallet.TotalCost(ctx, destination, amount)
+	if err != nil {
+		return nil, fmt.Errorf("PrepareSend: %w", err)
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("PrepareSend: generating token: %w", err)
+	}
+
+	wallet.preparedMu.Lock()
+	defer wallet.preparedMu.Unlock()
+
+	ttl := wallet.preparedTTL
+	if ttl <= 0 {
+		ttl = DefaultPreparedSendTTL
+	}
+	now := clockOrDefault(wallet.clock).Now()
+	prepared := &PreparedSend{
+		Token:       hex.EncodeToString(tokenBytes),
+		Destination: destination,
+		Cost:        cost,
+		Expires:     now.Add(ttl),
+	}
+
+	// Forget expired sends, so abandoned tokens do not accumulate.
+	for token, old := range wallet.prepared {
+		if !now.Before(old.Expires) {
+			delete(wallet.prepared, token)
+		}
+	}
+	if wallet.prepared == nil {
+		wallet.prepared = make(map[string]*PreparedSend)
+	}
+	wallet.prepared[prepared.Token] = prepared
+
+	copied := *prepared
+	return &copied, nil
+}
+
+// ConfirmSend makes the payment prepared by [Wallet.PrepareSend] with the given token.
+// Returns an error wrapping [ErrInvalidSendToken] if the token is unknown, expired,
+// or was already confirmed. The token is used up even if the payment fails.
+func (wallet *Wallet) ConfirmSend(ctx context.Context, token string) (*Payment, error) {
+	wallet.preparedMu.Lock()
+	prepared, ok := wallet.prepared[token]
+	delete(wallet.prepared, token)
+	wallet.preparedMu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("ConfirmSend: %w", ErrInvalidSendToken)
+	} else if !clockOrDefault(wallet.clock).Now().Before(prepared.Expires) {
+		return nil, fmt.Errorf("ConfirmSend: %w: expired at %s", ErrInvalidSendToken, prepared.Expires)
+	}
+
+	payment, err := wallet.Pay(ctx, prepared.Destination, prepared.Cost.Amount, "")
+	if err != nil {
+		return nil, fmt.Errorf("ConfirmSend: %w", err)
+	}
+	return payment, nil
+}
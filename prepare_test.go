@@ -0,0 +1,62 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPrepareSend(t *testing.T) {
+	var sent []sendPaymentRequest
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/wallet/payment" {
+			var req sendPaymentRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			sent = append(sent, req)
+			w.Write([]byte(`{"id":"payment"}`))
+			return
+		}
+		w.Write([]byte(`{"btcFixedFee":0.00002,"btcSendCommissionPercent":0.01}`))
+	})
+	clock := newFakeClock()
+	wallet.SetClock(clock)
+	ctx := context.Background()
+
+	prepared, err := wallet.PrepareSend(ctx, "bitcoin:bc1qdestination", 0.01)
+	if err != nil {
+		t.Fatalf("PrepareSend failed: %v", err)
+	} else if prepared.Destination != "bc1qdestination" || prepared.Cost.Amount != 0.01 || len(sent) != 0 {
+		t.Fatalf("unexpected prepared send: %+v", prepared)
+	} else if !prepared.Expires.Equal(clock.Now().Add(DefaultPreparedSendTTL)) {
+		t.Fatalf("unexpected expiry: %s", prepared.Expires)
+	}
+
+	payment, err := wallet.ConfirmSend(ctx, prepared.Token)
+	if err != nil {
+		t.Fatalf("ConfirmSend failed: %v", err)
+	} else if payment.ID != "payment" || len(sent) != 1 || sent[0].Address != "bc1qdestination" || sent[0].Amount != 0.01 {
+		t.Fatalf("unexpected payment %+v from requests %+v", payment, sent)
+	}
+
+	if _, err := wallet.ConfirmSend(ctx, prepared.Token); !errors.Is(err, ErrInvalidSendToken) {
+		t.Fatalf("expected reused token to be rejected, got %v", err)
+	}
+	if _, err := wallet.ConfirmSend(ctx, "unknown"); !errors.Is(err, ErrInvalidSendToken) {
+		t.Fatalf("expected unknown token to be rejected, got %v", err)
+	}
+
+	wallet.SetPreparedSendTTL(time.Minute)
+	prepared, err = wallet.PrepareSend(ctx, "bc1qdestination", 0.01)
+	if err != nil {
+		t.Fatalf("PrepareSend failed: %v", err)
+	}
+	<-clock.After(time.Minute)
+	if _, err := wallet.ConfirmSend(ctx, prepared.Token); !errors.Is(err, ErrInvalidSendToken) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	} else if len(sent) != 1 {
+		t.Fatalf("expected no payment for rejected tokens, got %d", len(sent))
+	}
+}
@@ -0,0 +1,241 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ProvisionOptions customizes the behavior of [ProvisionWallets].
+type ProvisionOptions struct {
+	// Concurrency is the maximum number of wallets which will be created in parallel.
+	// Defaults to 4.
+	Concurrency int
+
+	// MaxAttempts is the maximum number of times creating any single wallet
+	// will be attempted before giving up. Defaults to 5.
+	MaxAttempts int
+
+	// InitialBackoff is how long to wait before the first retry of a failed
+	// wallet creation. The delay doubles after each failed attempt. Defaults
+	// to 1 second.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries. Defaults to 30 seconds.
+	MaxBackoff time.Duration
+}
+
+// WalletWithCreds pairs a newly created [Wallet] with the [Credentials]
+// needed to re-open it later.
+type WalletWithCreds struct {
+	Wallet      *Wallet
+	Credentials *Credentials
+}
+
+// ProvisionWallets creates n brand new wallets using [CreateWallet], with bounded
+// concurrency and a per-wallet retry loop which backs off exponentially. This is more
+// robust than looping over [CreateWallet] when creating many wallets at once, as the
+// WoS API will start responding with [ErrRateLimited] errors if flooded.
+//
+// Only rate limits, server errors and network errors are retried, waiting at least as
+// long as WoS asks in any Retry-After header. Other errors, such as a request rejected
+// with a client error status, fail that wallet immediately.
+//
+// The [ProvisionOptions] argument customizes the concurrency and retry behavior. opts
+// can be nil, in which case defaults are used.
+//
+// ProvisionWallets returns every wallet which was successfully created, even if some
+// failed. The returned error joins together the final error of every wallet which could
+// not be created.
+func ProvisionWallets(
+	ctx context.Context,
+	n int,
+	httpClient *http.Client,
+	opts *ProvisionOptions,
+) ([]*WalletWithCreds, error) {
+	if opts == nil {
+		opts = &ProvisionOptions{}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	initialBackoff := opts.InitialBackoff
+	if initialBackoff <= 0 {
+		initialBackoff = time.Second
+	}
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []*WalletWithCreds
+		errs    []error
+	)
+
+	sem := make(chan struct{}, concurrency)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("wallet %d: %w", i, ctx.Err()))
+				mu.Unlock()
+				return
+			}
+			defer func() { <-sem }()
+
+			wallet, creds, err := createWalletWithRetry(ctx, httpClient, maxAttempts, initialBackoff, maxBackoff)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("wallet %d: %w", i, err))
+				return
+			}
+			results = append(results, &WalletWithCreds{wallet, creds})
+		}(i)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, fmt.Errorf("ProvisionWallets: %w", errors.Join(errs...))
+	}
+	return results, nil
+}
+
+func createWalletWithRetry(
+	ctx context.Context,
+	httpClient *http.Client,
+	maxAttempts int,
+	backoff, maxBackoff time.Duration,
+) (*Wallet, *Credentials, error) {
+	for attempt := 1; ; attempt++ {
+		wallet, creds, err := CreateWallet(ctx, httpClient)
+		if err == nil {
+			return wallet, creds, nil
+		} else if attempt >= maxAttempts || ctx.Err() != nil || !isTransientCreateError(err) {
+			return nil, nil, err
+		}
+
+		timer := time.NewTimer(provisionBackoff(err, backoff))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, err
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// isTransientCreateError returns true if creating a wallet failed because of a rate
+// limit, a server error or a network error, any of which may succeed if retried.
+// Other errors, such as a request WoS rejected as invalid, will fail every time.
+func isTransientCreateError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return errors.Is(apiErr, ErrRateLimited) || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// provisionBackoff returns how long to wait before retrying after err: the given
+// backoff, or longer if WoS asked for a longer wait with a Retry-After header.
+func provisionBackoff(err error, backoff time.Duration) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > backoff {
+		return apiErr.RetryAfter
+	}
+	return backoff
+}
+
+// DefaultProvisioningPollInterval is the interval at which [Wallet.WaitForProvisioning]
+// polls WoS if no interval is given.
+const DefaultProvisioningPollInterval = time.Second
+
+// onChainProvisioningGrace is how long [Wallet.WaitForProvisioning] keeps waiting for an
+// on-chain address once the lightning address is available. WoS reports an on-chain
+// address which is not yet provisioned the same way as one which is unavailable in the
+// wallet's region, so after this long the wallet is assumed to have none.
+const onChainProvisioningGrace = 30 * time.Second
+
+// WaitForProvisioning blocks until WoS has provisioned the wallet's addresses, polling
+// every pollInterval, or [DefaultProvisioningPollInterval] if pollInterval is zero. The
+// wallet's cached addresses are updated as they appear, so that afterwards
+// [Wallet.LightningAddress] and [Wallet.OnChainAddress] return them.
+//
+// Addresses are usually available as soon as [CreateWallet] returns, but for brand new
+// wallets WoS can take a few seconds to provision them. The lightning address is always
+// waited for. As WoS does not offer on-chain addresses in every region, the on-chain
+// address is only waited for up to 30 seconds after the lightning address appears, after
+// which WaitForProvisioning returns without it.
+func (wallet *Wallet) WaitForProvisioning(ctx context.Context, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = DefaultProvisioningPollInterval
+	}
+
+	clock := clockOrDefault(wallet.clock)
+	var lightningSince time.Time
+	var lastErr error
+	for {
+		addresses, err := wallet.reader.fetchAddresses(ctx)
+		if err == nil {
+			var lnAddress LightningAddress
+			lnAddress, err = parseProvisionedLightningAddress(addresses.Lightning)
+			if err == nil {
+				wallet.addressMu.Lock()
+				if addresses.OnChain != "" {
+					wallet.onChainAddress = addresses.OnChain
+				}
+				if !lnAddress.IsZero() {
+					wallet.lightningAddress = lnAddress
+				}
+				wallet.addressesFetched = time.Now()
+				wallet.addressMu.Unlock()
+
+				if !lnAddress.IsZero() {
+					if lightningSince.IsZero() {
+						lightningSince = clock.Now()
+					}
+					if addresses.OnChain != "" || clock.Now().Sub(lightningSince) >= onChainProvisioningGrace {
+						return nil
+					}
+				}
+			}
+		}
+		lastErr = err
+
+		select {
+		case <-clock.After(pollInterval):
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("WaitForProvisioning: %w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return fmt.Errorf("WaitForProvisioning: %w", ctx.Err())
+		}
+	}
+}
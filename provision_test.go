@@ -0,0 +1,141 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProvisionWallets(t *testing.T) {
+	var requests, rateLimited atomic.Int32
+
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if n == 2 {
+			rateLimited.Add(1)
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message":"slow down"}`))
+			return
+		}
+		fmt.Fprintf(w,
+			`{"apiSecret":"secret%d","apiToken":"token%d","btcDepositAddress":"bc1qexample","lightningAddress":"user%d@walletofsatoshi.com"}`,
+			n, n, n,
+		)
+	})
+
+	wallets, err := ProvisionWallets(context.Background(), 5, httpClient, &ProvisionOptions{
+		Concurrency:    2,
+		InitialBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to provision wallets: %v", err)
+	}
+
+	if len(wallets) != 5 {
+		t.Fatalf("expected 5 wallets, got %d", len(wallets))
+	} else if rateLimited.Load() != 1 {
+		t.Fatalf("expected 1 rate-limited request, got %d", rateLimited.Load())
+	} else if requests.Load() != 6 {
+		t.Fatalf("expected 6 total requests, got %d", requests.Load())
+	}
+
+	seen := make(map[string]bool)
+	for _, w := range wallets {
+		if w.Wallet == nil || w.Credentials == nil {
+			t.Fatalf("incomplete provisioning result: %+v", w)
+		}
+		if seen[w.Credentials.APIToken] {
+			t.Fatalf("duplicate wallet token %q", w.Credentials.APIToken)
+		}
+		seen[w.Credentials.APIToken] = true
+	}
+}
+
+func TestProvisionWalletsClientError(t *testing.T) {
+	var requests atomic.Int32
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"bad request"}`))
+	})
+
+	wallets, err := ProvisionWallets(context.Background(), 2, httpClient, &ProvisionOptions{
+		InitialBackoff: time.Millisecond,
+	})
+	if err == nil || len(wallets) != 0 {
+		t.Fatalf("expected provisioning to fail, got %d wallets and %v", len(wallets), err)
+	} else if requests.Load() != 2 {
+		t.Fatalf("expected client errors not to be retried, got %d requests", requests.Load())
+	}
+}
+
+func TestProvisionRetryAfter(t *testing.T) {
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message":"slow down"}`))
+	})
+
+	_, _, err := CreateWallet(context.Background(), httpClient)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 7*time.Second {
+		t.Fatalf("expected Retry-After of 7s, got %v", err)
+	} else if !isTransientCreateError(err) {
+		t.Fatalf("expected rate limit to be retried")
+	}
+	if backoff := provisionBackoff(err, time.Second); backoff != 7*time.Second {
+		t.Fatalf("expected backoff to honour Retry-After, got %s", backoff)
+	} else if backoff := provisionBackoff(err, time.Minute); backoff != time.Minute {
+		t.Fatalf("expected longer backoff to be kept, got %s", backoff)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	date := now.Add(90 * time.Second).Format(http.TimeFormat)
+	if d := parseRetryAfter(date, now); d != 90*time.Second {
+		t.Fatalf("expected 90s from HTTP date, got %s", d)
+	} else if d := parseRetryAfter("soon", now); d != 0 {
+		t.Fatalf("expected malformed header to be ignored, got %s", d)
+	}
+}
+
+func TestWaitForProvisioning(t *testing.T) {
+	var polls int
+	onChain := true
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		switch {
+		case polls < 3:
+			w.Write([]byte(`{"btcDepositAddress":"","lightningAddress":""}`))
+		case !onChain:
+			w.Write([]byte(`{"btcDepositAddress":"","lightningAddress":"fresh@walletofsatoshi.com"}`))
+		default:
+			w.Write([]byte(`{"btcDepositAddress":"bc1qfresh","lightningAddress":"fresh@walletofsatoshi.com"}`))
+		}
+	})
+	wallet.lightningAddress = LightningAddress{}
+	wallet.SetClock(newFakeClock())
+
+	if !wallet.LightningAddress().IsZero() || wallet.LightningAddress().String() != "" {
+		t.Fatalf("expected unprovisioned lightning address to be zero")
+	}
+	if err := wallet.WaitForProvisioning(context.Background(), time.Second); err != nil {
+		t.Fatalf("WaitForProvisioning failed: %v", err)
+	} else if polls != 3 {
+		t.Fatalf("expected to resolve on the third poll, got %d", polls)
+	}
+	if wallet.LightningAddress().String() != "fresh@walletofsatoshi.com" || wallet.OnChainAddress() != "bc1qfresh" {
+		t.Fatalf("expected provisioned addresses, got %s and %s", wallet.LightningAddress(), wallet.OnChainAddress())
+	}
+
+	// Without on-chain support, it gives up waiting for the on-chain address.
+	polls, onChain = 0, false
+	if err := wallet.WaitForProvisioning(context.Background(), time.Second); err != nil {
+		t.Fatalf("WaitForProvisioning failed: %v", err)
+	} else if polls < 30 {
+		t.Fatalf("expected to wait for the on-chain address, got %d polls", polls)
+	}
+}
@@ -0,0 +1,478 @@
+package wos
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ErrQRTooLong is returned when content is too long to render as a QR code. QR codes
+// are rendered at up to version 10 with error correction level M, which holds up to
+// 213 bytes.
+var ErrQRTooLong = errors.New("content too long to encode as a QR code")
+
+const (
+	// qrModuleSize is the width in pixels of each module in rendered QR codes.
+	qrModuleSize = 8
+
+	// qrQuietZone is the width in modules of the light border around rendered QR codes.
+	qrQuietZone = 4
+)
+
+// qrVersion describes the block structure of a QR code version at error correction
+// level M, the level used by most wallets for payment requests.
+type qrVersion struct {
+	ecPerBlock int
+	// blocks lists the number of data codewords in each error correction block.
+	blocks []int
+	// alignment lists the row and column coordinates of the alignment pattern centers.
+	alignment []int
+}
+
+// qrVersions holds versions 1 through 10, enough for any BIP21 URI with a short label.
+var qrVersions = []qrVersion{
+	{10, []int{16}, nil},
+	{16, []int{28}, []int{6, 18}},
+	{26, []int{44}, []int{6, 22}},
+	{18, []int{32, 32}, []int{6, 26}},
+	{24, []int{43, 43}, []int{6, 30}},
+	{16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v qrVersion) dataCodewords() int {
+	total := 0
+	for _, n := range v.blocks {
+		total += n
+	}
+	return total
+}
+
+// qrCode is a square matrix of modules, true meaning dark.
+type qrCode struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// encodeQR encodes content in byte mode at error correction level M, using the
+// smallest version which fits it.
+func encodeQR(content []byte) (*qrCode, error) {
+	for i, version := range qrVersions {
+		number := i + 1
+		countBits := 8
+		if number >= 10 {
+			countBits = 16
+		}
+		capacityBits := version.dataCodewords() * 8
+		if 4+countBits+len(content)*8 > capacityBits {
+			continue
+		}
+
+		var bits qrBitBuffer
+		bits.append(0b0100, 4)
+		bits.append(len(content), countBits)
+		for _, b := range content {
+			bits.append(int(b), 8)
+		}
+		bits.append(0, min(4, capacityBits-len(bits)))
+		bits.append(0, (8-len(bits)%8)%8)
+		for pad := 0xEC; len(bits) < capacityBits; pad ^= 0xEC ^ 0x11 {
+			bits.append(pad, 8)
+		}
+
+		qr := newQRCode(number, version)
+		qr.drawCodewords(version.interleave(bits.bytes()))
+		qr.applyBestMask()
+		return qr, nil
+	}
+	return nil, ErrQRTooLong
+}
+
+// interleave splits data into error correction blocks, appends the error correction
+// codewords of each block, and interleaves the result as the QR spec requires.
+func (v qrVersion) interleave(data []byte) []byte {
+	var dataBlocks, ecBlocks [][]byte
+	for _, n := range v.blocks {
+		dataBlocks = append(dataBlocks, data[:n])
+		ecBlocks = append(ecBlocks, reedSolomon(data[:n], v.ecPerBlock))
+		data = data[n:]
+	}
+
+	var result []byte
+	for i := 0; i < v.blocks[len(v.blocks)-1]; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+type qrBitBuffer []bool
+
+func (buf *qrBitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*buf = append(*buf, (value>>i)&1 == 1)
+	}
+}
+
+func (buf qrBitBuffer) bytes() []byte {
+	result := make([]byte, len(buf)/8)
+	for i, bit := range buf {
+		if bit {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies two elements of GF(2^8) modulo the QR polynomial 0x11D.
+func gfMultiply(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		carry := z >> 7
+		z <<= 1
+		z ^= carry * 0x1D
+		z ^= ((y >> i) & 1) * x
+	}
+	return z
+}
+
+// reedSolomon returns the n error correction codewords for data.
+func reedSolomon(data []byte, n int) []byte {
+	// Generator polynomial coefficients, highest degree first, excluding the leading 1.
+	generator := make([]byte, n)
+	generator[n-1] = 1
+	var root byte = 1
+	for i := 0; i < n; i++ {
+		for j := range generator {
+			generator[j] = gfMultiply(generator[j], root)
+			if j+1 < n {
+				generator[j] ^= generator[j+1]
+			}
+		}
+		root = gfMultiply(root, 2)
+	}
+
+	remainder := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[n-1] = 0
+		for j := range remainder {
+			remainder[j] ^= gfMultiply(generator[j], factor)
+		}
+	}
+	return remainder
+}
+
+func newQRCode(number int, version qrVersion) *qrCode {
+	size := number*4 + 17
+	qr := &qrCode{
+		size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+	for i := range qr.modules {
+		qr.modules[i] = make([]bool, size)
+		qr.isFunction[i] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		qr.setFunction(6, i, i%2 == 0)
+		qr.setFunction(i, 6, i%2 == 0)
+	}
+
+	qr.drawFinder(3, 3)
+	qr.drawFinder(size-4, 3)
+	qr.drawFinder(3, size-4)
+
+	last := len(version.alignment) - 1
+	for i, x := range version.alignment {
+		for j, y := range version.alignment {
+			// Alignment patterns never overlap the finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			qr.drawAlignment(x, y)
+		}
+	}
+
+	// Reserve the format areas with placeholder bits until a mask is chosen.
+	qr.drawFormatBits(0)
+	qr.drawVersion(number)
+	return qr
+}
+
+// setFunction sets the module at column x and row y, marking it as a function
+// module which is not used for data and not masked.
+func (qr *qrCode) setFunction(x, y int, dark bool) {
+	qr.modules[y][x] = dark
+	qr.isFunction[y][x] = true
+}
+
+func (qr *qrCode) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= qr.size || yy < 0 || yy >= qr.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			qr.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (qr *qrCode) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			qr.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// qrFormatBits returns the 15 format bits for error correction level M and mask.
+func qrFormatBits(mask int) int {
+	data := mask // Level M is encoded as 0b00.
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (qr *qrCode) drawFormatBits(mask int) {
+	bits := qrFormatBits(mask)
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		qr.setFunction(8, i, bit(i))
+	}
+	qr.setFunction(8, 7, bit(6))
+	qr.setFunction(8, 8, bit(7))
+	qr.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		qr.setFunction(qr.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunction(8, qr.size-15+i, bit(i))
+	}
+	qr.setFunction(8, qr.size-8, true)
+}
+
+func (qr *qrCode) drawVersion(number int) {
+	if number < 7 {
+		return
+	}
+	rem := number
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := number<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 == 1
+		a, b := qr.size-11+i%3, i/3
+		qr.setFunction(a, b, dark)
+		qr.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places data in the zigzag order defined by the QR spec.
+func (qr *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < qr.size; vert++ {
+			y := vert
+			if upward {
+				y = qr.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if !qr.isFunction[y][x] && i < len(data)*8 {
+					qr.modules[y][x] = (data[i/8]>>(7-i%8))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func qrMask(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask XORs mask into the data modules. Applying the same mask twice undoes it.
+func (qr *qrCode) applyMask(mask int) {
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if !qr.isFunction[y][x] && qrMask(mask, x, y) {
+				qr.modules[y][x] = !qr.modules[y][x]
+			}
+		}
+	}
+}
+
+func (qr *qrCode) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qr.applyMask(mask)
+		qr.drawFormatBits(mask)
+		if penalty := qr.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		qr.applyMask(mask)
+	}
+	qr.applyMask(best)
+	qr.drawFormatBits(best)
+}
+
+// penalty scores the readability of the code as defined by the QR spec. Lower is better.
+func (qr *qrCode) penalty() int {
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return qr.modules[x][y]
+		}
+		return qr.modules[y][x]
+	}
+
+	finderLike := []bool{true, false, true, true, true, false, true}
+	penalty := 0
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < qr.size; y++ {
+			run := 1
+			for x := 1; x <= qr.size; x++ {
+				if x < qr.size && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+
+			for x := 0; x+len(finderLike) <= qr.size; x++ {
+				matches := true
+				for i, dark := range finderLike {
+					if at(x+i, y, transpose) != dark {
+						matches = false
+						break
+					}
+				}
+				if matches && (qr.lightRun(x-4, y, transpose) || qr.lightRun(x+len(finderLike), y, transpose)) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if qr.modules[y][x] {
+				dark++
+			}
+			if x+1 < qr.size && y+1 < qr.size {
+				c := qr.modules[y][x]
+				if c == qr.modules[y][x+1] && c == qr.modules[y+1][x] && c == qr.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	// Ten points for every full 5% by which the proportion of dark modules deviates from 50%.
+	total := qr.size * qr.size
+	penalty += abs(dark*20-total*10) / total * 10
+	return penalty
+}
+
+// lightRun reports whether the four modules from x are light, treating modules
+// beyond the edge of the code as light.
+func (qr *qrCode) lightRun(x, y int, transpose bool) bool {
+	for i := x; i < x+4; i++ {
+		if i < 0 || i >= qr.size {
+			continue
+		}
+		dark := qr.modules[y][i]
+		if transpose {
+			dark = qr.modules[i][y]
+		}
+		if dark {
+			return false
+		}
+	}
+	return true
+}
+
+// png renders the code as a black and white PNG image, with a quiet zone border.
+func (qr *qrCode) png() ([]byte, error) {
+	width := (qr.size + 2*qrQuietZone) * qrModuleSize
+	img := image.NewGray(image.Rect(0, 0, width, width))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if !qr.modules[y][x] {
+				continue
+			}
+			px, py := (x+qrQuietZone)*qrModuleSize, (y+qrQuietZone)*qrModuleSize
+			for dy := 0; dy < qrModuleSize; dy++ {
+				for dx := 0; dx < qrModuleSize; dx++ {
+					img.SetGray(px+dx, py+dy, color.Gray{})
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
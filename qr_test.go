@@ -0,0 +1,171 @@
+package wos
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" at version 1-M, from the worked example of the QR spec.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomon(data, 10); !bytes.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestQRFormatBits(t *testing.T) {
+	tests := map[int]int{
+		0: 0b101010000010010,
+		5: 0b100000011001110,
+		7: 0b100101010100000,
+	}
+	for mask, want := range tests {
+		if got := qrFormatBits(mask); got != want {
+			t.Errorf("qrFormatBits(%d): expected %015b, got %015b", mask, want, got)
+		}
+	}
+}
+
+func TestEncodeQR(t *testing.T) {
+	qr, err := encodeQR([]byte("bitcoin:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq?amount=0.001"))
+	if err != nil {
+		t.Fatalf("encodeQR failed: %v", err)
+	}
+	// 63 bytes overflows version 4 at level M by one byte.
+	if qr.size != 37 {
+		t.Fatalf("expected version 5 (37 modules), got %d modules", qr.size)
+	}
+	for _, corner := range [][2]int{{0, 0}, {qr.size - 7, 0}, {0, qr.size - 7}} {
+		x, y := corner[0], corner[1]
+		if !qr.modules[y][x] || qr.modules[y+1][x+1] || !qr.modules[y+3][x+3] {
+			t.Errorf("missing finder pattern at %v", corner)
+		}
+	}
+
+	if _, err := encodeQR(make([]byte, 214)); !errors.Is(err, ErrQRTooLong) {
+		t.Fatalf("expected ErrQRTooLong, got %v", err)
+	} else if _, err := encodeQR(make([]byte, 213)); err != nil {
+		t.Fatalf("expected 213 bytes to fit in version 10, got %v", err)
+	}
+}
+
+// Golden module matrices produced by an independent reference encoder, in byte mode
+// at error correction level M, choosing the mask with ZXing's penalty rules.
+var qrGoldenTests = []struct {
+	content string
+	modules string
+}{
+	// Version 1, mask 3.
+	{"HELLO WORLD", `
+#######.#...#.#######
+#.....#.#...#.#.....#
+#.###.#.......#.###.#
+#.###.#.#.#.#.#.###.#
+#.###.#..###..#.###.#
+#.....#...###.#.....#
+#######.#.#.#.#######
+........#####........
+#.##.###.#.##.#..#.##
+.##....#.#######.##..
+.....#####.#.#.#...##
+#.#.##.##..#...#.#.#.
+#...#.##.##.##....#.#
+........#.##..##..#.#
+#######.#.#######....
+#.....#.###..#.#.####
+#.###.#..#..#.#..#...
+#.###.#.###...#..###.
+#.###.#.##..#..#..#..
+#.....#..###.####...#
+#######.##.#.#.#.....
+`},
+	// Version 10, mask 2: exercises the version information and the 16-bit length field.
+	{"bitcoin:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq?amount=0.001&label=Invoice%20%2342%20for%20consulting%20services%20rendered%20in%20October%2C%20including%20travel%20and%20expenses%20as%20agreed", `
+#######..##.##..##...##.#....##......#....#...##..#######
+#.....#..#...#.###..##..###..#.######.##.#.#...#..#.....#
+#.###.#.###.####.......####.##.#.##..#.###.#####..#.###.#
+#.###.#.#.#.#.#..#.##..#..##.#.....####...#....#..#.###.#
+#.###.#.#...#..########.#.######...###...##.#..#..#.###.#
+#.....#.#.#.##...#.#.#.#.##...#..#########...##...#.....#
+#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######
+........##..###.#.#.....###...###.##....#.#.##..#........
+#.#####....########.##....######.##.##.#...#.##...#####..
+.#..#....#.#####.##....###..###...###....##....##..######
+###...#...####.#####..####.......#....#...#..##.####.###.
+.##.#..##..#.##.#..#.##..#....###...#...#.####.##.#.#.#.#
+.#.#.##....###.######.#.#.##........#.##.##..........#..#
+#.####.#####.....#.#.#.#.##...##....#..#.###...###..#.#.#
+#.....#.##.##..###.#.##...##.#.#.#######.#.#.##.###.#.##.
+..#..#.#..####..#.##...#.######.#.#..##.#.#.##..#######..
+###..####.#....#...##.........##.#.##.##..##..#..##....#.
+##.##....##.#..#.#.#...##.#####.##..##.#####...###...##.#
+.#.##.###.##..######.##.##...#.#..###.##.#.#.###..#..##..
+.####...#.##.##..##.....##.###..####.#.###.##.##.####.#..
+###.####...###.##....#...##....#...####..###..#..#...#.#.
+#.##...###...#.#..##.#.##....#.##....#.#..#.#..###..#.###
+.....###.###.#######...##.#...#..##.####.#.####...##.....
+..#.....#...####...#..###..##.....#...#######.......###.#
+..##.##.#.#.#..##.#.##.#.###.########.....##...#..#......
+##..#...##.##..##.#..##.##...##.#....#.#.###...##.......#
+###.######..#.##....###...#########.#.##......#.#####....
+....#...####.#.##..##.#.#.#...####.#.#.##.####.##...###.#
+##.##.#.##...###.##..###..#.#.#....##.##.#....###.#.##...
+.#..#...#.#.........##..###...#.##...#..#.#.##.##...#.###
+#.########..###.....##.########....##.#.......#######..#.
+.#####...##.##....####.###......#.#.....#.#.#.##..#...#..
+#.....#...##..##...#.####.#.#....#.##..#.#..........##.#.
+##...#...##.###..##.###.#....#####.###.#..#.#..##..#.....
+##.#.##....#....##.....###.####...######.....##..#...####
+##.##...#..#...#.##.###.#...#..##.#.....#.###.###....##..
+###...##.##..###..#..###...#..##...####..#.#..#.#..##....
+#.#.......####.#..##..#.#.#.#.#.#..###.######..##.....#.#
+.#..#.#..##.....#.#.##.....#######.##.##.#...##..#.##..#.
+..#.....#.#####.#....##.#.#.##.###.#....#.#.##.##.#...#.#
+#######.....#.....#...#..#..#.#...###.##..##.##...#.##.##
+.###.#.....##..###.######..........###.##.##....##.#...##
+#.#.####.####..##..#.....##..###.####.##...#.##..#.####..
+....##.#..#.###...###...#..#...#.....#..#...##.##.#..###.
+.##..##.#....#.#..#.##.#...##.#...###.##.###..#.##.###.#.
+#..#.#.##.##..###.##....#....###.#.###.#####.#.#.....####
+#.#..##.......##...#.#####.##.#..##.#.##.....#####.#.#...
+#####...#..#..#..#...#.##..#.####.##.#########.#.##..##..
+......#..#..#..####...##.######..####....##.....#####..#.
+........##...#.#.#..##..#.#...##..##.#.##.##...##...#.###
+#######....##...#..#.#.####.#.##.#....#.##.#..#.#.#.#.##.
+#.....#.##.##.#.#...##.##.#...###.......#####.###...#####
+#.###.#.###.###..#.##...########.#######.###..#.#####..#.
+#.###.#.##...##.##...###.#######.#.###.##.##.....#.###...
+#.###.#.#...#####...#.#..#....##..###.#.##.#..####.......
+#.....#..##.#.#####.####.#..###.####.##.#.###.##...#..#..
+#######.##.##.##....#.##...#...#...##......#.###.###...#.
+`},
+}
+
+func TestEncodeQRGolden(t *testing.T) {
+	for _, test := range qrGoldenTests {
+		qr, err := encodeQR([]byte(test.content))
+		if err != nil {
+			t.Fatalf("encodeQR(%q) failed: %v", test.content, err)
+		}
+
+		var got strings.Builder
+		for _, row := range qr.modules {
+			got.WriteByte('\n')
+			for _, dark := range row {
+				if dark {
+					got.WriteByte('#')
+				} else {
+					got.WriteByte('.')
+				}
+			}
+		}
+		got.WriteByte('\n')
+
+		if got.String() != test.modules {
+			t.Errorf("encodeQR(%q) produced the wrong modules:\n%s\nexpected:%s", test.content, got.String(), test.modules)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package wos
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Rate limit headers which WoS may include in its responses. WoS does not document
+// these, so each is optional, and responses without them leave the last observed
+// [RateLimitInfo] unchanged.
+const (
+	rateLimitLimitHeader     = "X-RateLimit-Limit"
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// RateLimitInfo describes the API request quota last reported by WoS, as returned
+// by [Reader.RateLimitStatus].
+type RateLimitInfo struct {
+	// Limit is the number of requests permitted in the current window,
+	// or -1 if WoS did not report it.
+	Limit int
+
+	// Remaining is the number of requests left in the current window,
+	// or -1 if WoS did not report it.
+	Remaining int
+
+	// Reset is when the current window ends and the quota is replenished.
+	// It is zero if WoS did not report it.
+	Reset time.Time
+
+	// Updated is when the quota was last reported. It is zero if WoS has not
+	// reported a quota on any response, in which case the other fields are meaningless.
+	Updated time.Time
+}
+
+// parseRateLimitHeaders extracts the rate limit quota from a response's headers.
+// Returns false if the response has no rate limit headers.
+func parseRateLimitHeaders(header http.Header, now time.Time) (RateLimitInfo, bool) {
+	info := RateLimitInfo{Limit: -1, Remaining: -1, Updated: now}
+	found := false
+
+	if n, err := strconv.Atoi(header.Get(rateLimitLimitHeader)); err == nil {
+		info.Limit = n
+		found = true
+	}
+	if n, err := strconv.Atoi(header.Get(rateLimitRemainingHeader)); err == nil {
+		info.Remaining = n
+		found = true
+	}
+
+	// The reset header is either a number of seconds until the reset, or a Unix
+	// timestamp. Values too large to be a sensible delay are taken as timestamps.
+	if n, err := strconv.ParseInt(header.Get(rateLimitResetHeader), 10, 64); err == nil && n >= 0 {
+		if n > 1_000_000_000 {
+			info.Reset = time.Unix(n, 0)
+		} else {
+			info.Reset = now.Add(time.Duration(n) * time.Second)
+		}
+		found = true
+	}
+
+	return info, found
+}
+
+// observeRateLimit records the rate limit quota reported by resp, if any.
+func (rdr *Reader) observeRateLimit(resp *http.Response) {
+	info, ok := parseRateLimitHeaders(resp.Header, time.Now())
+	if !ok {
+		return
+	}
+	rdr.rateLimitMu.Lock()
+	defer rdr.rateLimitMu.Unlock()
+	rdr.rateLimit = info
+}
+
+// RateLimitStatus returns the API request quota most recently reported by WoS in
+// rate limit headers, so that callers can slow down before being rejected with
+// [ErrRateLimited]. If WoS has not reported a quota, the returned Updated time is zero.
+func (rdr *Reader) RateLimitStatus() RateLimitInfo {
+	rdr.rateLimitMu.Lock()
+	defer rdr.rateLimitMu.Unlock()
+	return rdr.rateLimit
+}
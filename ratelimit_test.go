@@ -0,0 +1,50 @@
+package wos
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimitStatus(t *testing.T) {
+	withHeaders := true
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		if withHeaders {
+			w.Header().Set("X-RateLimit-Limit", "100")
+			w.Header().Set("X-RateLimit-Remaining", "42")
+			w.Header().Set("X-RateLimit-Reset", "30")
+		}
+		w.Write([]byte(`{"btc":0.001,"btcUnconfirmed":0}`))
+	}))
+
+	if status := rdr.RateLimitStatus(); !status.Updated.IsZero() {
+		t.Fatalf("expected no quota before any request, got %+v", status)
+	}
+
+	before := time.Now()
+	if _, err := rdr.Balance(context.Background()); err != nil {
+		t.Fatalf("Balance failed: %v", err)
+	}
+	status := rdr.RateLimitStatus()
+	if status.Limit != 100 || status.Remaining != 42 || status.Updated.Before(before) {
+		t.Fatalf("unexpected rate limit status: %+v", status)
+	} else if reset := status.Reset.Sub(before); reset < 30*time.Second || reset > 31*time.Second {
+		t.Fatalf("expected reset in 30 seconds, got %s", reset)
+	}
+
+	// Responses without headers leave the last observed quota in place.
+	withHeaders = false
+	if _, err := rdr.Balance(context.Background()); err != nil {
+		t.Fatalf("Balance failed: %v", err)
+	} else if rdr.RateLimitStatus() != status {
+		t.Fatalf("expected quota to be unchanged, got %+v", rdr.RateLimitStatus())
+	}
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Reset", "1700000000")
+	info, ok := parseRateLimitHeaders(header, before)
+	if !ok || !info.Reset.Equal(time.Unix(1700000000, 0)) || info.Limit != -1 || info.Remaining != -1 {
+		t.Fatalf("unexpected parse of reset timestamp: %+v", info)
+	}
+}
@@ -0,0 +1,45 @@
+package wos
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping a copy of data in p.Raw.
+func (p *Payment) UnmarshalJSON(data []byte) error {
+	type payment Payment
+	if err := json.Unmarshal(data, (*payment)(p)); err != nil {
+		return err
+	}
+	p.Raw = rawJSON(data)
+	return nil
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping a copy of data in b.Raw.
+func (b *Balance) UnmarshalJSON(data []byte) error {
+	type balance Balance
+	if err := json.Unmarshal(data, (*balance)(b)); err != nil {
+		return err
+	}
+	b.Raw = rawJSON(data)
+	return nil
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping a copy of data in invoice.Raw.
+func (invoice *Invoice) UnmarshalJSON(data []byte) error {
+	type rawInvoice Invoice
+	if err := json.Unmarshal(data, (*rawInvoice)(invoice)); err != nil {
+		return err
+	}
+	invoice.Raw = rawJSON(data)
+	return nil
+}
+
+// rawJSON returns a copy of data, or nil if data is the JSON null, which is
+// conventionally a no-op when unmarshaling.
+func rawJSON(data []byte) json.RawMessage {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	return bytes.Clone(data)
+}
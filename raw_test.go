@@ -0,0 +1,64 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRawJSON(t *testing.T) {
+	const paymentJSON = `{"id":"p1","amount":0.001,"newField":"surprise"}`
+	const balanceJSON = `{"btc":0.5,"btcUnconfirmed":0,"savings":0.25}`
+	const invoiceJSON = `{"id":"i1","invoice":"lnbc1","btcAmount":0.0001,"qrColor":"orange"}`
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/payment":
+			w.Write([]byte("[" + paymentJSON + "]"))
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(balanceJSON))
+		case "/api/v1/wallet/createInvoice":
+			w.Write([]byte(invoiceJSON))
+		}
+	})
+	ctx := context.Background()
+
+	payments, err := wallet.reader.ListPayments(ctx)
+	if err != nil {
+		t.Fatalf("ListPayments failed: %v", err)
+	} else if len(payments) != 1 || string(payments[0].Raw) != paymentJSON {
+		t.Fatalf("expected raw payment %s, got %+v", paymentJSON, payments)
+	}
+	var extra struct {
+		NewField string `json:"newField"`
+	}
+	if err := json.Unmarshal(payments[0].Raw, &extra); err != nil || extra.NewField != "surprise" {
+		t.Fatalf("failed to extract custom field from raw payment: %v", err)
+	}
+
+	balance, err := wallet.reader.Balance(ctx)
+	if err != nil {
+		t.Fatalf("Balance failed: %v", err)
+	} else if string(balance.Raw) != balanceJSON {
+		t.Fatalf("expected raw balance %s, got %s", balanceJSON, balance.Raw)
+	}
+
+	invoice, err := wallet.NewInvoice(ctx, &InvoiceOptions{Amount: 0.0001})
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	} else if string(invoice.Raw) != invoiceJSON {
+		t.Fatalf("expected raw invoice %s, got %s", invoiceJSON, invoice.Raw)
+	}
+
+	// Raw is not itself marshaled, so re-encoding does not nest it.
+	encoded, err := json.Marshal(payments[0])
+	if err != nil {
+		t.Fatalf("failed to marshal payment: %v", err)
+	}
+	var decoded Payment
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal payment: %v", err)
+	} else if string(decoded.Raw) != string(encoded) {
+		t.Fatalf("expected raw JSON %s, got %s", encoded, decoded.Raw)
+	}
+}
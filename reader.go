@@ -1,16 +1,35 @@
 package wos
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"math"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+// ErrUnsupportedRegion is returned when WoS does not offer a feature in the wallet's
+// region, such as on-chain deposits and withdrawals. This is detected both when WoS
+// omits on-chain addresses, and when the API rejects a request with a regional
+// restriction, in which case the error is an [*APIError] naming the region if known.
+var ErrUnsupportedRegion = errors.New("feature unavailable in this region")
+
+// ErrFeatureUnavailable is an alias of [ErrUnsupportedRegion].
+var ErrFeatureUnavailable = ErrUnsupportedRegion
+
+var errOnChainUnavailable = fmt.Errorf("%w: on-chain addresses are not supported", ErrUnsupportedRegion)
+
 // Addresses represents the on-chain and lightning deposit addresses for
 // a [Wallet].
 type Addresses struct {
@@ -26,6 +45,9 @@ type Balance struct {
 	// This field is unreliable. It can even go negative in some cases.
 	// The `btc` field is the real confirmed balance.
 	// Lightning float64 `json:"lightning"`
+
+	// Raw is the JSON the balance was decoded from, if any. See [Payment.Raw].
+	Raw json.RawMessage `json:"-"`
 }
 
 // Total returns the sum of the confirmed and unconfirmed balances.
@@ -33,6 +55,8 @@ func (b Balance) Total() float64 {
 	return b.Confirmed + b.Unconfirmed
 }
 
+// FeeEstimate describes the fees WoS expects to charge when paying to a particular
+// destination, as returned by [Reader.FeeEstimate].
 type FeeEstimate struct {
 	BtcFixedFee              float64 `json:"btcFixedFee"`
 	BtcMinerFeePerKB         float64 `json:"btcMinerFeePerKb"`
@@ -41,6 +65,58 @@ type FeeEstimate struct {
 	LightningFee             float64 `json:"lightningFee"`
 	MaxLightningFee          float64 `json:"sendMaxLightningFee"`
 	IsWosInvoice             bool    `json:"wosInvoice"`
+
+	// Destination is the on-chain address or lightning invoice which
+	// this estimate was computed for.
+	Destination string `json:"-"`
+}
+
+// ErrFeeEstimateMismatch is returned when a [FeeEstimate] is used for a payment
+// to a different destination than the one it was computed for.
+var ErrFeeEstimateMismatch = errors.New("fee estimate was computed for a different destination")
+
+// CheckDestination returns an error wrapping [ErrFeeEstimateMismatch] if the estimate
+// was not computed for the given address or invoice.
+func (fe FeeEstimate) CheckDestination(addressOrInvoice string) error {
+	if fe.Destination != addressOrInvoice {
+		return fmt.Errorf("%w: estimate is for %q", ErrFeeEstimateMismatch, fe.Destination)
+	}
+	return nil
+}
+
+// MinerFeePerVByte returns the on-chain miner fee rate in satoshis per virtual byte,
+// derived from BtcMinerFeePerKB.
+func (fe FeeEstimate) MinerFeePerVByte() float64 {
+	return fe.BtcMinerFeePerKB * 100_000_000 / 1000
+}
+
+// CommissionOn returns the commission WoS charges for sending the given BTC amount on-chain.
+func (fe FeeEstimate) CommissionOn(amount float64) float64 {
+	return fe.BtcSendCommissionPercent * amount
+}
+
+// TotalOnChainFee returns the total fee WoS charges for sending the given BTC amount
+// on-chain, which is the fixed fee plus the commission.
+func (fe FeeEstimate) TotalOnChainFee(amount float64) float64 {
+	return fe.BtcFixedFee + fe.CommissionOn(amount)
+}
+
+// FeeFiat returns the fee for sending amount BTC to the estimate's destination, converted
+// to fiat at the given price of one bitcoin. This is the lightning fee for lightning
+// destinations, or [FeeEstimate.TotalOnChainFee] otherwise.
+//
+// Returns zero if the rate is zero or invalid, in which case the fiat figure should be
+// omitted from display.
+func (fe FeeEstimate) FeeFiat(rate float64, amount float64) float64 {
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return 0
+	}
+
+	fee := fe.TotalOnChainFee(amount)
+	if _, kind, err := NormalizeDestination(fe.Destination); err == nil && kind != DestinationOnChain {
+		fee = fe.LightningFee
+	}
+	return fee * rate
 }
 
 type (
@@ -71,16 +147,44 @@ type Payment struct {
 	ID string `json:"id"`
 
 	// For on-chain bitcoin, this is the address the payment was sent to.
-	// For lightning, this is the invoice or LN address.
+	// For lightning, this is the invoice or LN address:
+	//
+	//   - For lightning debits paid to an invoice, it is the full BOLT11 invoice
+	//     which was paid.
+	//   - For lightning debits paid to a lightning address, it is the lightning
+	//     address, as WoS does not record the invoice fetched from it.
+	//   - For lightning credits, it is the full BOLT11 invoice issued by this wallet.
+	//
+	// Use [Payment.Invoice] to extract the invoice, if any.
 	Address string `json:"address"`
 
 	// Amount is the Bitcoin-denominated amount of the payment.
 	Amount float64 `json:"amount"`
 
+	// FiatAmount, FiatCurrency and FiatRate record the fiat value WoS showed for the
+	// payment in its app, and the price of one bitcoin it used, so that reports can
+	// match what the user saw. FiatCurrency is an ISO 4217 code such as "USD".
+	//
+	// These fields are undocumented, and are left zero if WoS does not provide them.
+	// To convert amounts yourself, see [RateProvider].
+	FiatAmount   float64 `json:"fiatAmount,omitempty"`
+	FiatCurrency string  `json:"fiatCurrency,omitempty"`
+	FiatRate     float64 `json:"fiatRate,omitempty"`
+
+	// Fee is the BTC fee WoS charged for a sent payment, on top of Amount. This field
+	// is undocumented, and is left zero if WoS does not provide it.
+	Fee float64 `json:"fee,omitempty"`
+
 	// Currency is either PaymentCurrencyBitcoin or PaymentCurrencyLightning.
 	Currency PaymentCurrency `json:"currency"`
 
-	// Invoice description, or empty string otherwise.
+	// Description is the single description WoS stores for a payment. For payments
+	// this wallet received, it is the description of the invoice which was paid.
+	// For payments this wallet sent, it is the description passed to the method
+	// which made the payment, which acts as a private memo for the sender.
+	//
+	// WoS does not store the description of an invoice which was paid separately.
+	// Use [Payment.InvoiceDescription] to decode it from Address.
 	Description string `json:"description"`
 
 	// Invoice expiry time. Empty for debits.
@@ -104,14 +208,54 @@ type Payment struct {
 
 	// Type is either PaymentTypeCredit or PaymentTypeDebit.
 	Type PaymentType `json:"type"`
+
+	// Reversible and ReversibleUntil report whether WoS allows the payment to be
+	// reversed with [Wallet.ReversePayment], and until when. These fields are
+	// undocumented, and are left zero if WoS does not provide them, in which case
+	// the payment should be assumed final. See [Payment.IsReversible].
+	Reversible      bool      `json:"reversible,omitempty"`
+	ReversibleUntil time.Time `json:"reversibleUntil,omitempty"`
+
+	// SuccessAction is the action returned by the recipient's LNURL-pay server, if any.
+	// Only set on payments made with [Wallet.PayLightningAddress]. Malformed actions
+	// are discarded.
+	SuccessAction *SuccessAction `json:"successAction,omitempty"`
+
+	// Warnings lists advisories about a payment this wallet just sent, such as
+	// [WarningHighFee] for sweeps or [WarningRiskyInvoice]. Always empty for payments
+	// read from history.
+	Warnings []Warning `json:"-"`
+
+	// Raw is the JSON object the payment was decoded from, such as a WoS API response,
+	// or nil if the payment was not decoded from JSON. As the WoS API is undocumented
+	// and evolving, this lets callers read fields which Payment does not model yet.
+	Raw json.RawMessage `json:"-"`
 }
 
 // Reader facilitates read-only access to a WoS wallet.
 // It can be used to fetch balances, payment history,
 // and estimate fees.
 type Reader struct {
-	apiToken   string
-	httpClient *http.Client
+	apiToken     string
+	httpClient   *http.Client
+	coalescer    *coalescer
+	maxRedirects int
+	recorder     *requestRecorder
+	explorer     BlockExplorer
+	feeCache     *feeCache
+	retryPolicy  RetryPolicy
+
+	closeMu sync.Mutex
+	closed  chan struct{}
+
+	limitsMu sync.Mutex
+	limits   *Limits
+
+	currenciesMu sync.Mutex
+	currencies   []string
+
+	rateLimitMu sync.Mutex
+	rateLimit   RateLimitInfo
 }
 
 // NewReader constructs a Reader from a given [http.Client] and read-only apiToken.
@@ -121,12 +265,78 @@ func NewReader(apiToken string, httpClient *http.Client) *Reader {
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
-	return &Reader{apiToken, httpClient}
+	return &Reader{
+		apiToken:     apiToken,
+		httpClient:   httpClient,
+		maxRedirects: defaultMaxRedirects,
+	}
+}
+
+// WithToken returns a copy of the Reader which authenticates with a different API token,
+// for rotating tokens or issuing readers scoped to other wallets. The copy shares the
+// original's [http.Client], and inherits its redirect limit, request recorder, block
+// explorer and retry policy. The original Reader is not modified.
+//
+// State tied to the token is not shared: the copy has its own request coalescing and
+// fee estimate caches, configured like the original's, and it is not closed by closing
+// the original.
+func (rdr *Reader) WithToken(apiToken string) *Reader {
+	clone := NewReader(apiToken, rdr.httpClient)
+	clone.maxRedirects = rdr.maxRedirects
+	clone.recorder = rdr.recorder
+	clone.explorer = rdr.explorer
+	clone.retryPolicy = rdr.retryPolicy
+	if rdr.coalescer != nil {
+		clone.coalescer = newCoalescer(rdr.coalescer.window)
+	}
+	if rdr.feeCache != nil {
+		clone.feeCache = newFeeCache(rdr.feeCache.ttl)
+	}
+	return clone
+}
+
+// EnableCoalescing makes the Reader share a single in-flight HTTP request between
+// concurrent callers of [Reader.GetRequest] for the same endpoint, such as many
+// handlers calling [Reader.Balance] at the same moment. Successful responses are
+// also reused by later callers for the given window after they complete. A window
+// of zero only shares requests which are still in flight.
+//
+// Coalescing is disabled by default. Note that a shared request runs under the
+// context of whichever caller issued it first, so if that context is cancelled,
+// all waiting callers receive the resulting error.
+func (rdr *Reader) EnableCoalescing(window time.Duration) {
+	rdr.coalescer = newCoalescer(window)
+}
+
+// DisableCoalescing turns off request coalescing enabled by [Reader.EnableCoalescing].
+func (rdr *Reader) DisableCoalescing() {
+	rdr.coalescer = nil
 }
 
 // GetRequest issues a GET request to the given endpoint, authenticated with
 // the Reader's API token.
 func (rdr *Reader) GetRequest(ctx context.Context, endpoint string) ([]byte, error) {
+	get := func() ([]byte, error) {
+		resp, err := rdr.GetRequestRaw(ctx, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(resp.Body)
+	}
+
+	if c := rdr.coalescer; c != nil {
+		return c.do(endpoint, get)
+	}
+	return get()
+}
+
+// GetRequestRaw is like [Reader.GetRequest], but returns the full [http.Response],
+// so that callers can inspect the status code and headers for debugging.
+//
+// The response body is buffered in memory and the underlying connection is already
+// closed, so resp.Body can be read freely without needing to be closed. If the
+// server responds with an error status, the response is returned alongside the error.
+func (rdr *Reader) GetRequestRaw(ctx context.Context, endpoint string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", BaseURL+endpoint, nil)
 	if err != nil {
 		return nil, err
@@ -134,35 +344,68 @@ func (rdr *Reader) GetRequest(ctx context.Context, endpoint string) ([]byte, err
 	req.Header.Set("User-Agent", "")
 	req.Header.Set("Api-Token", rdr.apiToken)
 
-	resp, err := rdr.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("GET %s request failed: %w", endpoint, err)
-	}
-	defer resp.Body.Close()
-
-	if err := checkHTTPResponse(resp); err != nil {
-		return nil, fmt.Errorf("GET %s: %w", endpoint, err)
-	}
-
-	return io.ReadAll(resp.Body)
+	return rdr.send(req, "GET "+endpoint)
 }
 
 // Addresses re-fetches the wallet's on-chain and lightning addresses.
 // This can be useful to ensure you have the wallet's latest unused
 // on-chain deposit address.
+//
+// Returns an error wrapping [ErrUnsupportedRegion] if on-chain addresses are
+// not available in the wallet's region. Use [Reader.LightningAddress] if only
+// the lightning address is needed.
 func (rdr *Reader) Addresses(ctx context.Context) (*Addresses, error) {
-	respData, err := rdr.GetRequest(ctx, "/api/v1/wallet/account")
+	addresses, err := rdr.fetchAddresses(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("Addresses: %w", err)
 	}
 
-	var addresses Addresses
-	if err := json.Unmarshal(respData, &addresses); err != nil {
-		return nil, fmt.Errorf("error decoding Addresses response: %w", err)
+	if addresses.OnChain == "" {
+		return nil, fmt.Errorf("Addresses: %w", errOnChainUnavailable)
+	}
+	return addresses, nil
+}
+
+// LightningAddress fetches the wallet's lightning address. Unlike [Reader.Addresses],
+// this succeeds even in regions where on-chain addresses are not available.
+func (rdr *Reader) LightningAddress(ctx context.Context) (LightningAddress, error) {
+	addresses, err := rdr.fetchAddresses(ctx)
+	if err != nil {
+		return LightningAddress{}, fmt.Errorf("LightningAddress: %w", err)
+	}
+
+	lnAddress, err := ParseLightningAddress(addresses.Lightning)
+	if err != nil {
+		return LightningAddress{}, fmt.Errorf("LightningAddress: %w", err)
+	}
+	return lnAddress, nil
+}
+
+// OnChainAddress fetches the wallet's current on-chain deposit address.
+//
+// Returns an error wrapping [ErrUnsupportedRegion] if WoS does not offer
+// on-chain deposits in the wallet's region.
+func (rdr *Reader) OnChainAddress(ctx context.Context) (string, error) {
+	addresses, err := rdr.fetchAddresses(ctx)
+	if err != nil {
+		return "", fmt.Errorf("OnChainAddress: %w", err)
 	}
 
 	if addresses.OnChain == "" {
-		return nil, fmt.Errorf("Addresses: unsupported region")
+		return "", fmt.Errorf("OnChainAddress: %w", errOnChainUnavailable)
+	}
+	return addresses.OnChain, nil
+}
+
+func (rdr *Reader) fetchAddresses(ctx context.Context) (*Addresses, error) {
+	respData, err := rdr.GetRequest(ctx, "/api/v1/wallet/account")
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses Addresses
+	if err := json.Unmarshal(respData, &addresses); err != nil {
+		return nil, fmt.Errorf("error decoding addresses: %w", err)
 	}
 	return &addresses, nil
 }
@@ -185,12 +428,28 @@ func (rdr *Reader) Balance(ctx context.Context) (*Balance, error) {
 // FeeEstimate fetches the latest fee estimation data when paying to a given on-chain
 // address or lightning invoice.
 func (rdr *Reader) FeeEstimate(ctx context.Context, addressOrInvoice string) (*FeeEstimate, error) {
+	amount, _ := parseInvoiceAmount(addressOrInvoice)
+	return rdr.feeEstimate(ctx, addressOrInvoice, amount)
+}
+
+// feeEstimate fetches a fee estimate for sending amount to addressOrInvoice.
+// Either may be empty or zero if unknown.
+func (rdr *Reader) feeEstimate(ctx context.Context, addressOrInvoice string, amount float64) (*FeeEstimate, error) {
+	cache := rdr.feeCache
+	var cacheKey string
+	if cache != nil {
+		cacheKey = feeCacheKey(addressOrInvoice, amount)
+		if estimate, ok := cache.get(cacheKey); ok {
+			return estimate, nil
+		}
+	}
+
 	query := make(url.Values)
 	if addressOrInvoice != "" {
 		query.Set("address", addressOrInvoice)
 	}
-	if amt, err := parseInvoiceAmount(addressOrInvoice); err == nil {
-		query.Set("amount", strconv.FormatFloat(amt, 'f', 11, 64))
+	if amount > 0 {
+		query.Set("amount", strconv.FormatFloat(amount, 'f', 11, 64))
 	}
 
 	respData, err := rdr.GetRequest(ctx, "/api/v1/wallet/feeEstimate?"+query.Encode())
@@ -202,9 +461,125 @@ func (rdr *Reader) FeeEstimate(ctx context.Context, addressOrInvoice string) (*F
 	if err := json.Unmarshal(respData, &estimate); err != nil {
 		return nil, fmt.Errorf("invalid FeeEstimate response: %w", err)
 	}
+	estimate.Destination = addressOrInvoice
+	if cache != nil {
+		cache.put(cacheKey, &estimate)
+	}
 	return &estimate, nil
 }
 
+// ErrInvoiceNotFound is returned by [Reader.IsInvoicePaid] if WoS does not
+// recognize the invoice ID.
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+// IsInvoicePaid checks whether the WoS invoice with the given ID, as returned in
+// [Invoice.ID] by [Wallet.NewInvoice], has been paid. If it has, the payment
+// which settled it is also returned.
+//
+// This asks WoS directly, which is cheaper than scanning the wallet's history.
+// Returns an error wrapping [ErrInvoiceNotFound] if the ID is unknown.
+func (rdr *Reader) IsInvoicePaid(ctx context.Context, invoiceID string) (bool, *Payment, error) {
+	respData, err := rdr.GetRequest(ctx, "/api/v1/wallet/payment/"+url.PathEscape(invoiceID))
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil, fmt.Errorf("IsInvoicePaid: %w: %s", ErrInvoiceNotFound, invoiceID)
+	} else if err != nil {
+		return false, nil, fmt.Errorf("IsInvoicePaid: %w", err)
+	}
+
+	var payment Payment
+	if err := json.Unmarshal(respData, &payment); err != nil {
+		return false, nil, fmt.Errorf("invalid IsInvoicePaid response: %w", err)
+	}
+
+	if payment.Status != PaymentStatusPaid {
+		return false, nil, nil
+	}
+	return true, &payment, nil
+}
+
+// PaymentsForInvoice returns every credit in the wallet's history which settled the
+// given invoice, such as each part of a multi-part payment, ordered from oldest to newest.
+// Credits are matched by the invoice's payment hash, falling back to the invoice itself
+// or its ID for credits which WoS recorded without the hash. Unrelated credits and all
+// debits are ignored.
+//
+// The WoS API cannot filter payments by invoice, so this scans the full history.
+func (rdr *Reader) PaymentsForInvoice(ctx context.Context, invoice *Invoice) ([]Payment, error) {
+	decoded, err := DecodeInvoice(invoice.Bolt11)
+	if err != nil {
+		return nil, fmt.Errorf("PaymentsForInvoice: %w", err)
+	}
+	paymentHash := hex.EncodeToString(decoded.PaymentHash)
+
+	var matched []Payment
+	err = rdr.WalkPayments(ctx, func(payment *Payment) bool {
+		if payment.Type != PaymentTypeCredit {
+			return true
+		}
+		if strings.EqualFold(payment.Txid, paymentHash) ||
+			strings.EqualFold(payment.Address, invoice.Bolt11) ||
+			(invoice.ID != "" && payment.ID == invoice.ID) {
+			matched = append(matched, *payment)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("PaymentsForInvoice: %w", err)
+	}
+
+	SortPayments(matched, SortByTime)
+	return matched, nil
+}
+
+// CheaperRoute compares the cost of sending the given BTC amount over lightning and
+// on-chain, and recommends the cheaper of the two, along with the [FeeEstimate] used
+// for the comparison. This is useful when a destination accepts both, such as a BIP21
+// URI with a lightning invoice. If both routes cost the same, lightning is preferred,
+// as it settles faster.
+//
+// The on-chain cost is [FeeEstimate.TotalOnChainFee], as WoS's fixed on-chain fee
+// covers the miner fee. The lightning cost is [FeeEstimate.LightningFee] for the amount.
+func (rdr *Reader) CheaperRoute(ctx context.Context, amount float64) (PaymentCurrency, *FeeEstimate, error) {
+	fees, err := rdr.feeEstimate(ctx, "", amount)
+	if err != nil {
+		return "", nil, fmt.Errorf("CheaperRoute: %w", err)
+	}
+
+	if fees.TotalOnChainFee(amount) < fees.LightningFee {
+		return PaymentCurrencyBitcoin, fees, nil
+	}
+	return PaymentCurrencyLightning, fees, nil
+}
+
+// SplitFeeEstimate estimates the total fees of sending total BTC to destination in the
+// given number of equal parts, such as to work around lightning routing failures for
+// large amounts. Compare the result with parts set to 1 to see the cost of splitting.
+//
+// Each part pays its own lightning fee, or for on-chain destinations, its own fixed fee
+// and commission, so splitting usually costs more in total.
+func (rdr *Reader) SplitFeeEstimate(ctx context.Context, destination string, total float64, parts int) (float64, error) {
+	if parts < 1 {
+		return 0, fmt.Errorf("SplitFeeEstimate: invalid number of parts: %d", parts)
+	}
+
+	destination, kind, err := NormalizeDestination(destination)
+	if err != nil {
+		return 0, fmt.Errorf("SplitFeeEstimate: %w", err)
+	}
+
+	part := total / float64(parts)
+	fees, err := rdr.feeEstimate(ctx, destination, part)
+	if err != nil {
+		return 0, fmt.Errorf("SplitFeeEstimate: %w", err)
+	}
+
+	if kind == DestinationOnChain {
+		return fees.TotalOnChainFee(part) * float64(parts), nil
+	}
+	return fees.LightningFee * float64(parts), nil
+}
+
 func (rdr *Reader) BalanceAndFee(
 	ctx context.Context,
 	addressOrInvoice string,
@@ -261,7 +636,61 @@ func (rdr *Reader) BalanceAndFee(
 	return balance, fees, nil
 }
 
+// ListPayments returns the wallet's full payment history, ordered from oldest to newest.
+// Payments which occurred at the same time are ordered by ID, so that the output is
+// deterministic across calls.
+//
+// A single malformed payment record fails the whole call. Use [Reader.ListPaymentsWith]
+// with [ListPaymentsOptions.Lenient] to skip malformed records instead.
 func (rdr *Reader) ListPayments(ctx context.Context) ([]Payment, error) {
+	payments, _, err := rdr.ListPaymentsWith(ctx, nil)
+	return payments, err
+}
+
+// ListPaymentsOptions customizes the behavior of [Reader.ListPaymentsWith].
+type ListPaymentsOptions struct {
+	// Lenient skips payment records which cannot be decoded, such as a record with a
+	// field of the wrong type, instead of failing the whole history. The skipped
+	// records are reported as [*PaymentDecodeError] values.
+	//
+	// A response which is not a well-formed JSON array still fails the call.
+	Lenient bool
+}
+
+// PaymentDecodeError describes a payment record skipped by [Reader.ListPaymentsWith]
+// in lenient mode.
+type PaymentDecodeError struct {
+	// Index is the position of the record in the history returned by WoS.
+	Index int
+
+	// Raw is the undecodable JSON record.
+	Raw json.RawMessage
+
+	Err error
+}
+
+func (e *PaymentDecodeError) Error() string {
+	return fmt.Sprintf("payment record %d: %v", e.Index, e.Err)
+}
+
+func (e *PaymentDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ListPaymentsWith is the same as [Reader.ListPayments], but customized with opts.
+// If opts is nil, the defaults are used, which is strict mode.
+//
+// In lenient mode, the payments which could be decoded are returned along with a
+// [*PaymentDecodeError] for each record which could not. The decode errors are always
+// nil in strict mode.
+func (rdr *Reader) ListPaymentsWith(
+	ctx context.Context,
+	opts *ListPaymentsOptions,
+) ([]Payment, []*PaymentDecodeError, error) {
+	if opts == nil {
+		opts = &ListPaymentsOptions{}
+	}
+
 	query := make(url.Values)
 
 	query.Set("skip", "0")
@@ -271,13 +700,266 @@ func (rdr *Reader) ListPayments(ctx context.Context) ([]Payment, error) {
 
 	respData, err := rdr.GetRequest(ctx, "/api/v1/wallet/payment?"+query.Encode())
 	if err != nil {
-		return nil, fmt.Errorf("ListPayments: %w", err)
+		return nil, nil, fmt.Errorf("ListPayments: %w", err)
 	}
 
 	var payments []Payment
-	if err := json.Unmarshal(respData, &payments); err != nil {
-		return nil, fmt.Errorf("invalid ListPayments response: %w", err)
+	var decodeErrs []*PaymentDecodeError
+	if opts.Lenient {
+		payments, decodeErrs, err = decodePaymentsLenient(bytes.NewReader(respData))
+	} else {
+		err = json.Unmarshal(respData, &payments)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid ListPayments response: %w", err)
+	}
+
+	SortPayments(payments, SortByTime)
+	return payments, decodeErrs, nil
+}
+
+// decodePaymentsLenient decodes a JSON array of payments from r one record at a
+// time, collecting an error for each record which cannot be decoded as a Payment.
+func decodePaymentsLenient(r io.Reader) ([]Payment, []*PaymentDecodeError, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return nil, nil, err
+	} else if tok != json.Delim('[') {
+		return nil, nil, fmt.Errorf("expected array of payments, got %v", tok)
+	}
+
+	var payments []Payment
+	var decodeErrs []*PaymentDecodeError
+	for i := 0; dec.More(); i++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+
+		var payment Payment
+		if err := json.Unmarshal(raw, &payment); err != nil {
+			decodeErrs = append(decodeErrs, &PaymentDecodeError{Index: i, Raw: raw, Err: err})
+			continue
+		}
+		payments = append(payments, payment)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return payments, decodeErrs, nil
+}
+
+// WalkPayments calls fn with each payment in the wallet's history, ordered from oldest
+// to newest as returned by WoS, stopping early if fn returns false.
+//
+// Unlike [Reader.ListPayments], payments are decoded one at a time into a single reused
+// [Payment], which greatly reduces memory use when summarizing large histories (see
+// BenchmarkPaymentsStreamDecode), at the cost of somewhat slower decoding. fn must
+// not retain the pointer it is given; copy the Payment if it is needed after fn returns.
+// Payments at the same time are not re-ordered by ID as they are by ListPayments.
+func (rdr *Reader) WalkPayments(ctx context.Context, fn func(*Payment) bool) error {
+	query := make(url.Values)
+	query.Set("skip", "0")
+	query.Set("reverse", "false") // ascending
+
+	respData, err := rdr.GetRequest(ctx, "/api/v1/wallet/payment?"+query.Encode())
+	if err != nil {
+		return fmt.Errorf("WalkPayments: %w", err)
+	}
+
+	if err := decodePayments(bytes.NewReader(respData), fn); err != nil {
+		return fmt.Errorf("invalid WalkPayments response: %w", err)
+	}
+	return nil
+}
+
+// AllPayments returns an iterator over the wallet's entire payment history, ordered
+// from oldest to newest, for use with range-over-func loops such as:
+//
+//	for payment, err := range rdr.AllPayments(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Payments are decoded one at a time, as with [Reader.WalkPayments]. If the history
+// cannot be fetched or decoded, the iterator yields a single zero Payment with the error.
+func (rdr *Reader) AllPayments(ctx context.Context) iter.Seq2[Payment, error] {
+	return func(yield func(Payment, error) bool) {
+		stopped := false
+		err := rdr.WalkPayments(ctx, func(payment *Payment) bool {
+			stopped = !yield(*payment, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(Payment{}, err)
+		}
+	}
+}
+
+// decodePayments decodes a JSON array of payments from r, calling fn with each
+// in turn until fn returns false. The same Payment is reused for every call.
+func decodePayments(r io.Reader, fn func(*Payment) bool) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("expected array of payments, got %v", tok)
+	}
+
+	var payment Payment
+	for dec.More() {
+		payment = Payment{}
+		if err := dec.Decode(&payment); err != nil {
+			return err
+		}
+		if !fn(&payment) {
+			return nil
+		}
 	}
 
+	_, err := dec.Token()
+	return err
+}
+
+// PaymentsSince returns the payments which occurred at or after the given time,
+// ordered from oldest to newest.
+//
+// The WoS API cannot filter payments by time, so this fetches the full
+// history and filters it locally.
+func (rdr *Reader) PaymentsSince(ctx context.Context, since time.Time) ([]Payment, error) {
+	payments, err := rdr.ListPayments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	i := sort.Search(len(payments), func(i int) bool {
+		return !payments[i].Time.Before(since)
+	})
+	return payments[i:], nil
+}
+
+// RecentPayments returns up to limit of the wallet's most recent payments,
+// ordered from oldest to newest.
+func (rdr *Reader) RecentPayments(ctx context.Context, limit int) ([]Payment, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("RecentPayments: negative limit %d", limit)
+	}
+	payments, err := rdr.paymentPage(ctx, 0, limit)
+	if err != nil {
+		return nil, fmt.Errorf("RecentPayments: %w", err)
+	}
+
+	SortPayments(payments, SortByTime)
+	if len(payments) > limit {
+		payments = payments[len(payments)-limit:]
+	}
+	return payments, nil
+}
+
+// pendingPageSize is the number of payments fetched per request by [Reader.PendingPayments].
+const pendingPageSize = 50
+
+// PendingPayments returns the wallet's pending payments, such as on-chain payments
+// which are still confirming, ordered from oldest to newest. See [Payment.IsPending].
+//
+// As pending payments are recent, this fetches the history a page at a time from
+// newest to oldest, stopping at the first page with no pending payments.
+func (rdr *Reader) PendingPayments(ctx context.Context) ([]Payment, error) {
+	var pending []Payment
+	for skip := 0; ; skip += pendingPageSize {
+		page, err := rdr.paymentPage(ctx, skip, pendingPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("PendingPayments: %w", err)
+		}
+
+		found := false
+		for _, payment := range page {
+			if payment.IsPending() {
+				pending = append(pending, payment)
+				found = true
+			}
+		}
+		if !found || len(page) < pendingPageSize {
+			break
+		}
+	}
+
+	SortPayments(pending, SortByTime)
+	return pending, nil
+}
+
+// paymentPageEndpoint returns the endpoint for a page of the wallet's payment history,
+// skipping the skip most recent payments, ordered from newest to oldest.
+func paymentPageEndpoint(skip, limit int) string {
+	query := make(url.Values)
+	query.Set("skip", strconv.Itoa(skip))
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("reverse", "true") // descending
+	return "/api/v1/wallet/payment?" + query.Encode()
+}
+
+// paymentPage fetches up to limit payments from the wallet's history, skipping
+// the skip most recent payments, ordered from newest to oldest.
+func (rdr *Reader) paymentPage(ctx context.Context, skip, limit int) ([]Payment, error) {
+	respData, err := rdr.GetRequest(ctx, paymentPageEndpoint(skip, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []Payment
+	if err := json.Unmarshal(respData, &payments); err != nil {
+		return nil, fmt.Errorf("invalid payment history response: %w", err)
+	}
 	return payments, nil
 }
+
+// totalCountHeader is the conventional header for the total size of a paginated collection.
+const totalCountHeader = "X-Total-Count"
+
+// PaymentsPage fetches one page of up to limit payments from the wallet's history,
+// skipping the skip most recent payments. Payments are ordered from newest to oldest.
+//
+// The total number of payments in the history is also returned, so that paginators
+// can show progress without fetching every page. WoS does not document a total count,
+// so this is read from an X-Total-Count header or a total field in the response if
+// either is present, and is -1 otherwise.
+func (rdr *Reader) PaymentsPage(ctx context.Context, skip, limit int) (payments []Payment, total int, err error) {
+	resp, err := rdr.GetRequestRaw(ctx, paymentPageEndpoint(skip, limit))
+	if err != nil {
+		return nil, -1, fmt.Errorf("PaymentsPage: %w", err)
+	}
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, -1, fmt.Errorf("PaymentsPage: %w", err)
+	}
+
+	total = -1
+	if header := resp.Header.Get(totalCountHeader); header != "" {
+		if n, err := strconv.Atoi(header); err == nil && n >= 0 {
+			total = n
+		}
+	}
+
+	// The history is usually a bare array, but may be wrapped in an object with a total.
+	if trimmed := bytes.TrimSpace(respData); len(trimmed) > 0 && trimmed[0] == '{' {
+		var page struct {
+			Payments []Payment `json:"payments"`
+			Total    *int      `json:"total"`
+		}
+		if err := json.Unmarshal(trimmed, &page); err != nil {
+			return nil, -1, fmt.Errorf("invalid PaymentsPage response: %w", err)
+		}
+		if page.Total != nil && *page.Total >= 0 {
+			total = *page.Total
+		}
+		return page.Payments, total, nil
+	}
+
+	if err := json.Unmarshal(respData, &payments); err != nil {
+		return nil, -1, fmt.Errorf("invalid PaymentsPage response: %w", err)
+	}
+	return payments, total, nil
+}
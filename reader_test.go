@@ -0,0 +1,544 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetRequestRaw(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		if r.URL.Path == "/api/v1/wallet/balance" {
+			w.Write([]byte(`{"btc":0.1}`))
+		} else {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message":"slow down"}`))
+		}
+	}))
+
+	resp, err := rdr.GetRequestRaw(context.Background(), "/api/v1/wallet/balance")
+	if err != nil {
+		t.Fatalf("GetRequestRaw failed: %v", err)
+	} else if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "42" {
+		t.Fatalf("expected rate limit header 42, got %q", remaining)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read buffered body: %v", err)
+	} else if string(body) != `{"btc":0.1}` {
+		t.Fatalf("unexpected body: %q", body)
+	}
+
+	resp, err = rdr.GetRequestRaw(context.Background(), "/api/v1/wallet/payment")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	} else if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected response to be returned alongside error")
+	} else if resp.Header.Get("X-RateLimit-Remaining") != "42" {
+		t.Fatalf("headers not accessible on error response")
+	}
+}
+
+func TestFeeEstimateDerivations(t *testing.T) {
+	fees := FeeEstimate{
+		BtcFixedFee:              0.00001,
+		BtcMinerFeePerKB:         0.00012,
+		BtcSendCommissionPercent: 0.005,
+	}
+
+	if rate := fees.MinerFeePerVByte(); math.Abs(rate-12) > 1e-9 {
+		t.Errorf("expected 12 sat/vB, got %f", rate)
+	}
+	if commission := fees.CommissionOn(0.01); math.Abs(commission-0.00005) > 1e-12 {
+		t.Errorf("expected commission 0.00005, got %.8f", commission)
+	}
+	if total := fees.TotalOnChainFee(0.01); math.Abs(total-0.00006) > 1e-12 {
+		t.Errorf("expected total fee 0.00006, got %.8f", total)
+	}
+}
+
+func TestReaderCoalescing(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Write([]byte(`{"btc":0.25}`))
+	}))
+	rdr.EnableCoalescing(time.Second)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			balance, err := rdr.Balance(context.Background())
+			if err == nil && balance.Confirmed != 0.25 {
+				err = fmt.Errorf("unexpected balance %+v", balance)
+			}
+			errs <- err
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Balance failed: %v", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("expected 1 HTTP request, got %d", n)
+	}
+}
+
+func TestRecentPayments(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") != "2" || r.URL.Query().Get("reverse") != "true" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		fmt.Fprintf(w, `[{"id":"c","time":%q},{"id":"b","time":%q}]`,
+			base.Add(2*time.Minute).Format(time.RFC3339), base.Add(time.Minute).Format(time.RFC3339))
+	}))
+
+	payments, err := rdr.RecentPayments(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("RecentPayments failed: %v", err)
+	}
+	if len(payments) != 2 || payments[0].ID != "b" || payments[1].ID != "c" {
+		t.Fatalf("expected payments b, c in ascending order, got %+v", payments)
+	}
+
+	if _, err := rdr.RecentPayments(context.Background(), -1); err == nil {
+		t.Fatalf("expected an error for a negative limit")
+	}
+}
+
+// testPaymentsJSON returns a JSON array of n payments, as returned by the WoS history endpoint.
+func testPaymentsJSON(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf,
+			`{"id":"payment-%d","address":"lnbc1example%d","amount":0.0000%d,"currency":"LIGHTNING",`+
+				`"description":"payment number %d","status":"PAID","time":"2024-01-01T00:00:%02dZ","type":"CREDIT"}`,
+			i, i, i%10, i, i%60,
+		)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
+
+func TestWalkPayments(t *testing.T) {
+	data := testPaymentsJSON(50)
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+
+	var ids []string
+	err := rdr.WalkPayments(context.Background(), func(p *Payment) bool {
+		ids = append(ids, p.ID)
+		return len(ids) < 10
+	})
+	if err != nil {
+		t.Fatalf("WalkPayments failed: %v", err)
+	}
+	if len(ids) != 10 || ids[0] != "payment-0" || ids[9] != "payment-9" {
+		t.Fatalf("unexpected payments walked: %v", ids)
+	}
+
+	var walked []Payment
+	decodePayments(bytes.NewReader(data), func(p *Payment) bool {
+		walked = append(walked, *p)
+		return true
+	})
+	var unmarshaled []Payment
+	json.Unmarshal(data, &unmarshaled)
+	if !reflect.DeepEqual(walked, unmarshaled) {
+		t.Fatalf("streaming decode does not match full unmarshal")
+	}
+}
+
+func BenchmarkPaymentsUnmarshal(b *testing.B) {
+	data := testPaymentsJSON(5000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var total float64
+		var payments []Payment
+		if err := json.Unmarshal(data, &payments); err != nil {
+			b.Fatal(err)
+		}
+		for _, p := range payments {
+			total += p.Amount
+		}
+	}
+}
+
+func BenchmarkPaymentsStreamDecode(b *testing.B) {
+	data := testPaymentsJSON(5000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var total float64
+		err := decodePayments(bytes.NewReader(data), func(p *Payment) bool {
+			total += p.Amount
+			return true
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCheaperRoute(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		amount, _ := strconv.ParseFloat(r.URL.Query().Get("amount"), 64)
+		fmt.Fprintf(w, `{"btcFixedFee":0.00002,"btcSendCommissionPercent":0.001,"lightningFee":%.11f}`, amount*0.005)
+	}))
+
+	route, fees, err := rdr.CheaperRoute(context.Background(), 0.0001)
+	if err != nil {
+		t.Fatalf("CheaperRoute failed: %v", err)
+	} else if route != PaymentCurrencyLightning {
+		t.Fatalf("expected lightning for small amount, got %s (fees %+v)", route, fees)
+	}
+
+	route, fees, err = rdr.CheaperRoute(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("CheaperRoute failed: %v", err)
+	} else if route != PaymentCurrencyBitcoin {
+		t.Fatalf("expected on-chain for large amount, got %s (fees %+v)", route, fees)
+	}
+}
+
+func TestReaderSingleAddress(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"btcDepositAddress":"","lightningAddress":"user@walletofsatoshi.com"}`))
+	}))
+
+	lnAddress, err := rdr.LightningAddress(context.Background())
+	if err != nil {
+		t.Fatalf("LightningAddress failed: %v", err)
+	} else if lnAddress.String() != "user@walletofsatoshi.com" {
+		t.Fatalf("unexpected lightning address: %s", lnAddress)
+	}
+
+	if _, err := rdr.OnChainAddress(context.Background()); !errors.Is(err, ErrFeatureUnavailable) {
+		t.Fatalf("expected ErrFeatureUnavailable from OnChainAddress, got %v", err)
+	}
+	if _, err := rdr.Addresses(context.Background()); !errors.Is(err, ErrFeatureUnavailable) {
+		t.Fatalf("expected ErrFeatureUnavailable from Addresses, got %v", err)
+	}
+}
+
+func TestIsInvoicePaid(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/payment/paid-invoice":
+			w.Write([]byte(`{"id":"paid-invoice","amount":0.0001,"status":"PAID","type":"CREDIT"}`))
+		case "/api/v1/wallet/payment/unpaid-invoice":
+			w.Write([]byte(`{"id":"unpaid-invoice","amount":0.0001,"status":"PENDING","type":"CREDIT"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	paid, payment, err := rdr.IsInvoicePaid(context.Background(), "paid-invoice")
+	if err != nil {
+		t.Fatalf("IsInvoicePaid failed: %v", err)
+	} else if !paid || payment == nil || payment.Amount != 0.0001 {
+		t.Fatalf("expected paid invoice with payment, got %v %+v", paid, payment)
+	}
+
+	paid, payment, err = rdr.IsInvoicePaid(context.Background(), "unpaid-invoice")
+	if err != nil {
+		t.Fatalf("IsInvoicePaid failed: %v", err)
+	} else if paid || payment != nil {
+		t.Fatalf("expected unpaid invoice, got %v %+v", paid, payment)
+	}
+
+	if _, _, err := rdr.IsInvoicePaid(context.Background(), "unknown"); !errors.Is(err, ErrInvoiceNotFound) {
+		t.Fatalf("expected ErrInvoiceNotFound, got %v", err)
+	}
+}
+
+func TestPendingPayments(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// History from newest to oldest: the first page holds pending payments,
+	// the second has none, and the third should never be fetched.
+	var history []Payment
+	for i := 0; i < pendingPageSize*3; i++ {
+		status := PaymentStatusPaid
+		if i == 3 || i == pendingPageSize-1 {
+			status = PaymentStatusPending
+		}
+		history = append(history, Payment{
+			ID:     fmt.Sprintf("p%03d", i),
+			Status: status,
+			Time:   base.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	history[pendingPageSize*2+1].Status = PaymentStatusPending
+
+	var requests int
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		end := skip + limit
+		if end > len(history) {
+			end = len(history)
+		}
+		json.NewEncoder(w).Encode(history[skip:end])
+	}))
+
+	pending, err := rdr.PendingPayments(context.Background())
+	if err != nil {
+		t.Fatalf("PendingPayments failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].ID != fmt.Sprintf("p%03d", pendingPageSize-1) || pending[1].ID != "p003" {
+		t.Fatalf("unexpected pending payments: %+v", pending)
+	}
+	if requests != 2 {
+		t.Fatalf("expected to stop after 2 pages, made %d requests", requests)
+	}
+}
+
+func TestSplitFeeEstimate(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		// Lightning fees have a base of 1 sat plus 0.1% of the amount.
+		amount, _ := strconv.ParseFloat(r.URL.Query().Get("amount"), 64)
+		fmt.Fprintf(w, `{"btcFixedFee":0.00002,"btcSendCommissionPercent":0.001,"lightningFee":%.11f}`,
+			0.00000001+amount*0.001)
+	}))
+
+	for _, destination := range []string{"bob@getalby.com", "bc1qdestination"} {
+		var previous float64
+		for _, parts := range []int{1, 2, 5} {
+			fee, err := rdr.SplitFeeEstimate(context.Background(), destination, 0.01, parts)
+			if err != nil {
+				t.Fatalf("SplitFeeEstimate failed: %v", err)
+			}
+			if fee <= previous {
+				t.Fatalf("%s: expected %d parts to cost more than %.8f, got %.8f", destination, parts, previous, fee)
+			}
+			previous = fee
+		}
+	}
+
+	fee, _ := rdr.SplitFeeEstimate(context.Background(), "bc1qdestination", 0.01, 2)
+	if want := 2*0.00002 + 0.01*0.001; math.Abs(fee-want) > 1e-12 {
+		t.Fatalf("expected on-chain split fee %.8f, got %.8f", want, fee)
+	}
+}
+
+func TestFeeEstimateCache(t *testing.T) {
+	var requests atomic.Int32
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/wallet/feeEstimate" {
+			requests.Add(1)
+			w.Write([]byte(`{"btcFixedFee":0.00002,"lightningFee":0.000001}`))
+		} else {
+			w.Write([]byte(`{"btc":0.25}`))
+		}
+	}))
+
+	ctx := context.Background()
+	const address = "bc1qdestination"
+
+	if _, err := rdr.FeeEstimate(ctx, address); err != nil {
+		t.Fatalf("FeeEstimate failed: %v", err)
+	}
+	if _, err := rdr.FeeEstimate(ctx, address); err != nil {
+		t.Fatalf("FeeEstimate failed: %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("expected 2 requests without caching, got %d", n)
+	}
+
+	rdr.SetFeeEstimateCacheTTL(time.Minute)
+	requests.Store(0)
+
+	first, err := rdr.FeeEstimate(ctx, address)
+	if err != nil {
+		t.Fatalf("FeeEstimate failed: %v", err)
+	}
+	first.BtcFixedFee = 1 // Mutating a returned estimate must not affect the cache.
+
+	_, fees, err := rdr.BalanceAndFee(ctx, address)
+	if err != nil {
+		t.Fatalf("BalanceAndFee failed: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("expected second estimate within TTL to hit the cache, got %d requests", n)
+	}
+	if fees.BtcFixedFee != 0.00002 || fees.Destination != address {
+		t.Fatalf("unexpected cached estimate: %+v", fees)
+	}
+
+	// Different amounts to the same variable-amount destination are cached separately.
+	if _, err := rdr.SplitFeeEstimate(ctx, "bob@getalby.com", 0.01, 1); err != nil {
+		t.Fatalf("SplitFeeEstimate failed: %v", err)
+	}
+	if _, err := rdr.SplitFeeEstimate(ctx, "bob@getalby.com", 0.01, 2); err != nil {
+		t.Fatalf("SplitFeeEstimate failed: %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Fatalf("expected distinct amounts to miss the cache, got %d requests", n)
+	}
+
+	rdr.feeCache.ttl = 0 // Expire everything.
+	if _, err := rdr.FeeEstimate(ctx, address); err != nil {
+		t.Fatalf("FeeEstimate failed: %v", err)
+	}
+	if n := requests.Load(); n != 4 {
+		t.Fatalf("expected expired estimate to be refetched, got %d requests", n)
+	}
+}
+
+func TestPaymentsPage(t *testing.T) {
+	var body, header string
+	var query url.Values
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if header != "" {
+			w.Header().Set("X-Total-Count", header)
+		}
+		w.Write([]byte(body))
+	}))
+
+	body, header = `[{"id":"c"},{"id":"b"}]`, "42"
+	payments, total, err := rdr.PaymentsPage(context.Background(), 10, 2)
+	if err != nil {
+		t.Fatalf("PaymentsPage failed: %v", err)
+	} else if len(payments) != 2 || payments[0].ID != "c" || total != 42 {
+		t.Fatalf("unexpected page: %+v, total %d", payments, total)
+	} else if query.Get("skip") != "10" || query.Get("limit") != "2" || query.Get("reverse") != "true" {
+		t.Fatalf("unexpected query: %v", query)
+	}
+
+	body, header = `{"payments":[{"id":"a"}],"total":7}`, ""
+	payments, total, err = rdr.PaymentsPage(context.Background(), 0, 1)
+	if err != nil {
+		t.Fatalf("PaymentsPage failed: %v", err)
+	} else if len(payments) != 1 || payments[0].ID != "a" || total != 7 {
+		t.Fatalf("unexpected page: %+v, total %d", payments, total)
+	}
+
+	body, header = `[{"id":"a"}]`, ""
+	if _, total, err = rdr.PaymentsPage(context.Background(), 0, 1); err != nil {
+		t.Fatalf("PaymentsPage failed: %v", err)
+	} else if total != -1 {
+		t.Fatalf("expected total of -1 when unknown, got %d", total)
+	}
+}
+
+func TestReaderWithToken(t *testing.T) {
+	var tokens []string
+	rdr := NewReader("old", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Api-Token"))
+		w.Write([]byte(`{"btc":0.001,"btcUnconfirmed":0}`))
+	}))
+	rdr.SetMaxRedirects(3)
+	rdr.EnableCoalescing(time.Minute)
+
+	clone := rdr.WithToken("new")
+	if clone.httpClient != rdr.httpClient || clone.maxRedirects != 3 {
+		t.Fatalf("expected clone to share transport config")
+	} else if clone.coalescer == nil || clone.coalescer == rdr.coalescer {
+		t.Fatalf("expected clone to have its own coalescer")
+	}
+
+	ctx := context.Background()
+	if _, err := clone.Balance(ctx); err != nil {
+		t.Fatalf("Balance failed: %v", err)
+	}
+	if _, err := rdr.Balance(ctx); err != nil {
+		t.Fatalf("Balance failed: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0] != "new" || tokens[1] != "old" {
+		t.Fatalf("expected clone to use the new token and the original the old one, got %v", tokens)
+	}
+}
+
+func TestPaymentsForInvoice(t *testing.T) {
+	decoded, err := DecodeInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	hash := hex.EncodeToString(decoded.PaymentHash)
+
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[
+			{"id":"part2","type":"CREDIT","amount":0.001,"transactionId":%[1]q,"time":"2024-01-01T00:00:02Z"},
+			{"id":"other","type":"CREDIT","amount":0.005,"transactionId":"deadbeef","time":"2024-01-01T00:00:00Z"},
+			{"id":"part1","type":"CREDIT","amount":0.0015,"transactionId":%[1]q,"time":"2024-01-01T00:00:01Z"},
+			{"id":"debit","type":"DEBIT","amount":0.0025,"transactionId":%[1]q,"time":"2024-01-01T00:00:03Z"}
+		]`, strings.ToUpper(hash))
+	}))
+
+	payments, err := rdr.PaymentsForInvoice(context.Background(), &Invoice{ID: "inv", Bolt11: testInvoiceCoffee})
+	if err != nil {
+		t.Fatalf("PaymentsForInvoice failed: %v", err)
+	}
+	if len(payments) != 2 || payments[0].ID != "part1" || payments[1].ID != "part2" {
+		t.Fatalf("expected both parts of the invoice in order, got %+v", payments)
+	}
+}
+
+func TestListPaymentsLenient(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id":"a","amount":0.001},
+			{"id":"b","amount":"not a number"},
+			{"id":"c","amount":0.002}
+		]`))
+	}))
+	ctx := context.Background()
+
+	if _, err := rdr.ListPayments(ctx); err == nil {
+		t.Fatalf("expected strict mode to reject the corrupt record")
+	}
+
+	payments, decodeErrs, err := rdr.ListPaymentsWith(ctx, &ListPaymentsOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("lenient ListPaymentsWith failed: %v", err)
+	}
+	if len(payments) != 2 || payments[0].ID != "a" || payments[1].ID != "c" {
+		t.Fatalf("expected payments a and c, got %+v", payments)
+	}
+	if len(decodeErrs) != 1 || decodeErrs[0].Index != 1 || !strings.Contains(string(decodeErrs[0].Raw), `"b"`) {
+		t.Fatalf("expected one decode error for record 1, got %v", decodeErrs)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(decodeErrs[0], &typeErr) {
+		t.Fatalf("expected decode error to unwrap to the JSON error, got %v", decodeErrs[0].Err)
+	}
+}
@@ -0,0 +1,72 @@
+package wos
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReadOnlyWallet is a view-only handle on a WoS wallet. It can fetch balances,
+// addresses, payment history and fee estimates, but it has no [Signer], and so
+// it cannot create invoices or send payments.
+//
+// ReadOnlyWallet is well suited for monitoring services which only hold
+// a wallet's APIToken. Use [OpenReadOnlyWallet] to create one.
+type ReadOnlyWallet struct {
+	reader           *Reader
+	onChainAddress   string
+	lightningAddress LightningAddress
+}
+
+// OpenReadOnlyWallet opens a [ReadOnlyWallet] using only a [Reader].
+// No APISecret or [Signer] is needed.
+func OpenReadOnlyWallet(ctx context.Context, reader *Reader) (*ReadOnlyWallet, error) {
+	addresses, err := reader.Addresses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("OpenReadOnlyWallet: %w", err)
+	}
+
+	lnAddress, err := ParseLightningAddress(addresses.Lightning)
+	if err != nil {
+		return nil, fmt.Errorf("OpenReadOnlyWallet: %w", err)
+	}
+
+	wallet := &ReadOnlyWallet{
+		reader:           reader,
+		onChainAddress:   addresses.OnChain,
+		lightningAddress: lnAddress,
+	}
+	return wallet, nil
+}
+
+// LightningAddress returns the wallet's static Lightning Address.
+func (wallet *ReadOnlyWallet) LightningAddress() LightningAddress {
+	return wallet.lightningAddress
+}
+
+// OnChainAddress returns the wallet's on-chain deposit address, as of
+// when the wallet was opened. To fetch an up-to-date address, use
+// [ReadOnlyWallet.Addresses].
+func (wallet *ReadOnlyWallet) OnChainAddress() string {
+	return wallet.onChainAddress
+}
+
+// Addresses re-fetches the wallet's on-chain and lightning addresses.
+func (wallet *ReadOnlyWallet) Addresses(ctx context.Context) (*Addresses, error) {
+	return wallet.reader.Addresses(ctx)
+}
+
+// Balance returns the current confirmed and unconfirmed balances of the wallet.
+func (wallet *ReadOnlyWallet) Balance(ctx context.Context) (*Balance, error) {
+	return wallet.reader.Balance(ctx)
+}
+
+// FeeEstimate fetches the latest fee estimation data when paying to a given on-chain
+// address or lightning invoice.
+func (wallet *ReadOnlyWallet) FeeEstimate(ctx context.Context, addressOrInvoice string) (*FeeEstimate, error) {
+	return wallet.reader.FeeEstimate(ctx, addressOrInvoice)
+}
+
+// ListPayments returns the wallet's payment history.
+func (wallet *ReadOnlyWallet) ListPayments(ctx context.Context) ([]Payment, error) {
+	return wallet.reader.ListPayments(ctx)
+}
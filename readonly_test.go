@@ -0,0 +1,41 @@
+package wos
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestOpenReadOnlyWallet(t *testing.T) {
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Api-Token") != "read-only-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/wallet/account":
+			w.Write([]byte(`{"btcDepositAddress":"bc1qexample","lightningAddress":"monitor@walletofsatoshi.com"}`))
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":0.5,"btcUnconfirmed":0.1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+	wallet, err := OpenReadOnlyWallet(ctx, NewReader("read-only-token", httpClient))
+	if err != nil {
+		t.Fatalf("failed to open read-only wallet: %v", err)
+	}
+
+	if addr := wallet.LightningAddress().String(); addr != "monitor@walletofsatoshi.com" {
+		t.Fatalf("unexpected lightning address: %s", addr)
+	}
+
+	balance, err := wallet.Balance(ctx)
+	if err != nil {
+		t.Fatalf("failed to read balance: %v", err)
+	} else if balance.Confirmed != 0.5 {
+		t.Fatalf("unexpected balance: %+v", balance)
+	}
+}
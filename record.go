@@ -0,0 +1,124 @@
+package wos
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces secret values in recorded requests.
+const redacted = "[REDACTED]"
+
+// RecordedRequest is a single API request written by a recorder enabled with
+// [Reader.RecordRequests], as one line of JSON.
+type RecordedRequest struct {
+	Time       time.Time         `json:"time"`
+	Method     string            `json:"method"`
+	Endpoint   string            `json:"endpoint"`
+	Headers    map[string]string `json:"headers"`
+	Body       any               `json:"body,omitempty"`
+	Status     int               `json:"status,omitempty"`
+	Response   any               `json:"response,omitempty"`
+	DurationMs int64             `json:"durationMs"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type requestRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (rec *requestRecorder) write(entry *RecordedRequest) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.enc.Encode(entry)
+}
+
+// RecordRequests makes the Reader append every API request it sends to w, as JSON lines
+// describing the endpoint, headers, body, response and timing of each request. This
+// produces a trace which can be shared with WoS support or the maintainers of this
+// package when debugging unexpected API behavior. Pass a nil writer to stop recording.
+//
+// API tokens, signatures and secrets are redacted from headers, request bodies and
+// response bodies, but recordings may still contain addresses, invoices, and amounts.
+// Write errors are ignored.
+func (rdr *Reader) RecordRequests(w io.Writer) {
+	if w == nil {
+		rdr.recorder = nil
+		return
+	}
+	rdr.recorder = &requestRecorder{enc: json.NewEncoder(w)}
+}
+
+// RecordRequests makes the wallet record all API requests it sends to w.
+// See [Reader.RecordRequests].
+func (wallet *Wallet) RecordRequests(w io.Writer) {
+	wallet.reader.RecordRequests(w)
+}
+
+// newRecordedRequest captures the redacted details of a request before it is sent.
+func newRecordedRequest(req *http.Request) *RecordedRequest {
+	entry := &RecordedRequest{
+		Time:     time.Now(),
+		Method:   req.Method,
+		Endpoint: req.URL.RequestURI(),
+		Headers:  make(map[string]string, len(req.Header)),
+	}
+	for name := range req.Header {
+		value := req.Header.Get(name)
+		if isSecretKey(name) {
+			value = redacted
+		}
+		entry.Headers[name] = value
+	}
+
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			entry.Body = redactBody(data)
+		}
+	}
+	return entry
+}
+
+// isSecretKey reports whether a header or JSON field name might hold a credential.
+func isSecretKey(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "token") ||
+		strings.Contains(name, "secret") ||
+		strings.Contains(name, "signature")
+}
+
+// redactBody parses a JSON body and redacts any secret fields. Bodies which are not
+// JSON are recorded as plain strings.
+func redactBody(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return string(data)
+	}
+	return redactValue(value)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSecretKey(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []any:
+		for i, elem := range v {
+			v[i] = redactValue(elem)
+		}
+	}
+	return value
+}
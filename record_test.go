@@ -0,0 +1,72 @@
+package wos
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRecordRequests(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/account":
+			w.Write([]byte(`{"btcDepositAddress":"bc1qexample","apiToken":"leaked-token"}`))
+		case "/api/v1/wallet/createInvoice":
+			w.Write([]byte(`{"id":"inv1","invoice":"` + testInvoiceDonation + `"}`))
+		}
+	})
+
+	var buf bytes.Buffer
+	wallet.RecordRequests(&buf)
+
+	ctx := context.Background()
+	wallet.reader.GetRequest(ctx, "/api/v1/wallet/account")
+	if _, err := wallet.NewInvoice(ctx, &InvoiceOptions{Description: "coffee"}); err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	}
+
+	wallet.RecordRequests(nil)
+	wallet.reader.GetRequest(ctx, "/api/v1/wallet/account")
+
+	if strings.Contains(buf.String(), "leaked-token") || strings.Contains(buf.String(), `"token"`) {
+		t.Fatalf("recording contains secrets:\n%s", buf.String())
+	}
+
+	var entries []RecordedRequest
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var entry RecordedRequest
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 recorded requests, got %d", len(entries))
+	}
+
+	get, post := entries[0], entries[1]
+	if get.Method != "GET" || get.Endpoint != "/api/v1/wallet/account" || get.Status != 200 {
+		t.Fatalf("unexpected GET entry: %+v", get)
+	}
+	if get.Headers["Api-Token"] != redacted {
+		t.Fatalf("expected redacted Api-Token header, got %q", get.Headers["Api-Token"])
+	}
+	if resp := get.Response.(map[string]any); resp["apiToken"] != redacted || resp["btcDepositAddress"] != "bc1qexample" {
+		t.Fatalf("unexpected recorded response: %v", resp)
+	}
+
+	if post.Method != "POST" || post.Endpoint != "/api/v1/wallet/createInvoice" {
+		t.Fatalf("unexpected POST entry: %+v", post)
+	}
+	if post.Headers["Signature"] != redacted || post.Headers["Api-Token"] != redacted {
+		t.Fatalf("expected redacted POST headers, got %v", post.Headers)
+	}
+	if body := post.Body.(map[string]any); body["description"] != "coffee" {
+		t.Fatalf("unexpected recorded body: %v", body)
+	}
+}
@@ -0,0 +1,132 @@
+package wos
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDuplicateInvoice is returned by an [InvoiceRegistry] when an invoice collides with
+// one issued before. This should never happen, and indicates a bug such as an invoice
+// being stored and re-displayed across orders.
+var ErrDuplicateInvoice = errors.New("invoice was already issued")
+
+// ErrUnknownInvoice is returned by [InvoiceRegistry.MatchPayment] when a payment is for
+// an invoice the registry has no record of.
+var ErrUnknownInvoice = errors.New("invoice was not issued by this registry")
+
+// IssuedInvoice records an invoice registered with an [InvoiceRegistry].
+type IssuedInvoice struct {
+	// ID is the WoS invoice ID.
+	ID string `json:"id"`
+
+	// PaymentHash identifies the invoice's payment.
+	PaymentHash []byte `json:"paymentHash"`
+
+	// Bolt11 is the serialized invoice.
+	Bolt11 string `json:"bolt11"`
+
+	// Reference is the caller's identifier for what the invoice was issued for,
+	// such as an order ID.
+	Reference string `json:"reference,omitempty"`
+
+	// IssuedAt is when the invoice was registered.
+	IssuedAt time.Time `json:"issuedAt"`
+}
+
+// InvoiceRegistry records every invoice issued by an integration in an [IssuedInvoiceStore], keyed
+// by payment hash, to detect accidental invoice reuse. If the same invoice were shown
+// for two orders, a payment could not be attributed to either; the registry flags such
+// collisions when invoices are registered, and when payments are matched to them.
+//
+// An InvoiceRegistry is safe for concurrent use, though duplicates registered through
+// different registries sharing a store at the same moment may go undetected.
+type InvoiceRegistry struct {
+	store IssuedInvoiceStore
+
+	mu sync.Mutex
+}
+
+// NewInvoiceRegistry returns an InvoiceRegistry which records invoices in store.
+func NewInvoiceRegistry(store IssuedInvoiceStore) *InvoiceRegistry {
+	return &InvoiceRegistry{store: store}
+}
+
+// Register records an invoice returned by [Wallet.NewInvoice], issued for the given
+// reference. Returns an error wrapping [ErrDuplicateInvoice] if an invoice with the
+// same payment hash was registered before, in which case the earlier record is kept.
+func (reg *InvoiceRegistry) Register(ctx context.Context, invoice *Invoice, reference string) (*IssuedInvoice, error) {
+	if len(invoice.PaymentHash) == 0 {
+		return nil, fmt.Errorf("Register: invoice %s has no payment hash", invoice.ID)
+	}
+	name := hex.EncodeToString(invoice.PaymentHash)
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	prior, err := reg.store.LoadIssuedInvoice(ctx, name)
+	if err == nil {
+		return nil, fmt.Errorf(
+			"Register: %w: payment hash %s was issued as invoice %s for %q",
+			ErrDuplicateInvoice, name, prior.ID, prior.Reference,
+		)
+	} else if !errors.Is(err, ErrNotStored) {
+		return nil, fmt.Errorf("Register: %w", err)
+	}
+
+	issued := IssuedInvoice{
+		ID:          invoice.ID,
+		PaymentHash: invoice.PaymentHash,
+		Bolt11:      invoice.Bolt11,
+		Reference:   reference,
+		IssuedAt:    time.Now(),
+	}
+	if err := reg.store.SaveIssuedInvoice(ctx, name, issued); err != nil {
+		return nil, fmt.Errorf("Register: %w", err)
+	}
+	return &issued, nil
+}
+
+// Lookup returns the record of the invoice with the given payment hash, or an error
+// wrapping [ErrNotStored] if it was never registered.
+func (reg *InvoiceRegistry) Lookup(ctx context.Context, paymentHash []byte) (*IssuedInvoice, error) {
+	issued, err := reg.store.LoadIssuedInvoice(ctx, hex.EncodeToString(paymentHash))
+	if err != nil {
+		return nil, fmt.Errorf("Lookup: %w", err)
+	}
+	return issued, nil
+}
+
+// MatchPayment returns the record of the invoice a lightning payment was made to.
+// Returns an error wrapping [ErrUnknownInvoice] if the payment is not for a registered
+// invoice, or [ErrDuplicateInvoice] if its payment hash was registered for a different
+// invoice, meaning the payment cannot be attributed reliably.
+func (reg *InvoiceRegistry) MatchPayment(ctx context.Context, payment Payment) (*IssuedInvoice, error) {
+	bolt11, ok := payment.Invoice()
+	if !ok {
+		return nil, fmt.Errorf("MatchPayment: %w: payment %s has no invoice", ErrUnknownInvoice, payment.ID)
+	}
+	decoded, err := DecodeInvoice(bolt11)
+	if err != nil {
+		return nil, fmt.Errorf("MatchPayment: %w", err)
+	}
+
+	issued, err := reg.store.LoadIssuedInvoice(ctx, hex.EncodeToString(decoded.PaymentHash))
+	if errors.Is(err, ErrNotStored) {
+		return nil, fmt.Errorf("MatchPayment: %w: payment %s", ErrUnknownInvoice, payment.ID)
+	} else if err != nil {
+		return nil, fmt.Errorf("MatchPayment: %w", err)
+	}
+
+	if issued.Bolt11 != "" && !strings.EqualFold(issued.Bolt11, bolt11) {
+		return nil, fmt.Errorf(
+			"MatchPayment: %w: payment %s is for a different invoice with the payment hash of invoice %s",
+			ErrDuplicateInvoice, payment.ID, issued.ID,
+		)
+	}
+	return issued, nil
+}
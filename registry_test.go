@@ -0,0 +1,51 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestInvoiceRegistry(t *testing.T) {
+	// A buggy integration which hands out the same invoice for every order.
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"inv1","invoice":"` + testInvoiceCoffee + `","btcAmount":0.0025}`))
+	})
+	ctx := context.Background()
+	registry := NewInvoiceRegistry(&MemoryStore{})
+
+	first, err := wallet.NewInvoice(ctx, &InvoiceOptions{Amount: 0.0025})
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	}
+	if _, err := registry.Register(ctx, first, "order-1"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	second, err := wallet.NewInvoice(ctx, &InvoiceOptions{Amount: 0.0025})
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	}
+	if _, err := registry.Register(ctx, second, "order-2"); !errors.Is(err, ErrDuplicateInvoice) {
+		t.Fatalf("expected ErrDuplicateInvoice, got %v", err)
+	}
+
+	issued, err := registry.MatchPayment(ctx, Payment{ID: "p1", Currency: PaymentCurrencyLightning, Address: testInvoiceCoffee})
+	if err != nil {
+		t.Fatalf("MatchPayment failed: %v", err)
+	} else if issued.Reference != "order-1" {
+		t.Fatalf("expected payment to match order-1, got %q", issued.Reference)
+	}
+
+	// The BOLT11 test vectors share a payment hash, as a colliding invoice would.
+	_, err = registry.MatchPayment(ctx, Payment{ID: "p2", Currency: PaymentCurrencyLightning, Address: testInvoiceDonation})
+	if !errors.Is(err, ErrDuplicateInvoice) {
+		t.Fatalf("expected ErrDuplicateInvoice for a different invoice with the same hash, got %v", err)
+	}
+
+	_, err = registry.MatchPayment(ctx, Payment{ID: "p3", Currency: "BTC", Address: "bc1qexample"})
+	if !errors.Is(err, ErrUnknownInvoice) {
+		t.Fatalf("expected ErrUnknownInvoice for an on-chain payment, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRetriesExhausted is returned by [Wallet.PayInvoiceWithRetryStrategy] when every
+// attempt allowed by the [RetryStrategy] has failed.
+var ErrRetriesExhausted = errors.New("payment retries exhausted")
+
+// RetryStrategy controls how [Wallet.PayInvoiceWithRetryStrategy] retries a failed
+// lightning payment.
+type RetryStrategy struct {
+	// MaxAttempts caps the total number of payment attempts. Defaults to 3 if zero.
+	MaxAttempts int
+
+	// FeeBuffer returns the maximum routing fee in BTC to allow on the given attempt,
+	// numbered from zero. Raising the buffer on later attempts can help payments which
+	// failed to find a route cheap enough. If nil, WoS chooses the fee on every attempt.
+	FeeBuffer func(attempt int) float64
+
+	// MaxFeeBuffer caps the fee buffer returned by FeeBuffer for each attempt.
+	// Attempts whose buffer would exceed it are not made. Only attempts which WoS
+	// rejected outright are retried, so at most one attempt is paid, and its routing
+	// fee is bounded by this cap. If zero, the buffer is not capped.
+	MaxFeeBuffer float64
+
+	// Delay is how long to wait between attempts.
+	Delay time.Duration
+
+	// ShouldRetry decides whether a failed attempt may be retried. If nil, attempts
+	// are only retried if WoS clearly rejected the payment with a client error status
+	// matching [ErrLowFee] or [ErrNoRoute]. Errors where the outcome of the payment is
+	// unknown, such as network errors and server errors, must not be retried, or the
+	// invoice could be paid twice.
+	ShouldRetry func(err error) bool
+}
+
+func defaultShouldRetry(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode < 400 || apiErr.StatusCode >= 500 {
+		return false
+	}
+	return errors.Is(apiErr, ErrLowFee) || errors.Is(apiErr, ErrNoRoute)
+}
+
+// PayInvoiceWithRetryStrategy is an experimental variant of [Wallet.PayInvoice] which
+// retries payments that fail, for example due to routing failures, according to the
+// given strategy. The strategy can raise the fee buffer allowed for routing on each
+// attempt, up to a cap.
+//
+// Retrying with larger fee buffers can make a payment cost more than it would with
+// [Wallet.PayInvoice]. The fee buffer is passed to WoS as a maximum lightning fee,
+// which WoS does not document, and may ignore. In that case, this method simply
+// retries the payment.
+//
+// If every attempt fails, returns an error wrapping [ErrRetriesExhausted] and the
+// error of the last attempt.
+func (wallet *Wallet) PayInvoiceWithRetryStrategy(
+	ctx context.Context,
+	invoice, description string,
+	strategy RetryStrategy,
+) (*Payment, error) {
+	amount, err := parseInvoiceAmount(invoice)
+	if err != nil {
+		return nil, fmt.Errorf("PayInvoiceWithRetryStrategy: %w", err)
+	}
+
+	maxAttempts := strategy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	shouldRetry := strategy.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = defaultShouldRetry
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 && strategy.Delay > 0 {
+			select {
+			case <-time.After(strategy.Delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		var feeBuffer float64
+		if strategy.FeeBuffer != nil {
+			feeBuffer = strategy.FeeBuffer(attempt)
+		}
+		if strategy.MaxFeeBuffer > 0 && feeBuffer > strategy.MaxFeeBuffer {
+			break
+		}
+
+		payment, err := wallet.newPayment(ctx, "PayInvoiceWithRetryStrategy", sendPaymentRequest{
+			Address:     invoice,
+			Currency:    "LIGHTNING",
+			Description: description,
+			Amount:      amount,
+			MaxFee:      feeBuffer,
+		})
+		if err == nil {
+			return payment, nil
+		}
+
+		lastErr = err
+		if !shouldRetry(err) {
+			return nil, err
+		}
+	}
+
+	if lastErr == nil {
+		return nil, fmt.Errorf("PayInvoiceWithRetryStrategy: fee buffer exceeds MaxFeeBuffer")
+	}
+	return nil, fmt.Errorf("PayInvoiceWithRetryStrategy: %w: %w", ErrRetriesExhausted, lastErr)
+}
@@ -0,0 +1,88 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPayInvoiceWithRetryStrategy(t *testing.T) {
+	var attempts []float64
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		var req sendPaymentRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		attempts = append(attempts, req.MaxFee)
+
+		if req.MaxFee < 0.00002 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"FAILED_LOW_FEE"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"pay1","status":"PAID"}`))
+	})
+
+	strategy := RetryStrategy{
+		MaxAttempts:  5,
+		FeeBuffer:    func(attempt int) float64 { return 0.00001 * float64(attempt+1) },
+		MaxFeeBuffer: 0.0001,
+	}
+
+	payment, err := wallet.PayInvoiceWithRetryStrategy(context.Background(), testInvoiceCoffee, "", strategy)
+	if err != nil {
+		t.Fatalf("expected payment to succeed on retry: %v", err)
+	} else if payment.ID != "pay1" {
+		t.Fatalf("unexpected payment: %+v", payment)
+	}
+	if len(attempts) != 2 || attempts[0] != 0.00001 || attempts[1] != 0.00002 {
+		t.Fatalf("expected two attempts with increasing fee buffers, got %v", attempts)
+	}
+
+	// The buffer cap prevents further attempts.
+	attempts = nil
+	strategy.MaxFeeBuffer = 0.000015
+	_, err = wallet.PayInvoiceWithRetryStrategy(context.Background(), testInvoiceCoffee, "", strategy)
+	if !errors.Is(err, ErrRetriesExhausted) {
+		t.Fatalf("expected ErrRetriesExhausted, got %v", err)
+	} else if len(attempts) != 1 {
+		t.Fatalf("expected a single attempt within the buffer cap, got %v", attempts)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "FAILED_LOW_FEE" {
+		t.Fatalf("expected last APIError to be wrapped, got %v", err)
+	}
+}
+
+func TestPayInvoiceWithRetryStrategyNoRetry(t *testing.T) {
+	tests := []struct {
+		status  int
+		message string
+	}{
+		{http.StatusInternalServerError, "FAILED_LOW_FEE"},
+		{http.StatusBadGateway, "FAILED_NO_ROUTE"},
+		{http.StatusServiceUnavailable, "Service unavailable"},
+		{http.StatusBadRequest, "Something unexpected"},
+		{http.StatusBadRequest, "INSUFFICIENT_FUNDS"},
+	}
+	for _, test := range tests {
+		var requests int
+		wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(test.status)
+			fmt.Fprintf(w, `{"message":%q}`, test.message)
+		})
+
+		_, err := wallet.PayInvoiceWithRetryStrategy(context.Background(), testInvoiceCoffee, "", RetryStrategy{})
+		if err == nil || errors.Is(err, ErrRetriesExhausted) {
+			t.Errorf("status %d %q: expected the first error to be returned, got %v", test.status, test.message, err)
+		} else if requests != 1 {
+			t.Errorf("status %d %q: expected a single attempt, got %d", test.status, test.message, requests)
+		} else if !strings.HasPrefix(err.Error(), "PayInvoiceWithRetryStrategy: ") {
+			t.Errorf("expected error to be labelled with the method, got %v", err)
+		}
+	}
+}
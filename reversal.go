@@ -0,0 +1,48 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// IsReversible reports whether WoS has marked the payment as reversible with
+// [Wallet.ReversePayment], and its reversal window, if any, has not yet closed.
+//
+// WoS does not document any way to reverse payments, and lightning and on-chain
+// payments are final once sent, so this is false unless WoS reports otherwise.
+func (p Payment) IsReversible() bool {
+	return p.isReversibleAt(time.Now())
+}
+
+func (p Payment) isReversibleAt(now time.Time) bool {
+	return p.Reversible && (p.ReversibleUntil.IsZero() || now.Before(p.ReversibleUntil))
+}
+
+// ReversePayment asks WoS to reverse a payment this wallet sent, given its ID, for
+// recovering from mistaken sends while WoS still holds the funds.
+//
+// WoS does not document a reversal endpoint, so this tries an undocumented one. If it
+// does not exist, an error wrapping [ErrUnsupported] is returned, and the payment should
+// be treated as final; [Payment.IsReversible] reports whether WoS offers a reversal
+// for a given payment. Any refusal by WoS, such as for a payment whose reversal window
+// has closed, is returned as an [*APIError].
+func (wallet *Wallet) ReversePayment(ctx context.Context, paymentID string) error {
+	if paymentID == "" {
+		return errors.New("ReversePayment: payment ID is empty")
+	}
+
+	endpoint := "/api/v1/wallet/payment/" + url.PathEscape(paymentID) + "/reverse"
+	_, err := wallet.PostRequest(ctx, endpoint, map[string]any{})
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("ReversePayment: %w", ErrUnsupported)
+	} else if err != nil {
+		return fmt.Errorf("ReversePayment: %w", err)
+	}
+	return nil
+}
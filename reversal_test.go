@@ -0,0 +1,57 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPaymentIsReversible(t *testing.T) {
+	var payment Payment
+	if err := json.Unmarshal([]byte(`{"id":"p1","reversible":true,"reversibleUntil":"2024-01-01T00:10:00Z"}`), &payment); err != nil {
+		t.Fatalf("failed to decode payment: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !payment.isReversibleAt(start) {
+		t.Fatalf("expected payment to be reversible within its window")
+	} else if payment.isReversibleAt(start.Add(10 * time.Minute)) {
+		t.Fatalf("expected payment not to be reversible once its window closed")
+	}
+
+	if (Payment{ID: "p2"}).IsReversible() {
+		t.Fatalf("expected payments to be final by default")
+	}
+}
+
+func TestReversePayment(t *testing.T) {
+	var path string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`{}`))
+	})
+	if err := wallet.ReversePayment(context.Background(), "p1"); err != nil {
+		t.Fatalf("ReversePayment failed: %v", err)
+	} else if path != "/api/v1/wallet/payment/p1/reverse" {
+		t.Fatalf("unexpected reversal path %q", path)
+	}
+
+	wallet = mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	if err := wallet.ReversePayment(context.Background(), "p1"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+
+	wallet = mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"reversal window closed"}`))
+	})
+	var apiErr *APIError
+	if err := wallet.ReversePayment(context.Background(), "p1"); !errors.As(err, &apiErr) || errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+}
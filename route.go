@@ -0,0 +1,62 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+)
+
+// CanRouteLightning is a best-effort check of whether WoS is likely to be able to route
+// a lightning payment of amount BTC to the given invoice, to warn before attempting a large
+// send which would probably fail. If the invoice has a fixed amount, amount can be zero.
+//
+// WoS is custodial, and does not expose its channels or liquidity, so routability is
+// opaque. Instead, this asks WoS for a fee estimate, which fails or returns nonsensical
+// fees when WoS cannot find a route. A false result means the payment will likely fail.
+// A true result guarantees nothing: routes can vanish before the payment is sent.
+//
+// Errors which say nothing about routability, such as network errors or bad
+// credentials, are returned as errors rather than a false result.
+func (wallet *Wallet) CanRouteLightning(ctx context.Context, invoice string, amount float64) (bool, error) {
+	invoiceAmount, err := parseInvoiceAmount(invoice)
+	if err != nil && !errors.Is(err, ErrNoAmount) {
+		return false, fmt.Errorf("CanRouteLightning: %w", err)
+	} else if err == nil {
+		amount = invoiceAmount
+	} else if amount <= 0 {
+		return false, fmt.Errorf("CanRouteLightning: %w: invoice does not specify an amount", ErrAmountRequired)
+	}
+
+	estimate, err := wallet.reader.feeEstimate(ctx, invoice, amount)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && isRoutingRejection(apiErr) {
+			return false, nil
+		}
+		return false, fmt.Errorf("CanRouteLightning: %w", err)
+	}
+
+	// Payments to other WoS wallets are settled internally, without routing.
+	if estimate.IsWosInvoice {
+		return true, nil
+	}
+
+	fee := estimate.LightningFee
+	if fee < 0 || math.IsNaN(fee) || math.IsInf(fee, 0) || fee >= amount {
+		return false, nil
+	}
+	return true, nil
+}
+
+// isRoutingRejection reports whether WoS refused to estimate a fee for reasons which
+// plausibly concern the payment itself, rather than the request or the wallet.
+func isRoutingRejection(apiErr *APIError) bool {
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 &&
+		!errors.Is(apiErr, ErrWalletFrozen)
+}
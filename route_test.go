@@ -0,0 +1,49 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCanRouteLightning(t *testing.T) {
+	var status int
+	var response string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+		w.Write([]byte(response))
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		status   int
+		response string
+		routable bool
+	}{
+		{0, `{"lightningFee":0.000002}`, true},
+		{0, `{"lightningFee":0.01}`, false},
+		{0, `{"lightningFee":-1}`, false},
+		{0, `{"lightningFee":0.01,"wosInvoice":true}`, true},
+		{http.StatusBadRequest, `{"code":"NO_ROUTE","message":"unable to find route"}`, false},
+	}
+	for _, test := range tests {
+		status, response = test.status, test.response
+		routable, err := wallet.CanRouteLightning(ctx, testInvoiceCoffee, 0)
+		if err != nil {
+			t.Fatalf("CanRouteLightning(%s) failed: %v", test.response, err)
+		} else if routable != test.routable {
+			t.Errorf("CanRouteLightning(%s): expected %v, got %v", test.response, test.routable, routable)
+		}
+	}
+
+	status, response = http.StatusUnauthorized, `{}`
+	if _, err := wallet.CanRouteLightning(ctx, testInvoiceCoffee, 0); err == nil {
+		t.Fatalf("expected authentication failure to be returned as an error")
+	}
+	if _, err := wallet.CanRouteLightning(ctx, testInvoiceDonation, 0); !errors.Is(err, ErrAmountRequired) {
+		t.Fatalf("expected ErrAmountRequired for amountless invoice, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrSafeMode is wrapped by the errors of on-chain sends blocked by safe mode. See
+// [Wallet.SetSafeMode]. The reason for blocking the send is wrapped as well.
+var ErrSafeMode = errors.New("blocked by safe mode")
+
+// SetSafeMode enables or disables safe mode, which bundles the strictest guardrails this
+// package offers for on-chain sends by [Wallet.PayOnChain] and [Wallet.SweepOnChain].
+// It is intended for cautious integrators who would rather have a send fail than
+// proceed on a questionable basis. Safe mode is disabled by default.
+//
+// With safe mode enabled, an on-chain send fails with an error wrapping [ErrSafeMode]
+// before it is signed, if:
+//
+//   - the address is not a recognizable mainnet address of a known type, as detected by
+//     [DetectAddressType], rather than being left for WoS to validate;
+//   - the amount plus fees exceeds the confirmed balance, so that the send would rely on
+//     unconfirmed funds; the error also wraps [ErrInsufficientFunds];
+//   - the fees would make up an unusually large part of the amount sent, which otherwise
+//     only adds a [WarningHighFee] to the payment; the error also wraps [ErrHighFee].
+//
+// Checks which always apply, such as rejecting addresses for the wrong network with
+// [ErrWrongNetwork], continue to apply. Safe mode costs an extra balance and fee estimate
+// request for each [Wallet.PayOnChain]. Lightning payments are not affected.
+//
+// WoS is custodial, and sends on-chain payments from its own pooled funds, so there is
+// no control over which coins or change addresses a send spends from; safe mode cannot
+// prevent spending from reused or unconfirmed addresses on WoS's side.
+func (wallet *Wallet) SetSafeMode(enabled bool) {
+	wallet.safeMode = enabled
+}
+
+// checkSafeOnChainAddress returns an error wrapping [ErrSafeMode] if safe mode is enabled
+// and address is not a recognizable mainnet address.
+func (wallet *Wallet) checkSafeOnChainAddress(address string) error {
+	if !wallet.safeMode {
+		return nil
+	}
+	if _, ok := DetectAddressType(address); !ok {
+		return fmt.Errorf("%w: %w: unrecognized mainnet address %s", ErrSafeMode, ErrInvalidDestination, address)
+	}
+	return nil
+}
+
+// checkSafeOnChainPayment returns an error wrapping [ErrSafeMode] if safe mode is enabled,
+// and sending amount to address would rely on unconfirmed funds or incur high fees.
+func (wallet *Wallet) checkSafeOnChainPayment(ctx context.Context, address string, amount float64) error {
+	if !wallet.safeMode {
+		return nil
+	}
+	if err := wallet.checkSafeOnChainAddress(address); err != nil {
+		return err
+	}
+
+	balance, fees, err := wallet.reader.BalanceAndFee(ctx, address)
+	if err != nil {
+		return err
+	}
+	fee := fees.TotalOnChainFee(amount)
+	if total := amount + fee; total > balance.Confirmed {
+		return fmt.Errorf(
+			"%w: %w: %.8f BTC including fees exceeds confirmed balance of %.8f BTC",
+			ErrSafeMode, ErrInsufficientFunds, total, balance.Confirmed,
+		)
+	}
+	return wallet.checkSafeFee(fees, amount, fee)
+}
+
+// checkSafeFee returns an error wrapping [ErrSafeMode] and [ErrHighFee] if safe mode is
+// enabled, and an on-chain payment of amount costing fee would get a [WarningHighFee].
+func (wallet *Wallet) checkSafeFee(fees *FeeEstimate, amount, fee float64) error {
+	if !wallet.safeMode {
+		return nil
+	}
+	if warning := highFeeWarning(fees, amount, fee); warning != nil {
+		return fmt.Errorf("%w: %w", ErrSafeMode, warning)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSafeMode(t *testing.T) {
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	balance := "0.0001"
+	var payments int
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":` + balance + `,"btcUnconfirmed":0.01}`))
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00002,"btcSendCommissionPercent":0.001,"btcSendFeeWarningPercent":0.1}`))
+		case "/api/v1/wallet/payment":
+			payments++
+			w.Write([]byte(`{"id":"p1","status":"PENDING","currency":"BTC"}`))
+		}
+	})
+	ctx := context.Background()
+
+	// 9000 sats swept for over 2000 sats in fees only warns by default.
+	payment, err := wallet.SweepOnChain(ctx, address, "")
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	} else if len(payment.Warnings) != 1 || payment.Warnings[0].Code != WarningHighFee {
+		t.Fatalf("expected high fee warning, got %v", payment.Warnings)
+	}
+
+	wallet.SetSafeMode(true)
+	_, err = wallet.SweepOnChain(ctx, address, "")
+	if !errors.Is(err, ErrSafeMode) || !errors.Is(err, ErrHighFee) {
+		t.Fatalf("expected ErrSafeMode and ErrHighFee, got %v", err)
+	}
+	if _, err := wallet.PayOnChain(ctx, address, 0.00005, ""); !errors.Is(err, ErrSafeMode) || !errors.Is(err, ErrHighFee) {
+		t.Fatalf("expected ErrSafeMode and ErrHighFee, got %v", err)
+	}
+
+	// Sends relying on unconfirmed funds are blocked.
+	balance = "0.001"
+	if _, err := wallet.PayOnChain(ctx, address, 0.001, ""); !errors.Is(err, ErrSafeMode) || !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected ErrSafeMode and ErrInsufficientFunds, got %v", err)
+	}
+
+	// Unrecognized addresses are blocked.
+	if _, err := wallet.PayOnChain(ctx, "bc1qdest", 0.0005, ""); !errors.Is(err, ErrSafeMode) || !errors.Is(err, ErrInvalidDestination) {
+		t.Fatalf("expected ErrSafeMode and ErrInvalidDestination, got %v", err)
+	}
+
+	if _, err := wallet.PayOnChain(ctx, address, 0.0005, ""); err != nil {
+		t.Fatalf("expected safe payment to succeed: %v", err)
+	} else if payments != 2 {
+		t.Fatalf("expected 2 payments to be sent, got %d", payments)
+	}
+}
@@ -0,0 +1,93 @@
+package wos
+
+import "math"
+
+// FeeModel estimates the fees, in satoshis, which sending a payment of the given
+// number of satoshis would cost, as used by [FeeSavingsReportWith].
+type FeeModel interface {
+	OnChainFee(amount int64) int64
+	LightningFee(amount int64) int64
+}
+
+// FeeSchedule is a [FeeModel] made up of a fixed fee and a percentage-based commission
+// for on-chain payments, and a percentage-based fee for lightning payments.
+type FeeSchedule struct {
+	OnChainFixed     int64   // Fixed on-chain fee, in satoshis.
+	OnChainPercent   float64 // On-chain commission, as a percentage of the amount.
+	LightningPercent float64 // Lightning routing fee, as a percentage of the amount.
+}
+
+// OnChainFee implements [FeeModel].
+func (fs FeeSchedule) OnChainFee(amount int64) int64 {
+	return fs.OnChainFixed + int64(math.Round(float64(amount)*fs.OnChainPercent/100))
+}
+
+// LightningFee implements [FeeModel].
+func (fs FeeSchedule) LightningFee(amount int64) int64 {
+	return int64(math.Round(float64(amount) * fs.LightningPercent / 100))
+}
+
+// FeeScheduleFromEstimate returns a [FeeSchedule] charging the on-chain fees given by
+// an estimate for an on-chain destination, as returned by [Reader.FeeEstimate], so that
+// savings can be computed at current on-chain fee rates. Lightning fees are charged at
+// lightningPercent.
+func FeeScheduleFromEstimate(estimate *FeeEstimate, lightningPercent float64) FeeSchedule {
+	return FeeSchedule{
+		OnChainFixed:     toSats(estimate.BtcFixedFee),
+		OnChainPercent:   estimate.BtcSendCommissionPercent,
+		LightningPercent: lightningPercent,
+	}
+}
+
+// DefaultFeeModel is the [FeeModel] used by [FeeSavingsReport]. It is only a rough
+// guide to typical fees, as on-chain fees vary greatly with demand for block space;
+// use [FeeScheduleFromEstimate] to compute savings at current rates.
+var DefaultFeeModel FeeModel = FeeSchedule{
+	OnChainFixed:     2000,
+	LightningPercent: 0.1,
+}
+
+// FeeSavings estimates how much was saved in fees by making payments over lightning
+// rather than on-chain, as computed by [FeeSavingsReport]. All amounts are in satoshis.
+type FeeSavings struct {
+	// Count is the number of lightning payments considered.
+	Count int
+
+	// OnChainFees is the estimated total fee had the payments been made on-chain.
+	OnChainFees int64
+
+	// LightningFees is the estimated total fee of the payments over lightning.
+	LightningFees int64
+
+	// Saved is OnChainFees less LightningFees. It may be negative if the fee model
+	// makes lightning more expensive, such as for very large payments.
+	Saved int64
+}
+
+// FeeSavingsReport estimates how much was saved in fees by making lightning payments
+// rather than on-chain payments, using [DefaultFeeModel]. See [FeeSavingsReportWith].
+func FeeSavingsReport(payments []Payment) *FeeSavings {
+	return FeeSavingsReportWith(payments, DefaultFeeModel)
+}
+
+// FeeSavingsReportWith estimates how much was saved in fees by making lightning
+// payments rather than on-chain payments, such as for an insights screen. For each
+// lightning payment, in either direction, the equivalent on-chain fee and the lightning
+// fee are estimated with model and summed. On-chain payments are ignored.
+//
+// WoS does not report the fees actually paid for each payment, so the result is only
+// an estimate. This is pure analysis, and makes no API calls.
+func FeeSavingsReportWith(payments []Payment, model FeeModel) *FeeSavings {
+	savings := &FeeSavings{}
+	for _, payment := range payments {
+		if payment.Currency != PaymentCurrencyLightning {
+			continue
+		}
+		amount := toSats(payment.Amount)
+		savings.Count++
+		savings.OnChainFees += model.OnChainFee(amount)
+		savings.LightningFees += model.LightningFee(amount)
+	}
+	savings.Saved = savings.OnChainFees - savings.LightningFees
+	return savings
+}
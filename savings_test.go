@@ -0,0 +1,31 @@
+package wos
+
+import "testing"
+
+func TestFeeSavingsReport(t *testing.T) {
+	payments := []Payment{
+		{ID: "a", Amount: 0.0001, Currency: PaymentCurrencyLightning, Type: PaymentTypeDebit},
+		{ID: "b", Amount: 0.0005, Currency: PaymentCurrencyLightning, Type: PaymentTypeCredit},
+		{ID: "c", Amount: 0.01, Currency: PaymentCurrencyLightning, Type: PaymentTypeDebit},
+		{ID: "d", Amount: 0.002, Currency: PaymentCurrencyBitcoin, Type: PaymentTypeDebit},
+	}
+
+	model := FeeSchedule{OnChainFixed: 1000, OnChainPercent: 0.5, LightningPercent: 0.2}
+	savings := FeeSavingsReportWith(payments, model)
+
+	// On-chain: 3 * 1000 fixed, plus 0.5% of 1,060,000 sats = 5300.
+	// Lightning: 0.2% of 1,060,000 sats = 2120.
+	expected := FeeSavings{Count: 3, OnChainFees: 8300, LightningFees: 2120, Saved: 6180}
+	if *savings != expected {
+		t.Fatalf("expected savings %+v, got %+v", expected, *savings)
+	}
+
+	estimate := &FeeEstimate{BtcFixedFee: 0.00001, BtcSendCommissionPercent: 0.5}
+	if fromEstimate := FeeScheduleFromEstimate(estimate, 0.2); fromEstimate != model {
+		t.Fatalf("unexpected fee schedule from estimate: %+v", fromEstimate)
+	}
+
+	if empty := FeeSavingsReport(nil); *empty != (FeeSavings{}) {
+		t.Fatalf("unexpected savings for empty history: %+v", *empty)
+	}
+}
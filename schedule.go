@@ -0,0 +1,157 @@
+package wos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule determines when a [Scheduler] makes its payments.
+type Schedule interface {
+	// Next returns the first scheduled time strictly after the given time.
+	Next(after time.Time) time.Time
+}
+
+type intervalSchedule time.Duration
+
+// Every returns a Schedule which recurs every interval. Runs are aligned to the
+// interval since the zero time, so Every(time.Hour) runs on the hour.
+//
+// The interval must be positive. Otherwise the schedule never advances, and
+// [NewScheduler] rejects it with [ErrInvalidSchedule].
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule(interval)
+}
+
+func (interval intervalSchedule) Next(after time.Time) time.Time {
+	return after.Truncate(time.Duration(interval)).Add(time.Duration(interval))
+}
+
+// cronSchedule is a Schedule parsed from a cron expression. Each field is a bitmask
+// of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny record whether the day fields were "*". If both are
+	// restricted, a day matches if either field does, as in standard cron.
+	domAny, dowAny bool
+}
+
+// ParseCron parses a standard five-field cron expression into a Schedule:
+//
+//	minute hour day-of-month month day-of-week
+//
+// Each field may be "*", a value, a range such as "1-5", a step such as "*/15" or
+// "0-30/10", or a comma-separated list of these. Days of the week count from Sunday,
+// as 0 or 7. Names of months and days are not supported. Times are matched in the
+// location of the time passed to Next.
+func ParseCron(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	var sched cronSchedule
+	var err error
+	parsers := []struct {
+		mask     *uint64
+		min, max int
+	}{
+		{&sched.minute, 0, 59},
+		{&sched.hour, 0, 23},
+		{&sched.dom, 1, 31},
+		{&sched.month, 1, 12},
+		{&sched.dow, 0, 7},
+	}
+	for i, p := range parsers {
+		if *p.mask, err = parseCronField(fields[i], p.min, p.max); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+	}
+
+	// Sunday may be written as 7.
+	if sched.dow&(1<<7) != 0 {
+		sched.dow |= 1
+	}
+	sched.domAny = fields[2] == "*"
+	sched.dowAny = fields[4] == "*"
+	return &sched, nil
+}
+
+// parseCronField parses one field of a cron expression into a bitmask of the values
+// it matches, which must lie between min and max.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart = part[:i]
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step > 1 {
+				// "5/15" means from 5 to the maximum, every 15.
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << v
+		}
+	}
+	return mask, nil
+}
+
+// maxCronSearch bounds the search for the next run of a cron schedule which can never
+// match, such as one only running on February 30th.
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+func (sched *cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronSearch)
+
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case sched.month&(1<<uint(month)) == 0:
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, t.Location())
+		case !sched.dayMatches(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+		case sched.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, t.Location())
+		case sched.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (sched *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := sched.dom&(1<<uint(t.Day())) != 0
+	dowMatch := sched.dow&(1<<uint(t.Weekday())) != 0
+	if sched.domAny || sched.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
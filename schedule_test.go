@@ -0,0 +1,45 @@
+package wos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // A Monday.
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"0 9 * * *", time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC)},
+		{"30 8 2 * *", time.Date(2024, 1, 2, 8, 30, 0, 0, time.UTC)},
+		{"0 0 * * 5", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 3", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseCron(test.spec)
+		if err != nil {
+			t.Errorf("ParseCron(%q) failed: %v", test.spec, err)
+			continue
+		}
+		if next := schedule.Next(start); !next.Equal(test.expected) {
+			t.Errorf("ParseCron(%q).Next = %s, wanted %s", test.spec, next, test.expected)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("expected ParseCron(%q) to fail", spec)
+		}
+	}
+
+	if next := Every(time.Hour).Next(start.Add(90 * time.Minute)); !next.Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("unexpected Every(time.Hour).Next: %s", next)
+	}
+}
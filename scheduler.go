@@ -0,0 +1,226 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// ErrScheduleExhausted is returned by [Scheduler.Run] when its [Schedule] has no
+// further runs.
+var ErrScheduleExhausted = errors.New("schedule has no further runs")
+
+// ErrInvalidSchedule is returned by [NewScheduler] and [Scheduler.Run] when a [Schedule]
+// returns a time which is not strictly after the time given to it, such as a schedule
+// created by [Every] with an interval which is not positive. Such a schedule would make
+// payments in a tight loop.
+var ErrInvalidSchedule = errors.New("schedule does not advance")
+
+// MissedRunPolicy decides what a [Scheduler] does about runs which came due while it
+// was not running, such as while the process was restarting.
+type MissedRunPolicy int
+
+const (
+	// MissedRunsSkip drops missed runs, paying only for the most recent run which
+	// is due. This is the default.
+	MissedRunsSkip MissedRunPolicy = iota
+
+	// MissedRunsCatchUp pays for every missed run, one after another.
+	MissedRunsCatchUp
+)
+
+// SchedulerOptions customizes a [Scheduler].
+type SchedulerOptions struct {
+	// Store persists the time of the next run under Name, so that the schedule
+	// survives restarts. If nil, the schedule starts afresh each time Run is called.
+	Store ScheduleStore
+	Name  string
+
+	// MissedRuns decides what happens to runs which came due while the scheduler
+	// was not running. Defaults to [MissedRunsSkip].
+	MissedRuns MissedRunPolicy
+
+	// Description is stored in the WoS payment history for each payment.
+	Description string
+
+	// Logger receives a line describing each payment, and each skipped or failed run.
+	// If nil, nothing is logged.
+	Logger *log.Logger
+
+	// OnPayment is called after each successful payment.
+	OnPayment func(*Payment)
+}
+
+// Scheduler makes a recurring payment of a fixed amount to a destination, such as
+// for subscriptions or payroll, at the times given by a [Schedule].
+type Scheduler struct {
+	wallet      *Wallet
+	destination string
+	amount      float64
+	schedule    Schedule
+	opts        SchedulerOptions
+}
+
+// NewScheduler returns a Scheduler which pays amount BTC to destination, which may be
+// anything accepted by [Wallet.Pay] other than a single-use invoice, at every time given
+// by schedule. Use [Every] or [ParseCron] to create a schedule.
+//
+// Returns an error wrapping [ErrInvalidDestination] if destination is malformed or is a
+// lightning invoice, which can only be paid once, [ErrNegativeAmount] if amount is not
+// positive, or [ErrInvalidSchedule] if schedule does not advance.
+//
+// opts can be nil. Call [Scheduler.Run] to start making payments.
+func NewScheduler(
+	wallet *Wallet,
+	destination string,
+	amount float64,
+	schedule Schedule,
+	opts *SchedulerOptions,
+) (*Scheduler, error) {
+	destination, kind, err := NormalizeDestination(destination)
+	if err != nil {
+		return nil, fmt.Errorf("NewScheduler: %w", err)
+	} else if kind == DestinationInvoice {
+		return nil, fmt.Errorf("NewScheduler: %w: invoices are single-use and cannot be paid on a schedule",
+			ErrInvalidDestination)
+	} else if !(amount > 0) || math.IsInf(amount, 0) {
+		return nil, fmt.Errorf("NewScheduler: %w: %v", ErrNegativeAmount, amount)
+	}
+
+	now := clockOrDefault(wallet.clock).Now()
+	if next := schedule.Next(now); !next.IsZero() && !next.After(now) {
+		return nil, fmt.Errorf("NewScheduler: %w: next run after %s is %s", ErrInvalidSchedule, now, next)
+	}
+
+	scheduler := &Scheduler{
+		wallet:      wallet,
+		destination: destination,
+		amount:      amount,
+		schedule:    schedule,
+	}
+	if opts != nil {
+		scheduler.opts = *opts
+	}
+	return scheduler, nil
+}
+
+// Run makes the scheduled payments until ctx is done, and then returns ctx.Err().
+// The scheduler follows the wallet's clock; see [Wallet.SetClock].
+//
+// Before each payment, the wallet's confirmed balance is checked, and if it cannot cover
+// the amount and its estimated fees, as reported by [Wallet.TotalCost], that run is
+// skipped and logged. Failed payments are logged and not
+// retried, so that a payment whose outcome is unknown is never made twice. For the same
+// reason, the next run time is saved to the store before each payment is made.
+func (scheduler *Scheduler) Run(ctx context.Context) error {
+	clock := clockOrDefault(scheduler.wallet.clock)
+
+	next, err := scheduler.loadNextRun(ctx, clock.Now())
+	if err != nil {
+		return fmt.Errorf("Scheduler: %w", err)
+	}
+
+	for {
+		if next.IsZero() {
+			return fmt.Errorf("Scheduler: %w", ErrScheduleExhausted)
+		}
+
+		if wait := next.Sub(clock.Now()); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-clock.After(wait):
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		due := next
+		if next, err = scheduler.next(due); err != nil {
+			return err
+		}
+		if scheduler.opts.MissedRuns == MissedRunsSkip {
+			for !next.IsZero() && !next.After(clock.Now()) {
+				scheduler.logf("skipping missed run due at %s", due)
+				due = next
+				if next, err = scheduler.next(due); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := scheduler.saveNextRun(ctx, next); err != nil {
+			return fmt.Errorf("Scheduler: %w", err)
+		}
+		scheduler.pay(ctx, due)
+	}
+}
+
+// pay makes the payment for the run due at the given time, logging any failure.
+func (scheduler *Scheduler) pay(ctx context.Context, due time.Time) {
+	balance, err := scheduler.wallet.Balance(ctx)
+	if err != nil {
+		scheduler.logf("skipping run due at %s: checking balance: %v", due, err)
+		return
+	}
+	cost, err := scheduler.wallet.TotalCost(ctx, scheduler.destination, scheduler.amount)
+	if err != nil {
+		scheduler.logf("skipping run due at %s: estimating fees: %v", due, err)
+		return
+	} else if balance.Confirmed < cost.Total {
+		scheduler.logf("skipping run due at %s: balance of %.8f BTC cannot cover %.8f BTC including fees",
+			due, balance.Confirmed, cost.Total)
+		return
+	}
+
+	payment, err := scheduler.wallet.Pay(ctx, scheduler.destination, scheduler.amount, scheduler.opts.Description)
+	if err != nil {
+		scheduler.logf("payment due at %s failed: %v", due, err)
+		return
+	}
+	scheduler.logf("paid %.8f BTC to %s for run due at %s", scheduler.amount, scheduler.destination, due)
+	if scheduler.opts.OnPayment != nil {
+		scheduler.opts.OnPayment(payment)
+	}
+}
+
+// next returns the run scheduled after due, or an error wrapping [ErrInvalidSchedule]
+// if the schedule does not advance past due.
+func (scheduler *Scheduler) next(due time.Time) (time.Time, error) {
+	next := scheduler.schedule.Next(due)
+	if !next.IsZero() && !next.After(due) {
+		return time.Time{}, fmt.Errorf("Scheduler: %w: next run after %s is %s", ErrInvalidSchedule, due, next)
+	}
+	return next, nil
+}
+
+// loadNextRun returns the next run saved in the store, or the first run after now
+// if there is no store or nothing was saved.
+func (scheduler *Scheduler) loadNextRun(ctx context.Context, now time.Time) (time.Time, error) {
+	if scheduler.opts.Store != nil {
+		next, err := scheduler.opts.Store.LoadNextRun(ctx, scheduler.opts.Name)
+		if err == nil {
+			return next, nil
+		} else if !errors.Is(err, ErrNotStored) {
+			return time.Time{}, err
+		}
+	}
+	return scheduler.schedule.Next(now), nil
+}
+
+func (scheduler *Scheduler) saveNextRun(ctx context.Context, next time.Time) error {
+	if scheduler.opts.Store == nil {
+		return nil
+	}
+	return scheduler.opts.Store.SaveNextRun(ctx, scheduler.opts.Name, next)
+}
+
+func (scheduler *Scheduler) logf(format string, args ...any) {
+	if scheduler.opts.Logger != nil {
+		scheduler.opts.Logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,230 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerPaysOnSchedule(t *testing.T) {
+	clock := newFakeClock()
+	var payments atomic.Int32
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":0.01}`))
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00001}`))
+		case "/api/v1/wallet/payment":
+			n := payments.Add(1)
+			fmt.Fprintf(w, `{"id":"pay%d","address":"bc1qpayee","status":"PENDING"}`, n)
+		}
+	})
+	wallet.SetClock(clock)
+
+	schedule, err := ParseCron("0 9 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &MemoryStore{}
+	var paidAt []time.Time
+	scheduler, err := NewScheduler(wallet, "bc1qpayee", 0.001, schedule, &SchedulerOptions{
+		Store: store,
+		Name:  "rent",
+		OnPayment: func(*Payment) {
+			paidAt = append(paidAt, clock.Now())
+			if len(paidAt) == 2 {
+				cancel()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler failed: %v", err)
+	}
+
+	if err := scheduler.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected Run to return context.Canceled, got %v", err)
+	}
+
+	expected := []time.Time{
+		time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+	}
+	if len(paidAt) != len(expected) {
+		t.Fatalf("expected %d payments, got %d", len(expected), len(paidAt))
+	}
+	for i := range expected {
+		if !paidAt[i].Equal(expected[i]) {
+			t.Errorf("payment %d made at %s, wanted %s", i, paidAt[i], expected[i])
+		}
+	}
+
+	next, err := store.LoadNextRun(ctx, "rent")
+	if err != nil {
+		t.Fatalf("failed to load next run: %v", err)
+	} else if want := time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("expected next run %s to be saved, got %s", want, next)
+	}
+}
+
+func TestSchedulerMissedRuns(t *testing.T) {
+	for _, test := range []struct {
+		policy   MissedRunPolicy
+		expected int32
+	}{
+		{MissedRunsSkip, 1},
+		{MissedRunsCatchUp, 4},
+	} {
+		clock := newFakeClock()
+		var payments atomic.Int32
+		wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v1/wallet/balance":
+				w.Write([]byte(`{"btc":0.01}`))
+			case "/api/v1/wallet/feeEstimate":
+				w.Write([]byte(`{"btcFixedFee":0.00001}`))
+			case "/api/v1/wallet/payment":
+				payments.Add(1)
+				w.Write([]byte(`{"id":"pay","address":"bc1qpayee","status":"PENDING"}`))
+			}
+		})
+		wallet.SetClock(clock)
+
+		// The scheduler was last running three days ago, so four runs are due now.
+		store := &MemoryStore{}
+		store.SaveNextRun(context.Background(), "daily", clock.Now().Add(-72*time.Hour))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		scheduler, _ := NewScheduler(wallet, "bc1qpayee", 0.001, Every(24*time.Hour), &SchedulerOptions{
+			Store:      store,
+			Name:       "daily",
+			MissedRuns: test.policy,
+			OnPayment: func(*Payment) {
+				if clock.Now().After(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+					cancel()
+				}
+			},
+		})
+		scheduler.Run(ctx)
+		cancel()
+
+		// Both policies then pay the next regular run, once the clock reaches it.
+		if got := payments.Load(); got != test.expected+1 {
+			t.Errorf("policy %d: expected %d payments, got %d", test.policy, test.expected+1, got)
+		}
+	}
+}
+
+func TestSchedulerInsufficientBalance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newFakeClock()
+	var checks, payments atomic.Int32
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			if checks.Add(1) == 2 {
+				cancel()
+			}
+			// Enough for the amount, but not for the fee as well.
+			w.Write([]byte(`{"btc":0.001}`))
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00001}`))
+		case "/api/v1/wallet/payment":
+			payments.Add(1)
+		}
+	})
+	wallet.SetClock(clock)
+
+	var logs bytes.Buffer
+	scheduler, err := NewScheduler(wallet, "bc1qpayee", 0.001, Every(time.Hour), &SchedulerOptions{
+		Logger: log.New(&logs, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler failed: %v", err)
+	}
+	if err := scheduler.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected Run to return context.Canceled, got %v", err)
+	}
+
+	if payments.Load() != 0 {
+		t.Fatalf("expected no payments with insufficient balance")
+	}
+	if !strings.Contains(logs.String(), "balance of 0.00100000 BTC cannot cover 0.00101000 BTC including fees") {
+		t.Fatalf("expected skipped run to be logged, got %q", logs.String())
+	}
+}
+
+func TestNewSchedulerRejectsInvoice(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {})
+	if _, err := NewScheduler(wallet, testInvoiceCoffee, 0, Every(time.Hour), nil); !errors.Is(err, ErrInvalidDestination) {
+		t.Fatalf("expected ErrInvalidDestination for an invoice, got %v", err)
+	}
+	if _, err := NewScheduler(wallet, "user@walletofsatoshi.com", 0.001, Every(time.Hour), nil); err != nil {
+		t.Fatalf("expected lightning address to be accepted, got %v", err)
+	}
+}
+
+// stallingSchedule advances twice, and then returns the time it is given.
+type stallingSchedule struct{ calls int }
+
+func (s *stallingSchedule) Next(after time.Time) time.Time {
+	s.calls++
+	if s.calls <= 2 {
+		return after.Add(time.Hour)
+	}
+	return after
+}
+
+func TestNewSchedulerValidation(t *testing.T) {
+	var payments atomic.Int32
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":0.01}`))
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00001}`))
+		case "/api/v1/wallet/payment":
+			payments.Add(1)
+			w.Write([]byte(`{"id":"pay","status":"PENDING"}`))
+		}
+	})
+	wallet.SetClock(newFakeClock())
+
+	for _, interval := range []time.Duration{0, -time.Hour} {
+		if _, err := NewScheduler(wallet, "bc1qpayee", 0.001, Every(interval), nil); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("expected ErrInvalidSchedule for interval %s, got %v", interval, err)
+		}
+	}
+	for _, amount := range []float64{0, -0.001} {
+		if _, err := NewScheduler(wallet, "bc1qpayee", amount, Every(time.Hour), nil); !errors.Is(err, ErrNegativeAmount) {
+			t.Errorf("expected ErrNegativeAmount for amount %v, got %v", amount, err)
+		}
+	}
+
+	// A schedule which stops advancing later on stops the scheduler rather than paying in a loop.
+	for _, policy := range []MissedRunPolicy{MissedRunsSkip, MissedRunsCatchUp} {
+		payments.Store(0)
+		scheduler, err := NewScheduler(wallet, "bc1qpayee", 0.001, &stallingSchedule{}, &SchedulerOptions{MissedRuns: policy})
+		if err != nil {
+			t.Fatalf("NewScheduler failed: %v", err)
+		}
+		if err := scheduler.Run(context.Background()); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("policy %d: expected ErrInvalidSchedule, got %v", policy, err)
+		} else if payments.Load() != 0 {
+			t.Errorf("policy %d: expected no payments, got %d", policy, payments.Load())
+		}
+	}
+}
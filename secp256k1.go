@@ -0,0 +1,143 @@
+package wos
+
+import (
+	"errors"
+	"math/big"
+)
+
+// This file implements just enough of the secp256k1 curve to recover the public
+// key of a lightning node from the compact signature on a BOLT11 invoice. It is
+// not constant-time, and must never be used with secret data.
+
+var (
+	secp256k1P, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	secp256k1N, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secp256k1Gx, _ = new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	secp256k1Gy, _ = new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+)
+
+// curvePoint is an affine point on the secp256k1 curve. The point at
+// infinity is represented by a nil x coordinate.
+type curvePoint struct {
+	x, y *big.Int
+}
+
+func (pt curvePoint) isInfinity() bool {
+	return pt.x == nil
+}
+
+func (pt curvePoint) add(other curvePoint) curvePoint {
+	if pt.isInfinity() {
+		return other
+	} else if other.isInfinity() {
+		return pt
+	}
+
+	p := secp256k1P
+	var lambda *big.Int
+	if pt.x.Cmp(other.x) == 0 {
+		if pt.y.Cmp(other.y) != 0 || pt.y.Sign() == 0 {
+			return curvePoint{}
+		}
+		// lambda = 3x^2 / 2y
+		num := new(big.Int).Mul(pt.x, pt.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(pt.y, 1)
+		lambda = num.Mul(num, den.ModInverse(den.Mod(den, p), p))
+	} else {
+		// lambda = (y2 - y1) / (x2 - x1)
+		num := new(big.Int).Sub(other.y, pt.y)
+		den := new(big.Int).Sub(other.x, pt.x)
+		lambda = num.Mul(num, den.ModInverse(den.Mod(den, p), p))
+	}
+	lambda.Mod(lambda, p)
+
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, pt.x).Sub(x, other.x).Mod(x, p)
+
+	y := new(big.Int).Sub(pt.x, x)
+	y.Mul(y, lambda).Sub(y, pt.y).Mod(y, p)
+
+	return curvePoint{x, y}
+}
+
+// linearCombination computes a*A + b*B in a single double-and-add pass.
+func linearCombination(a *big.Int, pa curvePoint, b *big.Int, pb curvePoint) curvePoint {
+	both := pa.add(pb)
+	bits := a.BitLen()
+	if b.BitLen() > bits {
+		bits = b.BitLen()
+	}
+
+	var result curvePoint
+	for i := bits - 1; i >= 0; i-- {
+		result = result.add(result)
+		switch {
+		case a.Bit(i) == 1 && b.Bit(i) == 1:
+			result = result.add(both)
+		case a.Bit(i) == 1:
+			result = result.add(pa)
+		case b.Bit(i) == 1:
+			result = result.add(pb)
+		}
+	}
+	return result
+}
+
+// compressed serializes the point in 33-byte compressed SEC1 form.
+func (pt curvePoint) compressed() []byte {
+	out := make([]byte, 33)
+	out[0] = 2 + byte(pt.y.Bit(0))
+	pt.x.FillBytes(out[1:])
+	return out
+}
+
+// recoverPubKey recovers the compressed public key which produced the given
+// compact ECDSA signature (r || s) over hash, given the recovery ID.
+func recoverPubKey(hash, sig []byte, recoveryID byte) ([]byte, error) {
+	if len(sig) != 64 || recoveryID > 3 {
+		return nil, errors.New("invalid compact signature")
+	}
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(secp256k1N) >= 0 || s.Cmp(secp256k1N) >= 0 {
+		return nil, errors.New("signature values out of range")
+	}
+
+	// Find the point R whose x coordinate is r.
+	x := new(big.Int).Set(r)
+	if recoveryID&2 != 0 {
+		x.Add(x, secp256k1N)
+		if x.Cmp(secp256k1P) >= 0 {
+			return nil, errors.New("invalid recovery ID")
+		}
+	}
+
+	// y^2 = x^3 + 7
+	ySquared := new(big.Int).Exp(x, big.NewInt(3), secp256k1P)
+	ySquared.Add(ySquared, big.NewInt(7)).Mod(ySquared, secp256k1P)
+	y := new(big.Int).ModSqrt(ySquared, secp256k1P)
+	if y == nil {
+		return nil, errors.New("signature r value is not on the curve")
+	}
+	if y.Bit(0) != uint(recoveryID&1) {
+		y.Sub(secp256k1P, y)
+	}
+	bigR := curvePoint{x, y}
+
+	// Q = r^-1 * (s*R - e*G) = (-e/r)*G + (s/r)*R
+	e := new(big.Int).SetBytes(hash)
+	rInv := new(big.Int).ModInverse(r, secp256k1N)
+	u1 := new(big.Int).Neg(e)
+	u1.Mul(u1, rInv).Mod(u1, secp256k1N)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, secp256k1N)
+
+	g := curvePoint{secp256k1Gx, secp256k1Gy}
+	q := linearCombination(u1, g, u2, bigR)
+	if q.isInfinity() {
+		return nil, errors.New("recovered point at infinity")
+	}
+	return q.compressed(), nil
+}
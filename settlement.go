@@ -0,0 +1,146 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrPaymentNotFound is returned by [Wallet.VerifyPaymentSettled] if WoS does not
+// recognize the payment ID.
+var ErrPaymentNotFound = errors.New("payment not found")
+
+// SettlementOptions customizes [Wallet.VerifyPaymentSettledWith].
+type SettlementOptions struct {
+	// MinConfirmations, if positive, additionally requires an on-chain payment's
+	// transaction to have at least this many confirmations, as reported by the
+	// wallet's [BlockExplorer]. Ignored for lightning payments.
+	MinConfirmations int
+}
+
+// VerifyPaymentSettled reports whether the payment with the given ID has settled, by
+// cross-checking two sources: the payment's own status, and its status in the wallet's
+// payment history. WoS is a custodial system, and either may be momentarily stale, so
+// the payment is only considered settled if both agree that it is paid. This reduces
+// false positives when confirming high-value payments.
+//
+// Returns false without error if the payment is not yet settled, or if the sources
+// disagree, in which case it is worth checking again later. Returns an error wrapping
+// [ErrPaymentNotFound] if the ID is unknown.
+func (wallet *Wallet) VerifyPaymentSettled(ctx context.Context, paymentID string) (bool, error) {
+	return wallet.VerifyPaymentSettledWith(ctx, paymentID, nil)
+}
+
+// VerifyPaymentSettledWith is like [Wallet.VerifyPaymentSettled], but can also require
+// on-chain payments to have a number of confirmations. opts can be nil.
+func (wallet *Wallet) VerifyPaymentSettledWith(
+	ctx context.Context,
+	paymentID string,
+	opts *SettlementOptions,
+) (bool, error) {
+	if opts == nil {
+		opts = &SettlementOptions{}
+	}
+	rdr := wallet.reader
+
+	respData, err := rdr.GetRequest(ctx, "/api/v1/wallet/payment/"+url.PathEscape(paymentID))
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, fmt.Errorf("VerifyPaymentSettled: %w: %s", ErrPaymentNotFound, paymentID)
+	} else if err != nil {
+		return false, fmt.Errorf("VerifyPaymentSettled: %w", err)
+	}
+
+	var payment Payment
+	if err := json.Unmarshal(respData, &payment); err != nil {
+		return false, fmt.Errorf("invalid VerifyPaymentSettled response: %w", err)
+	} else if payment.Status != PaymentStatusPaid {
+		return false, nil
+	}
+
+	inHistory := false
+	err = rdr.WalkPayments(ctx, func(p *Payment) bool {
+		if p.ID != paymentID {
+			return true
+		}
+		inHistory = p.Status == PaymentStatusPaid
+		return false
+	})
+	if err != nil {
+		return false, fmt.Errorf("VerifyPaymentSettled: %w", err)
+	} else if !inHistory {
+		return false, nil
+	}
+
+	if opts.MinConfirmations > 0 && payment.Currency == PaymentCurrencyBitcoin {
+		if payment.Txid == "" {
+			return false, nil
+		}
+		confirmations, err := rdr.OnChainConfirmations(ctx, payment.Txid)
+		if err != nil {
+			return false, fmt.Errorf("VerifyPaymentSettled: %w", err)
+		}
+		return confirmations >= opts.MinConfirmations, nil
+	}
+	return true, nil
+}
+
+// DefaultPaymentPollInterval is the interval at which [Wallet.TrackPayment] polls
+// a payment's status.
+const DefaultPaymentPollInterval = 10 * time.Second
+
+// TrackPayment watches the payment with the given ID, calling onChange with the
+// payment when it is first seen and again each time its status changes, until the
+// payment is no longer pending or ctx is cancelled. Returns nil once the payment
+// reaches a final status such as [PaymentStatusPaid].
+//
+// The payment is polled every [DefaultPaymentPollInterval]. onChange is called
+// serially from the calling goroutine, so it need not be safe for concurrent use,
+// and no calls are made after TrackPayment returns. Errors from individual polls
+// are not fatal, except that an error wrapping [ErrPaymentNotFound] is returned
+// if WoS does not recognize the ID.
+func (wallet *Wallet) TrackPayment(
+	ctx context.Context,
+	paymentID string,
+	onChange func(*Payment),
+) error {
+	clock := clockOrDefault(wallet.clock)
+	var (
+		lastStatus PaymentStatus
+		lastErr    error
+	)
+	for {
+		respData, err := wallet.reader.GetRequest(ctx, "/api/v1/wallet/payment/"+url.PathEscape(paymentID))
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("TrackPayment: %w: %s", ErrPaymentNotFound, paymentID)
+		} else if err == nil {
+			var payment Payment
+			if err = json.Unmarshal(respData, &payment); err != nil {
+				err = fmt.Errorf("invalid TrackPayment response: %w", err)
+			} else {
+				if payment.Status != lastStatus {
+					lastStatus = payment.Status
+					onChange(&payment)
+				}
+				if !payment.IsPending() {
+					return nil
+				}
+			}
+		}
+		lastErr = err
+
+		select {
+		case <-clock.After(DefaultPaymentPollInterval):
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("TrackPayment: %w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return fmt.Errorf("TrackPayment: %w", ctx.Err())
+		}
+	}
+}
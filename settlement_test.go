@@ -0,0 +1,94 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestVerifyPaymentSettled(t *testing.T) {
+	var status, history string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/payment/abc":
+			w.Write([]byte(`{"id":"abc","currency":"BTC","transactionId":"deadbeef","status":"` + status + `"}`))
+		case "/api/v1/wallet/payment":
+			w.Write([]byte(`[{"id":"other","status":"PAID"},{"id":"abc","status":"` + history + `"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		status, history string
+		settled         bool
+	}{
+		{"PAID", "PAID", true},
+		{"PAID", "PENDING", false},
+		{"PENDING", "PAID", false},
+		{"PENDING", "PENDING", false},
+	}
+	for _, test := range tests {
+		status, history = test.status, test.history
+		settled, err := wallet.VerifyPaymentSettled(ctx, "abc")
+		if err != nil {
+			t.Fatalf("VerifyPaymentSettled failed: %v", err)
+		} else if settled != test.settled {
+			t.Errorf("status %s, history %s: expected settled=%v", test.status, test.history, test.settled)
+		}
+	}
+
+	status, history = "PAID", "PAID"
+	opts := &SettlementOptions{MinConfirmations: 2}
+	if _, err := wallet.VerifyPaymentSettledWith(ctx, "abc", opts); !errors.Is(err, ErrNoBlockExplorer) {
+		t.Fatalf("expected ErrNoBlockExplorer, got %v", err)
+	}
+	explorer := &mockExplorer{polls: 1}
+	wallet.reader.SetBlockExplorer(explorer)
+	if settled, _ := wallet.VerifyPaymentSettledWith(ctx, "abc", opts); settled {
+		t.Fatalf("expected payment with 1 confirmation not to be settled")
+	}
+	if settled, _ := wallet.VerifyPaymentSettledWith(ctx, "abc", opts); !settled {
+		t.Fatalf("expected payment with 2 confirmations to be settled")
+	}
+
+	if _, err := wallet.VerifyPaymentSettled(ctx, "missing"); !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("expected ErrPaymentNotFound, got %v", err)
+	}
+}
+
+func TestTrackPayment(t *testing.T) {
+	statuses := []string{"PENDING", "PENDING", "PENDING", "PAID"}
+	polls := 0
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/wallet/payment/abc" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		status := statuses[min(polls, len(statuses)-1)]
+		polls++
+		w.Write([]byte(`{"id":"abc","status":"` + status + `"}`))
+	})
+	wallet.SetClock(newFakeClock())
+
+	var seen []PaymentStatus
+	err := wallet.TrackPayment(context.Background(), "abc", func(p *Payment) {
+		seen = append(seen, p.Status)
+	})
+	if err != nil {
+		t.Fatalf("TrackPayment failed: %v", err)
+	} else if len(seen) != 2 || seen[0] != PaymentStatusPending || seen[1] != PaymentStatusPaid {
+		t.Fatalf("expected PENDING then PAID, got %v", seen)
+	} else if polls != len(statuses) {
+		t.Fatalf("expected %d polls, got %d", len(statuses), polls)
+	}
+
+	err = wallet.TrackPayment(context.Background(), "unknown", func(*Payment) {
+		t.Fatalf("unexpected callback for unknown payment")
+	})
+	if !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("expected ErrPaymentNotFound, got %v", err)
+	}
+}
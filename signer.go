@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
-	"io"
+	"errors"
+	"fmt"
+	"time"
 )
 
 // Signer represents an HMAC-SHA256 signer which signs the given HTTP request
@@ -12,7 +14,8 @@ import (
 //
 // For a simple instantiation of Signer, see [SimpleSigner].
 type Signer interface {
-	// SignRequest should return a SHA256 HMAC on the following string:
+	// SignRequest should return a SHA256 HMAC on the following string, as
+	// returned by [SigningMessage]:
 	//
 	// 	endpoint + nonce + apiToken + requestBody
 	//
@@ -41,10 +44,106 @@ func (s *SimpleSigner) SignRequest(
 	ctx context.Context,
 	endpoint, nonce, apiToken, requestBody string,
 ) ([]byte, error) {
-	hasher := hmac.New(sha256.New, []byte(s.apiSecret))
-	io.WriteString(hasher, endpoint)
-	io.WriteString(hasher, nonce)
-	io.WriteString(hasher, apiToken)
-	io.WriteString(hasher, requestBody)
-	return hasher.Sum(nil), nil
+	return computeSignature(s.apiSecret, endpoint, nonce, apiToken, requestBody), nil
+}
+
+// SigningMessage returns the exact bytes which a [Signer] must HMAC-SHA256 with the
+// APISecret to sign a request with the given details. This is the canonical reference
+// for the format, for developers implementing a [Signer] elsewhere, such as in a remote
+// signing service or another language.
+//
+// The message is the plain concatenation of endpoint, nonce, apiToken and requestBody,
+// in that order, with no separators or encoding. The endpoint is the request path, such
+// as "/api/v1/wallet/payment", and requestBody is the JSON body exactly as sent, or
+// empty for requests without a body.
+func SigningMessage(endpoint, nonce, apiToken, requestBody string) []byte {
+	message := make([]byte, 0, len(endpoint)+len(nonce)+len(apiToken)+len(requestBody))
+	message = append(message, endpoint...)
+	message = append(message, nonce...)
+	message = append(message, apiToken...)
+	message = append(message, requestBody...)
+	return message
+}
+
+func computeSignature(apiSecret, endpoint, nonce, apiToken, requestBody string) []byte {
+	hasher := hmac.New(sha256.New, []byte(apiSecret))
+	hasher.Write(SigningMessage(endpoint, nonce, apiToken, requestBody))
+	return hasher.Sum(nil)
+}
+
+// VerifySignature returns true if sig is the signature WoS expects for a request with
+// the given details, signed with apiSecret. Integrators implementing their own [Signer]
+// can use this to check their signatures, since a signer which concatenates the
+// request details in the wrong order produces signatures WoS silently rejects.
+func VerifySignature(apiSecret, endpoint, nonce, apiToken, requestBody string, sig []byte) bool {
+	return hmac.Equal(sig, computeSignature(apiSecret, endpoint, nonce, apiToken, requestBody))
+}
+
+// ErrSigningDisabled is returned by [DisabledSigner] for every request it is asked to sign.
+var ErrSigningDisabled = errors.New("request signing is disabled")
+
+// DisabledSigner implements [Signer] by refusing to sign anything. A [Wallet] opened
+// with a DisabledSigner can perform every read operation, but every write, such as
+// creating an invoice or sending a payment, fails with an error wrapping
+// [ErrSigningDisabled] before any request is made.
+//
+// This is a safety rail for staging environments which must never move real funds,
+// even if they are misconfigured with production credentials.
+type DisabledSigner struct{}
+
+// SignRequest implements Signer.
+func (DisabledSigner) SignRequest(
+	ctx context.Context,
+	endpoint, nonce, apiToken, requestBody string,
+) ([]byte, error) {
+	return nil, fmt.Errorf("%w: refusing to sign request to %s", ErrSigningDisabled, endpoint)
+}
+
+// ErrSignerTimeout is returned when a [Signer] takes longer to sign a request than the
+// timeout set with [Wallet.SetSignerTimeout].
+var ErrSignerTimeout = errors.New("signer timed out")
+
+// SetSignerTimeout limits how long the wallet waits for its [Signer] to sign each request,
+// separately from the deadline of the request's context, so that an unresponsive remote
+// signer fails the request with an error wrapping [ErrSignerTimeout] instead of hanging
+// until the caller gives up. The request is never sent if signing times out.
+//
+// The signer's context is cancelled at the timeout, but the wallet stops waiting even if
+// the signer ignores it. Zero, the default, disables the timeout.
+func (wallet *Wallet) SetSignerTimeout(timeout time.Duration) {
+	wallet.signerTimeout = timeout
+}
+
+// signRequest signs a request with the wallet's signer, subject to its signer timeout.
+func (wallet *Wallet) signRequest(ctx context.Context, endpoint, nonce, body string) ([]byte, error) {
+	timeout := wallet.signerTimeout
+	if timeout <= 0 {
+		return wallet.signer.SignRequest(ctx, endpoint, nonce, wallet.reader.apiToken, body)
+	}
+
+	signCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		signature []byte
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		signature, err := wallet.signer.SignRequest(signCtx, endpoint, nonce, wallet.reader.apiToken, body)
+		done <- result{signature, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && ctx.Err() == nil && errors.Is(signCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %s: %w", ErrSignerTimeout, timeout, r.err)
+		}
+		return r.signature, r.err
+	case <-signCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w after %s", ErrSignerTimeout, timeout)
+	}
 }
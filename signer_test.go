@@ -0,0 +1,139 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const (
+		secret   = "secret"
+		endpoint = "/api/v1/wallet/payment"
+		nonce    = "bm9uY2U="
+		apiToken = "93b9c574-30a2-4bf5-81ba-f9feadb313a7"
+		body     = `{"address":"bc1qexample","currency":"BTC","amount":0.001}`
+
+		// HMAC-SHA256 over endpoint + nonce + apiToken + body, as computed by WoS.
+		expected = "1a7db6bc74f2a5f5d319f40d9acb21b7ac748807aea6e174e7ffec7eeee2e032"
+	)
+
+	sig, err := NewSimpleSigner(secret).SignRequest(context.Background(), endpoint, nonce, apiToken, body)
+	if err != nil {
+		t.Fatalf("SignRequest failed: %v", err)
+	} else if hex.EncodeToString(sig) != expected {
+		t.Fatalf("expected signature %s, got %x", expected, sig)
+	}
+
+	if !VerifySignature(secret, endpoint, nonce, apiToken, body, sig) {
+		t.Fatalf("expected signature to verify")
+	}
+
+	// A signer which swaps the token and body produces a signature WoS would reject.
+	swapped, _ := NewSimpleSigner(secret).SignRequest(context.Background(), endpoint, nonce, body, apiToken)
+	if VerifySignature(secret, endpoint, nonce, apiToken, body, swapped) {
+		t.Fatalf("expected signature with swapped parameters to be rejected")
+	}
+	if VerifySignature("other", endpoint, nonce, apiToken, body, sig) {
+		t.Fatalf("expected signature with wrong secret to be rejected")
+	}
+}
+
+func TestSigningMessage(t *testing.T) {
+	const (
+		endpoint = "/api/v1/wallet/payment"
+		nonce    = "bm9uY2U="
+		apiToken = "token"
+		body     = `{"amount":0.001}`
+	)
+
+	message := SigningMessage(endpoint, nonce, apiToken, body)
+	if string(message) != endpoint+nonce+apiToken+body {
+		t.Fatalf("unexpected signing message %q", message)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(message)
+	sig, err := NewSimpleSigner("secret").SignRequest(context.Background(), endpoint, nonce, apiToken, body)
+	if err != nil {
+		t.Fatalf("SignRequest failed: %v", err)
+	} else if !bytes.Equal(mac.Sum(nil), sig) {
+		t.Fatalf("HMAC of signing message does not match SimpleSigner signature")
+	}
+}
+
+func TestDisabledSigner(t *testing.T) {
+	var posts int
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+		}
+		switch r.URL.Path {
+		case "/api/v1/wallet/account":
+			w.Write([]byte(`{"btcDepositAddress":"bc1qexample","lightningAddress":"satoshi@walletofsatoshi.com"}`))
+		default:
+			w.Write([]byte(`{"btc":0.001,"btcUnconfirmed":0}`))
+		}
+	})
+
+	ctx := context.Background()
+	wallet, err := OpenWallet(ctx, NewReader("token", httpClient), DisabledSigner{})
+	if err != nil {
+		t.Fatalf("OpenWallet failed: %v", err)
+	}
+	if balance, err := wallet.Balance(ctx); err != nil || balance.Confirmed != 0.001 {
+		t.Fatalf("expected reads to work, got %+v, %v", balance, err)
+	}
+
+	if _, err := wallet.PayOnChain(ctx, "bc1qexample", 0.0001, ""); !errors.Is(err, ErrSigningDisabled) {
+		t.Fatalf("expected ErrSigningDisabled, got %v", err)
+	}
+	if _, err := wallet.NewInvoice(ctx, nil); !errors.Is(err, ErrSigningDisabled) {
+		t.Fatalf("expected ErrSigningDisabled, got %v", err)
+	}
+	if posts != 0 {
+		t.Fatalf("expected no POST requests, got %d", posts)
+	}
+}
+
+// stallingSigner never returns until released, ignoring its context like a hung
+// remote signer might.
+type stallingSigner struct {
+	release chan struct{}
+}
+
+func (s stallingSigner) SignRequest(ctx context.Context, endpoint, nonce, apiToken, requestBody string) ([]byte, error) {
+	<-s.release
+	return nil, errors.New("released")
+}
+
+func TestSignerTimeout(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+	})
+	signer := stallingSigner{release: make(chan struct{})}
+	defer close(signer.release)
+	wallet.signer = signer
+	wallet.SetSignerTimeout(20 * time.Millisecond)
+
+	start := time.Now()
+	_, err := wallet.PostRequest(context.Background(), "/api/v1/wallet/payment", map[string]any{})
+	if !errors.Is(err, ErrSignerTimeout) {
+		t.Fatalf("expected ErrSignerTimeout, got %v", err)
+	} else if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("signer timeout took %s to fire", elapsed)
+	}
+
+	// The caller's own cancellation is not reported as a signer timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := wallet.PostRequest(ctx, "/api/v1/wallet/payment", map[string]any{}); errors.Is(err, ErrSignerTimeout) {
+		t.Fatalf("expected context error rather than ErrSignerTimeout, got %v", err)
+	}
+}
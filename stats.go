@@ -0,0 +1,202 @@
+package wos
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Stats summarizes a wallet's payment history, as computed by [PaymentStats].
+// All amounts are in satoshis.
+type Stats struct {
+	// Count is the number of payments.
+	Count int
+
+	// TotalIn and TotalOut are the sums of received and sent payments.
+	TotalIn  int64
+	TotalOut int64
+
+	// Average and Median are the mean and median payment sizes, in either direction.
+	// With an even number of payments, the median is the mean of the middle two,
+	// rounded down.
+	Average int64
+	Median  int64
+
+	// Largest points to the largest payment in the slice given to [PaymentStats],
+	// in either direction, or is nil if there are none.
+	Largest *Payment
+
+	// BusiestWeekday and BusiestHour are the day of the week and hour of the day
+	// in which the most payments occurred, in the time zone of each payment's Time.
+	// Ties are broken in favor of the earliest. Both are zero if there are no payments.
+	BusiestWeekday time.Weekday
+	BusiestHour    int
+}
+
+// toSats converts a BTC amount to satoshis, rounding to the nearest satoshi.
+func toSats(amount float64) int64 {
+	return int64(math.Round(amount * 100_000_000))
+}
+
+// PaymentStats computes statistics over a list of payments, such as for an insights
+// screen. This is pure analysis, and makes no API calls. To compute statistics over
+// a particular time zone, convert each payment's Time with [time.Time.In] first.
+func PaymentStats(payments []Payment) *Stats {
+	stats := &Stats{Count: len(payments)}
+	if len(payments) == 0 {
+		return stats
+	}
+
+	sizes := make([]int64, len(payments))
+	var total int64
+	var weekdays [7]int
+	var hours [24]int
+	for i := range payments {
+		payment := &payments[i]
+		sats := toSats(payment.Amount)
+		sizes[i] = sats
+		total += sats
+
+		if payment.Type == PaymentTypeCredit {
+			stats.TotalIn += sats
+		} else {
+			stats.TotalOut += sats
+		}
+		if stats.Largest == nil || sats > toSats(stats.Largest.Amount) {
+			stats.Largest = payment
+		}
+
+		weekdays[payment.Time.Weekday()]++
+		hours[payment.Time.Hour()]++
+	}
+
+	stats.Average = total / int64(len(payments))
+
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	mid := len(sizes) / 2
+	if len(sizes)%2 == 1 {
+		stats.Median = sizes[mid]
+	} else {
+		stats.Median = (sizes[mid-1] + sizes[mid]) / 2
+	}
+
+	for day, count := range weekdays {
+		if count > weekdays[stats.BusiestWeekday] {
+			stats.BusiestWeekday = time.Weekday(day)
+		}
+	}
+	for hour, count := range hours {
+		if count > hours[stats.BusiestHour] {
+			stats.BusiestHour = hour
+		}
+	}
+	return stats
+}
+
+// Bucket is a period of time into which [AggregatePayments] groups payments.
+type Bucket int
+
+const (
+	// BucketDay groups payments by calendar day.
+	BucketDay Bucket = iota
+
+	// BucketWeek groups payments by week, starting on Monday.
+	BucketWeek
+
+	// BucketMonth groups payments by calendar month.
+	BucketMonth
+)
+
+// start returns the start of the bucket containing t, in t's location.
+func (bucket Bucket) start(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch bucket {
+	case BucketWeek:
+		// Weekdays count from Sunday, but weeks start on Monday.
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case BucketMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// next returns the start of the bucket following the one starting at start.
+func (bucket Bucket) next(start time.Time) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	case BucketMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// AggregateBucket summarizes the payments in one period, as computed by
+// [AggregatePayments]. All amounts are in satoshis.
+type AggregateBucket struct {
+	// Start is the start of the period, at midnight in the aggregation's time zone.
+	Start time.Time
+
+	// Received and Sent are the sums of received and sent payments in the period.
+	Received int64
+	Sent     int64
+
+	// Net is Received minus Sent.
+	Net int64
+
+	// Count is the number of payments in the period.
+	Count int
+}
+
+// AggregatePayments groups payments into consecutive day, week or month buckets in the
+// local time zone, such as for a bar chart of revenue and expenses. See
+// [AggregatePaymentsIn] to aggregate in a different time zone.
+func AggregatePayments(payments []Payment, bucket Bucket) []AggregateBucket {
+	return AggregatePaymentsIn(payments, bucket, time.Local)
+}
+
+// AggregatePaymentsIn groups payments into consecutive day, week or month buckets in
+// the given time zone. Buckets run from the one containing the earliest payment to the
+// one containing the latest, ordered from oldest to newest, and periods without any
+// payments are included with zero totals, so that charts have no gaps.
+//
+// This is pure computation, and makes no API calls. Returns nil if there are no payments.
+func AggregatePaymentsIn(payments []Payment, bucket Bucket, loc *time.Location) []AggregateBucket {
+	if len(payments) == 0 {
+		return nil
+	}
+
+	first, last := payments[0].Time, payments[0].Time
+	for _, payment := range payments[1:] {
+		if payment.Time.Before(first) {
+			first = payment.Time
+		} else if payment.Time.After(last) {
+			last = payment.Time
+		}
+	}
+
+	var buckets []AggregateBucket
+	index := make(map[int64]int)
+	end := bucket.start(last.In(loc))
+	for start := bucket.start(first.In(loc)); !start.After(end); start = bucket.next(start) {
+		index[start.Unix()] = len(buckets)
+		buckets = append(buckets, AggregateBucket{Start: start})
+	}
+
+	for _, payment := range payments {
+		agg := &buckets[index[bucket.start(payment.Time.In(loc)).Unix()]]
+		sats := toSats(payment.Amount)
+		if payment.Type == PaymentTypeCredit {
+			agg.Received += sats
+			agg.Net += sats
+		} else {
+			agg.Sent += sats
+			agg.Net -= sats
+		}
+		agg.Count++
+	}
+	return buckets
+}
@@ -0,0 +1,90 @@
+package wos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPaymentStats(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	monday := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+	wednesday := monday.AddDate(0, 0, 2)
+
+	payments := []Payment{
+		{ID: "a", Amount: 0.00001, Type: PaymentTypeCredit, Time: monday},
+		{ID: "b", Amount: 0.00005, Type: PaymentTypeDebit, Time: wednesday.Add(5 * time.Hour)},
+		{ID: "c", Amount: 0.00002, Type: PaymentTypeCredit, Time: wednesday},
+		{ID: "d", Amount: 0.001, Type: PaymentTypeCredit, Time: wednesday.Add(time.Minute)},
+	}
+
+	stats := PaymentStats(payments)
+	if stats.Count != 4 || stats.TotalIn != 103_000 || stats.TotalOut != 5000 {
+		t.Fatalf("unexpected totals: %+v", stats)
+	}
+	if stats.Average != 27_000 {
+		t.Fatalf("expected average of 27000 sats, got %d", stats.Average)
+	}
+	// Sizes are 1000, 2000, 5000, 100000: the median is the mean of 2000 and 5000.
+	if stats.Median != 3500 {
+		t.Fatalf("expected median of 3500 sats, got %d", stats.Median)
+	}
+	if stats.Largest == nil || stats.Largest.ID != "d" {
+		t.Fatalf("expected largest payment d, got %+v", stats.Largest)
+	}
+	if stats.BusiestWeekday != time.Wednesday || stats.BusiestHour != 9 {
+		t.Fatalf("expected busiest Wednesday at 9:00, got %s at %d:00", stats.BusiestWeekday, stats.BusiestHour)
+	}
+
+	stats = PaymentStats(payments[:3])
+	if stats.Median != 2000 {
+		t.Fatalf("expected odd-count median of 2000 sats, got %d", stats.Median)
+	}
+
+	empty := PaymentStats(nil)
+	if empty.Count != 0 || empty.Median != 0 || empty.Largest != nil {
+		t.Fatalf("unexpected stats for empty history: %+v", empty)
+	}
+}
+
+func TestAggregatePayments(t *testing.T) {
+	// Payments are on Jan 30 and Feb 2 in UTC+2, but Jan 29 and Feb 1 in UTC.
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	payments := []Payment{
+		{Amount: 0.0001, Type: PaymentTypeCredit, Time: time.Date(2024, 1, 29, 23, 0, 0, 0, time.UTC)},
+		{Amount: 0.00002, Type: PaymentTypeDebit, Time: time.Date(2024, 1, 30, 10, 0, 0, 0, time.UTC)},
+		{Amount: 0.0003, Type: PaymentTypeCredit, Time: time.Date(2024, 2, 1, 23, 30, 0, 0, time.UTC)},
+	}
+
+	buckets := AggregatePaymentsIn(payments, BucketDay, loc)
+	if len(buckets) != 4 {
+		t.Fatalf("expected 4 daily buckets from Jan 30 to Feb 2, got %d", len(buckets))
+	}
+	expected := []AggregateBucket{
+		{Start: time.Date(2024, 1, 30, 0, 0, 0, 0, loc), Received: 10_000, Sent: 2000, Net: 8000, Count: 2},
+		{Start: time.Date(2024, 1, 31, 0, 0, 0, 0, loc)},
+		{Start: time.Date(2024, 2, 1, 0, 0, 0, 0, loc)},
+		{Start: time.Date(2024, 2, 2, 0, 0, 0, 0, loc), Received: 30_000, Net: 30_000, Count: 1},
+	}
+	for i, want := range expected {
+		got := buckets[i]
+		if !got.Start.Equal(want.Start) || got.Received != want.Received || got.Sent != want.Sent ||
+			got.Net != want.Net || got.Count != want.Count {
+			t.Fatalf("bucket %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	monthly := AggregatePaymentsIn(payments, BucketMonth, time.UTC)
+	if len(monthly) != 2 || monthly[0].Count != 2 || monthly[1].Received != 30_000 {
+		t.Fatalf("unexpected monthly buckets: %+v", monthly)
+	}
+
+	// Jan 29 2024 is a Monday, so all three payments fall in one week in UTC.
+	weekly := AggregatePaymentsIn(payments, BucketWeek, time.UTC)
+	if len(weekly) != 1 || weekly[0].Count != 3 || !weekly[0].Start.Equal(payments[0].Time.Truncate(24*time.Hour)) {
+		t.Fatalf("unexpected weekly buckets: %+v", weekly)
+	}
+
+	if AggregatePayments(nil, BucketDay) != nil {
+		t.Fatalf("expected no buckets without payments")
+	}
+}
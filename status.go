@@ -0,0 +1,76 @@
+package wos
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// statusEndpoint is a cheap API endpoint used to check whether WoS is up. Without
+// credentials it is rejected, but any response other than a server error shows the
+// API is reachable and functioning.
+const statusEndpoint = "/api/v1/wallet/balance"
+
+// ServiceStatus reports whether the WoS API appeared to be working when checked
+// by [CheckServiceStatus].
+type ServiceStatus struct {
+	// Up is true if the API responded without a server error.
+	Up bool
+
+	// StatusCode is the HTTP status of the API's response, or zero if it could not be reached.
+	StatusCode int
+
+	// Latency is how long the API took to respond.
+	Latency time.Duration
+
+	// Err describes why the API is considered down, if it is.
+	Err error
+
+	// CheckedAt is when the check was made.
+	CheckedAt time.Time
+}
+
+// CheckServiceStatus checks whether the WoS API is reachable and functioning, and
+// measures its latency. This requires no credentials, so it can help distinguish
+// between invalid credentials and an outage of Wallet of Satoshi itself.
+//
+// Failure to reach the API is reported in the returned [ServiceStatus], not as an
+// error. An error is only returned if ctx is done before the check completes.
+//
+// Uses [http.DefaultClient] if httpClient is nil.
+func CheckServiceStatus(ctx context.Context, httpClient *http.Client) (*ServiceStatus, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx, httpClient)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", BaseURL+statusEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "")
+
+	status := &ServiceStatus{CheckedAt: time.Now()}
+	resp, err := httpClient.Do(req)
+	status.Latency = time.Since(status.CheckedAt)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && ctxErr != context.DeadlineExceeded {
+			return nil, ctxErr
+		}
+		status.Err = err
+		return status, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	status.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 500 {
+		status.Err = checkHTTPResponse(resp, nil)
+	} else {
+		status.Up = true
+	}
+	return status, nil
+}
@@ -0,0 +1,38 @@
+package wos
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCheckServiceStatus(t *testing.T) {
+	var code int
+	client := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Api-Token") != "" {
+			t.Errorf("status check should not send credentials")
+		}
+		w.WriteHeader(code)
+	})
+
+	for _, test := range []struct {
+		code int
+		up   bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusUnauthorized, true},
+		{http.StatusBadGateway, false},
+		{http.StatusServiceUnavailable, false},
+	} {
+		code = test.code
+		status, err := CheckServiceStatus(context.Background(), client)
+		if err != nil {
+			t.Fatalf("CheckServiceStatus failed: %v", err)
+		}
+		if status.Up != test.up || status.StatusCode != test.code {
+			t.Errorf("status %d: expected up=%v, got %+v", test.code, test.up, status)
+		} else if !test.up && status.Err == nil {
+			t.Errorf("status %d: expected an error describing the outage", test.code)
+		}
+	}
+}
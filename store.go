@@ -0,0 +1,503 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotStored is returned by a [Store] when asked to load something which was
+// never saved.
+var ErrNotStored = errors.New("not found in store")
+
+// Store persists wallet credentials and [HistoryCursor] values, each under a name
+// chosen by the caller, such as a user ID. It lets services persist wallets and
+// history syncs without writing their own storage layer.
+//
+// This package provides [MemoryStore] and [FileStore]. Implementations must be safe
+// for concurrent use, and must return an error wrapping [ErrNotStored] when asked to
+// load a name which was never saved.
+//
+// Other features persist their state through their own small interfaces, such as
+// [ScheduleStore] and [BatchStore], so that implementations of Store need only
+// support the features they are used with. [MemoryStore] and [FileStore] implement
+// all of them.
+type Store interface {
+	SaveCredentials(ctx context.Context, name string, creds Credentials) error
+	LoadCredentials(ctx context.Context, name string) (*Credentials, error)
+
+	SaveCursor(ctx context.Context, name string, cursor HistoryCursor) error
+	LoadCursor(ctx context.Context, name string) (*HistoryCursor, error)
+}
+
+// ScheduleStore persists the next run times of a [Scheduler], under the schedule's
+// name. Like a [Store], implementations must be safe for concurrent use, and must
+// return an error wrapping [ErrNotStored] when asked to load a name never saved.
+type ScheduleStore interface {
+	SaveNextRun(ctx context.Context, name string, next time.Time) error
+	LoadNextRun(ctx context.Context, name string) (time.Time, error)
+}
+
+// BatchStore persists the progress of batches paid by [Wallet.PayBatch], under the
+// batch's ID. Like a [Store], implementations must be safe for concurrent use, and
+// must return an error wrapping [ErrNotStored] when asked to load a name never saved.
+type BatchStore interface {
+	SaveBatch(ctx context.Context, name string, state BatchState) error
+	LoadBatch(ctx context.Context, name string) (*BatchState, error)
+}
+
+// FiatInvoiceStore persists the quotes of [FiatInvoice] values, under a name such as
+// the invoice ID. Like a [Store], implementations must be safe for concurrent use, and
+// must return an error wrapping [ErrNotStored] when asked to load a name never saved.
+type FiatInvoiceStore interface {
+	SaveFiatInvoice(ctx context.Context, name string, invoice FiatInvoice) error
+	LoadFiatInvoice(ctx context.Context, name string) (*FiatInvoice, error)
+}
+
+// IssuedInvoiceStore persists the invoices recorded by an [InvoiceRegistry], under
+// their payment hash. Like a [Store], implementations must be safe for concurrent use,
+// and must return an error wrapping [ErrNotStored] when asked to load a name never saved.
+type IssuedInvoiceStore interface {
+	SaveIssuedInvoice(ctx context.Context, name string, invoice IssuedInvoice) error
+	LoadIssuedInvoice(ctx context.Context, name string) (*IssuedInvoice, error)
+}
+
+// MemoryStore is a [Store], [ScheduleStore], [BatchStore], [FiatInvoiceStore] and
+// [IssuedInvoiceStore] which keeps everything in memory, for tests and short-lived
+// processes. The zero value is ready to use.
+type MemoryStore struct {
+	mu       sync.Mutex
+	creds    map[string]Credentials
+	cursors  map[string]HistoryCursor
+	nextRuns map[string]time.Time
+	batches  map[string]BatchState
+	fiat     map[string]FiatInvoice
+	issued   map[string]IssuedInvoice
+}
+
+// SaveCredentials implements Store.
+func (store *MemoryStore) SaveCredentials(ctx context.Context, name string, creds Credentials) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.creds == nil {
+		store.creds = make(map[string]Credentials)
+	}
+	store.creds[name] = creds
+	return nil
+}
+
+// LoadCredentials implements Store.
+func (store *MemoryStore) LoadCredentials(ctx context.Context, name string) (*Credentials, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	creds, ok := store.creds[name]
+	if !ok {
+		return nil, fmt.Errorf("LoadCredentials: %w: %s", ErrNotStored, name)
+	}
+	return &creds, nil
+}
+
+// SaveCursor implements Store.
+func (store *MemoryStore) SaveCursor(ctx context.Context, name string, cursor HistoryCursor) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.cursors == nil {
+		store.cursors = make(map[string]HistoryCursor)
+	}
+	cursor.SeenAtLastTime = append([]string(nil), cursor.SeenAtLastTime...)
+	store.cursors[name] = cursor
+	return nil
+}
+
+// LoadCursor implements Store.
+func (store *MemoryStore) LoadCursor(ctx context.Context, name string) (*HistoryCursor, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	cursor, ok := store.cursors[name]
+	if !ok {
+		return nil, fmt.Errorf("LoadCursor: %w: %s", ErrNotStored, name)
+	}
+	cursor.SeenAtLastTime = append([]string(nil), cursor.SeenAtLastTime...)
+	return &cursor, nil
+}
+
+// SaveNextRun implements ScheduleStore.
+func (store *MemoryStore) SaveNextRun(ctx context.Context, name string, next time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.nextRuns == nil {
+		store.nextRuns = make(map[string]time.Time)
+	}
+	store.nextRuns[name] = next
+	return nil
+}
+
+// LoadNextRun implements ScheduleStore.
+func (store *MemoryStore) LoadNextRun(ctx context.Context, name string) (time.Time, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	next, ok := store.nextRuns[name]
+	if !ok {
+		return time.Time{}, fmt.Errorf("LoadNextRun: %w: %s", ErrNotStored, name)
+	}
+	return next, nil
+}
+
+// SaveBatch implements BatchStore.
+func (store *MemoryStore) SaveBatch(ctx context.Context, name string, state BatchState) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.batches == nil {
+		store.batches = make(map[string]BatchState)
+	}
+	state.Items = append([]BatchItemResult(nil), state.Items...)
+	store.batches[name] = state
+	return nil
+}
+
+// LoadBatch implements BatchStore.
+func (store *MemoryStore) LoadBatch(ctx context.Context, name string) (*BatchState, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	state, ok := store.batches[name]
+	if !ok {
+		return nil, fmt.Errorf("LoadBatch: %w: %s", ErrNotStored, name)
+	}
+	state.Items = append([]BatchItemResult(nil), state.Items...)
+	return &state, nil
+}
+
+// SaveFiatInvoice implements FiatInvoiceStore.
+func (store *MemoryStore) SaveFiatInvoice(ctx context.Context, name string, invoice FiatInvoice) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.fiat == nil {
+		store.fiat = make(map[string]FiatInvoice)
+	}
+	if invoice.Invoice != nil {
+		inv := *invoice.Invoice
+		invoice.Invoice = &inv
+	}
+	store.fiat[name] = invoice
+	return nil
+}
+
+// LoadFiatInvoice implements FiatInvoiceStore.
+func (store *MemoryStore) LoadFiatInvoice(ctx context.Context, name string) (*FiatInvoice, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	invoice, ok := store.fiat[name]
+	if !ok {
+		return nil, fmt.Errorf("LoadFiatInvoice: %w: %s", ErrNotStored, name)
+	}
+	if invoice.Invoice != nil {
+		inv := *invoice.Invoice
+		invoice.Invoice = &inv
+	}
+	return &invoice, nil
+}
+
+// SaveIssuedInvoice implements IssuedInvoiceStore.
+func (store *MemoryStore) SaveIssuedInvoice(ctx context.Context, name string, invoice IssuedInvoice) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.issued == nil {
+		store.issued = make(map[string]IssuedInvoice)
+	}
+	invoice.PaymentHash = append([]byte(nil), invoice.PaymentHash...)
+	store.issued[name] = invoice
+	return nil
+}
+
+// LoadIssuedInvoice implements IssuedInvoiceStore.
+func (store *MemoryStore) LoadIssuedInvoice(ctx context.Context, name string) (*IssuedInvoice, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	invoice, ok := store.issued[name]
+	if !ok {
+		return nil, fmt.Errorf("LoadIssuedInvoice: %w: %s", ErrNotStored, name)
+	}
+	invoice.PaymentHash = append([]byte(nil), invoice.PaymentHash...)
+	return &invoice, nil
+}
+
+// FileStore is a [Store], [ScheduleStore], [BatchStore], [FiatInvoiceStore] and
+// [IssuedInvoiceStore] which keeps each saved value in its own file in a directory.
+// Credentials are encrypted with [Credentials.Seal] under the store's passphrase, so
+// API secrets are never written to disk in plaintext. Cursors, batches, fiat invoices
+// and issued invoices are stored as JSON, and next run times as RFC 3339 timestamps.
+//
+// Files are written atomically, and readable only by their owner.
+type FileStore struct {
+	dir        string
+	passphrase string
+
+	mu sync.Mutex
+}
+
+// NewFileStore returns a FileStore which keeps files in dir, creating it if needed,
+// and encrypts credentials with the given passphrase.
+func NewFileStore(dir, passphrase string) (*FileStore, error) {
+	if passphrase == "" {
+		return nil, errors.New("NewFileStore: passphrase must not be empty")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("NewFileStore: %w", err)
+	}
+	return &FileStore{dir: dir, passphrase: passphrase}, nil
+}
+
+// path returns the path of the file storing name with the given extension.
+func (store *FileStore) path(name, ext string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid store name: %q", name)
+	}
+	return filepath.Join(store.dir, name+ext), nil
+}
+
+// write atomically replaces the file at path with data. The data is flushed to disk
+// before the file is replaced, so that after a crash or power loss the file holds
+// either the old or the new data in full, never an empty or truncated file.
+func (store *FileStore) write(path string, data []byte) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	tmp, err := os.CreateTemp(store.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Flush the rename itself. Not every platform supports syncing a directory,
+	// so this is best-effort.
+	if dir, err := os.Open(store.dir); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// read returns the content of the file at path, wrapping [ErrNotStored] if it does not exist.
+func (store *FileStore) read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotStored, filepath.Base(path))
+	}
+	return data, err
+}
+
+// SaveCredentials implements Store.
+func (store *FileStore) SaveCredentials(ctx context.Context, name string, creds Credentials) error {
+	path, err := store.path(name, ".credentials")
+	if err != nil {
+		return fmt.Errorf("SaveCredentials: %w", err)
+	}
+	sealed, err := creds.Seal(store.passphrase)
+	if err != nil {
+		return fmt.Errorf("SaveCredentials: %w", err)
+	}
+	if err := store.write(path, sealed); err != nil {
+		return fmt.Errorf("SaveCredentials: %w", err)
+	}
+	return nil
+}
+
+// LoadCredentials implements Store. Returns an error wrapping [ErrDecryptionFailed]
+// if the credentials were saved under a different passphrase.
+func (store *FileStore) LoadCredentials(ctx context.Context, name string) (*Credentials, error) {
+	path, err := store.path(name, ".credentials")
+	if err != nil {
+		return nil, fmt.Errorf("LoadCredentials: %w", err)
+	}
+	sealed, err := store.read(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCredentials: %w", err)
+	}
+	creds, err := OpenSealedCredentials(sealed, store.passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCredentials: %w", err)
+	}
+	return creds, nil
+}
+
+// SaveCursor implements Store.
+func (store *FileStore) SaveCursor(ctx context.Context, name string, cursor HistoryCursor) error {
+	path, err := store.path(name, ".cursor.json")
+	if err != nil {
+		return fmt.Errorf("SaveCursor: %w", err)
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return fmt.Errorf("SaveCursor: %w", err)
+	}
+	if err := store.write(path, data); err != nil {
+		return fmt.Errorf("SaveCursor: %w", err)
+	}
+	return nil
+}
+
+// LoadCursor implements Store.
+func (store *FileStore) LoadCursor(ctx context.Context, name string) (*HistoryCursor, error) {
+	path, err := store.path(name, ".cursor.json")
+	if err != nil {
+		return nil, fmt.Errorf("LoadCursor: %w", err)
+	}
+	data, err := store.read(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCursor: %w", err)
+	}
+	var cursor HistoryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("LoadCursor: invalid cursor: %w", err)
+	}
+	return &cursor, nil
+}
+
+// SaveNextRun implements ScheduleStore.
+func (store *FileStore) SaveNextRun(ctx context.Context, name string, next time.Time) error {
+	path, err := store.path(name, ".nextrun")
+	if err != nil {
+		return fmt.Errorf("SaveNextRun: %w", err)
+	}
+	if err := store.write(path, []byte(next.Format(time.RFC3339Nano))); err != nil {
+		return fmt.Errorf("SaveNextRun: %w", err)
+	}
+	return nil
+}
+
+// LoadNextRun implements ScheduleStore.
+func (store *FileStore) LoadNextRun(ctx context.Context, name string) (time.Time, error) {
+	path, err := store.path(name, ".nextrun")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("LoadNextRun: %w", err)
+	}
+	data, err := store.read(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("LoadNextRun: %w", err)
+	}
+	next, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("LoadNextRun: invalid time: %w", err)
+	}
+	return next, nil
+}
+
+// SaveBatch implements BatchStore.
+func (store *FileStore) SaveBatch(ctx context.Context, name string, state BatchState) error {
+	path, err := store.path(name, ".batch.json")
+	if err != nil {
+		return fmt.Errorf("SaveBatch: %w", err)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("SaveBatch: %w", err)
+	}
+	if err := store.write(path, data); err != nil {
+		return fmt.Errorf("SaveBatch: %w", err)
+	}
+	return nil
+}
+
+// LoadBatch implements BatchStore.
+func (store *FileStore) LoadBatch(ctx context.Context, name string) (*BatchState, error) {
+	path, err := store.path(name, ".batch.json")
+	if err != nil {
+		return nil, fmt.Errorf("LoadBatch: %w", err)
+	}
+	data, err := store.read(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadBatch: %w", err)
+	}
+	var state BatchState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("LoadBatch: invalid batch: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveFiatInvoice implements FiatInvoiceStore.
+func (store *FileStore) SaveFiatInvoice(ctx context.Context, name string, invoice FiatInvoice) error {
+	path, err := store.path(name, ".fiat.json")
+	if err != nil {
+		return fmt.Errorf("SaveFiatInvoice: %w", err)
+	}
+	data, err := json.Marshal(invoice)
+	if err != nil {
+		return fmt.Errorf("SaveFiatInvoice: %w", err)
+	}
+	if err := store.write(path, data); err != nil {
+		return fmt.Errorf("SaveFiatInvoice: %w", err)
+	}
+	return nil
+}
+
+// LoadFiatInvoice implements FiatInvoiceStore.
+func (store *FileStore) LoadFiatInvoice(ctx context.Context, name string) (*FiatInvoice, error) {
+	path, err := store.path(name, ".fiat.json")
+	if err != nil {
+		return nil, fmt.Errorf("LoadFiatInvoice: %w", err)
+	}
+	data, err := store.read(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadFiatInvoice: %w", err)
+	}
+	var invoice FiatInvoice
+	if err := json.Unmarshal(data, &invoice); err != nil {
+		return nil, fmt.Errorf("LoadFiatInvoice: invalid fiat invoice: %w", err)
+	}
+	return &invoice, nil
+}
+
+// SaveIssuedInvoice implements IssuedInvoiceStore.
+func (store *FileStore) SaveIssuedInvoice(ctx context.Context, name string, invoice IssuedInvoice) error {
+	path, err := store.path(name, ".issued.json")
+	if err != nil {
+		return fmt.Errorf("SaveIssuedInvoice: %w", err)
+	}
+	data, err := json.Marshal(invoice)
+	if err != nil {
+		return fmt.Errorf("SaveIssuedInvoice: %w", err)
+	}
+	if err := store.write(path, data); err != nil {
+		return fmt.Errorf("SaveIssuedInvoice: %w", err)
+	}
+	return nil
+}
+
+// LoadIssuedInvoice implements IssuedInvoiceStore.
+func (store *FileStore) LoadIssuedInvoice(ctx context.Context, name string) (*IssuedInvoice, error) {
+	path, err := store.path(name, ".issued.json")
+	if err != nil {
+		return nil, fmt.Errorf("LoadIssuedInvoice: %w", err)
+	}
+	data, err := store.read(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadIssuedInvoice: %w", err)
+	}
+	var invoice IssuedInvoice
+	if err := json.Unmarshal(data, &invoice); err != nil {
+		return nil, fmt.Errorf("LoadIssuedInvoice: invalid issued invoice: %w", err)
+	}
+	return &invoice, nil
+}
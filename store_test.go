@@ -0,0 +1,166 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fullStore is implemented by stores which support every feature, as [MemoryStore]
+// and [FileStore] do.
+type fullStore interface {
+	Store
+	ScheduleStore
+	BatchStore
+	FiatInvoiceStore
+	IssuedInvoiceStore
+}
+
+func testStoreRoundTrip(t *testing.T, store fullStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := store.LoadCredentials(ctx, "alice"); !errors.Is(err, ErrNotStored) {
+		t.Fatalf("expected ErrNotStored for missing credentials, got %v", err)
+	}
+	if _, err := store.LoadCursor(ctx, "alice"); !errors.Is(err, ErrNotStored) {
+		t.Fatalf("expected ErrNotStored for missing cursor, got %v", err)
+	}
+
+	creds := Credentials{APIToken: "token", APISecret: "secret"}
+	if err := store.SaveCredentials(ctx, "alice", creds); err != nil {
+		t.Fatalf("SaveCredentials failed: %v", err)
+	}
+	loaded, err := store.LoadCredentials(ctx, "alice")
+	if err != nil {
+		t.Fatalf("LoadCredentials failed: %v", err)
+	} else if *loaded != creds {
+		t.Fatalf("expected %+v, got %+v", creds, *loaded)
+	}
+
+	cursor := HistoryCursor{
+		LastID:         "abc",
+		LastTime:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		SeenAtLastTime: []string{"abc", "def"},
+	}
+	if err := store.SaveCursor(ctx, "alice", cursor); err != nil {
+		t.Fatalf("SaveCursor failed: %v", err)
+	}
+	loadedCursor, err := store.LoadCursor(ctx, "alice")
+	if err != nil {
+		t.Fatalf("LoadCursor failed: %v", err)
+	} else if !loadedCursor.LastTime.Equal(cursor.LastTime) || loadedCursor.LastID != cursor.LastID ||
+		!reflect.DeepEqual(loadedCursor.SeenAtLastTime, cursor.SeenAtLastTime) {
+		t.Fatalf("expected %+v, got %+v", cursor, *loadedCursor)
+	}
+
+	if _, err := store.LoadNextRun(ctx, "alice"); !errors.Is(err, ErrNotStored) {
+		t.Fatalf("expected ErrNotStored for missing next run, got %v", err)
+	}
+	next := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	if err := store.SaveNextRun(ctx, "alice", next); err != nil {
+		t.Fatalf("SaveNextRun failed: %v", err)
+	}
+	if loadedNext, err := store.LoadNextRun(ctx, "alice"); err != nil {
+		t.Fatalf("LoadNextRun failed: %v", err)
+	} else if !loadedNext.Equal(next) {
+		t.Fatalf("expected next run %s, got %s", next, loadedNext)
+	}
+
+	if _, err := store.LoadBatch(ctx, "alice"); !errors.Is(err, ErrNotStored) {
+		t.Fatalf("expected ErrNotStored for missing batch, got %v", err)
+	}
+	batch := BatchState{Items: []BatchItemResult{
+		{BatchItem: BatchItem{Destination: "bc1qexample", Amount: 0.001}, Status: BatchItemPaid, Payment: &Payment{ID: "p1"}},
+		{BatchItem: BatchItem{Destination: "bob@getalby.com", Amount: 0.0001}, Status: BatchItemUnstarted},
+	}}
+	if err := store.SaveBatch(ctx, "alice", batch); err != nil {
+		t.Fatalf("SaveBatch failed: %v", err)
+	}
+	loadedBatch, err := store.LoadBatch(ctx, "alice")
+	if err != nil {
+		t.Fatalf("LoadBatch failed: %v", err)
+	}
+	// Values decoded from a FileStore keep the stored JSON in Raw.
+	if payment := loadedBatch.Items[0].Payment; payment != nil {
+		payment.Raw = nil
+	}
+	if !reflect.DeepEqual(*loadedBatch, batch) {
+		t.Fatalf("expected batch %+v, got %+v", batch, *loadedBatch)
+	}
+
+	if _, err := store.LoadFiatInvoice(ctx, "alice"); !errors.Is(err, ErrNotStored) {
+		t.Fatalf("expected ErrNotStored for missing fiat invoice, got %v", err)
+	}
+	fiat := FiatInvoice{
+		Invoice:      &Invoice{ID: "inv1", Bolt11: "lnbc1", Amount: 0.0001},
+		FiatCurrency: "USD",
+		FiatAmount:   5,
+		Rate:         50_000,
+		QuotedAt:     time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := store.SaveFiatInvoice(ctx, "alice", fiat); err != nil {
+		t.Fatalf("SaveFiatInvoice failed: %v", err)
+	}
+	loadedFiat, err := store.LoadFiatInvoice(ctx, "alice")
+	if err != nil {
+		t.Fatalf("LoadFiatInvoice failed: %v", err)
+	}
+	loadedFiat.Invoice.Raw = nil
+	if !reflect.DeepEqual(*loadedFiat.Invoice, *fiat.Invoice) || loadedFiat.Rate != fiat.Rate ||
+		!loadedFiat.QuotedAt.Equal(fiat.QuotedAt) {
+		t.Fatalf("expected fiat invoice %+v, got %+v", fiat, *loadedFiat)
+	}
+
+	if _, err := store.LoadIssuedInvoice(ctx, "alice"); !errors.Is(err, ErrNotStored) {
+		t.Fatalf("expected ErrNotStored for missing issued invoice, got %v", err)
+	}
+	issued := IssuedInvoice{
+		ID:          "inv1",
+		PaymentHash: []byte{1, 2, 3},
+		Bolt11:      "lnbc1",
+		Reference:   "order-1",
+		IssuedAt:    time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := store.SaveIssuedInvoice(ctx, "alice", issued); err != nil {
+		t.Fatalf("SaveIssuedInvoice failed: %v", err)
+	}
+	if loadedIssued, err := store.LoadIssuedInvoice(ctx, "alice"); err != nil {
+		t.Fatalf("LoadIssuedInvoice failed: %v", err)
+	} else if !reflect.DeepEqual(*loadedIssued, issued) {
+		t.Fatalf("expected issued invoice %+v, got %+v", issued, *loadedIssued)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStoreRoundTrip(t, &MemoryStore{})
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, "hunter2")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	testStoreRoundTrip(t, store)
+
+	data, err := os.ReadFile(filepath.Join(dir, "alice.credentials"))
+	if err != nil {
+		t.Fatalf("failed to read credentials file: %v", err)
+	} else if strings.Contains(string(data), "secret") {
+		t.Fatalf("credentials were stored in plaintext")
+	}
+
+	other, _ := NewFileStore(dir, "wrong")
+	if _, err := other.LoadCredentials(context.Background(), "alice"); !errors.Is(err, ErrDecryptionFailed) {
+		t.Fatalf("expected ErrDecryptionFailed with wrong passphrase, got %v", err)
+	}
+	if err := store.SaveCursor(context.Background(), "../escape", HistoryCursor{}); err == nil {
+		t.Fatalf("expected path traversal to be rejected")
+	}
+}
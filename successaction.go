@@ -0,0 +1,126 @@
+package wos
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrInvalidSuccessAction is returned when an LNURL-pay success action is malformed.
+var ErrInvalidSuccessAction = errors.New("invalid LNURL success action")
+
+// Success action tags, as per LUD-09 and LUD-10.
+const (
+	SuccessActionMessage = "message"
+	SuccessActionURL     = "url"
+	SuccessActionAES     = "aes"
+)
+
+// maxSuccessActionText is the maximum length of a success action's message or description.
+const maxSuccessActionText = 144
+
+// SuccessAction is something an LNURL-pay server asks the payer's wallet to show after
+// a successful payment, such as a thank-you message, a receipt URL, or an encrypted
+// secret like a download code.
+//
+// https://github.com/lnurl/luds/blob/luds/09.md
+// https://github.com/lnurl/luds/blob/luds/10.md
+type SuccessAction struct {
+	// Tag is one of SuccessActionMessage, SuccessActionURL, or SuccessActionAES.
+	Tag string `json:"tag"`
+
+	// Message is the text to show the payer, for SuccessActionMessage.
+	Message string `json:"message,omitempty"`
+
+	// Description accompanies the URL or secret for SuccessActionURL and SuccessActionAES.
+	Description string `json:"description,omitempty"`
+
+	// URL is the link to show the payer, for SuccessActionURL.
+	URL string `json:"url,omitempty"`
+
+	// Ciphertext and IV are the base64-encoded AES-CBC encrypted secret and its
+	// initialization vector, for SuccessActionAES. See [SuccessAction.Decrypt].
+	Ciphertext string `json:"ciphertext,omitempty"`
+	IV         string `json:"iv,omitempty"`
+}
+
+// Validate checks that the success action is well formed, returning an error
+// wrapping [ErrInvalidSuccessAction] if not.
+func (sa *SuccessAction) Validate() error {
+	switch sa.Tag {
+	case SuccessActionMessage:
+		if len(sa.Message) > maxSuccessActionText {
+			return fmt.Errorf("%w: message too long", ErrInvalidSuccessAction)
+		}
+
+	case SuccessActionURL:
+		if len(sa.Description) > maxSuccessActionText {
+			return fmt.Errorf("%w: description too long", ErrInvalidSuccessAction)
+		}
+		u, err := url.Parse(sa.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%w: invalid URL %q", ErrInvalidSuccessAction, sa.URL)
+		}
+
+	case SuccessActionAES:
+		if len(sa.Description) > maxSuccessActionText {
+			return fmt.Errorf("%w: description too long", ErrInvalidSuccessAction)
+		}
+		if _, _, err := sa.decodeAES(); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown tag %q", ErrInvalidSuccessAction, sa.Tag)
+	}
+	return nil
+}
+
+func (sa *SuccessAction) decodeAES() (ciphertext, iv []byte, err error) {
+	iv, err = base64.StdEncoding.DecodeString(sa.IV)
+	if err != nil || len(iv) != aes.BlockSize {
+		return nil, nil, fmt.Errorf("%w: invalid IV", ErrInvalidSuccessAction)
+	}
+	ciphertext, err = base64.StdEncoding.DecodeString(sa.Ciphertext)
+	if err != nil || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 || len(ciphertext) > 4096 {
+		return nil, nil, fmt.Errorf("%w: invalid ciphertext", ErrInvalidSuccessAction)
+	}
+	return ciphertext, iv, nil
+}
+
+// Decrypt decrypts the secret in a SuccessActionAES success action, using the 32-byte
+// preimage of the payment as the AES-256-CBC key, as per LUD-10.
+func (sa *SuccessAction) Decrypt(preimage []byte) (string, error) {
+	if sa.Tag != SuccessActionAES {
+		return "", fmt.Errorf("%w: cannot decrypt %q action", ErrInvalidSuccessAction, sa.Tag)
+	} else if len(preimage) != 32 {
+		return "", fmt.Errorf("invalid preimage length %d", len(preimage))
+	}
+
+	ciphertext, iv, err := sa.decodeAES()
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(preimage)
+	if err != nil {
+		return "", err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	// Remove PKCS#7 padding.
+	pad := int(plaintext[len(plaintext)-1])
+	if pad == 0 || pad > aes.BlockSize {
+		return "", ErrDecryptionFailed
+	}
+	for _, b := range plaintext[len(plaintext)-pad:] {
+		if int(b) != pad {
+			return "", ErrDecryptionFailed
+		}
+	}
+	return string(plaintext[:len(plaintext)-pad]), nil
+}
@@ -0,0 +1,89 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+func mockLightningAddressPayment(successAction string) *Wallet {
+	return mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/lnurl":
+			w.Write([]byte(`{"callback":"https://getalby.com/cb","minSendable":1000,"maxSendable":100000000000}`))
+		case "/api/v1/wallet/lnPay":
+			w.Write([]byte(`{"id":"pay1","amount":0.0001,"status":"PAID","successAction":` + successAction + `}`))
+		}
+	})
+}
+
+func TestPayLightningAddressSuccessAction(t *testing.T) {
+	ctx := context.Background()
+	addr := LightningAddress{"bob", "getalby.com"}
+
+	payment, err := mockLightningAddressPayment(`{"tag":"message","message":"Thanks for your order!"}`).
+		PayLightningAddress(ctx, addr, "", 0.0001)
+	if err != nil {
+		t.Fatalf("PayLightningAddress failed: %v", err)
+	}
+	if sa := payment.SuccessAction; sa == nil || sa.Tag != SuccessActionMessage || sa.Message != "Thanks for your order!" {
+		t.Fatalf("unexpected message success action: %+v", sa)
+	}
+
+	payment, err = mockLightningAddressPayment(
+		`{"tag":"url","description":"Your receipt","url":"https://getalby.com/receipt/123"}`,
+	).PayLightningAddress(ctx, addr, "", 0.0001)
+	if err != nil {
+		t.Fatalf("PayLightningAddress failed: %v", err)
+	}
+	if sa := payment.SuccessAction; sa == nil || sa.Tag != SuccessActionURL ||
+		sa.URL != "https://getalby.com/receipt/123" || sa.Description != "Your receipt" {
+		t.Fatalf("unexpected URL success action: %+v", sa)
+	}
+
+	payment, err = mockLightningAddressPayment(`{"tag":"url","url":"javascript:alert(1)"}`).
+		PayLightningAddress(ctx, addr, "", 0.0001)
+	if err != nil {
+		t.Fatalf("PayLightningAddress failed: %v", err)
+	}
+	if payment.SuccessAction != nil {
+		t.Fatalf("expected invalid success action to be discarded, got %+v", payment.SuccessAction)
+	}
+}
+
+func TestSuccessActionDecrypt(t *testing.T) {
+	preimage := bytes.Repeat([]byte{7}, 32)
+	iv := bytes.Repeat([]byte{1}, aes.BlockSize)
+	secret := "download code: 1234"
+
+	padLen := aes.BlockSize - len(secret)%aes.BlockSize
+	padded := append([]byte(secret), bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+	block, _ := aes.NewCipher(preimage)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	sa := &SuccessAction{
+		Tag:         SuccessActionAES,
+		Description: "Your code",
+		Ciphertext:  base64.StdEncoding.EncodeToString(ciphertext),
+		IV:          base64.StdEncoding.EncodeToString(iv),
+	}
+	if err := sa.Validate(); err != nil {
+		t.Fatalf("expected valid AES action: %v", err)
+	}
+
+	plaintext, err := sa.Decrypt(preimage)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	} else if plaintext != secret {
+		t.Fatalf("expected %q, got %q", secret, plaintext)
+	}
+
+	if _, err := sa.Decrypt(bytes.Repeat([]byte{8}, 32)); err == nil {
+		t.Fatalf("expected decryption with wrong preimage to fail")
+	}
+}
@@ -0,0 +1,146 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DefaultSweepMinimum is the smallest confirmed balance in BTC which a [Sweeper] will
+// sweep, regardless of its threshold. Sweeping less than this on-chain would lose most
+// of the amount to WoS's fixed fee.
+var DefaultSweepMinimum = 0.0001
+
+// SweeperOptions customizes a [Sweeper].
+type SweeperOptions struct {
+	// Interval is how often the balance is checked. Defaults to 10 minutes.
+	Interval time.Duration
+
+	// MinAmount is the smallest balance which will be swept. Defaults to
+	// [DefaultSweepMinimum]. A threshold below MinAmount is raised to it.
+	MinAmount float64
+
+	// NextInvoice supplies a fresh amountless invoice for each sweep, for sweeping to
+	// another lightning wallet. If set, the Sweeper's destination is ignored.
+	NextInvoice func(ctx context.Context) (string, error)
+
+	// Logger receives a line describing each sweep and each failed sweep.
+	// If nil, nothing is logged.
+	Logger *log.Logger
+
+	// OnSweep is called after each successful sweep.
+	OnSweep func(*SweepResult)
+}
+
+// Sweeper automatically sweeps a wallet's balance to a destination, such as an
+// on-chain address in cold storage, whenever the confirmed balance reaches a threshold.
+type Sweeper struct {
+	wallet      *Wallet
+	destination string
+	threshold   float64
+	opts        SweeperOptions
+}
+
+// NewSweeper returns a Sweeper which sweeps the wallet's balance to destination whenever
+// its confirmed balance reaches threshold BTC. The destination may be an on-chain address,
+// or an amountless invoice. As invoices cannot be paid twice, a Sweeper with an invoice
+// destination stops after its first sweep, unless [SweeperOptions.NextInvoice] is set.
+//
+// opts can be nil. Call [Sweeper.Run] to start sweeping.
+func NewSweeper(wallet *Wallet, destination string, threshold float64, opts *SweeperOptions) *Sweeper {
+	sweeper := &Sweeper{
+		wallet:      wallet,
+		destination: destination,
+		threshold:   threshold,
+	}
+	if opts != nil {
+		sweeper.opts = *opts
+	}
+	if sweeper.opts.Interval <= 0 {
+		sweeper.opts.Interval = 10 * time.Minute
+	}
+	if sweeper.opts.MinAmount <= 0 {
+		sweeper.opts.MinAmount = DefaultSweepMinimum
+	}
+	if sweeper.threshold < sweeper.opts.MinAmount {
+		sweeper.threshold = sweeper.opts.MinAmount
+	}
+	return sweeper
+}
+
+// Run checks the wallet's balance immediately and then at every interval, sweeping
+// whenever it reaches the threshold, until ctx is done. Failed sweeps are logged and
+// retried at the next interval.
+//
+// Run returns ctx.Err() once ctx is done, or nil once a sweep to a single-use invoice
+// destination succeeds.
+func (sweeper *Sweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(sweeper.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := sweeper.check(ctx)
+		if err != nil && ctx.Err() == nil {
+			sweeper.logf("sweep failed: %v", err)
+		} else if result != nil {
+			sweeper.logf("swept %.8f BTC to %s", result.Amount, result.Payment.Address)
+			if sweeper.opts.OnSweep != nil {
+				sweeper.opts.OnSweep(result)
+			}
+			if sweeper.isSingleUse() {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (sweeper *Sweeper) isSingleUse() bool {
+	_, kind, _ := NormalizeDestination(sweeper.destination)
+	return sweeper.opts.NextInvoice == nil && kind == DestinationInvoice
+}
+
+// check sweeps the wallet if its balance has reached the threshold. Returns
+// a nil result if no sweep was needed.
+func (sweeper *Sweeper) check(ctx context.Context) (*SweepResult, error) {
+	balance, err := sweeper.wallet.Balance(ctx)
+	if err != nil {
+		return nil, err
+	} else if balance.Confirmed < sweeper.threshold {
+		return nil, nil
+	}
+
+	destination := sweeper.destination
+	if sweeper.opts.NextInvoice != nil {
+		if destination, err = sweeper.opts.NextInvoice(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get invoice: %w", err)
+		}
+	}
+
+	destination, kind, err := NormalizeDestination(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case DestinationOnChain:
+		return sweeper.wallet.SweepOnChainWith(ctx, destination, "", nil)
+	case DestinationInvoice:
+		return sweeper.wallet.SweepLightningWith(ctx, destination, "", nil)
+	default:
+		return nil, errors.New("sweeper destination must be an on-chain address or invoice")
+	}
+}
+
+func (sweeper *Sweeper) logf(format string, args ...any) {
+	if sweeper.opts.Logger != nil {
+		sweeper.opts.Logger.Printf(format, args...)
+	}
+}
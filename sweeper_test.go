@@ -0,0 +1,68 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSweeperTriggersAboveThreshold(t *testing.T) {
+	var checks, sweeps atomic.Int32
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			// The balance only crosses the threshold on the third check.
+			balance := 0.0005
+			if checks.Add(1) >= 3 && sweeps.Load() == 0 {
+				balance = 0.002
+			}
+			fmt.Fprintf(w, `{"btc":%f}`, balance)
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00002,"btcSendCommissionPercent":0}`))
+		case "/api/v1/wallet/payment":
+			sweeps.Add(1)
+			w.Write([]byte(`{"id":"sweep1","address":"bc1qcold","status":"PENDING"}`))
+		}
+	})
+
+	var logs bytes.Buffer
+	swept := make(chan *SweepResult, 1)
+	sweeper := NewSweeper(wallet, "bc1qcold", 0.001, &SweeperOptions{
+		Interval: 5 * time.Millisecond,
+		Logger:   log.New(&logs, "", 0),
+		OnSweep:  func(result *SweepResult) { swept <- result },
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- sweeper.Run(ctx) }()
+
+	select {
+	case result := <-swept:
+		if result.Amount != 0.002-0.00002 {
+			t.Fatalf("unexpected sweep amount: %.8f", result.Amount)
+		}
+	case <-ctx.Done():
+		t.Fatalf("sweeper never swept")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected Run to return context.Canceled, got %v", err)
+	}
+	if checks.Load() < 3 || sweeps.Load() != 1 {
+		t.Fatalf("expected one sweep after 3 checks, got %d sweeps after %d checks", sweeps.Load(), checks.Load())
+	}
+	if !strings.Contains(logs.String(), "swept 0.00198000 BTC to bc1qcold") {
+		t.Fatalf("expected sweep to be logged, got %q", logs.String())
+	}
+}
@@ -0,0 +1,174 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HistoryCursor records how far a [HistorySync] has read through a wallet's payment
+// history. It can be marshaled to JSON to persist a sync across restarts.
+type HistoryCursor struct {
+	// LastID and LastTime identify the newest payment seen so far.
+	LastID   string    `json:"lastId,omitempty"`
+	LastTime time.Time `json:"lastTime"`
+
+	// SeenAtLastTime lists the IDs of all payments seen which occurred exactly at
+	// LastTime, so that payments sharing a timestamp are neither missed nor repeated.
+	SeenAtLastTime []string `json:"seenAtLastTime,omitempty"`
+}
+
+// HistorySync incrementally syncs a wallet's payment history, returning only payments
+// which have not been seen before on each call to [HistorySync.Poll].
+//
+// Note that payments are only reported once, when they first appear in the history.
+// Later changes to a payment, such as a pending on-chain payment confirming, are not
+// reported again.
+//
+// A HistorySync is safe for concurrent use, though concurrent calls to Poll
+// are serialized.
+type HistorySync struct {
+	reader *Reader
+
+	mu     sync.Mutex
+	cursor HistoryCursor
+}
+
+// NewHistorySync returns a HistorySync which reads payments with the given [Reader],
+// resuming from cursor. If cursor is nil, the first call to Poll returns the entire
+// payment history.
+func NewHistorySync(reader *Reader, cursor *HistoryCursor) *HistorySync {
+	hs := &HistorySync{reader: reader}
+	if cursor != nil {
+		hs.cursor = *cursor
+	}
+	return hs
+}
+
+// syncPageSize is the number of payments fetched per request by [HistorySync.Poll].
+const syncPageSize = 50
+
+// Poll fetches any payments which have occurred since the last poll, ordered from oldest
+// to newest, and advances the cursor past them. If an error occurs, the cursor is unchanged.
+//
+// The history is fetched a page at a time from newest to oldest, stopping once the
+// cursor is reached, so each poll only downloads the payments it has not yet seen.
+func (hs *HistorySync) Poll(ctx context.Context) ([]Payment, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	payments, err := hs.fetchSince(ctx, hs.cursor.LastTime)
+	if err != nil {
+		return nil, fmt.Errorf("HistorySync: %w", err)
+	}
+
+	seen := make(map[string]bool, len(hs.cursor.SeenAtLastTime))
+	for _, id := range hs.cursor.SeenAtLastTime {
+		seen[id] = true
+	}
+
+	var fresh []Payment
+	for _, payment := range payments {
+		if payment.Time.Equal(hs.cursor.LastTime) && seen[payment.ID] {
+			continue
+		}
+		fresh = append(fresh, payment)
+	}
+
+	if len(fresh) > 0 {
+		last := fresh[len(fresh)-1]
+		if !last.Time.Equal(hs.cursor.LastTime) {
+			hs.cursor.SeenAtLastTime = nil
+		}
+		hs.cursor.LastID = last.ID
+		hs.cursor.LastTime = last.Time
+		for _, payment := range fresh {
+			if payment.Time.Equal(last.Time) {
+				hs.cursor.SeenAtLastTime = append(hs.cursor.SeenAtLastTime, payment.ID)
+			}
+		}
+	}
+
+	return fresh, nil
+}
+
+// fetchSince returns the payments which occurred at or after since, ordered from oldest
+// to newest, paging back through the history only as far as needed.
+func (hs *HistorySync) fetchSince(ctx context.Context, since time.Time) ([]Payment, error) {
+	var payments []Payment
+	fetched := make(map[string]bool)
+	for skip := 0; ; skip += syncPageSize {
+		page, err := hs.reader.paymentPage(ctx, skip, syncPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		reached := false
+		for _, payment := range page {
+			if payment.Time.Before(since) {
+				reached = true
+				continue
+			}
+			// Payments arriving mid-sync shift later pages, repeating earlier ones.
+			if !fetched[payment.ID] {
+				fetched[payment.ID] = true
+				payments = append(payments, payment)
+			}
+		}
+		if reached || len(page) < syncPageSize {
+			break
+		}
+	}
+
+	SortPayments(payments, SortByTime)
+	return payments, nil
+}
+
+// Cursor returns a copy of the sync's current cursor.
+func (hs *HistorySync) Cursor() HistoryCursor {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	cursor := hs.cursor
+	cursor.SeenAtLastTime = append([]string(nil), hs.cursor.SeenAtLastTime...)
+	return cursor
+}
+
+// MarshalJSON implements [json.Marshaler] by encoding the sync's cursor.
+func (hs *HistorySync) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hs.Cursor())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler] by restoring the sync's cursor.
+// The sync's [Reader] is left unchanged.
+func (hs *HistorySync) UnmarshalJSON(data []byte) error {
+	var cursor HistoryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return err
+	}
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.cursor = cursor
+	return nil
+}
+
+// LoadHistorySync returns a HistorySync which reads payments with the given [Reader],
+// resuming from the cursor saved in store under name. If no cursor was saved, the
+// sync starts from the beginning of the payment history.
+func LoadHistorySync(ctx context.Context, reader *Reader, store Store, name string) (*HistorySync, error) {
+	cursor, err := store.LoadCursor(ctx, name)
+	if errors.Is(err, ErrNotStored) {
+		cursor = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("LoadHistorySync: %w", err)
+	}
+	return NewHistorySync(reader, cursor), nil
+}
+
+// Save saves the sync's current cursor in store under name, so that it can be
+// resumed with [LoadHistorySync].
+func (hs *HistorySync) Save(ctx context.Context, store Store, name string) error {
+	return store.SaveCursor(ctx, name, hs.Cursor())
+}
@@ -0,0 +1,103 @@
+package wos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHistorySyncPoll(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := []Payment{
+		{ID: "a", Time: base},
+		{ID: "b", Time: base.Add(time.Minute)},
+	}
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(history)
+	}))
+
+	hs := NewHistorySync(rdr, nil)
+	first, err := hs.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("first poll failed: %v", err)
+	} else if len(first) != 2 {
+		t.Fatalf("expected 2 payments on first poll, got %d", len(first))
+	}
+
+	history = append(history,
+		Payment{ID: "c", Time: base.Add(time.Minute)},
+		Payment{ID: "d", Time: base.Add(2 * time.Minute)},
+	)
+
+	// Persist and restore the cursor, as a service would across restarts.
+	saved, err := json.Marshal(hs)
+	if err != nil {
+		t.Fatalf("failed to marshal sync: %v", err)
+	}
+	restored := NewHistorySync(rdr, nil)
+	if err := json.Unmarshal(saved, restored); err != nil {
+		t.Fatalf("failed to unmarshal sync: %v", err)
+	}
+
+	second, err := restored.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("second poll failed: %v", err)
+	}
+	if len(second) != 2 || second[0].ID != "c" || second[1].ID != "d" {
+		t.Fatalf("expected only payments c and d on second poll, got %+v", second)
+	}
+
+	if cursor := restored.Cursor(); cursor.LastID != "d" || !cursor.LastTime.Equal(base.Add(2*time.Minute)) {
+		t.Fatalf("unexpected cursor: %+v", cursor)
+	}
+
+	third, err := restored.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("third poll failed: %v", err)
+	} else if len(third) != 0 {
+		t.Fatalf("expected no new payments, got %+v", third)
+	}
+}
+
+func TestHistorySyncPollPagesToCursor(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var history []Payment
+	for i := 0; i < 3*syncPageSize; i++ {
+		history = append(history, Payment{ID: strconv.Itoa(i), Time: base.Add(time.Duration(i) * time.Minute)})
+	}
+	var requests int
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var page []Payment
+		for i := len(history) - 1 - skip; i >= 0 && len(page) < limit; i-- {
+			page = append(page, history[i])
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+
+	hs := NewHistorySync(rdr, nil)
+	first, err := hs.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("first poll failed: %v", err)
+	} else if len(first) != len(history) || first[0].ID != "0" {
+		t.Fatalf("expected the whole history in order on first poll, got %d payments", len(first))
+	}
+
+	history = append(history, Payment{ID: "new", Time: base.Add(time.Duration(len(history)) * time.Minute)})
+	requests = 0
+	second, err := hs.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("second poll failed: %v", err)
+	}
+	if len(second) != 1 || second[0].ID != "new" {
+		t.Fatalf("expected only the new payment, got %+v", second)
+	}
+	if requests != 1 {
+		t.Fatalf("expected one page to be fetched, got %d requests", requests)
+	}
+}
@@ -0,0 +1,64 @@
+package wos
+
+import (
+	"context"
+	"fmt"
+)
+
+// TransferResult describes a transfer between two WoS wallets made by [Wallet.Transfer].
+type TransferResult struct {
+	// Payment is the payment sent by the sending wallet.
+	Payment *Payment
+
+	// Invoice is the invoice created by the receiving wallet, and paid by the sender.
+	Invoice *Invoice
+
+	// ExpectedReceived is the BTC amount the receiving wallet should be credited. As
+	// transfers between WoS wallets are settled internally without fees, this is always
+	// the amount sent.
+	ExpectedReceived float64
+
+	// Received is the credit in the receiving wallet's history, or nil if WoS had not
+	// yet marked the invoice as paid when the transfer returned.
+	Received *Payment
+}
+
+// Transfer sends amount BTC from this wallet to another WoS wallet which is under your
+// control, such as between a hot wallet and a savings wallet, or in a test harness. An
+// invoice is created by the receiving wallet and paid by this one, and then the receiving
+// side is checked to confirm that exactly amount was received.
+//
+// Transfers between WoS wallets are settled internally, without any fee, so the amount
+// received must equal the amount sent. If the receiving wallet was credited a different
+// amount, the result is returned alongside an error wrapping [ErrUnderpaid] or
+// [ErrOverpaid]. The description is used for both the invoice and the payment.
+func (wallet *Wallet) Transfer(ctx context.Context, to *Wallet, amount float64, description string) (*TransferResult, error) {
+	invoice, err := to.NewInvoice(ctx, &InvoiceOptions{
+		Amount:      amount,
+		Description: description,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Transfer: creating invoice: %w", err)
+	}
+
+	payment, err := wallet.PayInvoice(ctx, invoice.Bolt11, description)
+	if err != nil {
+		return nil, fmt.Errorf("Transfer: %w", err)
+	}
+
+	result := &TransferResult{
+		Payment:          payment,
+		Invoice:          invoice,
+		ExpectedReceived: amount,
+	}
+
+	// The payment has already been sent, so only an incorrect amount is reported
+	// as an error, not a failure to check the receiving side.
+	if paid, received, err := to.reader.IsInvoicePaid(ctx, invoice.ID); err == nil && paid {
+		result.Received = received
+		if err := VerifyReceived(*received, amount, 0); err != nil {
+			return result, fmt.Errorf("Transfer: %w", err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestTransfer(t *testing.T) {
+	// The receiving wallet is credited whatever the sending wallet pays.
+	var credited float64
+	receiver := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/createInvoice":
+			fmt.Fprintf(w, `{"id":"inv1","invoice":%q,"btcAmount":0.0025}`, testInvoiceCoffee)
+		case "/api/v1/wallet/payment/inv1":
+			fmt.Fprintf(w, `{"id":"inv1","amount":%.8f,"status":"PAID","type":"CREDIT"}`, credited)
+		}
+	})
+
+	fee := 0.0
+	sender := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		credited = 0.0025 - fee
+		w.Write([]byte(`{"id":"p1","amount":0.0025,"status":"PAID","type":"DEBIT"}`))
+	})
+
+	result, err := sender.Transfer(context.Background(), receiver, 0.0025, "savings")
+	if err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if result.ExpectedReceived != 0.0025 || result.Received == nil || result.Received.Amount != 0.0025 {
+		t.Fatalf("expected 0.0025 BTC to be received, got %+v", result)
+	}
+
+	// A transfer which loses a fee on the way is reported.
+	fee = 0.00000010
+	result, err = sender.Transfer(context.Background(), receiver, 0.0025, "savings")
+	if !errors.Is(err, ErrUnderpaid) {
+		t.Fatalf("expected ErrUnderpaid, got %v", err)
+	} else if result == nil || result.Payment == nil {
+		t.Fatalf("expected result to be returned alongside the error")
+	}
+}
@@ -0,0 +1,273 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrUnsafeRedirect is returned when an HTTP redirect or LNURL callback would send a
+// request to a different host, or downgrade it from HTTPS to plain HTTP.
+var ErrUnsafeRedirect = errors.New("unsafe redirect")
+
+// defaultMaxRedirects matches the redirect limit of [http.Client].
+const defaultMaxRedirects = 10
+
+// DefaultRequestTimeout is applied to every API request whose context has no deadline,
+// when the [http.Client] making the request has no Timeout of its own. This prevents a
+// hung connection to WoS from blocking forever when using [http.DefaultClient].
+//
+// Set to zero to disable the default timeout.
+var DefaultRequestTimeout = 30 * time.Second
+
+// withDefaultTimeout returns a context with [DefaultRequestTimeout] applied, unless the
+// context already has a deadline or the httpClient enforces its own timeout.
+func withDefaultTimeout(ctx context.Context, httpClient *http.Client) (context.Context, context.CancelFunc) {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || httpClient.Timeout > 0 || DefaultRequestTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, DefaultRequestTimeout)
+}
+
+// RetryPolicy decides whether a failed API request is retried, given the request, the
+// response if one was received, the error, and the number of the attempt which failed,
+// starting from 1. It returns whether to retry, and how long to wait before doing so.
+// See [Reader.SetRetryPolicy].
+type RetryPolicy func(req *http.Request, resp *http.Response, err error, attempt int) (retry bool, backoff time.Duration)
+
+// MaintenanceBackoff is how long [DefaultRetryPolicy] waits before the first retry
+// of a request which failed with [ErrMaintenance]. It doubles on each further retry.
+// WoS maintenance windows typically last minutes, so retrying quickly is pointless.
+var MaintenanceBackoff = 30 * time.Second
+
+// DefaultRetryPolicy is a sensible [RetryPolicy] for most deployments. It retries GET
+// requests up to 3 attempts in total, if they failed with a network error, a rate limit
+// or a server error, backing off for 500ms and then 1s. If WoS is down for maintenance
+// it backs off for [MaintenanceBackoff] instead, and then twice that. Client errors such as bad
+// credentials are never retried, and neither are POST requests, since a POST whose
+// response was lost may still have created an invoice or sent a payment.
+func DefaultRetryPolicy(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+	if req.Method != http.MethodGet || attempt >= 3 ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0
+	}
+	if resp != nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return false, 0
+	}
+	if errors.Is(err, ErrMaintenance) {
+		return true, MaintenanceBackoff << (attempt - 1)
+	}
+	return true, 250 * time.Millisecond << attempt
+}
+
+// SetRetryPolicy installs a [RetryPolicy] which decides whether and when failed API
+// requests are retried, such as [DefaultRetryPolicy]. The policy fully controls retries:
+// no requests are retried unless it says so. Pass nil, the default, to disable retries.
+//
+// The policy is consulted for requests which fail with a network error or an error status,
+// but not for requests cancelled by their context or by [Wallet.Close]. Policies which
+// retry POST requests must be careful: a payment whose response was lost may have been
+// sent, and retrying it could pay twice.
+func (rdr *Reader) SetRetryPolicy(policy RetryPolicy) {
+	rdr.retryPolicy = policy
+}
+
+// SetRetryPolicy installs a [RetryPolicy] for the wallet's API requests.
+// See [Reader.SetRetryPolicy].
+func (wallet *Wallet) SetRetryPolicy(policy RetryPolicy) {
+	wallet.reader.SetRetryPolicy(policy)
+}
+
+// send executes an API request using the Reader's [http.Client], and buffers the
+// response body in memory. If the server responds with an error status, the response
+// is returned alongside the error. Errors are prefixed with the given label.
+//
+// Failed requests are retried as directed by the Reader's [RetryPolicy], if any.
+func (rdr *Reader) send(req *http.Request, label string) (*http.Response, error) {
+	policy := rdr.retryPolicy
+	for attempt := 1; ; attempt++ {
+		resp, err := rdr.sendOnce(req, label)
+		if err == nil || policy == nil || errors.Is(err, ErrWalletClosed) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		retry, backoff := policy(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return resp, err
+		case <-rdr.closedChan():
+			timer.Stop()
+			return nil, fmt.Errorf("%s: %w", label, ErrWalletClosed)
+		}
+	}
+}
+
+// sendOnce makes a single attempt at sending an API request, as described by [Reader.send].
+func (rdr *Reader) sendOnce(req *http.Request, label string) (*http.Response, error) {
+	closed := rdr.closedChan()
+	select {
+	case <-closed:
+		return nil, fmt.Errorf("%s: %w", label, ErrWalletClosed)
+	default:
+	}
+
+	ctx, cancel := withDefaultTimeout(req.Context(), rdr.httpClient)
+	defer cancel()
+
+	// Abort the request if the wallet is closed while it is in flight.
+	ctx, cancelOnClose := context.WithCancel(ctx)
+	defer cancelOnClose()
+	go func() {
+		select {
+		case <-closed:
+			cancelOnClose()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Copy the client so the redirect policy applies without modifying the caller's client.
+	client := *rdr.httpClient
+	client.CheckRedirect = rdr.checkRedirect
+
+	var record *RecordedRequest
+	if rdr.recorder != nil {
+		record = newRecordedRequest(req)
+		defer rdr.recorder.write(record)
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if record != nil {
+		record.DurationMs = time.Since(record.Time).Milliseconds()
+	}
+	if err != nil {
+		if record != nil {
+			record.Error = err.Error()
+		}
+		if isClosed(closed) {
+			return nil, fmt.Errorf("%s: %w", label, ErrWalletClosed)
+		}
+		return nil, fmt.Errorf("%s request failed: %w", label, err)
+	}
+
+	rdr.observeRateLimit(resp)
+
+	respData, err := bufferResponse(resp)
+	if record != nil {
+		record.Status = resp.StatusCode
+		record.Response = redactBody(respData)
+	}
+	if err != nil && isClosed(closed) {
+		return nil, fmt.Errorf("%s: %w", label, ErrWalletClosed)
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: failed to read body: %w", label, err)
+	}
+
+	if err := checkHTTPResponse(resp, respData); err != nil {
+		return resp, fmt.Errorf("%s: %w", label, err)
+	}
+
+	return resp, nil
+}
+
+// ErrWalletClosed is returned by requests made after [Wallet.Close] is called, or
+// which were still in flight when it was called.
+var ErrWalletClosed = errors.New("wallet is closed")
+
+// closedChan returns a channel which is closed once the Reader is closed.
+func (rdr *Reader) closedChan() <-chan struct{} {
+	rdr.closeMu.Lock()
+	defer rdr.closeMu.Unlock()
+	if rdr.closed == nil {
+		rdr.closed = make(chan struct{})
+	}
+	return rdr.closed
+}
+
+// close cancels all in-flight requests, and causes any future requests to fail
+// with [ErrWalletClosed]. It is safe to call more than once.
+func (rdr *Reader) close() {
+	rdr.closeMu.Lock()
+	defer rdr.closeMu.Unlock()
+	if rdr.closed == nil {
+		rdr.closed = make(chan struct{})
+	}
+	if !isClosed(rdr.closed) {
+		close(rdr.closed)
+	}
+}
+
+func isClosed(closed <-chan struct{}) bool {
+	select {
+	case <-closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close shuts the wallet down. Requests still in flight are cancelled, and they and
+// any later requests fail with an error wrapping [ErrWalletClosed], rather than a
+// generic context error. Close always returns nil, and is safe to call more than once.
+//
+// Closing the wallet also closes the [Reader] it was opened with, since the wallet
+// makes its requests through it.
+func (wallet *Wallet) Close() error {
+	wallet.reader.close()
+	return nil
+}
+
+// SetMaxRedirects limits the number of HTTP redirects the Reader will follow for a single
+// API request. Zero disables redirects entirely. By default up to 10 redirects are followed.
+//
+// Regardless of this limit, redirects to a different host or from HTTPS to HTTP are
+// never followed, and fail with an error wrapping [ErrUnsafeRedirect].
+func (rdr *Reader) SetMaxRedirects(n int) {
+	rdr.maxRedirects = n
+}
+
+// checkRedirect is used as the CheckRedirect policy of the Reader's [http.Client].
+func (rdr *Reader) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > rdr.maxRedirects {
+		return fmt.Errorf("%w: exceeded limit of %d redirects", ErrUnsafeRedirect, rdr.maxRedirects)
+	}
+	if err := checkSameOrigin(via[0].URL, req.URL); err != nil {
+		return err
+	}
+	if rdr.httpClient.CheckRedirect != nil {
+		return rdr.httpClient.CheckRedirect(req, via)
+	}
+	return nil
+}
+
+// checkSameOrigin returns an error wrapping [ErrUnsafeRedirect] if target is on a different
+// host than original, or if original uses HTTPS and target does not.
+func checkSameOrigin(original, target *url.URL) error {
+	if !strings.EqualFold(original.Host, target.Host) {
+		return fmt.Errorf("%w: host changed from %s to %s", ErrUnsafeRedirect, original.Host, target.Host)
+	} else if original.Scheme == "https" && target.Scheme != "https" {
+		return fmt.Errorf("%w: downgrade from https to %s", ErrUnsafeRedirect, target.Scheme)
+	}
+	return nil
+}
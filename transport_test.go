@@ -0,0 +1,220 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultRequestTimeout(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		deadline, hasDeadline = r.Context().Deadline()
+		w.Write([]byte(`{}`))
+	}
+
+	rdr := NewReader("token", mockClient(handler))
+	start := time.Now()
+	if _, err := rdr.GetRequest(context.Background(), "/api/v1/wallet/balance"); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !hasDeadline {
+		t.Fatalf("expected default deadline to be applied")
+	} else if d := deadline.Sub(start); d < DefaultRequestTimeout-time.Second || d > DefaultRequestTimeout+time.Second {
+		t.Fatalf("expected deadline about %s from now, got %s", DefaultRequestTimeout, d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	callerDeadline, _ := ctx.Deadline()
+	if _, err := rdr.GetRequest(ctx, "/api/v1/wallet/balance"); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !deadline.Equal(callerDeadline) {
+		t.Fatalf("caller's deadline was overridden: want %s, got %s", callerDeadline, deadline)
+	}
+
+	httpClient := mockClient(handler)
+	httpClient.Timeout = time.Minute
+	rdr = NewReader("token", httpClient)
+	start = time.Now()
+	if _, err := rdr.GetRequest(context.Background(), "/api/v1/wallet/balance"); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	// The client may enforce its own timeout via the request context.
+	if hasDeadline && deadline.Sub(start) < DefaultRequestTimeout+time.Second {
+		t.Fatalf("expected default deadline not to apply when the client has a timeout")
+	}
+}
+
+func TestRedirectPolicy(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			http.Redirect(w, r, "https://evil.example.com/steal", http.StatusFound)
+		case "/api/v1/wallet/feeEstimate":
+			http.Redirect(w, r, "/api/v1/wallet/balance2", http.StatusFound)
+		case "/api/v1/wallet/balance2":
+			w.Write([]byte(`{"btc":0.5}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL)
+		}
+	}
+
+	rdr := NewReader("token", mockClient(handler))
+	if _, err := rdr.GetRequest(context.Background(), "/api/v1/wallet/balance"); !errors.Is(err, ErrUnsafeRedirect) {
+		t.Fatalf("expected ErrUnsafeRedirect for cross-host redirect, got %v", err)
+	}
+
+	if _, err := rdr.GetRequest(context.Background(), "/api/v1/wallet/feeEstimate"); err != nil {
+		t.Fatalf("expected same-host redirect to be followed, got %v", err)
+	}
+
+	rdr.SetMaxRedirects(0)
+	if _, err := rdr.GetRequest(context.Background(), "/api/v1/wallet/feeEstimate"); !errors.Is(err, ErrUnsafeRedirect) {
+		t.Fatalf("expected redirects to be disabled, got %v", err)
+	}
+}
+
+func TestPayLightningAddressRejectsCrossDomainCallback(t *testing.T) {
+	var paid bool
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/lnurl":
+			w.Write([]byte(`{"callback":"https://attacker.example.com/cb","minSendable":1000,"maxSendable":100000000000}`))
+		case "/api/v1/wallet/lnPay":
+			paid = true
+			w.Write([]byte(`{"id":"pay1"}`))
+		}
+	})
+
+	addr := LightningAddress{"bob", "getalby.com"}
+	_, err := wallet.PayLightningAddress(context.Background(), addr, "", 0.0001)
+	if !errors.Is(err, ErrUnsafeRedirect) {
+		t.Fatalf("expected ErrUnsafeRedirect, got %v", err)
+	} else if paid {
+		t.Fatalf("payment should not have been sent")
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var requests int
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	var attempts []int
+	rdr.SetRetryPolicy(func(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+		attempts = append(attempts, attempt)
+		if resp == nil || resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("expected policy to see the 502 response, got %v", resp)
+		}
+		return attempt <= 2, 0
+	})
+
+	var apiErr *APIError
+	if _, err := rdr.Balance(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected final 502 error, got %v", err)
+	} else if requests != 3 {
+		t.Fatalf("expected 3 requests, got %d", requests)
+	} else if len(attempts) != 3 || attempts[2] != 3 {
+		t.Fatalf("unexpected attempts passed to policy: %v", attempts)
+	}
+
+	// Without a policy, nothing is retried.
+	requests = 0
+	rdr.SetRetryPolicy(nil)
+	rdr.Balance(context.Background())
+	if requests != 1 {
+		t.Fatalf("expected 1 request without a retry policy, got %d", requests)
+	}
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	get := httptest.NewRequest(http.MethodGet, "/api/v1/wallet/balance", nil)
+	post := httptest.NewRequest(http.MethodPost, "/api/v1/wallet/payment", nil)
+	status := func(code int) *http.Response { return &http.Response{StatusCode: code} }
+	networkErr := errors.New("connection reset")
+
+	tests := []struct {
+		req     *http.Request
+		resp    *http.Response
+		err     error
+		attempt int
+		retry   bool
+	}{
+		{get, nil, networkErr, 1, true},
+		{get, status(http.StatusServiceUnavailable), networkErr, 2, true},
+		{get, status(http.StatusTooManyRequests), networkErr, 1, true},
+		{get, status(http.StatusServiceUnavailable), networkErr, 3, false},
+		{get, status(http.StatusUnauthorized), networkErr, 1, false},
+		{get, nil, context.DeadlineExceeded, 1, false},
+		{post, status(http.StatusServiceUnavailable), networkErr, 1, false},
+	}
+	for i, test := range tests {
+		if retry, _ := DefaultRetryPolicy(test.req, test.resp, test.err, test.attempt); retry != test.retry {
+			t.Errorf("case %d: expected retry=%v", i, test.retry)
+		}
+	}
+	if _, backoff := DefaultRetryPolicy(get, nil, networkErr, 2); backoff != time.Second {
+		t.Errorf("expected 1s backoff before the third attempt, got %s", backoff)
+	}
+}
+
+func TestMaintenanceRetry(t *testing.T) {
+	rdr := NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"message":"Down for scheduled maintenance"}`))
+	}))
+
+	var backoffs []time.Duration
+	rdr.SetRetryPolicy(func(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+		retry, backoff := DefaultRetryPolicy(req, resp, err, attempt)
+		backoffs = append(backoffs, backoff)
+		return retry, 0
+	})
+
+	_, err := rdr.Balance(context.Background())
+	if !errors.Is(err, ErrMaintenance) {
+		t.Fatalf("expected ErrMaintenance, got %v", err)
+	} else if errors.Is(err, ErrRateLimited) {
+		t.Fatalf("maintenance error should not match ErrRateLimited")
+	}
+	expected := []time.Duration{MaintenanceBackoff, MaintenanceBackoff * 2, 0}
+	if !reflect.DeepEqual(backoffs, expected) {
+		t.Fatalf("expected backoffs %v, got %v", expected, backoffs)
+	}
+
+	// A plain 503 is not maintenance, and keeps the short backoff.
+	rdr = NewReader("token", mockClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"message":"Service Unavailable"}`))
+	}))
+	backoffs = nil
+	rdr.SetRetryPolicy(func(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+		retry, backoff := DefaultRetryPolicy(req, resp, err, attempt)
+		backoffs = append(backoffs, backoff)
+		return retry, 0
+	})
+	if _, err := rdr.Balance(context.Background()); errors.Is(err, ErrMaintenance) {
+		t.Fatalf("expected plain 503 not to match ErrMaintenance, got %v", err)
+	}
+	expected = []time.Duration{500 * time.Millisecond, time.Second, 0}
+	if !reflect.DeepEqual(backoffs, expected) {
+		t.Fatalf("expected backoffs %v for a plain 503, got %v", expected, backoffs)
+	}
+
+	// Ordinary server errors keep the short backoff.
+	get := httptest.NewRequest(http.MethodGet, "/api/v1/wallet/balance", nil)
+	serverErr := &APIError{StatusCode: http.StatusInternalServerError, Message: "internal error"}
+	resp := &http.Response{StatusCode: http.StatusInternalServerError}
+	if retry, backoff := DefaultRetryPolicy(get, resp, serverErr, 1); !retry || backoff != 500*time.Millisecond {
+		t.Fatalf("expected 500ms backoff for a server error, got retry=%v backoff=%s", retry, backoff)
+	}
+}
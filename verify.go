@@ -0,0 +1,53 @@
+package wos
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrUnderpaid is returned by [VerifyReceived] when a payment is smaller than expected.
+	ErrUnderpaid = errors.New("payment is less than the expected amount")
+
+	// ErrOverpaid is returned by [VerifyReceived] when a payment is larger than expected.
+	ErrOverpaid = errors.New("payment is more than the expected amount")
+
+	// ErrAmountMismatch is returned by [Invoice.AssertAmount] when an invoice is not
+	// for the expected amount.
+	ErrAmountMismatch = errors.New("invoice amount does not match expected amount")
+)
+
+// VerifyReceived checks that a received payment's amount matches the expected BTC amount,
+// give or take tolerance satoshis. This is useful for confirming that a variable-amount
+// invoice was paid in full, allowing for small fee-related shortfalls.
+//
+// Returns an error wrapping [ErrUnderpaid] or [ErrOverpaid], which includes the
+// difference in satoshis, if the payment falls outside the tolerated range.
+func VerifyReceived(p Payment, expected float64, tolerance float64) error {
+	delta := math.Round((p.Amount - expected) * 100_000_000)
+	if delta < -tolerance {
+		return fmt.Errorf("%w: short by %.0f sats", ErrUnderpaid, -delta)
+	} else if delta > tolerance {
+		return fmt.Errorf("%w: over by %.0f sats", ErrOverpaid, delta)
+	}
+	return nil
+}
+
+// AssertAmount checks that the invoice is for the expected BTC amount, give or take
+// tolerance satoshis, such as a price quoted to a customer before the invoice was
+// created. This is a cheap safety check to run after [Wallet.NewInvoice], to catch
+// bugs which would produce an invoice for the wrong amount.
+//
+// Returns an error wrapping [ErrAmountMismatch], which includes the difference in
+// satoshis, if the invoice amount falls outside the tolerated range.
+func (invoice *Invoice) AssertAmount(expected float64, tolerance float64) error {
+	delta := math.Round((invoice.Amount - expected) * 100_000_000)
+	if math.Abs(delta) > tolerance {
+		return fmt.Errorf(
+			"%w: invoice is for %.8f BTC, expected %.8f BTC (off by %.0f sats)",
+			ErrAmountMismatch, invoice.Amount, expected, delta,
+		)
+	}
+	return nil
+}
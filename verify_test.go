@@ -0,0 +1,62 @@
+package wos
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyReceived(t *testing.T) {
+	tests := []struct {
+		amount    float64
+		expected  float64
+		tolerance float64
+		err       error
+	}{
+		{0.0001, 0.0001, 0, nil},
+		{0.00009990, 0.0001, 10, nil},
+		{0.00010010, 0.0001, 10, nil},
+		{0.00009989, 0.0001, 10, ErrUnderpaid},
+		{0.00010011, 0.0001, 10, ErrOverpaid},
+		{0.00005, 0.0001, 0, ErrUnderpaid},
+	}
+
+	for _, test := range tests {
+		err := VerifyReceived(Payment{Amount: test.amount}, test.expected, test.tolerance)
+		if !errors.Is(err, test.err) || (test.err == nil && err != nil) {
+			t.Errorf("VerifyReceived(%.8f, %.8f, %.0f): expected %v, got %v",
+				test.amount, test.expected, test.tolerance, test.err, err)
+		}
+	}
+
+	err := VerifyReceived(Payment{Amount: 0.00009}, 0.0001, 0)
+	if err == nil || err.Error() != "payment is less than the expected amount: short by 1000 sats" {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestInvoiceAssertAmount(t *testing.T) {
+	tests := []struct {
+		amount    float64
+		expected  float64
+		tolerance float64
+		ok        bool
+	}{
+		{0.0001, 0.0001, 0, true},
+		{0.00010005, 0.0001, 5, true},
+		{0.00009995, 0.0001, 5, true},
+		{0.00010006, 0.0001, 5, false},
+		{0.001, 0.0001, 0, false},
+	}
+
+	for _, test := range tests {
+		invoice := &Invoice{Amount: test.amount}
+		err := invoice.AssertAmount(test.expected, test.tolerance)
+		if test.ok && err != nil {
+			t.Errorf("AssertAmount(%.8f, %.0f) on %.8f: unexpected error %v",
+				test.expected, test.tolerance, test.amount, err)
+		} else if !test.ok && !errors.Is(err, ErrAmountMismatch) {
+			t.Errorf("AssertAmount(%.8f, %.0f) on %.8f: expected ErrAmountMismatch, got %v",
+				test.expected, test.tolerance, test.amount, err)
+		}
+	}
+}
@@ -4,14 +4,20 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 )
 
@@ -22,41 +28,244 @@ const BaseURL = "https://www.livingroomofsatoshi.com"
 // the caller asks to send is outside the range accepted by the receiver.
 var ErrOutsideSendableRange = errors.New("amount to send to LN address is outside the recipient's accepted range")
 
+// ErrAmountOutOfRange is returned when sending to a lightning address, but the amount is
+// outside the range accepted by the receiver. It carries the accepted range so that it
+// can be shown to the user. It matches [ErrOutsideSendableRange] with [errors.Is].
+type ErrAmountOutOfRange struct {
+	// Amount is the BTC amount which the caller asked to send.
+	Amount float64
+
+	// Min and Max are the receiver's minSendable and maxSendable amounts, in BTC.
+	Min float64
+	Max float64
+}
+
+// Error implements the error interface.
+func (e *ErrAmountOutOfRange) Error() string {
+	return fmt.Sprintf(
+		"%s: %.8f BTC is not between %.8f and %.8f BTC",
+		ErrOutsideSendableRange, e.Amount, e.Min, e.Max,
+	)
+}
+
+// Is returns true if target is [ErrOutsideSendableRange].
+func (e *ErrAmountOutOfRange) Is(target error) bool {
+	return target == ErrOutsideSendableRange
+}
+
+// ErrRateLimited is returned when the WoS API responds with HTTP status 429,
+// indicating the caller is sending too many requests.
+var ErrRateLimited = errors.New("rate limited by WoS API")
+
+// ErrMaintenance is returned when the WoS API is down for a scheduled maintenance
+// window, indicating the request should be tried again later.
+var ErrMaintenance = errors.New("WoS API is down for maintenance")
+
 type errorResponse struct {
 	Message string
+	Region  string `json:"region"`
+	Country string `json:"country"`
 }
 
-func checkHTTPResponse(resp *http.Response) error {
-	if resp.StatusCode == http.StatusOK {
-		return nil
+// APIError is returned when the WoS API responds to a request with an error status.
+// A 429 status matches [ErrRateLimited] with [errors.Is], a maintenance window
+// matches [ErrMaintenance], a regional restriction
+// matches [ErrUnsupportedRegion], and known error codes match the sentinel errors
+// listed in [WoSErrorCodes], such as [ErrLowFee].
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Message is the error message given by WoS, or the raw response body
+	// if WoS did not give a structured error message.
+	Message string
+
+	// Region is the region WoS reported when rejecting the request because of a
+	// regional restriction, read from the "region" or "country" field of the error
+	// response. WoS does not document these fields and often omits them, in which
+	// case Region is empty.
+	Region string
+
+	// RetryAfter is how long WoS asked the client to wait before retrying, read from
+	// the Retry-After header of the response. It is zero if WoS did not say.
+	RetryAfter time.Duration
+
+	// Err is the sentinel error for Message, as found by [ParseWoSError], or nil
+	// if the message is not a known error code.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("received status %d: %s", e.StatusCode, e.Message)
+	if e.StatusCode == http.StatusTooManyRequests {
+		msg = ErrRateLimited.Error() + ": " + msg
+	} else if e.isMaintenance() {
+		msg = ErrMaintenance.Error() + ": " + msg
+	} else if e.isRegionRestricted() {
+		msg = ErrUnsupportedRegion.Error() + ": " + msg
+		if e.Region != "" {
+			msg += " (region " + e.Region + ")"
+		}
 	}
+	return msg
+}
 
-	err := fmt.Errorf("received status %d", resp.StatusCode)
+// Is returns true if target is [ErrRateLimited] and the API responded with status 429,
+// if target is [ErrMaintenance] and the API is down for maintenance,
+// if target is [ErrUnsupportedRegion] and the request was refused in the wallet's region,
+// if target is [ErrWalletFrozen] and the request was refused because the wallet is frozen,
+// or if target is [ErrInvoiceExpired] or [ErrAlreadyPaid] and an invoice payment was
+// refused for that reason.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrMaintenance:
+		return e.isMaintenance()
+	case ErrUnsupportedRegion:
+		return e.isRegionRestricted()
+	case ErrWalletFrozen:
+		return e.isFrozen()
+	case ErrInvoiceExpired:
+		return e.isInvoiceExpired()
+	case ErrAlreadyPaid:
+		return e.isAlreadyPaid()
+	}
+	return false
+}
 
-	rawBody, readErr := io.ReadAll(resp.Body)
-	if readErr == nil {
+// Unwrap returns the sentinel error for a known error code, if any.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
 
-		var respErrDetail errorResponse
-		decodeErr := json.Unmarshal(rawBody, &respErrDetail)
-		if decodeErr == nil && respErrDetail.Message != "" {
-			err = fmt.Errorf("%w: %s", err, respErrDetail.Message)
-		} else {
-			err = fmt.Errorf("%w: %s", err, string(rawBody))
+// regionRestrictionMessages are the error codes and messages, lower-cased, by which
+// WoS has been seen to refuse requests because of the wallet's region.
+var regionRestrictionMessages = []string{
+	"unsupported region",
+	"unsupported_region",
+	"region not supported",
+	"region_not_supported",
+	"not available in your region",
+}
+
+// isRegionRestricted returns true if the error indicates WoS refused the request
+// because of the wallet's region. WoS does not document how it reports this, so it is
+// detected from status 451 (Unavailable For Legal Reasons), or one of the messages in
+// regionRestrictionMessages, such as the "unsupported region" errors WoS has returned.
+func (e *APIError) isRegionRestricted() bool {
+	if e.StatusCode == http.StatusUnavailableForLegalReasons {
+		return true
+	}
+	message := strings.ToLower(e.Message)
+	for _, restricted := range regionRestrictionMessages {
+		if strings.Contains(message, restricted) {
+			return true
 		}
+	}
+	return false
+}
 
-	} else {
-		err = fmt.Errorf("%w: (failed to read body: %w)", err, readErr)
+// isMaintenance returns true if the error indicates the WoS API is down for
+// maintenance. WoS does not document its maintenance windows, so this is detected
+// from a server error whose message mentions maintenance. A plain 503 may just be
+// a transient overload, so it is not treated as maintenance.
+func (e *APIError) isMaintenance() bool {
+	return e.StatusCode >= 500 && strings.Contains(strings.ToLower(e.Message), "maintenance")
+}
+
+// isFrozen returns true if the error indicates WoS refused the request because the
+// wallet is frozen. Besides the codes listed in [WoSErrorCodes], this is detected from
+// free-form messages which say the account is frozen or suspended.
+func (e *APIError) isFrozen() bool {
+	message := strings.ToLower(e.Message)
+	return strings.Contains(message, "frozen") || strings.Contains(message, "suspended")
+}
+
+// isInvoiceExpired returns true if the error indicates WoS refused to pay an invoice
+// because it has expired. Besides the codes listed in [WoSErrorCodes], this is detected
+// from free-form messages such as "Invoice has expired".
+func (e *APIError) isInvoiceExpired() bool {
+	message := strings.ToLower(e.Message)
+	return strings.Contains(message, "invoice") && strings.Contains(message, "expired")
+}
+
+// isAlreadyPaid returns true if the error indicates WoS refused to pay an invoice
+// because it was already paid. Besides the codes listed in [WoSErrorCodes], this is
+// detected from free-form messages such as "Invoice already paid" or "This invoice
+// has already been paid".
+func (e *APIError) isAlreadyPaid() bool {
+	message := strings.ToLower(e.Message)
+	return strings.Contains(message, "already paid") || strings.Contains(message, "already been paid")
+}
+
+// bufferResponse reads and closes the body of resp, replacing it with an
+// in-memory copy so that the body can be re-read any number of times.
+func bufferResponse(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// checkHTTPResponse returns an [*APIError] if resp has an error status.
+func checkHTTPResponse(resp *http.Response, body []byte) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+
+	var respErrDetail errorResponse
+	decodeErr := json.Unmarshal(body, &respErrDetail)
+	if decodeErr == nil && respErrDetail.Message != "" {
+		apiErr.Message = respErrDetail.Message
+	}
+	apiErr.Err = ParseWoSError(apiErr.Message)
+	if decodeErr == nil {
+		apiErr.Region = respErrDetail.Region
+		if apiErr.Region == "" {
+			apiErr.Region = respErrDetail.Country
+		}
 	}
 
-	return err
+	return apiErr
+}
+
+// parseRetryAfter parses a Retry-After header, given either as a number of seconds
+// or as an HTTP date. Returns zero if the header is empty, malformed, or in the past.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
 }
 
 func fromMillisat(sat uint64) float64 {
-	return float64(sat) / 100_000_000.0 / 1_000.0
+	return Msat(sat).BTC()
 }
 
 func toMillisat(amount float64) uint64 {
-	return uint64(amount * 100_000_000 * 1_000)
+	return uint64(MsatFromBTC(amount))
 }
 
 // Credentials represents a full set of credentials for a WoS wallet.
@@ -100,6 +309,13 @@ func (creds Credentials) OpenWallet(
 	return OpenWallet(ctx, creds.Reader(httpClient), creds.SimpleSigner())
 }
 
+// LightningAddress fetches the wallet's lightning address using the APIToken alone,
+// without opening a [Wallet]. This is lighter than [Credentials.OpenWallet], and
+// succeeds even in regions where on-chain addresses are not available.
+func (creds Credentials) LightningAddress(ctx context.Context, httpClient *http.Client) (LightningAddress, error) {
+	return creds.Reader(httpClient).LightningAddress(ctx)
+}
+
 // Wallet represents a Wallet of Satoshi wallet, including the mechanisms
 // needed to read its history and balances, create invoices, and make payments.
 //
@@ -110,11 +326,41 @@ func (creds Credentials) OpenWallet(
 // To open a wallet from an isolated signing mechanism, use [OpenWallet] with a
 // given [Signer].
 type Wallet struct {
-	reader           *Reader
-	signer           Signer
-	httpClient       *http.Client
-	onChainAddress   string
-	lightningAddress LightningAddress
+	reader        *Reader
+	signer        Signer
+	signerTimeout time.Duration
+	httpClient    *http.Client
+
+	addressMu         sync.RWMutex
+	onChainAddress    string
+	lightningAddress  LightningAddress
+	addressesFetched  time.Time
+	addressTTL        time.Duration
+	addressRefreshing bool
+
+	creationInfo *CreationInfo
+
+	// sweepMu serializes sweeps, so that concurrent sweeps do not both
+	// try to spend the same balance.
+	sweepMu sync.Mutex
+
+	clock Clock
+
+	descriptionTemplate *template.Template
+
+	preparedMu  sync.Mutex
+	prepared    map[string]*PreparedSend
+	preparedTTL time.Duration
+
+	maxPaymentAmount float64
+	domainPolicy     DomainPolicy
+	paymentMetrics   PaymentMetrics
+	safeMode         bool
+
+	idempotency *coalescer
+
+	// paymentSlots limits the number of payments in flight, if not nil.
+	paymentSlots chan struct{}
 }
 
 // OpenWallet opens an existing wallet using a separate [Reader] and [Signer].
@@ -127,7 +373,7 @@ func OpenWallet(ctx context.Context, reader *Reader, signer Signer) (*Wallet, er
 		return nil, fmt.Errorf("OpenWallet: %w", err)
 	}
 
-	lnAddress, err := ParseLightningAddress(addresses.Lightning)
+	lnAddress, err := parseProvisionedLightningAddress(addresses.Lightning)
 	if err != nil {
 		return nil, fmt.Errorf("OpenWallet: %w", err)
 	}
@@ -138,6 +384,7 @@ func OpenWallet(ctx context.Context, reader *Reader, signer Signer) (*Wallet, er
 		httpClient:       reader.httpClient,
 		onChainAddress:   addresses.OnChain,
 		lightningAddress: lnAddress,
+		addressesFetched: time.Now(),
 	}
 
 	return wallet, nil
@@ -154,6 +401,9 @@ type createWalletResponse struct {
 // It returns a [Wallet] which can be used right away, and a set of access
 // [Credentials] which should be saved in a persistent storage medium so that
 // the wallet can be re-opened later with [OpenWallet].
+//
+// Any other details WoS includes in its response, such as the wallet's addresses and
+// creation time, are available from [Wallet.CreationInfo].
 func CreateWallet(ctx context.Context, httpClient *http.Client) (*Wallet, *Credentials, error) {
 	if httpClient == nil {
 		httpClient = http.DefaultClient
@@ -167,26 +417,32 @@ func CreateWallet(ctx context.Context, httpClient *http.Client) (*Wallet, *Crede
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "")
 
-	resp, err := httpClient.Do(req)
+	resp, err := NewReader("", httpClient).send(req, "CreateWallet")
 	if err != nil {
-		return nil, nil, fmt.Errorf("CreateWallet request failed: %w", err)
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
 
-	if err := checkHTTPResponse(resp); err != nil {
-		return nil, nil, fmt.Errorf("CreateWallet: %w", err)
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var respStruct createWalletResponse
-	if err := json.NewDecoder(resp.Body).Decode(&respStruct); err != nil {
+	if err := json.Unmarshal(respData, &respStruct); err != nil {
 		return nil, nil, fmt.Errorf("error decoding CreateWallet response: %w", err)
 	}
 
-	lnAddress, err := ParseLightningAddress(respStruct.LightningAddress)
+	lnAddress, err := parseProvisionedLightningAddress(respStruct.LightningAddress)
 	if err != nil {
 		return nil, nil, fmt.Errorf("CreateWallet: %w", err)
 	}
 
+	receivedAt := time.Now()
+	creationInfo, err := parseCreationInfo(respData, receivedAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error decoding CreateWallet response: %w", err)
+	}
+
 	creds := &Credentials{
 		APISecret: respStruct.APISecret,
 		APIToken:  respStruct.APIToken,
@@ -198,53 +454,172 @@ func CreateWallet(ctx context.Context, httpClient *http.Client) (*Wallet, *Crede
 		httpClient:       httpClient,
 		onChainAddress:   respStruct.OnChainAddress,
 		lightningAddress: lnAddress,
+		addressesFetched: receivedAt,
+		creationInfo:     creationInfo,
 	}
 
 	return wallet, creds, nil
 }
 
-// LightningAddress returns the wallet's static Lightning Address.
+// CreationInfo returns the details WoS reported when the wallet was created, or nil if
+// the wallet was not created by [CreateWallet] in this process, such as when it was
+// opened with [OpenWallet].
+func (wallet *Wallet) CreationInfo() *CreationInfo {
+	if wallet.creationInfo == nil {
+		return nil
+	}
+	info := *wallet.creationInfo
+	return &info
+}
+
+// LightningAddress returns the wallet's static Lightning Address. For a brand new
+// wallet, WoS may not have provisioned the address yet, in which case this returns
+// the zero LightningAddress; see [LightningAddress.IsZero] and [Wallet.WaitForProvisioning].
 func (wallet *Wallet) LightningAddress() LightningAddress {
+	wallet.addressMu.RLock()
+	defer wallet.addressMu.RUnlock()
 	return wallet.lightningAddress
 }
 
 // OnChainAddress returns the wallet's on-chain deposit address.
 // Be aware this address might be reused, which is sub-optimal for privacy.
-// To fetch an up-to-date address, use [Wallet.Addresses], or re-open
+// To fetch an up-to-date address, use [Wallet.RefreshAddresses], or re-open
 // the wallet.
+//
+// If an address TTL has been set with [Wallet.SetAddressTTL] and the cached
+// address is older than the TTL, a refresh is started in the background. The
+// current cached address is returned immediately regardless.
 func (wallet *Wallet) OnChainAddress() string {
+	wallet.addressMu.Lock()
+	defer wallet.addressMu.Unlock()
+
+	stale := wallet.addressTTL > 0 && time.Since(wallet.addressesFetched) > wallet.addressTTL
+	if stale && !wallet.addressRefreshing {
+		wallet.addressRefreshing = true
+		go func() {
+			wallet.RefreshAddresses(context.Background())
+
+			wallet.addressMu.Lock()
+			wallet.addressRefreshing = false
+			wallet.addressMu.Unlock()
+		}()
+	}
+
 	return wallet.onChainAddress
 }
 
+// SetAddressTTL sets how long the wallet's cached addresses are considered fresh.
+// Once the TTL elapses, the next call to [Wallet.OnChainAddress] triggers a background
+// refresh. A TTL of zero, the default, disables automatic refreshing.
+func (wallet *Wallet) SetAddressTTL(ttl time.Duration) {
+	wallet.addressMu.Lock()
+	defer wallet.addressMu.Unlock()
+	wallet.addressTTL = ttl
+}
+
+// RefreshAddresses re-fetches the wallet's on-chain and lightning addresses and
+// updates the values cached by the wallet.
+func (wallet *Wallet) RefreshAddresses(ctx context.Context) error {
+	addresses, err := wallet.reader.Addresses(ctx)
+	if err != nil {
+		return fmt.Errorf("RefreshAddresses: %w", err)
+	}
+
+	lnAddress, err := parseProvisionedLightningAddress(addresses.Lightning)
+	if err != nil {
+		return fmt.Errorf("RefreshAddresses: %w", err)
+	}
+
+	wallet.addressMu.Lock()
+	defer wallet.addressMu.Unlock()
+	wallet.onChainAddress = addresses.OnChain
+	wallet.lightningAddress = lnAddress
+	wallet.addressesFetched = time.Now()
+	return nil
+}
+
 // SetHTTPClient updates the [http.Client] used by the wallet and its internal [Reader].
 func (wallet *Wallet) SetHTTPClient(httpClient *http.Client) {
 	wallet.httpClient = httpClient
 	wallet.reader.httpClient = httpClient
 }
 
+// SetMaxRedirects limits the number of HTTP redirects followed by the wallet's
+// API requests. See [Reader.SetMaxRedirects].
+func (wallet *Wallet) SetMaxRedirects(n int) {
+	wallet.reader.SetMaxRedirects(n)
+}
+
 // PostRequest issues an HTTP POST request to the given endpoint, authenticated by the
 // Wallet's internal [Signer]. The body parameter is marshaled to JSON and sent
 // as the request body.
+//
+// If [Wallet.EnableIdempotencyCache] is in effect, a request identical to one which
+// recently succeeded returns the earlier response without being sent again.
 func (wallet *Wallet) PostRequest(ctx context.Context, endpoint string, body any) ([]byte, error) {
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
 
+	post := func() ([]byte, error) {
+		resp, err := wallet.PostRequestRaw(ctx, endpoint, json.RawMessage(bodyBytes))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(resp.Body)
+	}
+
+	if c := wallet.idempotency; c != nil {
+		bodyHash := sha256.Sum256(bodyBytes)
+		return c.do(endpoint+"|"+hex.EncodeToString(bodyHash[:]), post)
+	}
+	return post()
+}
+
+// EnableIdempotencyCache makes the wallet remember the responses to successful POST
+// requests for the given TTL. Within the TTL, repeating a request with an identical
+// endpoint and body, such as when retrying a payment whose response was lost, returns
+// the remembered response instead of sending the request again. Concurrent identical
+// requests share a single request. Failed requests are never remembered.
+//
+// This protects every mutating call, such as creating invoices and sending payments,
+// from accidental duplication. Requests are matched on their body alone: every request
+// is signed with a fresh nonce, but two payments with the same destination, amount and
+// description are considered identical. So, with the cache enabled, deliberately paying
+// the same destination the same amount twice within the TTL requires different
+// descriptions. The cache is disabled by default.
+func (wallet *Wallet) EnableIdempotencyCache(ttl time.Duration) {
+	wallet.idempotency = newCoalescer(ttl)
+}
+
+// DisableIdempotencyCache turns off the cache enabled by [Wallet.EnableIdempotencyCache].
+func (wallet *Wallet) DisableIdempotencyCache() {
+	wallet.idempotency = nil
+}
+
+// PostRequestRaw is like [Wallet.PostRequest], but returns the full [http.Response],
+// so that callers can inspect the status code and headers for debugging.
+//
+// The response body is buffered in memory and the underlying connection is already
+// closed, so resp.Body can be read freely without needing to be closed. If the
+// server responds with an error status, the response is returned alongside the error.
+func (wallet *Wallet) PostRequestRaw(ctx context.Context, endpoint string, body any) (*http.Response, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
 	nonceBytes := make([]byte, 16)
 	if _, err := rand.Read(nonceBytes); err != nil {
 		return nil, fmt.Errorf("generating nonce: %w", err)
 	}
 	nonce := base64.StdEncoding.EncodeToString(nonceBytes)
 
-	hmacSignature, err := wallet.signer.SignRequest(
-		ctx,
-		endpoint,
-		nonce,
-		wallet.reader.apiToken,
-		string(bodyBytes),
-	)
-	if err != nil {
+	hmacSignature, err := wallet.signRequest(ctx, endpoint, nonce, string(bodyBytes))
+	if errors.Is(err, ErrSignerTimeout) {
+		return nil, err
+	} else if err != nil {
 		return nil, fmt.Errorf("Signer returned error: %w", err)
 	}
 
@@ -258,17 +633,7 @@ func (wallet *Wallet) PostRequest(ctx context.Context, endpoint string, body any
 	req.Header.Set("Nonce", nonce)
 	req.Header.Set("Signature", hex.EncodeToString(hmacSignature))
 
-	resp, err := wallet.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("POST %s request failed: %w", endpoint, err)
-	}
-	defer resp.Body.Close()
-
-	if err := checkHTTPResponse(resp); err != nil {
-		return nil, fmt.Errorf("POST %s: %w", endpoint, err)
-	}
-
-	return io.ReadAll(resp.Body)
+	return wallet.reader.send(req, "POST "+endpoint)
 }
 
 // Addresses re-fetches the wallet's on-chain and lightning addresses.
@@ -299,17 +664,62 @@ type InvoiceOptions struct {
 	// no description will be provided to the payee.
 	Description string
 
+	// DescriptionHash is the SHA256 hash of a description to commit to in the
+	// invoice instead of a plaintext Description, such as the metadata of an
+	// LNURL-pay response. The description itself must be revealed to the payer
+	// out of band, who can check it with [DecodedInvoice.VerifyDescription].
+	// Cannot be combined with Description.
+	//
+	// WoS does not document support for description hashes. If the invoice
+	// returned by WoS does not commit to DescriptionHash, NewInvoice returns
+	// an error wrapping [ErrDescriptionHashUnsupported].
+	DescriptionHash []byte
+
+	// DescriptionData is the data used to render the wallet's description template,
+	// set with [Wallet.SetInvoiceDescriptionTemplate], into the invoice's Description.
+	// It is ignored if Description or DescriptionHash is set, or no template is set.
+	DescriptionData map[string]any
+
 	// The expiry time for the invoice, after which it can no longer be paid.
-	// If omitted, defaults to 24 hours.
+	// If omitted, defaults to 24 hours. Non-zero values are clamped into the
+	// range between [MinInvoiceExpiry] and [MaxInvoiceExpiry].
 	Expiry time.Duration
+
+	// Verify asks NewInvoice to decode the invoice returned by WoS, and check that its
+	// amount and description match those requested. If not, NewInvoice returns an error
+	// wrapping [ErrInvoiceMismatch]. This guards against server bugs or tampering, at
+	// the cost of decoding the invoice.
+	Verify bool
 }
 
+// ErrInvoiceMismatch is returned by [Wallet.NewInvoice] when [InvoiceOptions.Verify]
+// is set and the invoice returned by WoS does not match the one requested.
+var ErrInvoiceMismatch = errors.New("invoice does not match request")
+
+// MinInvoiceExpiry and MaxInvoiceExpiry define the range of invoice expiry times which
+// [Wallet.NewInvoice] will request from WoS. Expiry times outside this range are clamped
+// into it, rather than risking the invoice being rejected by the server.
+var (
+	MinInvoiceExpiry = time.Minute
+	MaxInvoiceExpiry = 7 * 24 * time.Hour
+)
+
+// ErrExpiryClamped is wrapped by a [WarningExpiryClamped] warning in [Invoice.Warnings]
+// when the expiry time requested in [InvoiceOptions] was outside the range accepted by
+// WoS and had to be adjusted.
+var ErrExpiryClamped = errors.New("invoice expiry clamped to accepted range")
+
 type createInvoiceRequest struct {
-	Amount      float64 `json:"amount"`
-	Description string  `json:"description,omitempty"`
-	Expiry      uint    `json:"expiry,omitempty"`
+	Amount          float64 `json:"amount"`
+	Description     string  `json:"description,omitempty"`
+	DescriptionHash string  `json:"descriptionHash,omitempty"`
+	Expiry          uint    `json:"expiry,omitempty"`
 }
 
+// ErrDescriptionHashUnsupported is returned by [Wallet.NewInvoice] when WoS issues an
+// invoice which does not commit to the requested [InvoiceOptions.DescriptionHash].
+var ErrDescriptionHashUnsupported = errors.New("WoS did not honor the requested description hash")
+
 // Invoice is a Bitcoin Lightning invoice returned by the WoS API.
 type Invoice struct {
 	// ID is a UUID which identifies the invoice.
@@ -324,6 +734,106 @@ type Invoice struct {
 
 	// Expires is the expiry time at which the invoice is no longer payable.
 	Expires time.Time `json:"expires"`
+
+	// PaymentHash is the SHA256 hash of the invoice's payment preimage, which identifies
+	// its payment. It is taken from the WoS response if given, or else decoded from Bolt11.
+	// It is nil if neither is available.
+	PaymentHash []byte `json:"-"`
+
+	// Warnings lists any non-fatal problems encountered while creating the invoice,
+	// such as [WarningExpiryClamped].
+	Warnings []Warning `json:"-"`
+
+	// Raw is the JSON the invoice was decoded from, if any. See [Payment.Raw].
+	Raw json.RawMessage `json:"-"`
+
+	clock Clock
+}
+
+// ExpiryTimer returns a channel which receives the time remaining until the invoice
+// expires, once immediately and then every second, for displaying a live countdown.
+// Once the invoice has expired, the channel receives zero and is closed.
+//
+// Each value is computed when it is sent, so a slow receiver never sees a stale
+// duration. The channel is closed early if ctx is cancelled. The countdown follows
+// the clock of the wallet which created the invoice; see [Wallet.SetClock].
+func (invoice *Invoice) ExpiryTimer(ctx context.Context) <-chan time.Duration {
+	clock := clockOrDefault(invoice.clock)
+	remainingChan := make(chan time.Duration)
+
+	go func() {
+		defer close(remainingChan)
+		for {
+			remaining := invoice.Expires.Sub(clock.Now())
+			if remaining < 0 {
+				remaining = 0
+			}
+
+			select {
+			case remainingChan <- remaining:
+			case <-ctx.Done():
+				return
+			}
+			if remaining == 0 {
+				return
+			}
+
+			// Wake up exactly at expiry for the final tick.
+			wait := time.Second
+			if remaining < wait {
+				wait = remaining
+			}
+			select {
+			case <-clock.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return remainingChan
+}
+
+// SetInvoiceDescriptionTemplate sets a [text/template] used to generate invoice descriptions,
+// such as "Order #{{.OrderRef}} - Acme Store". It is rendered by [Wallet.NewInvoice] with
+// [InvoiceOptions.DescriptionData], to which the following fields are added unless the
+// data already has them:
+//
+//   - .Amount is the invoice's [InvoiceOptions.Amount].
+//   - .Timestamp is the [time.Time] at which the invoice is created.
+//
+// Referring to a field missing from the data is an error when creating an invoice. Returns
+// an error if the template cannot be parsed. An empty template disables templating.
+func (wallet *Wallet) SetInvoiceDescriptionTemplate(tmpl string) error {
+	if tmpl == "" {
+		wallet.descriptionTemplate = nil
+		return nil
+	}
+
+	parsed, err := template.New("description").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("invalid invoice description template: %w", err)
+	}
+	wallet.descriptionTemplate = parsed
+	return nil
+}
+
+// renderInvoiceDescription renders an invoice description template with the given data,
+// adding the Amount and Timestamp fields.
+func renderInvoiceDescription(tmpl *template.Template, data map[string]any, amount float64, now time.Time) (string, error) {
+	fields := map[string]any{
+		"Amount":    amount,
+		"Timestamp": now,
+	}
+	for k, v := range data {
+		fields[k] = v
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, fields); err != nil {
+		return "", fmt.Errorf("rendering description template: %w", err)
+	}
+	return buf.String(), nil
 }
 
 // NewInvoice creates a new [BOLT11] payment invoice, essentially a request for payment.
@@ -341,12 +851,49 @@ func (wallet *Wallet) NewInvoice(ctx context.Context, opts *InvoiceOptions) (*In
 		return nil, fmt.Errorf("invalid invoice amount: %f", opts.Amount)
 	} else if opts.Expiry < 0 {
 		return nil, fmt.Errorf("invalid invoice expiry time: %s", opts.Expiry)
+	} else if opts.DescriptionHash != nil && len(opts.DescriptionHash) != sha256.Size {
+		return nil, fmt.Errorf("invalid invoice description hash length: %d", len(opts.DescriptionHash))
+	} else if opts.DescriptionHash != nil && opts.Description != "" {
+		return nil, errors.New("invoice cannot have both a description and a description hash")
+	}
+
+	if minAmount := wallet.reader.minInvoiceAmount(); opts.Amount > 0 && opts.Amount < minAmount {
+		return nil, fmt.Errorf(
+			"NewInvoice: %w: %.8f BTC is below the minimum of %.8f BTC",
+			ErrInvalidAmount, opts.Amount, minAmount,
+		)
+	}
+
+	if opts.DescriptionData != nil && opts.Description == "" && opts.DescriptionHash == nil {
+		if tmpl := wallet.descriptionTemplate; tmpl != nil {
+			description, err := renderInvoiceDescription(tmpl, opts.DescriptionData, opts.Amount, clockOrDefault(wallet.clock).Now())
+			if err != nil {
+				return nil, fmt.Errorf("NewInvoice: %w", err)
+			}
+			withDescription := *opts
+			withDescription.Description = description
+			opts = &withDescription
+		}
+	}
+
+	var warnings []Warning
+
+	expiry := opts.Expiry
+	if expiry != 0 && expiry < MinInvoiceExpiry {
+		warnings = append(warnings, newWarning(WarningExpiryClamped,
+			fmt.Errorf("%w: %s raised to %s", ErrExpiryClamped, expiry, MinInvoiceExpiry)))
+		expiry = MinInvoiceExpiry
+	} else if expiry > MaxInvoiceExpiry {
+		warnings = append(warnings, newWarning(WarningExpiryClamped,
+			fmt.Errorf("%w: %s lowered to %s", ErrExpiryClamped, expiry, MaxInvoiceExpiry)))
+		expiry = MaxInvoiceExpiry
 	}
 
 	request := createInvoiceRequest{
-		Amount:      opts.Amount,
-		Description: opts.Description,
-		Expiry:      uint(opts.Expiry.Seconds()),
+		Amount:          opts.Amount,
+		Description:     opts.Description,
+		DescriptionHash: hex.EncodeToString(opts.DescriptionHash),
+		Expiry:          uint(expiry.Seconds()),
 	}
 
 	respData, err := wallet.PostRequest(ctx, "/api/v1/wallet/createInvoice", request)
@@ -358,10 +905,67 @@ func (wallet *Wallet) NewInvoice(ctx context.Context, opts *InvoiceOptions) (*In
 	if err := json.Unmarshal(respData, &invoice); err != nil {
 		return nil, fmt.Errorf("invalid NewInvoice response: %w", err)
 	}
+	invoice.PaymentHash = invoicePaymentHash(respData, invoice.Bolt11)
+
+	if opts.DescriptionHash != nil || opts.Verify {
+		decoded, err := DecodeInvoice(invoice.Bolt11)
+		if err != nil {
+			return nil, fmt.Errorf("NewInvoice: %w", err)
+		} else if opts.DescriptionHash != nil && !bytes.Equal(decoded.DescriptionHash, opts.DescriptionHash) {
+			return nil, fmt.Errorf("NewInvoice: %w", ErrDescriptionHashUnsupported)
+		}
+		if opts.Verify {
+			if err := checkInvoiceMatches(decoded, opts); err != nil {
+				return nil, fmt.Errorf("NewInvoice: %w", err)
+			}
+		}
+	}
 
+	invoice.Warnings = warnings
+	invoice.clock = wallet.clock
 	return &invoice, nil
 }
 
+// checkInvoiceMatches returns an error wrapping [ErrInvoiceMismatch] if a decoded
+// invoice does not have the amount and description requested by opts.
+func checkInvoiceMatches(decoded *DecodedInvoice, opts *InvoiceOptions) error {
+	if toSats(decoded.Amount) != toSats(opts.Amount) {
+		return fmt.Errorf(
+			"%w: invoice amount is %.8f BTC, requested %.8f BTC",
+			ErrInvoiceMismatch, decoded.Amount, opts.Amount,
+		)
+	}
+	if opts.DescriptionHash == nil && decoded.Description != opts.Description {
+		return fmt.Errorf(
+			"%w: invoice description is %q, requested %q",
+			ErrInvoiceMismatch, decoded.Description, opts.Description,
+		)
+	}
+	return nil
+}
+
+// invoicePaymentHash extracts the payment hash from a createInvoice response. WoS
+// does not document a payment hash field, so if none is found, the hash is decoded
+// from the invoice itself. Returns nil if neither works.
+func invoicePaymentHash(respData []byte, bolt11 string) []byte {
+	var extra struct {
+		PaymentHash string `json:"paymentHash"`
+		Hash        string `json:"hash"`
+	}
+	if json.Unmarshal(respData, &extra) == nil {
+		for _, hexHash := range []string{extra.PaymentHash, extra.Hash} {
+			if hash, err := hex.DecodeString(hexHash); err == nil && len(hash) == 32 {
+				return hash
+			}
+		}
+	}
+
+	if decoded, err := DecodeInvoice(bolt11); err == nil {
+		return decoded.PaymentHash
+	}
+	return nil
+}
+
 type sendPaymentRequest struct {
 	Address      string  `json:"address"`
 	Currency     string  `json:"currency"`
@@ -369,27 +973,104 @@ type sendPaymentRequest struct {
 	Description  string  `json:"description,omitempty"`
 	MaxLightning bool    `json:"sendMaxLightning,omitempty"`
 	MaxBitcoin   bool    `json:"sendMaxBtc,omitempty"`
+	MaxFee       float64 `json:"maxLightningFee,omitempty"`
+}
+
+// ErrAmountExceedsCap is returned by every payment method when asked to send more
+// than the cap set by [MaxPaymentAmount] or [Wallet.SetMaxPaymentAmount].
+var ErrAmountExceedsCap = errors.New("payment amount exceeds safety cap")
+
+// MaxPaymentAmount is a hard cap on the BTC amount any [Wallet] will send in a single
+// payment, guarding against catastrophic mistakes. Payments above it fail with an error
+// wrapping [ErrAmountExceedsCap] before they are signed. A wallet-level cap can also be
+// set with [Wallet.SetMaxPaymentAmount]; payments must be within both.
+//
+// Set to zero, the default, to disable the package-level cap.
+var MaxPaymentAmount float64
+
+// SetMaxPaymentAmount sets a hard cap on the BTC amount the wallet will send in a single
+// payment, in addition to [MaxPaymentAmount]. Unlike a [Signer] policy, this applies no
+// matter how requests are signed. Zero, the default, disables the wallet-level cap.
+func (wallet *Wallet) SetMaxPaymentAmount(amount float64) {
+	wallet.maxPaymentAmount = amount
+}
+
+// checkPaymentCap returns an error wrapping [ErrAmountExceedsCap] if amount exceeds
+// either [MaxPaymentAmount] or the wallet's own cap.
+func (wallet *Wallet) checkPaymentCap(amount float64) error {
+	for _, limit := range []float64{MaxPaymentAmount, wallet.maxPaymentAmount} {
+		if limit > 0 && amount > limit {
+			return fmt.Errorf("%w: %.8f BTC exceeds cap of %.8f BTC", ErrAmountExceedsCap, amount, limit)
+		}
+	}
+	return nil
+}
+
+// SetMaxConcurrentPayments limits how many payment requests the wallet sends to WoS at
+// once, so that batch or scheduled payouts do not overwhelm the API, trip its rate limits,
+// or race against each other on the wallet's balance. Further payments wait in line for
+// a free slot, or fail with the context's error if their context is done first.
+//
+// A limit of zero or less, the default, allows unlimited concurrent payments. Payments
+// already waiting or in flight are unaffected by changes to the limit.
+func (wallet *Wallet) SetMaxConcurrentPayments(limit int) {
+	if limit <= 0 {
+		wallet.paymentSlots = nil
+	} else {
+		wallet.paymentSlots = make(chan struct{}, limit)
+	}
+}
+
+// acquirePaymentSlot waits for a free slot under the limit set by
+// [Wallet.SetMaxConcurrentPayments]. The returned function must be called to
+// release the slot once the payment request completes.
+func (wallet *Wallet) acquirePaymentSlot(ctx context.Context) (release func(), err error) {
+	slots := wallet.paymentSlots
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
 func (wallet *Wallet) newPayment(
 	ctx context.Context,
 	method string,
 	req sendPaymentRequest,
-) (*Payment, error) {
+) (payment *Payment, err error) {
+	start := time.Now()
+	defer func() {
+		wallet.recordPayment(ctx, method, PaymentCurrency(req.Currency), req.Amount, start, err)
+	}()
+
+	if err := wallet.checkPaymentCap(req.Amount); err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+
+	release, err := wallet.acquirePaymentSlot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+	defer release()
+
 	respData, err := wallet.PostRequest(ctx, "/api/v1/wallet/payment", req)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", method, err)
 	}
 
-	var payment Payment
 	if err := json.Unmarshal(respData, &payment); err != nil {
 		return nil, fmt.Errorf("invalid %s response: %w", method, err)
 	}
-	return &payment, nil
+	return payment, nil
 }
 
 // PayInvoice executes a payment to a given lightning invoice. The description is
-// stored in the WoS payment history.
+// stored in the WoS payment history as a private memo, in place of the invoice's own
+// description. See [Payment.InvoiceDescription] to recover the latter.
 //
 // Returns an error wrapping [ErrInvalidInvoice] if the invoice is not valid.
 //
@@ -397,76 +1078,197 @@ func (wallet *Wallet) newPayment(
 // wrapping [ErrNoAmount]. To pay a variable-amount invoice, use [Wallet.PayVariableInvoice].
 //
 // To estimate fees, use [Wallet.FeeEstimate] or [Reader.FeeEstimate].
+//
+// To tip extra on top of the invoice's amount, use [Wallet.PayInvoiceWith].
 func (wallet *Wallet) PayInvoice(ctx context.Context, invoice, description string) (*Payment, error) {
+	return wallet.PayInvoiceWith(ctx, invoice, description, nil)
+}
+
+// ErrOverpayNotAllowed is returned by [Wallet.PayInvoiceWith] when asked to overpay
+// an invoice beyond the cap set by [PayInvoiceOptions.MaxOverpay].
+var ErrOverpayNotAllowed = errors.New("overpayment exceeds allowed cap")
+
+// PayInvoiceOptions customizes [Wallet.PayInvoiceWith].
+type PayInvoiceOptions struct {
+	// Overpay is an extra BTC amount to send on top of the invoice's fixed amount,
+	// such as a tip. Zero, the default, pays exactly the invoice's amount.
+	//
+	// Most payees will not accept overpayment, and may reject the payment outright,
+	// so this should only be used with payees known to accept it. BOLT11 permits
+	// paying at most twice an invoice's amount, so overpayment is capped there too.
+	Overpay float64
+
+	// MaxOverpay is the largest Overpay allowed, guarding against accidentally
+	// sending far more than intended. Overpay is rejected unless MaxOverpay is set.
+	MaxOverpay float64
+
+	// RiskThresholds are the limits beyond which the invoice is considered risky, as
+	// checked by [DecodedInvoice.CheckRisk]. Risky invoices are still paid, but a
+	// [WarningRiskyInvoice] is added to [Payment.Warnings]. If nil,
+	// [DefaultInvoiceRiskThresholds] are used.
+	RiskThresholds *InvoiceRiskThresholds
+}
+
+// PayInvoiceWith is like [Wallet.PayInvoice], but accepts [PayInvoiceOptions] to
+// customize the payment. opts can be nil, in which case this is exactly [Wallet.PayInvoice].
+//
+// Returns an error wrapping [ErrOverpayNotAllowed] if opts.Overpay exceeds opts.MaxOverpay,
+// or [ErrInvalidAmount] if the total exceeds the maximum the invoice permits.
+//
+// If the invoice could lock up funds for an abnormally long time, it is paid anyway,
+// and a [WarningRiskyInvoice] is added to [Payment.Warnings].
+func (wallet *Wallet) PayInvoiceWith(
+	ctx context.Context,
+	invoice, description string,
+	opts *PayInvoiceOptions,
+) (*Payment, error) {
+	if opts == nil {
+		opts = &PayInvoiceOptions{}
+	}
+
+	if opts.Overpay < 0 {
+		return nil, fmt.Errorf("PayInvoice: invalid overpay amount: %f", opts.Overpay)
+	} else if opts.Overpay > opts.MaxOverpay {
+		return nil, fmt.Errorf(
+			"PayInvoice: %w: %.8f BTC exceeds cap of %.8f BTC",
+			ErrOverpayNotAllowed, opts.Overpay, opts.MaxOverpay,
+		)
+	}
+
 	amount, err := parseInvoiceAmount(invoice)
 	if err != nil {
 		return nil, fmt.Errorf("PayInvoice: %w", err)
 	}
 
-	return wallet.newPayment(ctx, "PayInvoice", sendPaymentRequest{
+	decoded, decodeErr := DecodeInvoice(invoice)
+	if opts.Overpay > 0 {
+		if decodeErr != nil {
+			return nil, fmt.Errorf("PayInvoice: %w", decodeErr)
+		}
+		amount += opts.Overpay
+		if err := decoded.CheckAmount(amount); err != nil {
+			return nil, fmt.Errorf("PayInvoice: %w", err)
+		}
+	}
+
+	payment, err := wallet.newPayment(ctx, "PayInvoice", sendPaymentRequest{
 		Address:     invoice,
 		Currency:    "LIGHTNING",
 		Description: description,
 		Amount:      amount,
 	})
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr == nil {
+		if warning := decoded.riskWarning(opts.RiskThresholds); warning != nil {
+			payment.Warnings = append(payment.Warnings, *warning)
+		}
+	}
+	return payment, nil
 }
 
 // PayLightningAddress executes a payment of the given BTC amount to a
 // given lightning address. The description is stored in the WoS payment history.
 //
-// Returns ErrOutsideSendableRange if the amount to be sent is outside the receiver's
-// acceptable min/max sendable range.
+// Returns an [*ErrAmountOutOfRange] error if the amount to be sent is outside the
+// receiver's acceptable min/max sendable range.
+//
+// Returns an error wrapping [ErrUnsafeRedirect] if the recipient's LNURL-pay callback
+// is not an HTTPS URL on the lightning address's own domain.
 //
 // Under the hood, this uses the WoS API to proxy your request to [LightningAddress.Domain],
 // so that the recipient does not see your IP address.
+//
+// If the recipient's server responds with a success action, such as a message or receipt
+// URL to show the payer, it is returned in [Payment.SuccessAction].
+//
+// Payments to lightning addresses on the [WoSLightningDomain] are settled internally
+// by WoS without any routing fee, so the recipient receives exactly amount. To confirm
+// this when both wallets are under your control, use [Wallet.Transfer].
 func (wallet *Wallet) PayLightningAddress(
 	ctx context.Context,
 	lnAddress LightningAddress,
 	description string,
 	amount float64,
 ) (*Payment, error) {
-	respData, err := wallet.PostRequest(ctx, "/api/v1/wallet/lnurl", map[string]any{
-		"address": lnAddress.LNURL(),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("PayLightningAddress: %w", err)
-	}
+	return wallet.PayLightningAddressWith(ctx, lnAddress, description, amount, nil)
+}
 
-	var lnPayResponseBody struct {
-		Callback    string `json:"callback"`
-		MaxSendable uint64 `json:"maxSendable"`
-		MinSendable uint64 `json:"minSendable"`
-	}
+// PayLightningAddressOptions customizes [Wallet.PayLightningAddressWith].
+type PayLightningAddressOptions struct {
+	// Comment is sent to the recipient along with the payment, as per LUD-12. If the
+	// recipient does not accept comments this long, an error wrapping [ErrCommentTooLong]
+	// is returned and nothing is paid.
+	Comment string
 
-	if err := json.Unmarshal(respData, &lnPayResponseBody); err != nil {
-		return nil, fmt.Errorf("PayLightningAddress: invalid response JSON: %w", err)
+	// IdempotencyKey guards against paying twice when retrying a payment whose outcome
+	// is unknown, such as after a network failure. It should be unique to the payment
+	// being made, such as an order ID, and reused on every retry of that payment.
+	//
+	// The key is embedded in the payment's memo in the WoS payment history. Before paying,
+	// the wallet's recent payments are searched for a memo with the same key, and if one
+	// is found, that payment is returned instead of paying again.
+	IdempotencyKey string
+}
+
+// idempotencyLookback is the number of recent payments searched for an earlier payment
+// with the same idempotency key.
+const idempotencyLookback = 50
+
+// idempotencyRef returns the reference embedded in payment memos for an idempotency key.
+func idempotencyRef(key string) string {
+	return "[ref:" + key + "]"
+}
+
+// PayLightningAddressWith is like [Wallet.PayLightningAddress], but accepts
+// [PayLightningAddressOptions] to customize the payment. opts can be nil.
+//
+// If opts.IdempotencyKey is set and a recent payment with the same key is found, it is
+// returned without paying again. If the wallet's history cannot be checked, an error
+// is returned rather than risking a double payment.
+func (wallet *Wallet) PayLightningAddressWith(
+	ctx context.Context,
+	lnAddress LightningAddress,
+	description string,
+	amount float64,
+	opts *PayLightningAddressOptions,
+) (*Payment, error) {
+	if opts == nil {
+		opts = &PayLightningAddressOptions{}
+	}
+	if err := wallet.domainPolicy.check(lnAddress.Domain); err != nil {
+		return nil, fmt.Errorf("PayLightningAddress: %w", err)
 	}
 
-	if maxSendable := fromMillisat(lnPayResponseBody.MaxSendable); amount > maxSendable {
-		return nil, fmt.Errorf(
-			"PayLightningAddress: %w: exceeds maxSendable (%f BTC)",
-			ErrOutsideSendableRange, maxSendable,
-		)
-	} else if minSendable := fromMillisat(lnPayResponseBody.MinSendable); amount < minSendable {
-		return nil, fmt.Errorf(
-			"PayLightningAddress: %w: exceeds minSendable (%f BTC)",
-			ErrOutsideSendableRange, minSendable,
-		)
+	if opts.IdempotencyKey != "" {
+		ref := idempotencyRef(opts.IdempotencyKey)
+		recent, err := wallet.reader.RecentPayments(ctx, idempotencyLookback)
+		if err != nil {
+			return nil, fmt.Errorf("PayLightningAddress: checking for duplicate payment: %w", err)
+		}
+		for i := len(recent) - 1; i >= 0; i-- {
+			if recent[i].Type == PaymentTypeDebit && strings.Contains(recent[i].Description, ref) {
+				return &recent[i], nil
+			}
+		}
+
+		if description == "" {
+			description = ref
+		} else {
+			description += " " + ref
+		}
 	}
 
-	respData, err = wallet.PostRequest(ctx, "/api/v1/wallet/lnPay", map[string]any{
-		"amount":   toMillisat(amount),
-		"callback": lnPayResponseBody.Callback,
-	})
+	params, err := wallet.fetchLNURL(ctx, lnAddress.LNURL())
 	if err != nil {
+		if isNotLightningAddressError(err) {
+			return nil, fmt.Errorf("PayLightningAddress: %w: %s does not serve LNURL-pay requests (%v)",
+				ErrNotALightningAddress, lnAddress.Domain, err)
+		}
 		return nil, fmt.Errorf("PayLightningAddress: %w", err)
 	}
-
-	var payment Payment
-	if err := json.Unmarshal(respData, &payment); err != nil {
-		return nil, fmt.Errorf("PayLightningAddress: invalid response JSON: %w", err)
-	}
-	return &payment, nil
+	return wallet.payLNURL(ctx, "PayLightningAddress", lnAddress.LNURL(), params, amount, description, opts.Comment)
 }
 
 // PayVariableInvoice executes a payment to a given variable-amount lightning invoice.
@@ -477,6 +1279,10 @@ func (wallet *Wallet) PayLightningAddress(
 // Returns an error wrapping [ErrFixedAmount] if the invoice specifies a fixed amount.
 // In this case, you should use [Wallet.PayInvoice].
 //
+// Returns an error wrapping [ErrInvalidAmount] if amount is outside the range
+// permitted by the invoice. See [DecodedInvoice.MinAmount], or [Wallet.AmountBounds]
+// for the range to offer in an amount picker.
+//
 // To estimate fees, use [Wallet.FeeEstimate] or [Reader.FeeEstimate].
 func (wallet *Wallet) PayVariableInvoice(
 	ctx context.Context,
@@ -484,24 +1290,39 @@ func (wallet *Wallet) PayVariableInvoice(
 	description string,
 	amount float64,
 ) (*Payment, error) {
-	_, err := parseInvoiceAmount(invoice)
-	if err != nil && !errors.Is(err, ErrNoAmount) {
+	decoded, err := DecodeInvoice(invoice)
+	if err != nil {
 		return nil, fmt.Errorf("PayVariableInvoice: %w", err)
-	} else if err == nil {
+	} else if decoded.Amount != 0 {
+		return nil, fmt.Errorf("PayVariableInvoice: %w", ErrFixedAmount)
+	} else if err := decoded.CheckAmount(amount); err != nil {
 		return nil, fmt.Errorf("PayVariableInvoice: %w", err)
 	}
 
-	return wallet.newPayment(ctx, "PayInvoice", sendPaymentRequest{
+	payment, err := wallet.newPayment(ctx, "PayInvoice", sendPaymentRequest{
 		Address:     invoice,
 		Currency:    "LIGHTNING",
 		Description: description,
 		Amount:      amount,
 	})
+	if err != nil {
+		return nil, err
+	}
+	if warning := decoded.riskWarning(nil); warning != nil {
+		payment.Warnings = append(payment.Warnings, *warning)
+	}
+	return payment, nil
 }
 
 // PayOnChain executes an on-chain payment transaction, paying amount to the given address.
 // The description is stored in the WoS payment history.
 //
+// Returns an error wrapping [ErrUnsupportedRegion] if WoS does not offer on-chain
+// withdrawals in the wallet's region, or [ErrWrongNetwork] if the address is for a
+// network other than mainnet, such as testnet. Returns an error wrapping
+// [ErrAmountTooSmall] without contacting WoS if amount is below the on-chain minimum
+// cached by [Reader.OnChainMinimum], or [DefaultMinOnChainAmount] if not yet fetched.
+//
 // To estimate fees, use [Wallet.FeeEstimate] or [Reader.FeeEstimate].
 func (wallet *Wallet) PayOnChain(
 	ctx context.Context,
@@ -509,6 +1330,19 @@ func (wallet *Wallet) PayOnChain(
 	amount float64,
 	description string,
 ) (*Payment, error) {
+	if err := checkAddressNetwork(address); err != nil {
+		return nil, fmt.Errorf("PayOnChain: %w", err)
+	}
+	if minAmount := wallet.reader.minOnChainAmount(); amount < minAmount {
+		return nil, fmt.Errorf(
+			"PayOnChain: %w: %.8f BTC is below the on-chain minimum of %.8f BTC",
+			ErrAmountTooSmall, amount, minAmount,
+		)
+	}
+	if err := wallet.checkSafeOnChainPayment(ctx, address, amount); err != nil {
+		return nil, fmt.Errorf("PayOnChain: %w", err)
+	}
+
 	return wallet.newPayment(ctx, "PayOnChain", sendPaymentRequest{
 		Address:     address,
 		Currency:    "BTC",
@@ -517,6 +1351,96 @@ func (wallet *Wallet) PayOnChain(
 	})
 }
 
+// ErrNothingToSweep is returned by sweeps when the wallet's confirmed balance is empty, or
+// too small to cover fees, such as when another sweep has already emptied the wallet.
+var ErrNothingToSweep = errors.New("balance already swept: nothing left to sweep")
+
+// ErrBalanceTooSmallForOnChain is returned by [Wallet.SweepOnChain] when the confirmed
+// balance is too small to pay the on-chain fixed fee and commission, or would leave an
+// uneconomical dust output. Lightning fees are usually far smaller, so such a balance
+// can often still be swept with [Wallet.SweepLightning]; see [Wallet.ViableSweepMethod].
+var ErrBalanceTooSmallForOnChain = errors.New("balance too small to sweep on-chain; sweep it over lightning instead")
+
+// SweepOptions customizes the behavior of [Wallet.SweepLightningWith] and
+// [Wallet.SweepOnChainWith].
+type SweepOptions struct {
+	// ZeroOut asks the sweep to verify the wallet was left with exactly zero balance.
+	//
+	// WoS has been observed to leave a tiny residual balance (typically a single satoshi)
+	// after a sweep, because the fee is reserved up front and the amount is rounded before
+	// the actual fee is known. WoS does not permit sending an amount smaller than the fees
+	// it charges, and a swept invoice cannot be paid twice, so this residual usually cannot
+	// be swept in a follow-up payment.
+	//
+	// When ZeroOut is set, the confirmed balance is re-read after the sweep completes,
+	// and any leftover amount is reported in [SweepResult.Residual].
+	ZeroOut bool
+
+	// FeeEstimate is an optional fee estimate to use for the sweep, instead of
+	// fetching a fresh one. It must have been computed for the sweep's destination,
+	// or else the sweep fails with an error wrapping [ErrFeeEstimateMismatch].
+	FeeEstimate *FeeEstimate
+
+	// DustThreshold is the smallest BTC amount an on-chain sweep will send. If the
+	// balance left after fees is smaller, the sweep fails with an error wrapping
+	// [ErrAmountBelowDust] rather than creating an output the bitcoin network may
+	// refuse to relay. Defaults to [DustLimit]. Ignored for lightning sweeps.
+	DustThreshold float64
+
+	// ReadAttempts caps the number of attempts to read the balance and fee estimate
+	// before sweeping, retrying transient failures such as network errors, rate limits
+	// and server errors with exponential backoff. Defaults to 3 if zero; set it to 1
+	// to disable retries. The sweep payment itself is never retried, since a payment
+	// which failed with an unknown outcome may still have been sent.
+	ReadAttempts int
+}
+
+// sweepReadRetryDelay is the delay before the first retry of a sweep's balance and
+// fee reads, doubling on each further retry.
+const sweepReadRetryDelay = 500 * time.Millisecond
+
+// SweepResult describes the outcome of a sweep.
+type SweepResult struct {
+	// Payment is the payment which swept the wallet's balance.
+	Payment *Payment
+
+	// Amount is the amount the sweep asked WoS to send, after deducting fees.
+	Amount float64
+
+	// Residual is the confirmed balance left in the wallet after the sweep. It is
+	// only measured if [SweepOptions.ZeroOut] is set, and is zero otherwise.
+	Residual float64
+
+	// ExpectedResidual is the confirmed balance the sweep expected to leave behind,
+	// computed before it was sent. For on-chain sweeps, this is the fraction of a
+	// satoshi left over after fees, as on-chain amounts are whole satoshis. It is
+	// zero for lightning sweeps.
+	ExpectedResidual float64
+
+	// ReservedFee is the fee the sweep set aside from the balance before sending: the
+	// maximum lightning fee for lightning sweeps, or the fixed fee plus commission for
+	// on-chain sweeps.
+	ReservedFee float64
+
+	// ActualFee is the fee WoS actually charged for the sweep, as reported by the
+	// payment if WoS includes a fee, or else measured from the balance left behind if
+	// [SweepOptions.ZeroOut] is set. It is -1 if the fee could not be determined.
+	ActualFee float64
+}
+
+// UnusedReserve returns how much of the reserved fee WoS did not charge, which was
+// left behind in the wallet. Reserving too much is what leaves lightning sweeps
+// unable to send the last few satoshis; a follow-up sweep to a fresh invoice may
+// recover the remainder, if it exceeds the fee that sweep would reserve in turn.
+// Returns zero if the actual fee is unknown, and a negative amount if WoS charged
+// more than was reserved.
+func (result *SweepResult) UnusedReserve() float64 {
+	if result.ActualFee < 0 {
+		return 0
+	}
+	return result.ReservedFee - result.ActualFee
+}
+
 // SweepLightning executes a lightning payment, sweeping the entire available lightning balance
 // to a given variable-amount invoice. The description is stored in the WoS payment history.
 //
@@ -524,56 +1448,320 @@ func (wallet *Wallet) PayOnChain(
 //
 // Returns an error wrapping [ErrFixedAmount] if the invoice embeds a fixed amount.
 func (wallet *Wallet) SweepLightning(ctx context.Context, invoice, description string) (*Payment, error) {
+	result, err := wallet.SweepLightningWith(ctx, invoice, description, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.Payment, nil
+}
+
+// SweepLightningWith is like [Wallet.SweepLightning], but accepts [SweepOptions] to
+// customize the sweep, and returns a detailed [SweepResult]. opts can be nil.
+//
+// Sweeps of the same [Wallet] are serialized: if another sweep is in progress, this
+// waits for it to finish. If it emptied the wallet, an error wrapping [ErrNothingToSweep]
+// is returned.
+//
+// The sweep reserves the maximum lightning fee up front, which WoS may not charge in
+// full. The result reports the fee reserved and, where it can be determined, the fee
+// actually charged; see [SweepResult.UnusedReserve].
+func (wallet *Wallet) SweepLightningWith(
+	ctx context.Context,
+	invoice, description string,
+	opts *SweepOptions,
+) (*SweepResult, error) {
+	if opts == nil {
+		opts = &SweepOptions{}
+	}
+
 	if _, err := parseInvoiceAmount(invoice); !errors.Is(err, ErrNoAmount) {
 		return nil, fmt.Errorf("SweepLightning: %w", ErrFixedAmount)
 	}
 
-	balance, fees, err := wallet.reader.BalanceAndFee(ctx, invoice)
+	wallet.sweepMu.Lock()
+	defer wallet.sweepMu.Unlock()
+
+	balance, fees, err := wallet.sweepBalanceAndFee(ctx, invoice, opts)
 	if err != nil {
 		return nil, fmt.Errorf("SweepLightning: %w", err)
 	}
 
-	return wallet.newPayment(ctx, "SweepLightning", sendPaymentRequest{
+	amount := balance.Confirmed - fees.MaxLightningFee
+	if amount <= 0 {
+		return nil, fmt.Errorf(
+			"SweepLightning: %w: confirmed balance (%.8f) does not cover max fee (%.8f)",
+			ErrNothingToSweep, balance.Confirmed, fees.MaxLightningFee,
+		)
+	}
+	payment, err := wallet.newPayment(ctx, "SweepLightning", sendPaymentRequest{
 		Address:      invoice,
 		Currency:     "LIGHTNING",
 		Description:  description,
 		MaxLightning: true,
-		Amount:       balance.Confirmed - fees.MaxLightningFee,
+		Amount:       amount,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet.sweepResult(ctx, opts, payment, balance, amount, fees.MaxLightningFee), nil
 }
 
 // SweepOnChain executes an on-chain payment transaction, sweeping the entire available wallet
 // balance to a given on-chain address. The description is stored in the WoS payment history.
+//
+// If fees make up an unusually large part of the sweep, the payment is still sent, and
+// a [WarningHighFee] is added to [Payment.Warnings].
+//
+// Returns an error wrapping [ErrWrongNetwork] if the address is for a network
+// other than mainnet, such as testnet.
+//
+// Returns an error wrapping [ErrBalanceTooSmallForOnChain] if the balance cannot cover
+// the on-chain fees, in which case it may still be swept over lightning. The error also
+// wraps [ErrNothingToSweep], since a previous on-chain sweep leaves such a balance behind.
 func (wallet *Wallet) SweepOnChain(ctx context.Context, address, description string) (*Payment, error) {
-	balance, fees, err := wallet.reader.BalanceAndFee(ctx, address)
+	result, err := wallet.SweepOnChainWith(ctx, address, description, nil)
 	if err != nil {
+		return nil, err
+	}
+	return result.Payment, nil
+}
+
+// SweepOnChainWith is like [Wallet.SweepOnChain], but accepts [SweepOptions] to
+// customize the sweep, and returns a detailed [SweepResult]. opts can be nil.
+//
+// Like [Wallet.SweepLightningWith], sweeps are serialized, and an error wrapping
+// [ErrNothingToSweep] is returned if the wallet has already been emptied, including
+// when all that is left is the [SweepResult.ExpectedResidual] of an earlier sweep.
+//
+// The amount sent is the confirmed balance, less the fixed fee and the commission,
+// rounded down to a whole satoshi. WoS pays the miner fee from the fixed fee, and sends
+// from its own pooled funds, so the wallet has no change output of its own; WoS has
+// been observed to debit exactly the amount plus fees, leaving only the remainder of
+// the rounding, which is reported in [SweepResult.ExpectedResidual].
+func (wallet *Wallet) SweepOnChainWith(
+	ctx context.Context,
+	address, description string,
+	opts *SweepOptions,
+) (*SweepResult, error) {
+	if opts == nil {
+		opts = &SweepOptions{}
+	}
+	if err := checkAddressNetwork(address); err != nil {
+		return nil, fmt.Errorf("SweepOnChain: %w", err)
+	} else if err := wallet.checkSafeOnChainAddress(address); err != nil {
 		return nil, fmt.Errorf("SweepOnChain: %w", err)
 	}
 
-	availableBalance := balance.Confirmed - fees.BtcFixedFee
-	if availableBalance < 0 {
-		return nil, fmt.Errorf(
-			"confirmed balance (%.8f) insufficient for fixed fee (%.8f)",
-			balance.Confirmed,
-			fees.BtcFixedFee,
-		)
+	wallet.sweepMu.Lock()
+	defer wallet.sweepMu.Unlock()
+
+	balance, fees, err := wallet.sweepBalanceAndFee(ctx, address, opts)
+	if err != nil {
+		return nil, fmt.Errorf("SweepOnChain: %w", err)
+	} else if balance.Confirmed <= 0 {
+		return nil, fmt.Errorf("SweepOnChain: %w", ErrNothingToSweep)
 	}
 
-	commission := fees.BtcSendCommissionPercent * balance.Confirmed
-	amount := availableBalance - commission
-	if amount <= 0 {
-		return nil, fmt.Errorf(
-			"available balance (%.8f) insufficient for commission (%.8f)",
-			availableBalance,
-			commission,
-		)
+	amount, exact, err := onChainSweepAmount(balance, fees, opts.DustThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("SweepOnChain: %w: %w", ErrNothingToSweep, err)
+	}
+	commission := fees.CommissionOn(balance.Confirmed)
+	if err := wallet.checkSafeFee(fees, amount, fees.BtcFixedFee+commission); err != nil {
+		return nil, fmt.Errorf("SweepOnChain: %w", err)
 	}
 
-	return wallet.newPayment(ctx, "SweepOnChain", sendPaymentRequest{
+	payment, err := wallet.newPayment(ctx, "SweepOnChain", sendPaymentRequest{
 		Address:     address,
 		Currency:    "BTC",
 		Description: description,
 		MaxBitcoin:  true,
 		Amount:      amount,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if warning := highFeeWarning(fees, amount, fees.BtcFixedFee+commission); warning != nil {
+		payment.Warnings = append(payment.Warnings, *warning)
+	}
+	result := wallet.sweepResult(ctx, opts, payment, balance, amount, fees.BtcFixedFee+commission)
+	result.ExpectedResidual = math.Max(exact-amount, 0)
+	return result, nil
+}
+
+// onChainSweepAmount returns the whole-satoshi amount an on-chain sweep of the confirmed
+// balance can send after fees, along with the exact amount before rounding. Returns an
+// error wrapping [ErrBalanceTooSmallForOnChain] if the balance does not cover the fees,
+// or if what is left is smaller than dustThreshold, in which case the error also wraps
+// [ErrAmountBelowDust]. A dustThreshold of zero means [DustLimit].
+func onChainSweepAmount(balance *Balance, fees *FeeEstimate, dustThreshold float64) (amount, exact float64, err error) {
+	availableBalance := balance.Confirmed - fees.BtcFixedFee
+	if availableBalance < 0 {
+		return 0, 0, fmt.Errorf(
+			"%w: confirmed balance (%.8f) insufficient for fixed fee (%.8f)",
+			ErrBalanceTooSmallForOnChain, balance.Confirmed, fees.BtcFixedFee,
+		)
+	}
+
+	commission := fees.CommissionOn(balance.Confirmed)
+	exact = availableBalance - commission
+	if exact <= 0 {
+		return 0, 0, fmt.Errorf(
+			"%w: available balance (%.8f) insufficient for commission (%.8f)",
+			ErrBalanceTooSmallForOnChain, availableBalance, commission,
+		)
+	}
+
+	// On-chain outputs are whole satoshis. Allow for floating point error, so that
+	// an amount which is a whole number of satoshis is not rounded down by one.
+	amount = math.Floor(exact*100_000_000+1e-6) / 100_000_000
+
+	if dustThreshold <= 0 {
+		dustThreshold = DustLimit
+	}
+	if amount < dustThreshold {
+		return 0, 0, fmt.Errorf(
+			"%w: %w: %.8f BTC after fees is less than %.8f BTC",
+			ErrBalanceTooSmallForOnChain, ErrAmountBelowDust, amount, dustThreshold,
+		)
+	}
+	return amount, exact, nil
+}
+
+// ViableSweepMethod returns the cheapest way to sweep the wallet's confirmed balance
+// which is viable given the current fees: [PaymentCurrencyBitcoin] if an on-chain sweep
+// to address would send at least [DustLimit] after fees, or else [PaymentCurrencyLightning]
+// if the balance covers the maximum lightning fee. Callers can then use
+// [Wallet.SweepOnChain] or [Wallet.SweepLightning] accordingly.
+//
+// Returns an error wrapping [ErrNothingToSweep] if the balance is too small for either.
+func (wallet *Wallet) ViableSweepMethod(ctx context.Context, address string) (PaymentCurrency, error) {
+	if err := checkAddressNetwork(address); err != nil {
+		return "", fmt.Errorf("ViableSweepMethod: %w", err)
+	}
+
+	balance, fees, err := wallet.sweepBalanceAndFee(ctx, address, &SweepOptions{})
+	if err != nil {
+		return "", fmt.Errorf("ViableSweepMethod: %w", err)
+	} else if balance.Confirmed <= 0 {
+		return "", fmt.Errorf("ViableSweepMethod: %w", ErrNothingToSweep)
+	}
+
+	if _, _, err := onChainSweepAmount(balance, fees, 0); err == nil {
+		return PaymentCurrencyBitcoin, nil
+	} else if balance.Confirmed > fees.MaxLightningFee {
+		return PaymentCurrencyLightning, nil
+	}
+	return "", fmt.Errorf(
+		"ViableSweepMethod: %w: confirmed balance (%.8f) does not cover on-chain or lightning fees",
+		ErrNothingToSweep, balance.Confirmed,
+	)
+}
+
+// sweepBalanceAndFee fetches the balance and fee estimate needed to sweep to the
+// given destination, using the caller's fee estimate if one was provided. Transient
+// failures are retried up to opts.ReadAttempts times. Only reads happen here, so
+// retrying can never cause a second sweep.
+func (wallet *Wallet) sweepBalanceAndFee(
+	ctx context.Context,
+	destination string,
+	opts *SweepOptions,
+) (*Balance, *FeeEstimate, error) {
+	attempts := opts.ReadAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	clock := clockOrDefault(wallet.clock)
+	delay := sweepReadRetryDelay
+	for attempt := 1; ; attempt++ {
+		balance, fees, err := wallet.readBalanceAndFee(ctx, destination, opts)
+		if err == nil || attempt >= attempts || !isTransientReadError(err) {
+			return balance, fees, err
+		}
+
+		select {
+		case <-clock.After(delay):
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		}
+		delay *= 2
+	}
+}
+
+// isTransientReadError returns true if a failed read may succeed when retried:
+// network errors, rate limits and server errors. Like [isTransientCreateError], anything
+// else, such as an invalid response or a closed wallet, is assumed to fail every time.
+func isTransientReadError(err error) bool {
+	// A context deadline is reported as a net.Error too.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// readBalanceAndFee makes one attempt at fetching the balance and fee estimate
+// needed to sweep to the given destination.
+func (wallet *Wallet) readBalanceAndFee(
+	ctx context.Context,
+	destination string,
+	opts *SweepOptions,
+) (*Balance, *FeeEstimate, error) {
+	if opts.FeeEstimate == nil {
+		return wallet.reader.BalanceAndFee(ctx, destination)
+	}
+
+	if err := opts.FeeEstimate.CheckDestination(destination); err != nil {
+		return nil, nil, err
+	}
+
+	balance, err := wallet.reader.Balance(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return balance, opts.FeeEstimate, nil
+}
+
+// sweepResult builds the result of a completed sweep of the given balance, which
+// reserved reservedFee. The actual fee is taken from the payment if WoS reported it,
+// and the residual balance is measured if the caller asked for it, from which the
+// actual fee is otherwise derived.
+func (wallet *Wallet) sweepResult(
+	ctx context.Context,
+	opts *SweepOptions,
+	payment *Payment,
+	balance *Balance,
+	amount, reservedFee float64,
+) *SweepResult {
+	result := &SweepResult{
+		Payment:     payment,
+		Amount:      amount,
+		ReservedFee: reservedFee,
+		ActualFee:   -1,
+	}
+	if payment.Fee > 0 {
+		result.ActualFee = payment.Fee
+	}
+
+	if opts.ZeroOut {
+		// The sweep has already been sent, so failing to measure the residual must
+		// not be reported as a failure of the sweep itself.
+		if after, err := wallet.reader.Balance(ctx); err == nil {
+			result.Residual = after.Confirmed
+			if result.ActualFee < 0 {
+				// Round to whole millisatoshis to cancel out floating point error.
+				fee := balance.Confirmed - after.Confirmed - amount
+				result.ActualFee = math.Max(math.Round(fee*100_000_000_000)/100_000_000_000, 0)
+			}
+		}
+	}
+
+	return result
 }
@@ -0,0 +1,1235 @@
+package wos
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPostRequestRaw(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Signature") == "" || r.Header.Get("Nonce") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Request-Id", "abc123")
+		w.Write([]byte(`{"id":"xyz"}`))
+	})
+
+	resp, err := wallet.PostRequestRaw(context.Background(), "/api/v1/wallet/createInvoice", map[string]any{})
+	if err != nil {
+		t.Fatalf("PostRequestRaw failed: %v", err)
+	} else if id := resp.Header.Get("X-Request-Id"); id != "abc123" {
+		t.Fatalf("expected header to be accessible, got %q", id)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read buffered body: %v", err)
+	} else if string(body) != `{"id":"xyz"}` {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+func TestSweepOnChainZeroOutResidual(t *testing.T) {
+	balanceCalls := 0
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			balanceCalls++
+			if balanceCalls == 1 {
+				w.Write([]byte(`{"btc":0.0001,"btcUnconfirmed":0}`))
+			} else {
+				w.Write([]byte(`{"btc":0.00000001,"btcUnconfirmed":0}`))
+			}
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00001,"btcSendCommissionPercent":0.01}`))
+		case "/api/v1/wallet/payment":
+			w.Write([]byte(`{"id":"p1","status":"PENDING","currency":"BTC"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	result, err := wallet.SweepOnChainWith(context.Background(), "bc1qdest", "", &SweepOptions{ZeroOut: true})
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	}
+
+	if result.Payment.ID != "p1" {
+		t.Fatalf("unexpected payment: %+v", result.Payment)
+	} else if want := 0.0001 - 0.00001 - 0.000001; math.Abs(result.Amount-want) > 1e-12 {
+		t.Fatalf("expected sweep amount %.8f, got %.8f", want, result.Amount)
+	} else if result.Residual != 0.00000001 {
+		t.Fatalf("expected residual of 1 sat, got %.8f", result.Residual)
+	}
+}
+
+func TestSweepOnChainHighFeeWarning(t *testing.T) {
+	balance := "0.0001"
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":` + balance + `}`))
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00001,"btcSendCommissionPercent":0.01,"btcSendFeeWarningPercent":0.05}`))
+		case "/api/v1/wallet/payment":
+			w.Write([]byte(`{"id":"p1","status":"PENDING","currency":"BTC"}`))
+		}
+	})
+
+	// Fees are 0.00001 + 0.000001 on 0.000089 BTC sent: over 12%.
+	payment, err := wallet.SweepOnChain(context.Background(), "bc1qdest", "")
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	} else if len(payment.Warnings) != 1 || payment.Warnings[0].Code != WarningHighFee {
+		t.Fatalf("expected a WarningHighFee, got %v", payment.Warnings)
+	} else if !errors.Is(payment.Warnings[0], ErrHighFee) {
+		t.Fatalf("expected warning to wrap ErrHighFee")
+	}
+
+	balance = "0.01"
+	payment, err = wallet.SweepOnChain(context.Background(), "bc1qdest", "")
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	} else if len(payment.Warnings) != 0 {
+		t.Fatalf("expected no warnings, got %v", payment.Warnings)
+	}
+}
+
+func TestNewInvoiceClampsExpiry(t *testing.T) {
+	var requested createInvoiceRequest
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		requested = createInvoiceRequest{}
+		json.NewDecoder(r.Body).Decode(&requested)
+		w.Write([]byte(`{"id":"inv1","invoice":"lnbc1","btcAmount":0.0001}`))
+	})
+
+	invoice, err := wallet.NewInvoice(context.Background(), &InvoiceOptions{
+		Amount: 0.0001,
+		Expiry: 30 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	}
+
+	if requested.Expiry != uint(MaxInvoiceExpiry.Seconds()) {
+		t.Fatalf("expected expiry to be clamped to %d seconds, got %d", uint(MaxInvoiceExpiry.Seconds()), requested.Expiry)
+	} else if len(invoice.Warnings) != 1 || invoice.Warnings[0].Code != WarningExpiryClamped ||
+		!errors.Is(invoice.Warnings[0], ErrExpiryClamped) {
+		t.Fatalf("expected ErrExpiryClamped warning, got %v", invoice.Warnings)
+	}
+
+	invoice, err = wallet.NewInvoice(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	} else if requested.Expiry != 0 {
+		t.Fatalf("expected default expiry to be left to the server, got %d", requested.Expiry)
+	} else if len(invoice.Warnings) != 0 {
+		t.Fatalf("expected no warnings, got %v", invoice.Warnings)
+	}
+}
+
+func TestSweepLightningActualFee(t *testing.T) {
+	var paymentResp string
+	balanceCalls := 0
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			balanceCalls++
+			if balanceCalls%2 == 1 {
+				w.Write([]byte(`{"btc":0.001}`))
+			} else {
+				w.Write([]byte(`{"btc":0.000007}`))
+			}
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"sendMaxLightningFee":0.00001}`))
+		case "/api/v1/wallet/payment":
+			w.Write([]byte(paymentResp))
+		}
+	})
+	ctx := context.Background()
+
+	// The fee is reported by the payment.
+	paymentResp = `{"id":"p1","status":"PAID","fee":0.000002}`
+	result, err := wallet.SweepLightningWith(ctx, testInvoiceDonation, "", nil)
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	} else if result.ReservedFee != 0.00001 || result.ActualFee != 0.000002 {
+		t.Fatalf("expected reserved 0.00001 and actual 0.000002, got %.8f and %.8f", result.ReservedFee, result.ActualFee)
+	} else if math.Abs(result.UnusedReserve()-0.000008) > 1e-12 {
+		t.Fatalf("expected unused reserve of 800 sats, got %.8f", result.UnusedReserve())
+	}
+	balanceCalls = 0
+
+	// The fee is measured from the balance left behind: 100000 sats, less 99000 sent,
+	// leaves 700 sats, so 300 sats were charged.
+	paymentResp = `{"id":"p2","status":"PAID"}`
+	result, err = wallet.SweepLightningWith(ctx, testInvoiceDonation, "", &SweepOptions{ZeroOut: true})
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	} else if result.Residual != 0.000007 {
+		t.Fatalf("expected residual of 700 sats, got %.8f", result.Residual)
+	} else if result.ActualFee != 0.000003 {
+		t.Fatalf("expected measured fee of 300 sats, got %.11f", result.ActualFee)
+	} else if math.Abs(result.UnusedReserve()-0.000007) > 1e-12 {
+		t.Fatalf("expected unused reserve of 700 sats, got %.8f", result.UnusedReserve())
+	}
+
+	// Without ZeroOut, an unreported fee is unknown.
+	result, err = wallet.SweepLightningWith(ctx, testInvoiceDonation, "", nil)
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	} else if result.ActualFee != -1 || result.UnusedReserve() != 0 {
+		t.Fatalf("expected unknown fee, got %.8f", result.ActualFee)
+	}
+}
+
+func TestSweepLightningRejectsMismatchedFeeEstimate(t *testing.T) {
+	var payments int
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":0.001}`))
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"sendMaxLightningFee":0.00001}`))
+		case "/api/v1/wallet/payment":
+			payments++
+			w.Write([]byte(`{"id":"p1","status":"PAID"}`))
+		}
+	})
+
+	ctx := context.Background()
+	estimate, err := wallet.FeeEstimate(ctx, testInvoiceDonation)
+	if err != nil {
+		t.Fatalf("FeeEstimate failed: %v", err)
+	}
+
+	otherInvoice := buildTestInvoice(t, "lnbc", 1700000000)
+	_, err = wallet.SweepLightningWith(ctx, otherInvoice, "", &SweepOptions{FeeEstimate: estimate})
+	if !errors.Is(err, ErrFeeEstimateMismatch) {
+		t.Fatalf("expected ErrFeeEstimateMismatch, got %v", err)
+	} else if payments != 0 {
+		t.Fatalf("expected no payment to be attempted")
+	}
+
+	result, err := wallet.SweepLightningWith(ctx, testInvoiceDonation, "", &SweepOptions{FeeEstimate: estimate})
+	if err != nil {
+		t.Fatalf("sweep with matching estimate failed: %v", err)
+	} else if math.Abs(result.Amount-0.00099) > 1e-12 {
+		t.Fatalf("unexpected sweep amount %.8f", result.Amount)
+	}
+}
+
+func TestWalletAddressTTL(t *testing.T) {
+	var fetches atomic.Int32
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write([]byte(`{"btcDepositAddress":"bc1qfresh","lightningAddress":"user@walletofsatoshi.com"}`))
+	})
+	wallet.addressesFetched = time.Now()
+
+	if addr := wallet.OnChainAddress(); addr != "bc1qexample" {
+		t.Fatalf("unexpected address %s", addr)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if wallet.OnChainAddress(); fetches.Load() != 0 {
+		t.Fatalf("expected no refresh without a TTL")
+	}
+
+	wallet.SetAddressTTL(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	wallet.OnChainAddress()
+
+	deadline := time.Now().Add(time.Second)
+	for wallet.OnChainAddress() != "bc1qfresh" {
+		if time.Now().After(deadline) {
+			t.Fatalf("address was not refreshed after TTL elapsed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if fetches.Load() < 1 {
+		t.Fatalf("expected at least one refresh request")
+	}
+}
+
+func TestPayVariableInvoiceValidatesAmount(t *testing.T) {
+	var sent sendPaymentRequest
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"id":"pay1","status":"PAID"}`))
+	})
+
+	if _, err := wallet.PayVariableInvoice(context.Background(), testInvoiceDonation, "", 0.0001); err != nil {
+		t.Fatalf("expected amountless invoice payment to succeed: %v", err)
+	} else if sent.Amount != 0.0001 || sent.Address != testInvoiceDonation {
+		t.Fatalf("unexpected payment request: %+v", sent)
+	}
+
+	if _, err := wallet.PayVariableInvoice(context.Background(), testInvoiceDonation, "", 0); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount, got %v", err)
+	}
+
+	if _, err := wallet.PayVariableInvoice(context.Background(), testInvoiceCoffee, "", 0.0025); !errors.Is(err, ErrFixedAmount) {
+		t.Fatalf("expected ErrFixedAmount, got %v", err)
+	}
+}
+
+func TestNewInvoiceDescriptionHash(t *testing.T) {
+	const cakeDescription = "One piece of chocolate cake, one icecream cone, one pickle, one slice of swiss cheese, " +
+		"one slice of salami, one lollypop, one piece of cherry pie, one sausage, one cupcake, and one slice of watermelon"
+	hash := sha256.Sum256([]byte(cakeDescription))
+
+	var requested createInvoiceRequest
+	returned := testInvoiceHashedDescription
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		requested = createInvoiceRequest{}
+		json.NewDecoder(r.Body).Decode(&requested)
+		w.Write([]byte(`{"id":"inv1","invoice":"` + returned + `"}`))
+	})
+
+	invoice, err := wallet.NewInvoice(context.Background(), &InvoiceOptions{
+		Amount:          0.02,
+		DescriptionHash: hash[:],
+	})
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	}
+	if requested.DescriptionHash != hex.EncodeToString(hash[:]) || requested.Description != "" {
+		t.Fatalf("expected description hash to be sent instead of description, got %+v", requested)
+	}
+
+	decoded, err := DecodeInvoice(invoice.Bolt11)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	if err := decoded.VerifyDescription(cakeDescription); err != nil {
+		t.Fatalf("expected description to verify: %v", err)
+	}
+	if err := decoded.VerifyDescription("one slice of cake"); !errors.Is(err, ErrDescriptionMismatch) {
+		t.Fatalf("expected ErrDescriptionMismatch, got %v", err)
+	}
+
+	returned = testInvoiceDonation
+	_, err = wallet.NewInvoice(context.Background(), &InvoiceOptions{DescriptionHash: hash[:]})
+	if !errors.Is(err, ErrDescriptionHashUnsupported) {
+		t.Fatalf("expected ErrDescriptionHashUnsupported, got %v", err)
+	}
+}
+
+func TestPayLightningAddressAmountOutOfRange(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/lnurl":
+			w.Write([]byte(`{"callback":"https://getalby.com/cb","minSendable":10000000,"maxSendable":50000000}`))
+		case "/api/v1/wallet/lnPay":
+			t.Errorf("payment should not be sent")
+		}
+	})
+	addr := LightningAddress{"bob", "getalby.com"}
+
+	for _, amount := range []float64{0.00005, 0.001} {
+		_, err := wallet.PayLightningAddress(context.Background(), addr, "", amount)
+
+		var rangeErr *ErrAmountOutOfRange
+		if !errors.As(err, &rangeErr) {
+			t.Fatalf("expected ErrAmountOutOfRange for %.8f, got %v", amount, err)
+		}
+		if rangeErr.Amount != amount || rangeErr.Min != 0.0001 || rangeErr.Max != 0.0005 {
+			t.Fatalf("unexpected range error: %+v", rangeErr)
+		}
+		if !errors.Is(err, ErrOutsideSendableRange) {
+			t.Fatalf("expected error to match ErrOutsideSendableRange")
+		}
+	}
+}
+
+func TestPayLightningAddressDomainPolicy(t *testing.T) {
+	var resolved []string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/lnurl":
+			var body struct{ Address string }
+			json.NewDecoder(r.Body).Decode(&body)
+			resolved = append(resolved, body.Address)
+			u, _ := url.Parse(body.Address)
+			fmt.Fprintf(w, `{"tag":"payRequest","callback":"https://%s/cb","minSendable":1000,"maxSendable":100000000}`, u.Host)
+		case "/api/v1/wallet/lnPay":
+			w.Write([]byte(`{"id":"pay1","status":"PAID"}`))
+		}
+	})
+	wallet.SetDomainPolicy(DomainPolicy{
+		Allow: []string{"walletofsatoshi.com", "*.example.com"},
+		Deny:  []string{"evil.example.com"},
+	})
+	ctx := context.Background()
+
+	for _, addr := range []LightningAddress{{"alice", "walletofsatoshi.com"}, {"bob", "pay.Example.com"}} {
+		if _, err := wallet.PayLightningAddress(ctx, addr, "", 0.0001); err != nil {
+			t.Fatalf("expected %s to be allowed, got %v", addr, err)
+		}
+	}
+	for _, addr := range []LightningAddress{{"carol", "getalby.com"}, {"dave", "example.com"}, {"eve", "evil.example.com"}} {
+		if _, err := wallet.PayLightningAddress(ctx, addr, "", 0.0001); !errors.Is(err, ErrDomainNotAllowed) {
+			t.Fatalf("expected ErrDomainNotAllowed for %s, got %v", addr, err)
+		}
+	}
+
+	if _, err := wallet.PayLNURL(ctx, "lnurlp://pay.example.com/tip", 0.0001); err != nil {
+		t.Fatalf("expected LNURL on an allowed domain to be paid, got %v", err)
+	}
+	if _, err := wallet.PayLNURL(ctx, "lnurlp://evil.example.com/tip", 0.0001); !errors.Is(err, ErrDomainNotAllowed) {
+		t.Fatalf("expected ErrDomainNotAllowed from PayLNURL, got %v", err)
+	}
+	if _, err := wallet.HandleLNURL(ctx, "lnurlp://getalby.com/tip", "", 0.0001); !errors.Is(err, ErrDomainNotAllowed) {
+		t.Fatalf("expected ErrDomainNotAllowed from HandleLNURL, got %v", err)
+	}
+	if len(resolved) != 3 {
+		t.Fatalf("expected only allowed domains to be resolved, got %v", resolved)
+	}
+}
+
+func TestConcurrentSweepsAreSerialized(t *testing.T) {
+	// A sweep may leave nothing, or the sub-satoshi remainder of its rounding.
+	for _, residual := range []string{"0", "0.000000005"} {
+		var swept atomic.Bool
+		var payments atomic.Int32
+		wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v1/wallet/balance":
+				if swept.Load() {
+					w.Write([]byte(`{"btc":` + residual + `}`))
+				} else {
+					w.Write([]byte(`{"btc":0.010000005}`))
+				}
+			case "/api/v1/wallet/feeEstimate":
+				w.Write([]byte(`{"btcFixedFee":0.00002}`))
+			case "/api/v1/wallet/payment":
+				payments.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				swept.Store(true)
+				w.Write([]byte(`{"id":"sweep"}`))
+			}
+		})
+
+		errs := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := wallet.SweepOnChain(context.Background(), "bc1qcold", "")
+				errs <- err
+			}()
+		}
+
+		var succeeded, alreadySwept int
+		for i := 0; i < 2; i++ {
+			err := <-errs
+			if err == nil {
+				succeeded++
+			} else if errors.Is(err, ErrNothingToSweep) {
+				alreadySwept++
+			} else {
+				t.Fatalf("residual %s: unexpected sweep error: %v", residual, err)
+			}
+		}
+
+		if succeeded != 1 || alreadySwept != 1 || payments.Load() != 1 {
+			t.Fatalf("residual %s: expected one sweep and one ErrNothingToSweep, got %d and %d (%d payments)",
+				residual, succeeded, alreadySwept, payments.Load())
+		}
+	}
+}
+
+func TestInvoiceExpiryTimer(t *testing.T) {
+	clock := newFakeClock()
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		expires := clock.Now().Add(2500 * time.Millisecond).Format(time.RFC3339Nano)
+		w.Write([]byte(`{"id":"abc","expires":"` + expires + `"}`))
+	})
+	wallet.SetClock(clock)
+
+	invoice, err := wallet.NewInvoice(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	}
+
+	var countdown []time.Duration
+	for remaining := range invoice.ExpiryTimer(context.Background()) {
+		countdown = append(countdown, remaining)
+	}
+
+	expected := []time.Duration{2500 * time.Millisecond, 1500 * time.Millisecond, 500 * time.Millisecond, 0}
+	if len(countdown) != len(expected) {
+		t.Fatalf("expected countdown %v, got %v", expected, countdown)
+	}
+	for i := range expected {
+		if countdown[i] != expected[i] {
+			t.Fatalf("expected countdown %v, got %v", expected, countdown)
+		}
+	}
+
+	// An already-expired invoice emits zero and closes.
+	countdown = nil
+	for remaining := range invoice.ExpiryTimer(context.Background()) {
+		countdown = append(countdown, remaining)
+	}
+	if len(countdown) != 1 || countdown[0] != 0 {
+		t.Fatalf("expected expired invoice to emit only zero, got %v", countdown)
+	}
+}
+
+func TestInvoiceExpiryTimerCancel(t *testing.T) {
+	invoice := &Invoice{Expires: time.Now().Add(time.Hour)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := invoice.ExpiryTimer(ctx)
+	if remaining := <-timer; remaining <= 59*time.Minute {
+		t.Fatalf("unexpected remaining time: %s", remaining)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-timer:
+		if ok {
+			// A tick may have raced with the cancellation; the next must be the close.
+			if _, ok := <-timer; ok {
+				t.Fatalf("expected timer to close after cancellation")
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timer did not close after cancellation")
+	}
+}
+
+func TestPayInvoiceOverpay(t *testing.T) {
+	var sent sendPaymentRequest
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"id":"payment"}`))
+	})
+	ctx := context.Background()
+
+	if _, err := wallet.PayInvoice(ctx, testInvoiceCoffee, ""); err != nil {
+		t.Fatalf("PayInvoice failed: %v", err)
+	} else if sent.Amount != 0.0025 {
+		t.Fatalf("expected exact amount 0.0025, sent %.8f", sent.Amount)
+	}
+
+	opts := &PayInvoiceOptions{Overpay: 0.0005, MaxOverpay: 0.001}
+	if _, err := wallet.PayInvoiceWith(ctx, testInvoiceCoffee, "", opts); err != nil {
+		t.Fatalf("PayInvoiceWith failed: %v", err)
+	} else if math.Abs(sent.Amount-0.003) > 1e-12 {
+		t.Fatalf("expected overpaid amount 0.003, sent %.8f", sent.Amount)
+	}
+
+	sent = sendPaymentRequest{}
+	opts = &PayInvoiceOptions{Overpay: 0.002, MaxOverpay: 0.001}
+	if _, err := wallet.PayInvoiceWith(ctx, testInvoiceCoffee, "", opts); !errors.Is(err, ErrOverpayNotAllowed) {
+		t.Fatalf("expected ErrOverpayNotAllowed, got %v", err)
+	}
+	if _, err := wallet.PayInvoiceWith(ctx, testInvoiceCoffee, "", &PayInvoiceOptions{Overpay: 0.0001}); !errors.Is(err, ErrOverpayNotAllowed) {
+		t.Fatalf("expected overpay without a cap to be rejected, got %v", err)
+	}
+
+	// BOLT11 forbids paying more than twice the invoice amount, whatever the cap.
+	opts = &PayInvoiceOptions{Overpay: 0.003, MaxOverpay: 1}
+	if _, err := wallet.PayInvoiceWith(ctx, testInvoiceCoffee, "", opts); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount, got %v", err)
+	}
+	if sent.Address != "" {
+		t.Fatalf("rejected overpayments should not be sent")
+	}
+}
+
+func TestWalletClose(t *testing.T) {
+	started := make(chan struct{})
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := wallet.Balance(context.Background())
+		errs <- err
+	}()
+
+	<-started
+	if err := wallet.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrWalletClosed) {
+			t.Fatalf("expected in-flight request to fail with ErrWalletClosed, got %v", err)
+		} else if errors.Is(err, context.Canceled) {
+			t.Fatalf("expected ErrWalletClosed instead of a generic context error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("in-flight request was not cancelled by Close")
+	}
+
+	if _, err := wallet.PayInvoice(context.Background(), testInvoiceCoffee, ""); !errors.Is(err, ErrWalletClosed) {
+		t.Fatalf("expected requests after Close to fail with ErrWalletClosed, got %v", err)
+	}
+	if err := wallet.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+}
+
+func TestCredentialsLightningAddress(t *testing.T) {
+	creds := Credentials{APIToken: "token", APISecret: "secret"}
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/wallet/account" || r.Header.Get("Api-Token") != "token" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		// No on-chain address, as in regions where on-chain is unavailable.
+		w.Write([]byte(`{"btcDepositAddress":"","lightningAddress":"satoshi@walletofsatoshi.com"}`))
+	})
+
+	lnAddress, err := creds.LightningAddress(context.Background(), httpClient)
+	if err != nil {
+		t.Fatalf("LightningAddress failed: %v", err)
+	} else if lnAddress != (LightningAddress{"satoshi", "walletofsatoshi.com"}) {
+		t.Fatalf("unexpected lightning address: %+v", lnAddress)
+	}
+}
+
+func TestPayLightningAddressIdempotency(t *testing.T) {
+	var history []Payment
+	var callback string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/payment":
+			json.NewEncoder(w).Encode(history)
+		case "/api/v1/wallet/lnurl":
+			w.Write([]byte(`{"tag":"payRequest","callback":"https://getalby.com/cb","minSendable":1000,"maxSendable":100000000,"commentAllowed":32}`))
+		case "/api/v1/wallet/lnPay":
+			var body struct {
+				Callback    string `json:"callback"`
+				Description string `json:"description"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			callback = body.Callback
+
+			payment := Payment{ID: "original", Type: PaymentTypeDebit, Description: body.Description, Time: time.Now()}
+			history = append(history, payment)
+			// The response is lost, as on a flaky network.
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+	addr := LightningAddress{"bob", "getalby.com"}
+	opts := &PayLightningAddressOptions{IdempotencyKey: "order-1234", Comment: "thanks!"}
+
+	if _, err := wallet.PayLightningAddressWith(context.Background(), addr, "coffee", 0.0001, opts); err == nil {
+		t.Fatalf("expected first attempt to fail")
+	}
+	if callback != "https://getalby.com/cb?comment=thanks%21" {
+		t.Fatalf("expected comment in callback, got %q", callback)
+	}
+
+	payment, err := wallet.PayLightningAddressWith(context.Background(), addr, "coffee", 0.0001, opts)
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	} else if payment.ID != "original" || payment.Description != "coffee [ref:order-1234]" {
+		t.Fatalf("expected retry to return the original payment, got %+v", payment)
+	} else if len(history) != 1 {
+		t.Fatalf("expected a single payment, got %d", len(history))
+	}
+
+	opts = &PayLightningAddressOptions{Comment: strings.Repeat("x", 33)}
+	if _, err := wallet.PayLightningAddressWith(context.Background(), addr, "", 0.0001, opts); !errors.Is(err, ErrCommentTooLong) {
+		t.Fatalf("expected ErrCommentTooLong, got %v", err)
+	}
+}
+
+func TestInvoiceDescriptionTemplate(t *testing.T) {
+	var requested createInvoiceRequest
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		requested = createInvoiceRequest{}
+		json.NewDecoder(r.Body).Decode(&requested)
+		w.Write([]byte(`{"id":"abc"}`))
+	})
+	wallet.SetClock(newFakeClock())
+
+	if err := wallet.SetInvoiceDescriptionTemplate("Order #{{.OrderRef"); err == nil {
+		t.Fatalf("expected invalid template to be rejected")
+	}
+	err := wallet.SetInvoiceDescriptionTemplate(
+		`Order #{{.OrderRef}} - Acme Store ({{printf "%.8f" .Amount}} BTC, {{.Timestamp.Format "2006-01-02"}})`,
+	)
+	if err != nil {
+		t.Fatalf("failed to set template: %v", err)
+	}
+
+	ctx := context.Background()
+	_, err = wallet.NewInvoice(ctx, &InvoiceOptions{
+		Amount:          0.0001,
+		DescriptionData: map[string]any{"OrderRef": 1234},
+	})
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	} else if want := "Order #1234 - Acme Store (0.00010000 BTC, 2024-01-01)"; requested.Description != want {
+		t.Fatalf("expected description %q, got %q", want, requested.Description)
+	}
+
+	// An explicit description takes precedence over the template.
+	if _, err := wallet.NewInvoice(ctx, &InvoiceOptions{Description: "custom", DescriptionData: map[string]any{}}); err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	} else if requested.Description != "custom" {
+		t.Fatalf("expected explicit description, got %q", requested.Description)
+	}
+
+	requested = createInvoiceRequest{Description: "unchanged"}
+	if _, err := wallet.NewInvoice(ctx, &InvoiceOptions{DescriptionData: map[string]any{}}); err == nil {
+		t.Fatalf("expected error rendering template with missing OrderRef")
+	} else if requested.Description != "unchanged" {
+		t.Fatalf("invoice should not be requested when the template fails")
+	}
+}
+
+func TestNewInvoiceMinimumAmount(t *testing.T) {
+	var limitsStatus int
+	var created int
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case limitsEndpoint:
+			if limitsStatus != 0 {
+				w.WriteHeader(limitsStatus)
+				return
+			}
+			w.Write([]byte(`{"minInvoiceAmount":0.00001}`))
+		case "/api/v1/wallet/createInvoice":
+			created++
+			w.Write([]byte(`{"id":"inv1","invoice":"lnbc1","btcAmount":0.0001}`))
+		}
+	})
+	ctx := context.Background()
+
+	// Before limits are fetched, only the default minimum applies.
+	if _, err := wallet.NewInvoice(ctx, &InvoiceOptions{Amount: 0.000000001}); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount below default minimum, got %v", err)
+	}
+	if _, err := wallet.NewInvoice(ctx, &InvoiceOptions{Amount: 0.000001}); err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	}
+
+	limits, err := wallet.reader.Limits(ctx)
+	if err != nil {
+		t.Fatalf("Limits failed: %v", err)
+	} else if limits.MinInvoiceAmount != 0.00001 {
+		t.Fatalf("unexpected limits: %+v", limits)
+	}
+
+	created = 0
+	if _, err := wallet.NewInvoice(ctx, &InvoiceOptions{Amount: 0.000001}); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount below reported minimum, got %v", err)
+	} else if created != 0 {
+		t.Fatalf("expected invoice to be rejected before the API call")
+	}
+	if _, err := wallet.NewInvoice(ctx, nil); err != nil || created != 1 {
+		t.Fatalf("expected amountless invoice to be allowed, got %v", err)
+	}
+
+	limitsStatus = http.StatusNotFound
+	rdr := NewReader("token", wallet.reader.httpClient)
+	if limits, err := rdr.Limits(ctx); err != nil || limits.MinInvoiceAmount != DefaultMinInvoiceAmount {
+		t.Fatalf("expected default limits without a limits endpoint, got %+v, %v", limits, err)
+	}
+}
+
+func TestPayOnChainMinimum(t *testing.T) {
+	var sent int
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case limitsEndpoint:
+			w.Write([]byte(`{"minOnChainAmount":0.0001}`))
+		case "/api/v1/wallet/payment":
+			sent++
+			w.Write([]byte(`{"id":"pay1","amount":0.0002,"status":"PENDING"}`))
+		}
+	})
+	ctx := context.Background()
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+
+	if _, err := wallet.PayOnChain(ctx, address, 0.000001, ""); !errors.Is(err, ErrAmountTooSmall) {
+		t.Fatalf("expected ErrAmountTooSmall below the dust limit, got %v", err)
+	}
+
+	minimum, err := wallet.reader.OnChainMinimum(ctx)
+	if err != nil {
+		t.Fatalf("OnChainMinimum failed: %v", err)
+	} else if minimum != 0.0001 {
+		t.Fatalf("expected on-chain minimum 0.0001, got %v", minimum)
+	}
+
+	if _, err := wallet.PayOnChain(ctx, address, 0.00005, ""); !errors.Is(err, ErrAmountTooSmall) {
+		t.Fatalf("expected ErrAmountTooSmall below reported minimum, got %v", err)
+	} else if sent != 0 {
+		t.Fatalf("expected payment to be rejected before the API call")
+	}
+	if _, err := wallet.PayOnChain(ctx, address, 0.0002, ""); err != nil || sent != 1 {
+		t.Fatalf("expected payment above minimum to be sent, got %v", err)
+	}
+}
+
+func TestCredentialsValidate(t *testing.T) {
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"btcDepositAddress":"bc1qexample","lightningAddress":"satoshi@walletofsatoshi.com"}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		expected, _ := NewSimpleSigner("secret").SignRequest(
+			r.Context(), r.URL.Path, r.Header.Get("Nonce"), r.Header.Get("Api-Token"), string(body),
+		)
+		if r.Header.Get("Signature") != hex.EncodeToString(expected) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Invalid signature"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"Invalid address"}`))
+	})
+
+	ctx := context.Background()
+	if err := (Credentials{APIToken: "token", APISecret: "secret"}).Validate(ctx, httpClient); err != nil {
+		t.Fatalf("expected matching credentials to validate, got %v", err)
+	}
+
+	err := (Credentials{APIToken: "token", APISecret: "other"}).Validate(ctx, httpClient)
+	if !errors.Is(err, ErrCredentialsMismatch) {
+		t.Fatalf("expected ErrCredentialsMismatch, got %v", err)
+	}
+}
+
+func TestCredentialsOpenAndVerify(t *testing.T) {
+	var balanceStatus int
+	httpClient := mockClient(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/wallet/account":
+			w.Write([]byte(`{"btcDepositAddress":"bc1qexample","lightningAddress":"satoshi@walletofsatoshi.com"}`))
+			return
+		case r.URL.Path == "/api/v1/wallet/balance":
+			if balanceStatus != 0 {
+				w.WriteHeader(balanceStatus)
+				w.Write([]byte(`{"message":"internal error"}`))
+				return
+			}
+			w.Write([]byte(`{"btc":0.1}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		expected, _ := NewSimpleSigner("secret").SignRequest(
+			r.Context(), r.URL.Path, r.Header.Get("Nonce"), r.Header.Get("Api-Token"), string(body),
+		)
+		if r.Header.Get("Signature") != hex.EncodeToString(expected) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Invalid signature"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"Invalid address"}`))
+	})
+	ctx := context.Background()
+
+	wallet, err := (Credentials{APIToken: "token", APISecret: "secret"}).OpenAndVerify(ctx, httpClient)
+	if err != nil {
+		t.Fatalf("expected wallet to verify, got %v", err)
+	} else if wallet.OnChainAddress() != "bc1qexample" {
+		t.Fatalf("expected opened wallet, got address %q", wallet.OnChainAddress())
+	}
+
+	_, err = (Credentials{APIToken: "token", APISecret: "other"}).OpenAndVerify(ctx, httpClient)
+	if !errors.Is(err, ErrCredentialsMismatch) || errors.Is(err, ErrReadFailed) {
+		t.Fatalf("expected ErrCredentialsMismatch, got %v", err)
+	}
+
+	balanceStatus = http.StatusInternalServerError
+	_, err = (Credentials{APIToken: "token", APISecret: "secret"}).OpenAndVerify(ctx, httpClient)
+	if !errors.Is(err, ErrReadFailed) || errors.Is(err, ErrCredentialsMismatch) {
+		t.Fatalf("expected ErrReadFailed, got %v", err)
+	}
+}
+
+func TestPayOnChainUnsupportedRegion(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"Unsupported region","region":"US"}`))
+	})
+
+	_, err := wallet.PayOnChain(context.Background(), "bc1qexample", 0.001, "")
+	var apiErr *APIError
+	if !errors.Is(err, ErrUnsupportedRegion) || !errors.Is(err, ErrFeatureUnavailable) {
+		t.Fatalf("expected ErrUnsupportedRegion, got %v", err)
+	} else if !errors.As(err, &apiErr) || apiErr.Region != "US" || !strings.Contains(err.Error(), "US") {
+		t.Fatalf("expected error to name the region, got %v", err)
+	}
+	if errors.Is(err, ErrRateLimited) {
+		t.Fatalf("region restriction should not match ErrRateLimited")
+	}
+
+	// Other errors which merely mention a region are not restrictions.
+	wallet = mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"Invalid region code in request"}`))
+	})
+	_, err = wallet.PayOnChain(context.Background(), "bc1qexample", 0.001, "")
+	if err == nil || errors.Is(err, ErrUnsupportedRegion) {
+		t.Fatalf("expected plain APIError, got %v", err)
+	}
+
+	// Status 451 is a restriction even without a recognized message.
+	wallet = mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnavailableForLegalReasons)
+		w.Write([]byte(`{"message":"Unavailable","country":"GB"}`))
+	})
+	_, err = wallet.PayOnChain(context.Background(), "bc1qexample", 0.001, "")
+	if !errors.Is(err, ErrUnsupportedRegion) || !errors.As(err, &apiErr) || apiErr.Region != "GB" {
+		t.Fatalf("expected ErrUnsupportedRegion naming GB, got %v", err)
+	}
+}
+
+func TestMaxPaymentAmount(t *testing.T) {
+	var sent int
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		w.Write([]byte(`{"id":"payment"}`))
+	})
+	ctx := context.Background()
+
+	wallet.SetMaxPaymentAmount(0.001)
+	if _, err := wallet.PayInvoice(ctx, testInvoiceCoffee, ""); !errors.Is(err, ErrAmountExceedsCap) {
+		t.Fatalf("expected ErrAmountExceedsCap, got %v", err)
+	} else if sent != 0 {
+		t.Fatalf("expected payment to be rejected before sending")
+	}
+	if _, err := wallet.PayOnChain(ctx, "bc1qexample", 0.0005, ""); err != nil || sent != 1 {
+		t.Fatalf("expected payment below the cap to proceed, got %v", err)
+	}
+
+	wallet.SetMaxPaymentAmount(0)
+	MaxPaymentAmount = 0.0001
+	defer func() { MaxPaymentAmount = 0 }()
+	if _, err := wallet.PayOnChain(ctx, "bc1qexample", 0.0005, ""); !errors.Is(err, ErrAmountExceedsCap) {
+		t.Fatalf("expected package-level cap to apply, got %v", err)
+	}
+}
+
+func TestIdempotencyCache(t *testing.T) {
+	var sent int
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		w.Write([]byte(`{"id":"payment"}`))
+	})
+	ctx := context.Background()
+
+	wallet.EnableIdempotencyCache(time.Minute)
+	first, err := wallet.PayOnChain(ctx, "bc1qexample", 0.001, "rent")
+	if err != nil {
+		t.Fatalf("PayOnChain failed: %v", err)
+	}
+	second, err := wallet.PayOnChain(ctx, "bc1qexample", 0.001, "rent")
+	if err != nil {
+		t.Fatalf("PayOnChain failed: %v", err)
+	} else if sent != 1 || second.ID != first.ID {
+		t.Fatalf("expected repeated payment to return the cached result, sent %d requests", sent)
+	}
+
+	if _, err := wallet.PayOnChain(ctx, "bc1qexample", 0.001, "deposit"); err != nil || sent != 2 {
+		t.Fatalf("expected a different payment to be sent, got %v", err)
+	}
+
+	wallet.DisableIdempotencyCache()
+	if _, err := wallet.PayOnChain(ctx, "bc1qexample", 0.001, "rent"); err != nil || sent != 3 {
+		t.Fatalf("expected payment to be sent with the cache disabled, got %v", err)
+	}
+}
+
+func TestPayLightningAddressNotALightningAddress(t *testing.T) {
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/wallet/lnurl" {
+			t.Errorf("unexpected request to %s", r.URL)
+			return
+		}
+		var body struct {
+			Address string `json:"address"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Address {
+		case "https://gmail.com/.well-known/lnurlp/alice":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"LNURL request failed with status 404"}`))
+		case "https://proxied.example/.well-known/lnurlp/alice":
+			w.Write([]byte(`{"status":"ERROR","reason":"Not Found"}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"message":"LNURL request failed with status 500"}`))
+		}
+	})
+	ctx := context.Background()
+
+	for _, domain := range []string{"gmail.com", "proxied.example"} {
+		_, err := wallet.PayLightningAddress(ctx, LightningAddress{"alice", domain}, "", 0.0001)
+		if !errors.Is(err, ErrNotALightningAddress) {
+			t.Fatalf("expected ErrNotALightningAddress for %s, got %v", domain, err)
+		}
+	}
+
+	_, err := wallet.PayLightningAddress(ctx, LightningAddress{"alice", "broken.example"}, "", 0.0001)
+	if err == nil || errors.Is(err, ErrNotALightningAddress) {
+		t.Fatalf("expected a generic error for a failing server, got %v", err)
+	}
+}
+
+func TestMaxConcurrentPayments(t *testing.T) {
+	arrived := make(chan struct{}, 3)
+	unblock := make(chan struct{})
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-unblock
+		w.Write([]byte(`{"id":"payment"}`))
+	})
+	wallet.SetMaxConcurrentPayments(2)
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := wallet.PayOnChain(context.Background(), "bc1qexample", 0.001, strconv.Itoa(i))
+			errs <- err
+		}()
+	}
+
+	// Two payments reach the server, and the third waits for a slot.
+	<-arrived
+	<-arrived
+	select {
+	case <-arrived:
+		t.Fatalf("third payment was sent before a slot was free")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A payment which gives up waiting fails with its context error.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := wallet.PayOnChain(ctx, "bc1qexample", 0.001, "impatient"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected queued payment to honor its context, got %v", err)
+	}
+
+	close(unblock)
+	<-arrived
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("payment failed: %v", err)
+		}
+	}
+}
+
+func TestSweepOnChainDustThreshold(t *testing.T) {
+	balance := "0.001"
+	var sent sendPaymentRequest
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":` + balance + `}`))
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00002,"btcSendCommissionPercent":0.001234}`))
+		case "/api/v1/wallet/payment":
+			json.NewDecoder(r.Body).Decode(&sent)
+			w.Write([]byte(`{"id":"p1","status":"PENDING","currency":"BTC"}`))
+		}
+	})
+	ctx := context.Background()
+
+	// 100000 sats, less 2000 sats fixed fee and 123.4 sats commission, leaves
+	// 97876.6 sats: 97876 are sent, and 0.6 sats are left behind.
+	result, err := wallet.SweepOnChainWith(ctx, "bc1qdest", "", nil)
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	}
+	if result.Amount != 0.00097876 || sent.Amount != 0.00097876 {
+		t.Fatalf("expected to sweep 0.00097876 BTC, got %.11f (sent %.11f)", result.Amount, sent.Amount)
+	} else if math.Abs(result.ExpectedResidual-0.000000006) > 1e-12 {
+		t.Fatalf("expected residual of 0.6 sats, got %.11f", result.ExpectedResidual)
+	}
+
+	_, err = wallet.SweepOnChainWith(ctx, "bc1qdest", "", &SweepOptions{DustThreshold: 0.001})
+	if !errors.Is(err, ErrAmountBelowDust) {
+		t.Fatalf("expected ErrAmountBelowDust with a high dust threshold, got %v", err)
+	}
+
+	// The default threshold is DustLimit.
+	balance = "0.000025"
+	if _, err := wallet.SweepOnChainWith(ctx, "bc1qdest", "", nil); !errors.Is(err, ErrAmountBelowDust) {
+		t.Fatalf("expected ErrAmountBelowDust below DustLimit, got %v", err)
+	}
+}
+
+func TestSweepOnChainBalanceTooSmall(t *testing.T) {
+	balance := "0.00001"
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			w.Write([]byte(`{"btc":` + balance + `}`))
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00002,"btcSendCommissionPercent":0.001,"sendMaxLightningFee":0.000001}`))
+		case "/api/v1/wallet/payment":
+			t.Errorf("unexpected payment")
+		}
+	})
+	ctx := context.Background()
+
+	// 1000 sats cannot cover the 2000 sat fixed fee.
+	if _, err := wallet.SweepOnChain(ctx, "bc1qdest", ""); !errors.Is(err, ErrBalanceTooSmallForOnChain) {
+		t.Fatalf("expected ErrBalanceTooSmallForOnChain, got %v", err)
+	}
+	if method, err := wallet.ViableSweepMethod(ctx, "bc1qdest"); err != nil {
+		t.Fatalf("ViableSweepMethod failed: %v", err)
+	} else if method != PaymentCurrencyLightning {
+		t.Fatalf("expected lightning sweep, got %q", method)
+	}
+
+	// 2100 sats cover the fixed fee, but leave only dust.
+	balance = "0.000021"
+	_, err := wallet.SweepOnChain(ctx, "bc1qdest", "")
+	if !errors.Is(err, ErrBalanceTooSmallForOnChain) || !errors.Is(err, ErrAmountBelowDust) {
+		t.Fatalf("expected ErrBalanceTooSmallForOnChain and ErrAmountBelowDust, got %v", err)
+	}
+
+	balance = "0.001"
+	if method, err := wallet.ViableSweepMethod(ctx, "bc1qdest"); err != nil {
+		t.Fatalf("ViableSweepMethod failed: %v", err)
+	} else if method != PaymentCurrencyBitcoin {
+		t.Fatalf("expected on-chain sweep, got %q", method)
+	}
+
+	balance = "0.0000001"
+	if _, err := wallet.ViableSweepMethod(ctx, "bc1qdest"); !errors.Is(err, ErrNothingToSweep) {
+		t.Fatalf("expected ErrNothingToSweep, got %v", err)
+	}
+}
+
+func TestNewInvoicePaymentHash(t *testing.T) {
+	hash := bytes.Repeat([]byte{0xab}, 32)
+	var response string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(response))
+	})
+
+	response = `{"id":"inv1","invoice":"` + testInvoiceCoffee + `","paymentHash":"` + hex.EncodeToString(hash) + `"}`
+	invoice, err := wallet.NewInvoice(context.Background(), &InvoiceOptions{Amount: 0.0025})
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	} else if !bytes.Equal(invoice.PaymentHash, hash) {
+		t.Fatalf("expected payment hash from response, got %x", invoice.PaymentHash)
+	}
+
+	decoded, err := DecodeInvoice(testInvoiceCoffee)
+	if err != nil {
+		t.Fatalf("failed to decode invoice: %v", err)
+	}
+	response = `{"id":"inv1","invoice":"` + testInvoiceCoffee + `"}`
+	invoice, err = wallet.NewInvoice(context.Background(), &InvoiceOptions{Amount: 0.0025})
+	if err != nil {
+		t.Fatalf("NewInvoice failed: %v", err)
+	} else if !bytes.Equal(invoice.PaymentHash, decoded.PaymentHash) {
+		t.Fatalf("expected payment hash decoded from invoice, got %x", invoice.PaymentHash)
+	}
+}
+
+func TestSweepRetriesReadsOnly(t *testing.T) {
+	var balanceReads, payments atomic.Int32
+	var paymentStatus atomic.Int32
+	var malformed atomic.Bool
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/balance":
+			if balanceReads.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			} else if malformed.Load() {
+				w.Write([]byte(`{"btc":`))
+				return
+			}
+			w.Write([]byte(`{"btc":0.001}`))
+		case "/api/v1/wallet/feeEstimate":
+			w.Write([]byte(`{"btcFixedFee":0.00002}`))
+		case "/api/v1/wallet/payment":
+			payments.Add(1)
+			if status := paymentStatus.Load(); status != 0 {
+				w.WriteHeader(int(status))
+				return
+			}
+			w.Write([]byte(`{"id":"p1","status":"PENDING","currency":"BTC"}`))
+		}
+	})
+	wallet.SetClock(newFakeClock())
+	ctx := context.Background()
+
+	if _, err := wallet.SweepOnChain(ctx, "bc1qdest", ""); err != nil {
+		t.Fatalf("sweep failed despite transient read failure: %v", err)
+	} else if balanceReads.Load() != 2 || payments.Load() != 1 {
+		t.Fatalf("expected 2 balance reads and 1 payment, got %d and %d", balanceReads.Load(), payments.Load())
+	}
+
+	// A failed payment is never retried, even if the failure looks transient.
+	payments.Store(0)
+	paymentStatus.Store(http.StatusServiceUnavailable)
+	if _, err := wallet.SweepOnChain(ctx, "bc1qdest", ""); err == nil {
+		t.Fatalf("expected sweep to fail")
+	} else if payments.Load() != 1 {
+		t.Fatalf("expected exactly one payment attempt, got %d", payments.Load())
+	}
+
+	// An invalid response will not improve on retrying.
+	balanceReads.Store(1)
+	malformed.Store(true)
+	if _, err := wallet.SweepOnChain(ctx, "bc1qdest", ""); err == nil {
+		t.Fatalf("expected sweep to fail")
+	} else if balanceReads.Load() != 2 {
+		t.Fatalf("expected an invalid response not to be retried, got %d reads", balanceReads.Load()-1)
+	}
+}
+
+func TestNewInvoiceVerify(t *testing.T) {
+	var returned string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"inv1","invoice":"` + returned + `"}`))
+	})
+	ctx := context.Background()
+
+	returned = testInvoiceCoffee
+	opts := &InvoiceOptions{Amount: 0.0025, Description: "1 cup coffee", Verify: true}
+	if _, err := wallet.NewInvoice(ctx, opts); err != nil {
+		t.Fatalf("expected matching invoice to verify, got %v", err)
+	}
+
+	mismatched := []*InvoiceOptions{
+		{Amount: 0.001, Description: "1 cup coffee", Verify: true},
+		{Amount: 0.0025, Description: "2 cups coffee", Verify: true},
+		{Description: "1 cup coffee", Verify: true},
+	}
+	for _, opts := range mismatched {
+		if _, err := wallet.NewInvoice(ctx, opts); !errors.Is(err, ErrInvoiceMismatch) {
+			t.Errorf("expected ErrInvoiceMismatch for %+v, got %v", opts, err)
+		}
+	}
+
+	// Verification is opt-in.
+	if _, err := wallet.NewInvoice(ctx, &InvoiceOptions{Amount: 0.001}); err != nil {
+		t.Fatalf("expected unverified invoice to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package wos
+
+import "errors"
+
+// WarningCode identifies the kind of a [Warning], so that callers can decide how
+// to surface it without parsing messages.
+type WarningCode string
+
+const (
+	WarningHighFee            WarningCode = "HIGH_FEE"            // Fees are an unusually large part of a payment.
+	WarningExpiryClamped      WarningCode = "EXPIRY_CLAMPED"      // An invoice expiry was clamped to the accepted range.
+	WarningAddressReused      WarningCode = "ADDRESS_REUSED"      // An on-chain address has prior activity.
+	WarningPartialResult      WarningCode = "PARTIAL_RESULT"      // Part of a result could not be fetched.
+	WarningConcurrentActivity WarningCode = "CONCURRENT_ACTIVITY" // Other payments overlapped a measurement.
+	WarningRiskyInvoice       WarningCode = "RISKY_INVOICE"       // A paid invoice could lock up funds for a long time.
+)
+
+// ErrHighFee is the error wrapped by [WarningHighFee] warnings.
+var ErrHighFee = errors.New("fees exceed the recommended proportion of the amount sent")
+
+// Warning is a non-fatal condition encountered by an operation which otherwise
+// succeeded: it worked, but the caller should know something.
+//
+// Warning implements error, and unwraps to the underlying error, so that warnings
+// can be matched with [errors.Is], such as against [ErrExpiryClamped].
+type Warning struct {
+	// Code identifies the kind of warning.
+	Code WarningCode
+
+	// Message describes the warning in human-readable form.
+	Message string
+
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// newWarning returns a [Warning] with the given code, described by err.
+func newWarning(code WarningCode, err error) Warning {
+	return Warning{Code: code, Message: err.Error(), Err: err}
+}
+
+// Error implements error.
+func (w Warning) Error() string {
+	return w.Message
+}
+
+// Unwrap returns the underlying error.
+func (w Warning) Unwrap() error {
+	return w.Err
+}
@@ -0,0 +1,65 @@
+package wos
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrLowFee is matched by [*APIError] when WoS could not route a payment within
+	// the allowed fee, reported as FAILED_LOW_FEE. Retrying with a larger fee buffer,
+	// as [Wallet.PayInvoiceWithRetryStrategy] does, may succeed.
+	ErrLowFee = errors.New("payment fee too low")
+
+	// ErrInsufficientFunds is matched by [*APIError] when the wallet's balance cannot
+	// cover a payment and its fees.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+
+	// ErrInvoiceExpired is matched by [*APIError] when paying an invoice which has expired.
+	ErrInvoiceExpired = errors.New("invoice expired")
+
+	// ErrAlreadyPaid is matched by [*APIError] when paying an invoice which was already paid.
+	ErrAlreadyPaid = errors.New("invoice already paid")
+
+	// ErrInvoiceAlreadyPaid is an alias of [ErrAlreadyPaid].
+	ErrInvoiceAlreadyPaid = ErrAlreadyPaid
+
+	// ErrNoRoute is matched by [*APIError] when WoS could not find a route to the payee.
+	ErrNoRoute = errors.New("no route to payee")
+
+	// ErrWalletFrozen is matched by [*APIError] when WoS refuses a request because the
+	// wallet has been frozen or suspended. This is usually permanent until resolved
+	// with WoS support, so such requests should not be retried.
+	ErrWalletFrozen = errors.New("wallet is frozen")
+)
+
+// WoSErrorCodes maps error codes and messages returned by the WoS API to the sentinel
+// errors which [*APIError] matches with [errors.Is]. Keys are upper case, and matched
+// against the trimmed, upper-cased error message.
+//
+// WoS does not document its error codes, so this lists those known to be returned.
+// New codes can be added as they are discovered, but the map must not be modified
+// while API requests are in flight.
+var WoSErrorCodes = map[string]error{
+	"FAILED_LOW_FEE":       ErrLowFee,
+	"INSUFFICIENT_FUNDS":   ErrInsufficientFunds,
+	"INSUFFICIENT_BALANCE": ErrInsufficientFunds,
+	"INVALID_ADDRESS":      ErrInvalidDestination,
+	"INVALID_INVOICE":      ErrInvalidDestination,
+	"INVOICE_EXPIRED":      ErrInvoiceExpired,
+	"EXPIRED_INVOICE":      ErrInvoiceExpired,
+	"INVOICE_ALREADY_PAID": ErrAlreadyPaid,
+	"INVOICE_PAID":         ErrAlreadyPaid,
+	"ALREADY_PAID":         ErrAlreadyPaid,
+	"FAILED_NO_ROUTE":      ErrNoRoute,
+	"ACCOUNT_FROZEN":       ErrWalletFrozen,
+	"WALLET_FROZEN":        ErrWalletFrozen,
+	"ACCOUNT_LOCKED":       ErrWalletFrozen,
+	"ACCOUNT_SUSPENDED":    ErrWalletFrozen,
+}
+
+// ParseWoSError returns the sentinel error for an error message returned by the WoS
+// API, as listed in [WoSErrorCodes]. Returns nil if the message is not recognized.
+func ParseWoSError(message string) error {
+	return WoSErrorCodes[strings.ToUpper(strings.TrimSpace(message))]
+}
@@ -0,0 +1,99 @@
+package wos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseWoSError(t *testing.T) {
+	tests := map[string]error{
+		"FAILED_LOW_FEE":       ErrLowFee,
+		" insufficient_funds ": ErrInsufficientFunds,
+		"INVALID_ADDRESS":      ErrInvalidDestination,
+		"INVOICE_ALREADY_PAID": ErrAlreadyPaid,
+		"something else broke": nil,
+		"":                     nil,
+	}
+	for message, want := range tests {
+		if got := ParseWoSError(message); got != want {
+			t.Fatalf("ParseWoSError(%q): expected %v, got %v", message, want, got)
+		}
+	}
+}
+
+func TestAPIErrorCodes(t *testing.T) {
+	var message string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"message":%q}`, message)
+	})
+
+	message = "FAILED_LOW_FEE"
+	_, err := wallet.PayOnChain(context.Background(), "bc1qexample", 0.001, "")
+	var apiErr *APIError
+	if !errors.Is(err, ErrLowFee) || !errors.As(err, &apiErr) || apiErr.Message != message {
+		t.Fatalf("expected APIError matching ErrLowFee, got %v", err)
+	}
+
+	message = "Unexpected failure"
+	_, err = wallet.PayOnChain(context.Background(), "bc1qexample", 0.001, "")
+	if !errors.As(err, &apiErr) || apiErr.Err != nil || errors.Is(err, ErrLowFee) {
+		t.Fatalf("expected plain APIError for unknown message, got %v", err)
+	}
+}
+
+func TestWalletFrozen(t *testing.T) {
+	var message string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, `{"message":%q}`, message)
+	})
+
+	for _, message = range []string{"ACCOUNT_FROZEN", "Your account has been suspended"} {
+		_, err := wallet.PayOnChain(context.Background(), "bc1qexample", 0.001, "")
+		if !errors.Is(err, ErrWalletFrozen) {
+			t.Fatalf("expected %q to match ErrWalletFrozen, got %v", message, err)
+		} else if defaultShouldRetry(err) {
+			t.Fatalf("expected frozen wallet error not to be retried")
+		}
+	}
+
+	message = "FAILED_NO_ROUTE"
+	if _, err := wallet.PayOnChain(context.Background(), "bc1qexample", 0.001, ""); errors.Is(err, ErrWalletFrozen) {
+		t.Fatalf("unexpected ErrWalletFrozen for %q", message)
+	}
+}
+
+func TestInvoiceExpiredOrAlreadyPaid(t *testing.T) {
+	var message string
+	wallet := mockWallet(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"message":%q}`, message)
+	})
+
+	tests := map[string]error{
+		"INVOICE_EXPIRED":                    ErrInvoiceExpired,
+		"Invoice has expired":                ErrInvoiceExpired,
+		"INVOICE_ALREADY_PAID":               ErrInvoiceAlreadyPaid,
+		"This invoice has already been paid": ErrInvoiceAlreadyPaid,
+		"Invoice already paid":               ErrInvoiceAlreadyPaid,
+	}
+	for message = range tests {
+		want := tests[message]
+		_, err := wallet.PayInvoice(context.Background(), testInvoiceCoffee, "")
+		if !errors.Is(err, want) {
+			t.Errorf("expected %q to match %v, got %v", message, want, err)
+		}
+		for _, other := range []error{ErrInvoiceExpired, ErrInvoiceAlreadyPaid} {
+			if other != want && errors.Is(err, other) {
+				t.Errorf("expected %q not to match %v", message, other)
+			}
+		}
+		if defaultShouldRetry(err) {
+			t.Errorf("expected %q not to be retried", message)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+// Package wostest provides helpers for testing code which uses the [wos] package,
+// without a live WoS server.
+package wostest
@@ -0,0 +1,68 @@
+package wostest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/conduition/wos"
+)
+
+// SignedRequest records the details of a request signed by a [RecordingSigner].
+type SignedRequest struct {
+	Endpoint  string
+	Nonce     string
+	APIToken  string
+	Body      string
+	Signature []byte
+}
+
+// RecordingSigner is a [wos.Signer] which signs requests like a [wos.SimpleSigner],
+// producing valid signatures, and records every request it signs so that tests can
+// assert which requests their code made. It is safe for concurrent use.
+type RecordingSigner struct {
+	signer *wos.SimpleSigner
+
+	mu       sync.Mutex
+	requests []SignedRequest
+}
+
+// NewRecordingSigner returns a RecordingSigner which signs with the given APISecret.
+func NewRecordingSigner(apiSecret string) *RecordingSigner {
+	return &RecordingSigner{signer: wos.NewSimpleSigner(apiSecret)}
+}
+
+// SignRequest implements [wos.Signer].
+func (s *RecordingSigner) SignRequest(
+	ctx context.Context,
+	endpoint, nonce, apiToken, requestBody string,
+) ([]byte, error) {
+	sig, err := s.signer.SignRequest(ctx, endpoint, nonce, apiToken, requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, SignedRequest{
+		Endpoint:  endpoint,
+		Nonce:     nonce,
+		APIToken:  apiToken,
+		Body:      requestBody,
+		Signature: sig,
+	})
+	return sig, nil
+}
+
+// Requests returns a copy of every request signed so far, in the order they were signed.
+func (s *RecordingSigner) Requests() []SignedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SignedRequest(nil), s.requests...)
+}
+
+// Reset forgets all recorded requests.
+func (s *RecordingSigner) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
@@ -0,0 +1,76 @@
+package wostest
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/conduition/wos"
+)
+
+type handlerTransport http.HandlerFunc
+
+func (handler handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+func TestRecordingSigner(t *testing.T) {
+	var signature string
+	httpClient := &http.Client{Transport: handlerTransport(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/wallet/account":
+			w.Write([]byte(`{"btcDepositAddress":"bc1qexample","lightningAddress":"user@walletofsatoshi.com"}`))
+		case "/api/v1/wallet/payment":
+			signature = r.Header.Get("Signature")
+			w.Write([]byte(`{"id":"p1","status":"PENDING","currency":"BTC"}`))
+		}
+	})}
+
+	signer := NewRecordingSigner("secret")
+	wallet, err := wos.OpenWallet(context.Background(), wos.NewReader("token", httpClient), signer)
+	if err != nil {
+		t.Fatalf("OpenWallet failed: %v", err)
+	}
+	if _, err := wallet.PayOnChain(context.Background(), "bc1qdest", 0.001, "rent"); err != nil {
+		t.Fatalf("PayOnChain failed: %v", err)
+	}
+
+	requests := signer.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 signed request, got %d", len(requests))
+	}
+	req := requests[0]
+	if req.Endpoint != "/api/v1/wallet/payment" || req.APIToken != "token" {
+		t.Fatalf("unexpected signed request: %+v", req)
+	}
+
+	var body struct {
+		Address     string  `json:"address"`
+		Currency    string  `json:"currency"`
+		Amount      float64 `json:"amount"`
+		Description string  `json:"description"`
+	}
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		t.Fatalf("invalid signed body: %v", err)
+	} else if body.Address != "bc1qdest" || body.Currency != "BTC" || body.Amount != 0.001 || body.Description != "rent" {
+		t.Fatalf("unexpected signed body: %s", req.Body)
+	}
+
+	if hex.EncodeToString(req.Signature) != signature {
+		t.Fatalf("recorded signature does not match the one sent")
+	} else if !wos.VerifySignature("secret", req.Endpoint, req.Nonce, req.APIToken, req.Body, req.Signature) {
+		t.Fatalf("recorded signature is not valid")
+	}
+
+	signer.Reset()
+	if len(signer.Requests()) != 0 {
+		t.Fatalf("expected Reset to forget recorded requests")
+	}
+}